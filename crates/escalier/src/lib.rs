@@ -31,15 +31,13 @@ fn _compile(input: &str, lib: &str) -> Result<(String, String, String, String),
     let mut program = escalier_parser::parse(input)?;
     let ast = format!("{program:#?}");
 
-    let (js, srcmap) = escalier_codegen::js::codegen_js(input, &program);
-
     // TODO: return errors as part of CompileResult
     let (mut checker, mut ctx) = parse_dts(lib).unwrap();
 
     // TODO: get rid of panics and return errors instead
     match checker.infer_script(&mut program, &mut ctx) {
         Ok(_) => {
-            if !checker.current_report.diagnostics.is_empty() {
+            if checker.current_report.has_errors() {
                 panic!("was expecting infer_prog() to return no errors");
             }
         }
@@ -49,6 +47,9 @@ fn _compile(input: &str, lib: &str) -> Result<(String, String, String, String),
         }
     }
 
+    // The checker annotates the AST with things that codegen needs, e.g.
+    // which member accesses are private, so it has to run first.
+    let (js, srcmap) = escalier_codegen::js::codegen_js(input, &program);
     let dts = escalier_codegen::d_ts::codegen_d_ts(&program, &ctx, &checker)?;
 
     Ok((js, srcmap, dts, ast))
@@ -90,9 +90,11 @@ fn fail(in_path: PathBuf) {
 }
 
 pub fn report_to_string(src: &str, report: &Report) -> String {
+    // Only errors cause fixtures to fail, warnings are ignored.
     report
         .diagnostics
         .iter()
+        .filter(|d| d.is_error())
         .map(|d| {
             let reasons = type_errors_to_string(&d.reasons, src);
             format!("{}:\n{}", d.message, reasons)
@@ -103,11 +105,11 @@ pub fn report_to_string(src: &str, report: &Report) -> String {
 
 pub fn all_reports_to_string(src: &str, checker: &Checker) -> String {
     let mut reports = vec![];
-    if !checker.current_report.diagnostics.is_empty() {
+    if checker.current_report.has_errors() {
         reports.push(report_to_string(src, &checker.current_report));
     }
     for report in &checker.parent_reports {
-        if !report.diagnostics.is_empty() {
+        if report.has_errors() {
             reports.push(report_to_string(src, report));
         }
     }
@@ -127,12 +129,15 @@ fn compile(input: &str, lib: &str) -> (String, String, String, String) {
         }
     };
 
-    let (js, srcmap) = escalier_codegen::js::codegen_js(input, &script);
-
     // TODO: return errors as part of CompileResult
     let (mut checker, mut ctx) = parse_dts(lib).unwrap();
 
-    match checker.infer_script(&mut script, &mut ctx) {
+    let result = checker.infer_script(&mut script, &mut ctx);
+    // Codegen relies on the checker's annotations, e.g. which member accesses
+    // are private.
+    let (js, srcmap) = escalier_codegen::js::codegen_js(input, &script);
+
+    match result {
         Ok(_) => (),
         Err(error) => {
             return (
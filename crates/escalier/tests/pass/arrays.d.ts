@@ -1,5 +1,5 @@
 export declare const a1: readonly number[];
 export declare const a1_squared: readonly number[];
-export declare const a2: readonly number[];
+export declare let a2: readonly number[];
 export declare const len1: number;
 export declare const len2: number;
@@ -5,7 +5,7 @@ export const a1 = [
 ];
 export const a1_squared = a1.map((x)=>x * x);
 export const len1 = a1.length;
-export const a2 = [
+export let a2 = [
     3,
     2,
     1
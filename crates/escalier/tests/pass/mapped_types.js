@@ -4,7 +4,7 @@ export const partial_obj = {
     b: "hello"
 };
 ;
-export const custom_obj = {
+export let custom_obj = {
     b: "hello"
 };
 custom_obj.b = "world";
@@ -1 +1 @@
-export declare const products: readonly number[];
+export declare let products: readonly number[];
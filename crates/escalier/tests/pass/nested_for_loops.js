@@ -1,4 +1,4 @@
-export const products = [];
+export let products = [];
 for (const x of [
     1,
     2,
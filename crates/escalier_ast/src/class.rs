@@ -13,6 +13,11 @@ pub struct Class {
     pub type_params: Option<Vec<TypeParam>>,
     pub super_class: Option<Ident>,
     pub super_type_args: Option<Vec<TypeAnn>>,
+    pub implements: Vec<TypeAnn>,
+    pub is_abstract: bool,
+    // Declared classes only describe a class that's defined elsewhere so
+    // their methods don't have bodies.
+    pub is_declare: bool,
     pub body: Vec<ClassMember>,
 }
 
@@ -20,17 +25,18 @@ pub struct Class {
 pub struct Method {
     pub span: Span,
     pub name: PropName,
-    pub is_public: bool, // TODO: change to is_private
+    pub is_private: bool,
     pub is_mutating: bool,
     pub is_static: bool,
-    pub function: Function,
+    pub is_abstract: bool,
+    pub function: Function, // abstract methods have an empty body
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Getter {
     pub span: Span,
     pub name: PropName,
-    pub is_public: bool,
+    pub is_private: bool,
     pub type_ann: Option<TypeAnn>,
     pub params: Vec<FuncParam>, // should only contain `self` param
     pub body: Block,
@@ -40,7 +46,7 @@ pub struct Getter {
 pub struct Setter {
     pub span: Span,
     pub name: PropName,
-    pub is_public: bool,
+    pub is_private: bool,
     pub type_ann: Option<TypeAnn>, // should always be `void`
     pub params: Vec<FuncParam>,    // should only contain `self`, `value` params
     pub body: Block,
@@ -57,7 +63,7 @@ pub enum PropName {
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Constructor {
     pub span: Span,
-    pub is_public: bool,
+    pub is_private: bool,
     pub params: Vec<FuncParam>,
     pub body: Block,
 }
@@ -66,7 +72,7 @@ pub struct Constructor {
 pub struct Field {
     pub span: Span,
     pub name: Ident,
-    pub is_public: bool,
+    pub is_private: bool,
     pub is_static: bool,
     pub type_ann: Option<TypeAnn>,
     pub init: Option<Box<Expr>>,
@@ -0,0 +1,8 @@
+use crate::span::Span;
+
+// Only line comments are supported.  `text` doesn't include the leading `//`.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct Comment {
+    pub text: String,
+    pub span: Span,
+}
@@ -1,4 +1,5 @@
 use crate::expr::Expr;
+use crate::identifier::Ident;
 use crate::pattern::Pattern;
 use crate::span::Span;
 use crate::type_ann::TypeAnn;
@@ -18,16 +19,56 @@ pub struct TypeDecl {
     pub name: String,
     pub type_ann: TypeAnn,
     pub type_params: Option<Vec<TypeParam>>,
+    // Unlike type aliases, multiple interfaces with the same name are merged.
+    pub is_interface: bool,
+}
+
+// e.g. `declare global { let foo: number }`, the decls are added to the
+// global scope.  All of the var decls are ambient.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct GlobalDecl {
+    pub decls: Vec<Decl>,
+}
+
+// e.g. `namespace Foo { let bar = 5 }`, the values declared inside of the
+// namespace are accessed as members of it, e.g. `Foo.bar`.  Declared
+// namespaces, e.g. `declare namespace Foo { ... }`, are ambient and only
+// contain declared values and types.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct NamespaceDecl {
+    pub name: Ident,
+    pub decls: Vec<Decl>,
+    pub is_declare: bool,
+}
+
+// e.g. `namespace Short = Very.Long.Namespace`, `target` is an identifier or
+// a chain of member accesses.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct NamespaceAliasDecl {
+    pub name: Ident,
+    pub target: Expr,
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub enum DeclKind {
     TypeDecl(TypeDecl),
     VarDecl(VarDecl),
+    GlobalDecl(GlobalDecl),
+    NamespaceDecl(NamespaceDecl),
+    NamespaceAliasDecl(NamespaceAliasDecl),
+}
+
+// e.g. `@deprecated("use bar instead")`
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct Annotation {
+    pub name: String,
+    pub args: Vec<Expr>,
+    pub span: Span,
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Decl {
     pub kind: DeclKind,
     pub span: Span,
+    pub annotations: Vec<Annotation>,
 }
@@ -49,6 +49,12 @@ pub struct Str {
     pub value: String,
 }
 
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct Regex {
+    pub pattern: String,
+    pub flags: Option<String>,
+}
+
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Bool {
     pub value: bool,
@@ -118,7 +124,7 @@ pub struct Function {
 pub struct Call {
     pub callee: Box<Expr>,
     pub type_args: Option<Vec<TypeAnn>>,
-    pub args: Vec<Expr>,
+    pub args: Vec<ExprOrSpread>,
     pub opt_chain: bool,
     pub throws: Option<Index>,
 }
@@ -127,7 +133,7 @@ pub struct Call {
 pub struct New {
     pub callee: Box<Expr>,
     pub type_args: Option<Vec<TypeAnn>>,
-    pub args: Vec<Expr>,
+    pub args: Vec<ExprOrSpread>,
     pub throws: Option<Index>,
 }
 
@@ -136,6 +142,9 @@ pub struct Member {
     pub object: Box<Expr>,
     pub property: MemberProp,
     pub opt_chain: bool,
+    // Set by the checker when `property` is a private member of the class
+    // that `object` is an instance of.
+    pub is_private: bool,
 }
 
 #[derive(Clone, Debug, PartialEq, Eq)]
@@ -176,6 +185,8 @@ pub struct Try {
     pub finally: Option<Block>,
 }
 
+// Either `do {...}` or a bare block in expression position.  Its value is
+// the value of its last statement or `undefined` if that isn't an expression.
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Do {
     pub body: Block,
@@ -209,8 +220,7 @@ pub enum ExprKind {
     Undefined(Undefined),
     TemplateLiteral(TemplateLiteral),
     TaggedTemplateLiteral(TaggedTemplateLiteral),
-    // TODO: Add regex support
-    // Regex(Regex),
+    Regex(Regex),
     Object(Object),
     Tuple(Tuple),
     Assign(Assign),
@@ -285,6 +295,8 @@ pub enum BinaryOp {
     GreaterThanOrEqual,
     Or,
     And,
+    In,
+    InstanceOf,
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
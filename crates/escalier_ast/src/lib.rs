@@ -1,5 +1,6 @@
 pub mod block;
 pub mod class;
+pub mod comment;
 pub mod decl;
 pub mod expr;
 pub mod func_param;
@@ -17,6 +18,7 @@ pub mod visitor;
 
 pub use block::*;
 pub use class::*;
+pub use comment::*;
 pub use decl::*;
 pub use expr::*;
 pub use func_param::*;
@@ -55,8 +55,15 @@ impl From<&Literal> for swc_ecma_ast::Expr {
                 span,
                 value: *value,
             }),
-            Literal::Null => todo!(),
-            Literal::Undefined => todo!(),
+            Literal::Null => Lit::Null(Null { span }),
+            // `undefined` is a global variable in JavaScript, not a literal.
+            Literal::Undefined => {
+                return Expr::Ident(Ident {
+                    span,
+                    sym: swc_atoms::JsWord::from("undefined"),
+                    optional: false,
+                })
+            }
         };
 
         Expr::Lit(lit)
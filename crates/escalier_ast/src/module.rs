@@ -1,3 +1,4 @@
+use crate::comment::Comment;
 use crate::decl::Decl;
 use crate::span::Span;
 
@@ -18,10 +19,24 @@ pub struct Export {
     pub decl: Decl,
 }
 
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct ExportSpecifier {
+    pub exported: String,      // the name the symbol is exported as
+    pub local: Option<String>, // the symbol being re-exported
+}
+
+// e.g. `export {foo, bar as baz} from "./util"`
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct ReExport {
+    pub specifiers: Vec<ExportSpecifier>,
+    pub source: String,
+}
+
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub enum ModuleItemKind {
     Import(Import),
     Export(Export),
+    ReExport(ReExport),
     Decl(Decl),
 }
 
@@ -34,4 +49,5 @@ pub struct ModuleItem {
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Module {
     pub items: Vec<ModuleItem>,
+    pub comments: Vec<Comment>,
 }
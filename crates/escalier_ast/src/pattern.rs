@@ -13,6 +13,8 @@ pub enum PatternKind {
     Tuple(TuplePat),
     Lit(LitPat),
     Is(IsPat),
+    Or(OrPat),
+    Range(RangePat),
     Wildcard,
     // This can't be used at the top level similar to rest
     // Assign(AssignPat),
@@ -45,6 +47,20 @@ pub struct IsPat {
     pub is_id: Ident,
 }
 
+// e.g. `1 | 2 | 3`, all of the alternatives must bind the same names.
+#[derive(Clone, Debug, PartialEq, Eq)]
+pub struct OrPat {
+    pub patterns: Vec<Pattern>,
+}
+
+// e.g. `0..=9` or `"a".."z"`, both bounds must be numbers or strings.
+#[derive(Clone, Debug, PartialEq, Eq)]
+pub struct RangePat {
+    pub start: Literal,
+    pub end: Literal,
+    pub inclusive: bool,
+}
+
 #[derive(Clone, Debug, PartialEq, Eq)]
 pub struct RestPat {
     pub arg: Box<Pattern>,
@@ -1,6 +1,8 @@
+use crate::comment::Comment;
 use crate::stmt::Stmt;
 
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub struct Script {
     pub stmts: Vec<Stmt>,
+    pub comments: Vec<Comment>,
 }
@@ -16,6 +16,7 @@ pub struct ForStmt {
     pub left: Box<Pattern>,
     pub right: Box<Expr>,
     pub body: Block,
+    pub is_await: bool,
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
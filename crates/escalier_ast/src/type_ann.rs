@@ -1,6 +1,6 @@
 use generational_arena::Index;
 
-use crate::expr::BinaryOp;
+use crate::expr::{BinaryOp, Regex, Str};
 // use crate::func_param::FuncParam;
 use crate::identifier::Ident;
 use crate::pattern::Pattern;
@@ -61,6 +61,9 @@ pub enum PropModifier {
 pub struct Prop {
     pub span: Span,
     pub name: String,
+    // Computed props are keyed by a symbol, e.g. `[Symbol.iterator]: T` or
+    // `[sym]: T`, `name` is the expression inside the brackets.
+    pub computed: bool,
     pub modifier: Option<PropModifier>,
     pub optional: bool,
     pub readonly: bool,
@@ -81,6 +84,7 @@ pub struct Mapped {
     pub target: String,
     pub source: Box<TypeAnn>,
     pub optional: Option<MappedModifier>,
+    pub readonly: Option<MappedModifier>,
 
     // First half of a Conditional
     pub check: Option<Box<TypeAnn>>,
@@ -123,6 +127,29 @@ pub struct BinaryTypeAnn {
     pub right: Box<TypeAnn>,
 }
 
+// The `label` is purely documentary, e.g. `x` in `[x: number, y: number]`.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct TupleElemTypeAnn {
+    pub label: Option<Ident>,
+    pub type_ann: TypeAnn,
+}
+
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct TemplateLitTypeAnn {
+    pub parts: Vec<Str>,
+    pub types: Vec<TypeAnn>,
+}
+
+// A user-defined type guard, e.g. `x is string`, or an assertion signature,
+// e.g. `asserts x is string` or `asserts x`.  These can only be used as the
+// return type of a function.
+#[derive(Debug, PartialEq, Eq, Clone)]
+pub struct PredicateTypeAnn {
+    pub asserts: bool,
+    pub param: Ident,
+    pub type_ann: Option<Box<TypeAnn>>,
+}
+
 #[derive(Debug, PartialEq, Eq, Clone)]
 pub enum TypeAnnKind {
     BoolLit(bool),
@@ -131,13 +158,17 @@ pub enum TypeAnnKind {
     Number,
     StrLit(String),
     String,
+    TemplateLiteral(TemplateLitTypeAnn),
+    Regex(Regex),
     Symbol,
+    UniqueSymbol,
     Null,
     Undefined,
     Unknown,
     Never,
+    Void,
     Object(Vec<ObjectProp>),
-    Tuple(Vec<TypeAnn>),
+    Tuple(Vec<TupleElemTypeAnn>),
     Array(Box<TypeAnn>),
     TypeRef(String, Option<Vec<TypeAnn>>),
     Function(FunctionType),
@@ -152,6 +183,7 @@ pub enum TypeAnnKind {
     Wildcard,
     Infer(String),
     Binary(BinaryTypeAnn),
+    Predicate(PredicateTypeAnn),
 }
 
 #[derive(Debug, PartialEq, Eq, Clone)]
@@ -47,6 +47,7 @@ pub fn walk_expr<V: Visitor>(visitor: &mut V, expr: &Expr) {
         crate::ExprKind::Ident(_) => {}
         crate::ExprKind::Num(_) => {}
         crate::ExprKind::Str(_) => {}
+        crate::ExprKind::Regex(_) => {}
         crate::ExprKind::Bool(_) => {}
         crate::ExprKind::Null(_) => {}
         crate::ExprKind::Undefined(_) => {}
@@ -140,6 +141,9 @@ pub fn walk_expr<V: Visitor>(visitor: &mut V, expr: &Expr) {
             type_params,
             super_class: _,
             super_type_args,
+            implements,
+            is_abstract: _,
+            is_declare: _,
             body,
         }) => {
             if let Some(type_params) = type_params {
@@ -159,6 +163,10 @@ pub fn walk_expr<V: Visitor>(visitor: &mut V, expr: &Expr) {
                 }
             }
 
+            for type_ann in implements {
+                visitor.visit_type_ann(type_ann);
+            }
+
             // TODO
             for member in body {
                 match member {
@@ -183,7 +191,10 @@ pub fn walk_expr<V: Visitor>(visitor: &mut V, expr: &Expr) {
                 }
             }
             for arg in args {
-                visitor.visit_expr(arg);
+                match arg {
+                    ExprOrSpread::Expr(expr) => visitor.visit_expr(expr),
+                    ExprOrSpread::Spread(expr) => visitor.visit_expr(expr),
+                }
             }
         }
         crate::ExprKind::New(New {
@@ -199,13 +210,17 @@ pub fn walk_expr<V: Visitor>(visitor: &mut V, expr: &Expr) {
                 }
             }
             for arg in args {
-                visitor.visit_expr(arg);
+                match arg {
+                    ExprOrSpread::Expr(expr) => visitor.visit_expr(expr),
+                    ExprOrSpread::Spread(expr) => visitor.visit_expr(expr),
+                }
             }
         }
         crate::ExprKind::Member(Member {
             object,
             property,
             opt_chain: _,
+            is_private: _,
         }) => {
             visitor.visit_expr(object);
             match property {
@@ -302,6 +317,12 @@ pub fn walk_pattern<V: Visitor>(visitor: &mut V, pattern: &Pattern) {
         }
         crate::PatternKind::Lit(_) => {}
         crate::PatternKind::Is(_) => {}
+        crate::PatternKind::Range(_) => {}
+        crate::PatternKind::Or(OrPat { patterns }) => {
+            for pattern in patterns {
+                visitor.visit_pattern(pattern);
+            }
+        }
         crate::PatternKind::Wildcard => {}
     }
 }
@@ -327,6 +348,7 @@ pub fn walk_decl<V: Visitor>(visitor: &mut V, decl: &Decl) {
             name: _,
             type_ann,
             type_params,
+            is_interface: _,
         }) => {
             if let Some(type_params) = type_params {
                 for type_param in type_params {
@@ -340,13 +362,24 @@ pub fn walk_decl<V: Visitor>(visitor: &mut V, decl: &Decl) {
             }
             visitor.visit_type_ann(type_ann);
         }
+        DeclKind::GlobalDecl(crate::GlobalDecl { decls })
+        | DeclKind::NamespaceDecl(crate::NamespaceDecl { decls, .. }) => {
+            for decl in decls {
+                visitor.visit_decl(decl);
+            }
+        }
+        DeclKind::NamespaceAliasDecl(crate::NamespaceAliasDecl { name: _, target }) => {
+            visitor.visit_expr(target);
+        }
     }
 }
 
 pub fn walk_stmt<V: Visitor>(visitor: &mut V, stmt: &Stmt) {
     match &stmt.kind {
         StmtKind::Expr(ExprStmt { expr }) => visitor.visit_expr(expr),
-        StmtKind::For(ForStmt { left, right, body }) => {
+        StmtKind::For(ForStmt {
+            left, right, body, ..
+        }) => {
             visitor.visit_pattern(left);
             visitor.visit_expr(right);
             walk_block(visitor, body);
@@ -369,11 +402,15 @@ pub fn walk_type_ann<V: Visitor>(_visitor: &mut V, type_ann: &TypeAnn) {
         crate::TypeAnnKind::Number => {}
         crate::TypeAnnKind::StrLit(_) => {}
         crate::TypeAnnKind::String => {}
+        crate::TypeAnnKind::TemplateLiteral(_) => {}
+        crate::TypeAnnKind::Regex(_) => {}
         crate::TypeAnnKind::Symbol => {}
+        crate::TypeAnnKind::UniqueSymbol => {}
         crate::TypeAnnKind::Null => {}
         crate::TypeAnnKind::Undefined => {}
         crate::TypeAnnKind::Unknown => {}
         crate::TypeAnnKind::Never => {}
+        crate::TypeAnnKind::Void => {}
         crate::TypeAnnKind::Object(_) => {}
         crate::TypeAnnKind::Tuple(_) => {}
         crate::TypeAnnKind::Array(_) => {}
@@ -390,6 +427,7 @@ pub fn walk_type_ann<V: Visitor>(_visitor: &mut V, type_ann: &TypeAnn) {
         crate::TypeAnnKind::Wildcard => {}
         crate::TypeAnnKind::Infer(_) => {}
         crate::TypeAnnKind::Binary(_) => {}
+        crate::TypeAnnKind::Predicate(_) => {}
     }
 }
 
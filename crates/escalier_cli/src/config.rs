@@ -0,0 +1,242 @@
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use serde::Deserialize;
+
+pub const CONFIG_FILE_NAME: &str = "escalier.json";
+
+/// The contents of an `escalier.json` file.  All paths are relative to the
+/// directory containing the file.
+#[derive(Debug, Default, Clone, PartialEq, Eq, Deserialize)]
+#[serde(default, deny_unknown_fields, rename_all = "camelCase")]
+pub struct Config {
+    // Where to write .js and .d.ts files, defaults to next to the input.
+    pub out_dir: Option<PathBuf>,
+    // Whether to write a .js.map file next to each .js file.
+    pub sourcemap: Option<bool>,
+    // Whether to shorten generated names and leave out whitespace.
+    pub minify: Option<bool>,
+    // Whether to compute operations on literals at compile time.
+    pub fold_constants: Option<bool>,
+    // Whether to turn self-recursive calls in tail position into loops.
+    pub optimize_tailcalls: Option<bool>,
+    // Whether to leave out declarations that can't be reached from
+    // `entryPoints`.
+    pub treeshake: Option<bool>,
+    // The names of the declarations that are used by other code, defaults to
+    // all of them.
+    pub entry_points: Option<Vec<String>>,
+    // A .d.ts file with the types of globals, e.g. lib.es5.d.ts.
+    pub lib: Option<PathBuf>,
+    // The packages in node_modules whose type definitions are loaded after
+    // `lib`, e.g. "lodash" which uses the types from @types/lodash.
+    pub types: Option<Vec<String>>,
+    // Whether accessing properties on possibly null values is an error.
+    pub strict_null_checks: Option<bool>,
+    // Turns on all of the strict checks, e.g. `strictNullChecks`, unless
+    // they're turned off individually.
+    pub strict: Option<bool>,
+    // A .d.ts file with React's types, e.g. the `JSXElement` type used by
+    // JSX, it's loaded after `lib`.
+    pub react_types_path: Option<PathBuf>,
+}
+
+impl Config {
+    pub fn parse(src: &str) -> Result<Config, String> {
+        serde_json::from_str(src).map_err(|error| error.to_string())
+    }
+
+    /// Returns a copy with all of the paths resolved relative to `dir`.
+    pub fn resolve_paths(&self, dir: &Path) -> Config {
+        Config {
+            out_dir: self.out_dir.as_ref().map(|path| dir.join(path)),
+            sourcemap: self.sourcemap,
+            minify: self.minify,
+            fold_constants: self.fold_constants,
+            optimize_tailcalls: self.optimize_tailcalls,
+            treeshake: self.treeshake,
+            entry_points: self.entry_points.clone(),
+            lib: self.lib.as_ref().map(|path| dir.join(path)),
+            types: self.types.clone(),
+            strict_null_checks: self.strict_null_checks,
+            strict: self.strict,
+            react_types_path: self.react_types_path.as_ref().map(|path| dir.join(path)),
+        }
+    }
+
+    /// Combines two configs, values from `overrides` take precedence.
+    pub fn merge(&self, overrides: &Config) -> Config {
+        Config {
+            out_dir: overrides.out_dir.clone().or_else(|| self.out_dir.clone()),
+            sourcemap: overrides.sourcemap.or(self.sourcemap),
+            minify: overrides.minify.or(self.minify),
+            fold_constants: overrides.fold_constants.or(self.fold_constants),
+            optimize_tailcalls: overrides.optimize_tailcalls.or(self.optimize_tailcalls),
+            treeshake: overrides.treeshake.or(self.treeshake),
+            entry_points: overrides
+                .entry_points
+                .clone()
+                .or_else(|| self.entry_points.clone()),
+            lib: overrides.lib.clone().or_else(|| self.lib.clone()),
+            types: overrides.types.clone().or_else(|| self.types.clone()),
+            strict_null_checks: overrides.strict_null_checks.or(self.strict_null_checks),
+            strict: overrides.strict.or(self.strict),
+            react_types_path: overrides
+                .react_types_path
+                .clone()
+                .or_else(|| self.react_types_path.clone()),
+        }
+    }
+
+    /// Whether accessing properties on possibly null values is an error,
+    /// `strictNullChecks` takes precedence over `strict`.
+    pub fn is_strict_null_checks(&self) -> bool {
+        self.strict_null_checks.or(self.strict) == Some(true)
+    }
+}
+
+/// Looks for an `escalier.json` file in `dir` and each of its ancestors.
+pub fn find_config_file(dir: &Path) -> Option<PathBuf> {
+    dir.ancestors()
+        .map(|dir| dir.join(CONFIG_FILE_NAME))
+        .find(|path| path.is_file())
+}
+
+/// Looks for a `node_modules` directory in `dir` and each of its ancestors.
+pub fn find_node_modules(dir: &Path) -> Option<PathBuf> {
+    dir.ancestors()
+        .map(|dir| dir.join("node_modules"))
+        .find(|path| path.is_dir())
+}
+
+/// Loads the config file at `path` with its paths resolved.
+pub fn load_config(path: &Path) -> Result<Config, String> {
+    let src = fs::read_to_string(path)
+        .map_err(|error| format!("couldn't read {}: {error}", path.display()))?;
+    let config =
+        Config::parse(&src).map_err(|error| format!("invalid {}: {error}", path.display()))?;
+    let dir = path.parent().unwrap_or_else(|| Path::new(""));
+    Ok(config.resolve_paths(dir))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn parse_config() {
+        let config = Config::parse(
+            r#"{
+                "outDir": "dist",
+                "sourcemap": true,
+                "minify": true,
+                "foldConstants": true,
+                "optimizeTailcalls": true,
+                "treeshake": true,
+                "entryPoints": ["main"],
+                "lib": "types/lib.d.ts",
+                "types": ["lodash"],
+                "strictNullChecks": true,
+                "strict": true,
+                "reactTypesPath": "types/react.d.ts"
+            }"#,
+        )
+        .unwrap();
+
+        assert_eq!(
+            config,
+            Config {
+                out_dir: Some(PathBuf::from("dist")),
+                sourcemap: Some(true),
+                minify: Some(true),
+                fold_constants: Some(true),
+                optimize_tailcalls: Some(true),
+                treeshake: Some(true),
+                entry_points: Some(vec![String::from("main")]),
+                lib: Some(PathBuf::from("types/lib.d.ts")),
+                types: Some(vec![String::from("lodash")]),
+                strict_null_checks: Some(true),
+                strict: Some(true),
+                react_types_path: Some(PathBuf::from("types/react.d.ts")),
+            }
+        );
+    }
+
+    #[test]
+    fn parse_empty_config() {
+        assert_eq!(Config::parse("{}").unwrap(), Config::default());
+    }
+
+    #[test]
+    fn parse_config_with_unknown_keys() {
+        let error = Config::parse(r#"{"outdir": "dist"}"#).unwrap_err();
+
+        assert!(error.starts_with("unknown field `outdir`"), "{error}");
+    }
+
+    #[test]
+    fn parse_config_with_wrong_type() {
+        assert!(Config::parse(r#"{"sourcemap": "yes"}"#).is_err());
+    }
+
+    #[test]
+    fn resolve_and_merge_configs() {
+        let file_config = Config {
+            out_dir: Some(PathBuf::from("dist")),
+            sourcemap: Some(true),
+            minify: None,
+            fold_constants: None,
+            optimize_tailcalls: None,
+            treeshake: Some(true),
+            entry_points: Some(vec![String::from("main")]),
+            lib: Some(PathBuf::from("lib.d.ts")),
+            types: Some(vec![String::from("lodash")]),
+            strict_null_checks: Some(true),
+            strict: Some(true),
+            react_types_path: Some(PathBuf::from("react.d.ts")),
+        }
+        .resolve_paths(Path::new("project"));
+        let flags = Config {
+            out_dir: Some(PathBuf::from("build")),
+            entry_points: Some(vec![String::from("start")]),
+            strict_null_checks: Some(false),
+            ..Config::default()
+        };
+
+        assert_eq!(
+            file_config.merge(&flags),
+            Config {
+                out_dir: Some(PathBuf::from("build")),
+                sourcemap: Some(true),
+                minify: None,
+                fold_constants: None,
+                optimize_tailcalls: None,
+                treeshake: Some(true),
+                entry_points: Some(vec![String::from("start")]),
+                lib: Some(PathBuf::from("project/lib.d.ts")),
+                types: Some(vec![String::from("lodash")]),
+                strict_null_checks: Some(false),
+                strict: Some(true),
+                react_types_path: Some(PathBuf::from("project/react.d.ts")),
+            }
+        );
+    }
+
+    #[test]
+    fn strict_turns_on_strict_null_checks() {
+        let strict = Config {
+            strict: Some(true),
+            ..Config::default()
+        };
+        assert!(strict.is_strict_null_checks());
+
+        let overridden = Config {
+            strict: Some(true),
+            strict_null_checks: Some(false),
+            ..Config::default()
+        };
+        assert!(!overridden.is_strict_null_checks());
+
+        assert!(!Config::default().is_strict_null_checks());
+    }
+}
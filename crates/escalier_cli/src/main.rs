@@ -1,3 +1,379 @@
+use std::env;
+use std::fs;
+use std::io::{self, IsTerminal};
+use std::path::PathBuf;
+use std::process;
+
+use escalier_ast::Script;
+use escalier_codegen::d_ts::codegen_d_ts;
+use escalier_codegen::js::{codegen_js_with_options, codegen_ts, CodegenOptions, JsxRuntime};
+use escalier_codegen::treeshake::treeshake;
+use escalier_hm::checker::Checker;
+use escalier_hm::context::Context;
+use escalier_hm::diagnostic::{codes, Diagnostic, Severity};
+use escalier_interop::packages::PackageRegistry;
+use escalier_interop::parse::{load_dts, parse_dts};
+
+mod config;
+mod render;
+
+use config::*;
+use render::*;
+
+const USAGE: &str = "usage: escalier_cli [--config escalier.json] [--out-dir dir] [--sourcemap] \
+[--minify] [--fold-constants] [--optimize-tailcalls] [--treeshake] [--entry name]... \
+[--types package]... [--react-types react.d.ts] [--strict] [--strict-null-checks] \
+[--no-color] [--error-format human|json] [--target js|ts] [--jsx automatic|classic] \
+<input.esc> [lib.d.ts]";
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Target {
+    // Writes a .js file along with a .d.ts file with its types.
+    Js,
+    // Writes a .ts file with the inferred types as annotations.
+    Ts,
+}
+
+struct Options {
+    in_path: PathBuf,
+    config_path: Option<PathBuf>,
+    // Settings from flags, these take precedence over the config file.
+    flags: Config,
+    color: bool,
+    error_format: ErrorFormat,
+    target: Target,
+    jsx: JsxRuntime,
+}
+
+fn parse_args(args: &[String]) -> Result<Options, String> {
+    let mut positional: Vec<&String> = vec![];
+    let mut config_path: Option<PathBuf> = None;
+    let mut flags = Config::default();
+    let mut color = io::stderr().is_terminal();
+    let mut error_format = ErrorFormat::Human;
+    let mut target = Target::Js;
+    let mut jsx = JsxRuntime::Automatic;
+
+    let mut iter = args.iter();
+    while let Some(arg) = iter.next() {
+        let (name, value) = match arg.split_once('=') {
+            Some((name, value)) if name.starts_with("--") => (name, Some(value)),
+            _ => (arg.as_str(), None),
+        };
+        let mut get_value = || match value {
+            Some(value) => Ok(value),
+            None => iter
+                .next()
+                .map(|value| value.as_str())
+                .ok_or(format!("{name} requires a value")),
+        };
+
+        match name {
+            "--no-color" => color = false,
+            "--sourcemap" => flags.sourcemap = Some(true),
+            "--minify" => flags.minify = Some(true),
+            "--fold-constants" => flags.fold_constants = Some(true),
+            "--optimize-tailcalls" => flags.optimize_tailcalls = Some(true),
+            "--treeshake" => flags.treeshake = Some(true),
+            "--entry" => {
+                let entry_point = get_value()?.to_string();
+                flags
+                    .entry_points
+                    .get_or_insert_with(Vec::new)
+                    .push(entry_point);
+            }
+            "--types" => {
+                let package = get_value()?.to_string();
+                flags.types.get_or_insert_with(Vec::new).push(package);
+            }
+            "--strict" => flags.strict = Some(true),
+            "--strict-null-checks" => flags.strict_null_checks = Some(true),
+            "--react-types" => flags.react_types_path = Some(PathBuf::from(get_value()?)),
+            "--config" => config_path = Some(PathBuf::from(get_value()?)),
+            "--out-dir" => flags.out_dir = Some(PathBuf::from(get_value()?)),
+            "--error-format" => {
+                error_format = match get_value()? {
+                    "human" => ErrorFormat::Human,
+                    "json" => ErrorFormat::Json,
+                    format => return Err(format!("unknown error format {format}")),
+                }
+            }
+            "--target" => {
+                target = match get_value()? {
+                    "js" => Target::Js,
+                    "ts" => Target::Ts,
+                    target => return Err(format!("unknown target {target}")),
+                }
+            }
+            "--jsx" => {
+                jsx = match get_value()? {
+                    "automatic" => JsxRuntime::Automatic,
+                    "classic" => JsxRuntime::Classic,
+                    runtime => return Err(format!("unknown JSX runtime {runtime}")),
+                }
+            }
+            _ if name.starts_with("--") => return Err(format!("unknown option {arg}")),
+            _ => positional.push(arg),
+        }
+    }
+
+    match positional.as_slice() {
+        [_] => {}
+        [_, lib_path] => flags.lib = Some(PathBuf::from(lib_path)),
+        _ => return Err(USAGE.to_string()),
+    };
+
+    Ok(Options {
+        in_path: PathBuf::from(positional[0]),
+        config_path,
+        flags,
+        color,
+        error_format,
+        target,
+        jsx,
+    })
+}
+
+// Usage: escalier_cli [options] <input.esc> [lib.d.ts]
+//
+// Type checks the input file and prints all diagnostics to stderr.  With
+// `--error-format json` the diagnostics are printed to stdout as a JSON array
+// instead.  If there are no errors, the .js and .d.ts files are written next
+// to the input file or to `outDir`.  With `--target ts` a .ts file with the
+// inferred types as annotations is written instead.  With `--treeshake`
+// declarations that can't be reached from the `--entry` declarations are left
+// out.  With `--react-types` React's type definitions, e.g. the `JSXElement`
+// type, are loaded from the given .d.ts after the lib.d.ts file.  With
+// `--types` the type definitions for packages in the nearest
+// node_modules directory are loaded after the lib.d.ts file, declarations in
+// them that aren't supported yet are skipped with a warning.  Each package is
+// in a namespace named after it, e.g. `left_pad` for "left-pad".  Warnings and
+// info diagnostics don't affect the exit code.  JSX is compiled to calls to
+// the functions in "react/jsx-runtime" unless `--jsx classic` is used, in
+// which case it's compiled to calls to `React.createElement`.
+//
+// Settings are read from the nearest escalier.json in the input file's
+// directory or one of its ancestors, unless --config is used.  Flags take
+// precedence over settings from the config file.
 fn main() {
-    eprintln!("Hello, world!");
+    let args: Vec<String> = env::args().collect();
+
+    let options = match parse_args(&args[1..]) {
+        Ok(options) => options,
+        Err(message) => {
+            eprintln!("{message}");
+            process::exit(2);
+        }
+    };
+    let in_path = options.in_path.clone();
+
+    let config_path = options.config_path.clone().or_else(|| {
+        let in_path = env::current_dir().unwrap_or_default().join(&in_path);
+        find_config_file(in_path.parent()?)
+    });
+    let config = match &config_path {
+        Some(config_path) => match load_config(config_path) {
+            Ok(config) => config.merge(&options.flags),
+            Err(message) => {
+                eprintln!("error: {message}");
+                process::exit(2);
+            }
+        },
+        None => options.flags.clone(),
+    };
+
+    let input = match fs::read_to_string(&in_path) {
+        Ok(input) => input,
+        Err(error) => {
+            eprintln!("error: couldn't read {}: {error}", in_path.display());
+            process::exit(2);
+        }
+    };
+
+    let lib = match &config.lib {
+        Some(lib_path) => match fs::read_to_string(lib_path) {
+            Ok(lib) => lib,
+            Err(error) => {
+                eprintln!("error: couldn't read {}: {error}", lib_path.display());
+                process::exit(2);
+            }
+        },
+        None => "".to_string(),
+    };
+
+    let (mut checker, mut ctx) = match parse_dts(&lib) {
+        Ok(value) => value,
+        Err(_) => {
+            eprintln!("error: parsing .d.ts file failed");
+            process::exit(2);
+        }
+    };
+
+    if let Some(react_types_path) = &config.react_types_path {
+        let react_types = match fs::read_to_string(react_types_path) {
+            Ok(react_types) => react_types,
+            Err(error) => {
+                eprintln!(
+                    "error: couldn't read {}: {error}",
+                    react_types_path.display()
+                );
+                process::exit(2);
+            }
+        };
+        match load_dts(&mut checker, &mut ctx, &react_types) {
+            Ok(warnings) => {
+                let source = react_types_path.display().to_string();
+                report_warnings(&mut checker, &source, warnings);
+            }
+            Err(_) => {
+                eprintln!("error: parsing {} failed", react_types_path.display());
+                process::exit(2);
+            }
+        }
+    }
+
+    let packages = config.types.clone().unwrap_or_default();
+    if !packages.is_empty() {
+        let node_modules = env::current_dir()
+            .unwrap_or_default()
+            .join(&in_path)
+            .parent()
+            .and_then(find_node_modules);
+        let node_modules = match node_modules {
+            Some(node_modules) => node_modules,
+            None => {
+                eprintln!("error: couldn't find node_modules for --types");
+                process::exit(2);
+            }
+        };
+        let mut registry = PackageRegistry::new(&node_modules);
+        for package in &packages {
+            match registry.load(&mut checker, &mut ctx, package) {
+                Ok(warnings) => report_warnings(&mut checker, package, warnings),
+                Err(message) => {
+                    eprintln!("error: {message}");
+                    process::exit(2);
+                }
+            }
+        }
+    }
+
+    ctx.strict_null_checks = config.is_strict_null_checks();
+
+    let result = check(&input, &mut checker, &mut ctx);
+
+    let path = in_path.display().to_string();
+    let mut json_objects: Vec<String> = vec![];
+    for diagnostic in &checker.current_report.diagnostics {
+        match options.error_format {
+            ErrorFormat::Human => eprint!(
+                "{}",
+                render_diagnostic(&path, &input, diagnostic, options.color)
+            ),
+            ErrorFormat::Json => {
+                json_objects.push(render_diagnostic_json(&path, &input, diagnostic))
+            }
+        }
+    }
+    if let Err(message) = &result {
+        match options.error_format {
+            ErrorFormat::Human => eprint!("{}", render_error(&path, message, options.color)),
+            ErrorFormat::Json => json_objects.push(render_error_json(&path, message)),
+        }
+    }
+    if options.error_format == ErrorFormat::Json {
+        print!("{}", render_json_array(&json_objects));
+    }
+
+    let script = match result {
+        Ok(script) if !checker.current_report.has_errors() => script,
+        _ => process::exit(1),
+    };
+
+    let script = match config.treeshake {
+        Some(true) => {
+            let entry_points = config.entry_points.clone().unwrap_or_default();
+            if let Some(name) = entry_points
+                .iter()
+                .find(|name| !ctx.values.contains_key(*name))
+            {
+                eprintln!("error: entry point {name} isn't declared");
+                process::exit(2);
+            }
+            treeshake(&script, &entry_points)
+        }
+        _ => script,
+    };
+
+    let out_path = match &config.out_dir {
+        Some(out_dir) => {
+            fs::create_dir_all(out_dir).expect("unable to create output directory");
+            out_dir.join(in_path.file_name().expect("input path must be a file"))
+        }
+        None => in_path.clone(),
+    };
+
+    let codegen_options = CodegenOptions {
+        minify: config.minify == Some(true),
+        fold_constants: config.fold_constants == Some(true),
+        optimize_tailcalls: config.optimize_tailcalls == Some(true),
+        jsx: options.jsx,
+    };
+    let (extension, mut code, source_map) = match options.target {
+        Target::Js => {
+            let (js, source_map) = codegen_js_with_options(&input, &script, &codegen_options);
+            let dts = match codegen_d_ts(&script, &ctx, &checker) {
+                Ok(dts) => dts,
+                Err(error) => {
+                    eprintln!("error: generating .d.ts file failed: {}", error.message);
+                    process::exit(1);
+                }
+            };
+            fs::write(out_path.with_extension("d.ts"), dts).expect("unable to write .d.ts file");
+            ("js", js, source_map)
+        }
+        Target::Ts => {
+            let (ts, source_map) = codegen_ts(&input, &script, &ctx, &checker, &codegen_options);
+            ("ts", ts, source_map)
+        }
+    };
+
+    if config.sourcemap == Some(true) {
+        let map_path = out_path.with_extension(format!("{extension}.map"));
+        let file_name = map_path.file_name().unwrap().to_string_lossy();
+        code.push_str(&format!("//# sourceMappingURL={file_name}\n"));
+        fs::write(&map_path, source_map)
+            .unwrap_or_else(|_| panic!("unable to write .{extension}.map file"));
+    }
+
+    fs::write(out_path.with_extension(extension), code)
+        .unwrap_or_else(|_| panic!("unable to write .{extension} file"));
+}
+
+// Adds a warning for each of the declarations from `source`, a .d.ts file or
+// a package, that were skipped because they aren't supported yet.
+fn report_warnings(checker: &mut Checker, source: &str, warnings: Vec<String>) {
+    for warning in warnings {
+        checker.current_report.diagnostics.push(Diagnostic {
+            code: codes::UNSUPPORTED_DECLARATION,
+            severity: Severity::Warning,
+            message: format!("{source}: {warning}"),
+            reasons: vec![],
+            span: None,
+        });
+    }
+}
+
+// Parses and type checks `input`, diagnostics are added to the checker's
+// current report unless they're suppressed by an `escalier-ignore` comment.
+fn check(input: &str, checker: &mut Checker, ctx: &mut Context) -> Result<Script, String> {
+    let mut script = escalier_parser::parse(input).map_err(|error| error.message)?;
+
+    checker
+        .infer_script(&mut script, ctx)
+        .map_err(|error| error.message)?;
+
+    checker.apply_suppressions(input, &script.comments);
+
+    Ok(script)
 }
@@ -0,0 +1,306 @@
+use escalier_hm::diagnostic::{Diagnostic, Severity};
+
+const RESET: &str = "\x1b[0m";
+const BOLD: &str = "\x1b[1m";
+const RED: &str = "\x1b[31m";
+const YELLOW: &str = "\x1b[33m";
+const BLUE: &str = "\x1b[34m";
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ErrorFormat {
+    Human,
+    Json,
+}
+
+/// A location in the source, both `line` and `column` start at 1.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct Location {
+    pub line: usize,
+    pub column: usize,
+}
+
+pub fn get_location(src: &str, offset: usize) -> Location {
+    let offset = offset.min(src.len());
+    let before = &src[..offset];
+    let line = before.matches('\n').count() + 1;
+    let line_start = before.rfind('\n').map(|index| index + 1).unwrap_or(0);
+    Location {
+        line,
+        column: before[line_start..].chars().count() + 1,
+    }
+}
+
+struct Style {
+    color: bool,
+}
+
+impl Style {
+    fn paint(&self, codes: &[&str], text: &str) -> String {
+        match self.color {
+            true => format!("{}{text}{RESET}", codes.concat()),
+            false => text.to_string(),
+        }
+    }
+}
+
+fn severity_color(severity: Severity) -> &'static str {
+    match severity {
+        Severity::Error => RED,
+        Severity::Warning => YELLOW,
+        Severity::Info => BLUE,
+    }
+}
+
+/// Renders `diagnostic` similar to how rustc and gcc do, e.g.
+///
+/// ```text
+/// error[ESC_1000]: message
+///  --> path/to/file.esc:2:5
+///   |
+/// 2 | let x: string = 5
+///   |                 ^
+/// ```
+pub fn render_diagnostic(path: &str, src: &str, diagnostic: &Diagnostic, color: bool) -> String {
+    let style = Style { color };
+    let severity_color = severity_color(diagnostic.severity);
+
+    let mut result = format!(
+        "{}{}\n",
+        style.paint(
+            &[BOLD, severity_color],
+            &format!("{}[{}]", diagnostic.severity, diagnostic.code_name())
+        ),
+        style.paint(&[BOLD], &format!(": {}", diagnostic.message)),
+    );
+
+    match diagnostic.span {
+        Some(span) => {
+            let start = get_location(src, span.start);
+            let gutter = " ".repeat(start.line.to_string().len());
+            let bar = style.paint(&[BOLD, BLUE], "|");
+
+            result.push_str(&format!(
+                "{gutter}{} {path}:{}:{}\n",
+                style.paint(&[BOLD, BLUE], "-->"),
+                start.line,
+                start.column
+            ));
+
+            if let Some(line) = src.lines().nth(start.line - 1) {
+                // Spans that cover multiple lines are only underlined up to
+                // the end of the first line.
+                let end = get_location(src, span.end);
+                let end_column = match end.line == start.line {
+                    true => end.column,
+                    false => line.chars().count() + 1,
+                };
+                let carets = "^".repeat(end_column.saturating_sub(start.column).max(1));
+
+                result.push_str(&format!("{gutter} {bar}\n"));
+                result.push_str(&format!(
+                    "{} {bar} {line}\n",
+                    style.paint(&[BOLD, BLUE], &start.line.to_string())
+                ));
+                result.push_str(&format!(
+                    "{gutter} {bar} {}{}\n",
+                    " ".repeat(start.column - 1),
+                    style.paint(&[BOLD, severity_color], &carets)
+                ));
+            }
+        }
+        None => result.push_str(&format!(" {} {path}\n", style.paint(&[BOLD, BLUE], "-->"))),
+    }
+
+    for reason in &diagnostic.reasons {
+        result.push_str(&format!(
+            "  {} {}\n",
+            style.paint(&[BOLD, BLUE], "="),
+            reason.message
+        ));
+    }
+
+    result
+}
+
+/// Renders an error that doesn't have a diagnostic associated with it, e.g.
+/// a parse error.
+pub fn render_error(path: &str, message: &str, color: bool) -> String {
+    let style = Style { color };
+    format!(
+        "{}{}\n {} {path}\n",
+        style.paint(&[BOLD, RED], "error"),
+        style.paint(&[BOLD], &format!(": {message}")),
+        style.paint(&[BOLD, BLUE], "-->"),
+    )
+}
+
+fn json_string(value: &str) -> String {
+    let mut result = String::from("\"");
+    for c in value.chars() {
+        match c {
+            '"' => result.push_str("\\\""),
+            '\\' => result.push_str("\\\\"),
+            '\n' => result.push_str("\\n"),
+            '\r' => result.push_str("\\r"),
+            '\t' => result.push_str("\\t"),
+            c if (c as u32) < 0x20 => result.push_str(&format!("\\u{:04x}", c as u32)),
+            c => result.push(c),
+        }
+    }
+    result.push('"');
+    result
+}
+
+fn json_location(src: &str, offset: usize) -> String {
+    let Location { line, column } = get_location(src, offset);
+    format!("{{\"offset\":{offset},\"line\":{line},\"column\":{column}}}")
+}
+
+/// Renders `diagnostic` as a JSON object for use by other tools.  `start` and
+/// `end` are null if the diagnostic doesn't have a span.
+pub fn render_diagnostic_json(path: &str, src: &str, diagnostic: &Diagnostic) -> String {
+    let reasons = diagnostic
+        .reasons
+        .iter()
+        .map(|reason| json_string(&reason.message))
+        .collect::<Vec<_>>();
+
+    let (start, end) = match diagnostic.span {
+        Some(span) => (json_location(src, span.start), json_location(src, span.end)),
+        None => ("null".to_string(), "null".to_string()),
+    };
+
+    format!(
+        "{{\"file\":{},\"code\":\"{}\",\"severity\":\"{}\",\"message\":{},\"reasons\":[{}],\"start\":{start},\"end\":{end}}}",
+        json_string(path),
+        diagnostic.code_name(),
+        diagnostic.severity,
+        json_string(&diagnostic.message),
+        reasons.join(","),
+    )
+}
+
+/// Renders an error that doesn't have a diagnostic associated with it as a
+/// JSON object, its `code`, `start`, and `end` are always null.
+pub fn render_error_json(path: &str, message: &str) -> String {
+    format!(
+        "{{\"file\":{},\"code\":null,\"severity\":\"error\",\"message\":{},\"reasons\":[],\"start\":null,\"end\":null}}",
+        json_string(path),
+        json_string(message),
+    )
+}
+
+/// Combines objects returned by `render_diagnostic_json` and
+/// `render_error_json` into a JSON array with one object per line.
+pub fn render_json_array(objects: &[String]) -> String {
+    match objects.is_empty() {
+        true => "[]\n".to_string(),
+        false => format!("[\n{}\n]\n", objects.join(",\n")),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use escalier_ast::Span;
+    use escalier_hm::diagnostic::codes;
+    use escalier_hm::type_error::TypeError;
+
+    use super::*;
+
+    fn diagnostic(span: Option<Span>) -> Diagnostic {
+        Diagnostic {
+            code: codes::UNUSED_BINDING,
+            severity: Severity::Warning,
+            message: "x is declared but never used".to_string(),
+            reasons: vec![],
+            span,
+        }
+    }
+
+    #[test]
+    fn locations() {
+        let src = "let a = 5\nlet bc = 10\n";
+
+        assert_eq!(get_location(src, 0), Location { line: 1, column: 1 });
+        assert_eq!(get_location(src, 4), Location { line: 1, column: 5 });
+        assert_eq!(get_location(src, 10), Location { line: 2, column: 1 });
+        assert_eq!(get_location(src, 14), Location { line: 2, column: 5 });
+    }
+
+    #[test]
+    fn render_with_span() {
+        let src = "let y = 5\nlet x = y\n";
+        let diagnostic = diagnostic(Some(Span { start: 14, end: 15 }));
+
+        insta::assert_snapshot!(render_diagnostic("foo.esc", src, &diagnostic, false), @r###"
+        warning[ESC_2001]: x is declared but never used
+         --> foo.esc:2:5
+          |
+        2 | let x = y
+          |     ^
+        "###);
+    }
+
+    #[test]
+    fn render_multiline_span() {
+        let src = "let f = fn () {\n  return 5\n}\n";
+        let diagnostic = diagnostic(Some(Span { start: 8, end: 28 }));
+
+        insta::assert_snapshot!(render_diagnostic("foo.esc", src, &diagnostic, false), @r###"
+        warning[ESC_2001]: x is declared but never used
+         --> foo.esc:1:9
+          |
+        1 | let f = fn () {
+          |         ^^^^^^^
+        "###);
+    }
+
+    #[test]
+    fn render_without_span() {
+        let mut diagnostic = diagnostic(None);
+        diagnostic.reasons.push(TypeError {
+            message: "some reason".to_string(),
+        });
+
+        insta::assert_snapshot!(render_diagnostic("foo.esc", "", &diagnostic, false), @r###"
+        warning[ESC_2001]: x is declared but never used
+         --> foo.esc
+          = some reason
+        "###);
+    }
+
+    #[test]
+    fn render_with_color() {
+        let src = "let x = 5";
+        let diagnostic = diagnostic(Some(Span { start: 4, end: 5 }));
+
+        let output = render_diagnostic("foo.esc", src, &diagnostic, true);
+
+        assert!(output.starts_with("\x1b[1m\x1b[33mwarning[ESC_2001]\x1b[0m"));
+    }
+
+    #[test]
+    fn render_json() {
+        let src = "let y = 5\nlet x = \"y\"\n";
+        let mut diagnostic = diagnostic(Some(Span { start: 14, end: 15 }));
+        diagnostic.message = "\"x\" is declared but never used".to_string();
+
+        insta::assert_snapshot!(render_diagnostic_json("foo.esc", src, &diagnostic), @r###"{"file":"foo.esc","code":"ESC_2001","severity":"warning","message":"\"x\" is declared but never used","reasons":[],"start":{"offset":14,"line":2,"column":5},"end":{"offset":15,"line":2,"column":6}}"###);
+    }
+
+    #[test]
+    fn render_json_arrays() {
+        let objects = vec![
+            render_diagnostic_json("foo.esc", "", &diagnostic(None)),
+            render_error_json("foo.esc", "type checking failed"),
+        ];
+
+        insta::assert_snapshot!(render_json_array(&objects), @r###"
+        [
+        {"file":"foo.esc","code":"ESC_2001","severity":"warning","message":"x is declared but never used","reasons":[],"start":null,"end":null},
+        {"file":"foo.esc","code":null,"severity":"error","message":"type checking failed","reasons":[],"start":null,"end":null}
+        ]
+        "###);
+        assert_eq!(render_json_array(&[]), "[]\n");
+    }
+}
@@ -25,6 +25,19 @@ fn template_literal() {
     "###);
 }
 
+#[test]
+fn regex_literal() {
+    let src = r#"
+    let re = /(?<year>\d{4})/g
+    let plain = /a\/b/
+    "#;
+
+    insta::assert_snapshot!(compile(src), @r###"
+    export const re = /(?<year>\d{4})/g;
+    export const plain = /a\/b/;
+    "###);
+}
+
 #[test]
 fn number_literal_whole() {
     insta::assert_snapshot!(compile("123"), @"123;");
@@ -307,6 +320,44 @@ fn codegen_jsx() {
     "###);
 }
 
+#[test]
+fn codegen_jsx_key_and_fragments() {
+    insta::assert_snapshot!(compile("<Foo key=\"a\" bar={baz} />"), @r###"
+    import { jsx as _jsx } from "react/jsx-runtime";
+    _jsx(Foo, {
+        bar: baz
+    }, "a");
+    "###);
+    insta::assert_snapshot!(compile("<>{bar}</>"), @r###"
+    import { jsx as _jsx, Fragment as _Fragment } from "react/jsx-runtime";
+    _jsx(_Fragment, {
+        children: bar
+    });
+    "###);
+}
+
+#[test]
+fn codegen_jsx_classic_runtime() {
+    let compile = |src: &str| {
+        let script = parse(src).unwrap();
+        let options = CodegenOptions {
+            jsx: JsxRuntime::Classic,
+            ..CodegenOptions::default()
+        };
+        let (js, _) = codegen_js_with_options(src, &script, &options);
+        js
+    };
+
+    insta::assert_snapshot!(compile("<Foo bar={baz}>Hello</Foo>"), @r###"
+    React.createElement(Foo, {
+        bar: baz
+    }, "Hello");
+    "###);
+    insta::assert_snapshot!(compile("<>{bar}</>"), @r###"
+    React.createElement(React.Fragment, null, bar);
+    "###);
+}
+
 #[test]
 fn js_print_member_access() {
     insta::assert_snapshot!(compile("a.b.c"), @"a.b.c;");
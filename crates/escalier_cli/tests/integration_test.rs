@@ -632,8 +632,8 @@ fn calling_a_fn_with_an_obj_missing_a_property() {
     let (_, (_, checker)) = infer_script(src);
 
     insta::assert_snapshot!(current_report_message(&checker), @r###"
-    ESC_1000 - Function arguments are incorrect:
-    └ TypeError: 'y' is missing in {x: 5}
+    ESC_1000 - Argument 1 of type {x: 5} is not assignable to param p: {x: number, y: number}:
+    └ TypeError: 'y' is missing in {x: 5} but required in {x: number, y: number}
     "###);
 }
 
@@ -780,11 +780,10 @@ fn infer_fn_using_type_decl() {
     assert_eq!(result, "(p: Point) -> number");
 }
 
-// TODO: infer JSX element type
 #[test]
-#[ignore]
 fn infer_react_component() {
     let src = r#"
+    type JSXElement = {}
     type Props = {name: string}
     let Foo = fn (props: Props) {
         return <div>Hello, world</div>
@@ -793,7 +792,7 @@ fn infer_react_component() {
     let (_, (ctx, checker)) = infer_script(src);
 
     let result = checker.print_type(&ctx.values.get("Foo").unwrap().index);
-    assert_eq!(result, "(props: Props) => JSXElement");
+    assert_eq!(result, "(props: Props) -> JSXElement");
 }
 
 #[test]
@@ -1340,9 +1339,7 @@ fn destructure_lam_param_tuple() {
     assert_eq!(b, "number");
 }
 
-// TODO: infer JSX element type
 #[test]
-#[ignore]
 fn infer_jsx() {
     let src = r#"
     type JSXElement = {}
@@ -1366,8 +1363,10 @@ fn incorrect_args() {
     let (_, (_, checker)) = infer_script(src);
 
     insta::assert_snapshot!(current_report_message(&checker), @r###"
-    ESC_1000 - Function arguments are incorrect:
-    ├ TypeError: type mismatch: unify("hello", number) failed
+    ESC_1000 - Argument 1 of type "hello" is not assignable to param a: number:
+    └ TypeError: type mismatch: unify("hello", number) failed
+
+    ESC_1000 - Argument 2 of type "world" is not assignable to param b: number:
     └ TypeError: type mismatch: unify("world", number) failed
     "###);
 }
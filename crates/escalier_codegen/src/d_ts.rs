@@ -9,7 +9,7 @@ use swc_ecma_codegen::*;
 use escalier_ast::visitor::*;
 use escalier_ast::{self as values};
 use escalier_hm::checker::Checker;
-use escalier_hm::context::Context;
+use escalier_hm::context::{Binding, Context};
 use escalier_hm::type_error::TypeError;
 use escalier_hm::types;
 
@@ -54,6 +54,10 @@ fn build_type_params_from_type_params(
                         .constraint
                         .as_ref()
                         .map(|constraint| Box::from(build_type(constraint, ctx, checker)));
+                    let default = type_param
+                        .default
+                        .as_ref()
+                        .map(|default| Box::from(build_type(default, ctx, checker)));
                     TsTypeParam {
                         span: DUMMY_SP,
                         name: build_ident(&type_param.name),
@@ -61,7 +65,7 @@ fn build_type_params_from_type_params(
                         is_out: false,
                         is_const: false, // TODO: find ways to leverage this
                         constraint,
-                        default: None, // TODO
+                        default,
                     }
                 })
                 .collect(),
@@ -77,6 +81,7 @@ fn build_d_ts(
     // TODO: Create a common `Export` type
     let mut type_exports: BTreeSet<String> = BTreeSet::new();
     let mut value_exports: BTreeSet<String> = BTreeSet::new();
+    let mut global_decls: Vec<&values::Decl> = vec![];
 
     for stmt in &program.stmts {
         match &stmt.kind {
@@ -99,6 +104,20 @@ fn build_d_ts(
                         value_exports.insert(name);
                     }
                 }
+                values::DeclKind::GlobalDecl(values::GlobalDecl { decls }) => {
+                    global_decls.extend(decls);
+                }
+                // The namespace's type is an object type with its values,
+                // the types in it are shared with the top-level.
+                values::DeclKind::NamespaceDecl(decl) => {
+                    value_exports.insert(decl.name.name.to_owned());
+                    type_exports.extend(get_namespace_type_names(decl));
+                }
+                values::DeclKind::NamespaceAliasDecl(values::NamespaceAliasDecl {
+                    name, ..
+                }) => {
+                    value_exports.insert(name.name.to_owned());
+                }
             },
             values::StmtKind::Expr(_) => (),   // nothing is exported
             values::StmtKind::For(_) => (),    // nothing is exported
@@ -109,48 +128,10 @@ fn build_d_ts(
     let mut body: Vec<ModuleItem> = vec![];
 
     for name in type_exports {
-        let scheme = ctx.get_scheme(&name)?;
-
-        let type_params =
-            build_type_params_from_type_params(scheme.type_params.as_ref(), ctx, checker);
-
-        if let types::TypeKind::Object(obj) = &checker.arena[scheme.t].kind {
-            let mutable_decl =
-                ModuleItem::Stmt(Stmt::Decl(Decl::TsTypeAlias(Box::from(TsTypeAliasDecl {
-                    span: DUMMY_SP,
-                    declare: true,
-                    id: build_ident(&name),
-                    type_params: type_params.clone(),
-                    type_ann: Box::from(build_obj_type(obj, ctx, checker)),
-                }))));
-            body.push(mutable_decl);
-
-            if !name.ends_with("Constructor") {
-                if let Some(obj) = immutable_obj_type(obj) {
-                    let immutable_decl = ModuleItem::Stmt(Stmt::Decl(Decl::TsTypeAlias(
-                        Box::from(TsTypeAliasDecl {
-                            span: DUMMY_SP,
-                            declare: true,
-                            id: build_ident(format!("Readonly{name}").as_str()),
-                            type_params,
-                            type_ann: Box::from(build_obj_type(&obj, ctx, checker)),
-                        }),
-                    )));
-
-                    body.push(immutable_decl);
-                }
-            }
-        } else {
-            let decl =
-                ModuleItem::Stmt(Stmt::Decl(Decl::TsTypeAlias(Box::from(TsTypeAliasDecl {
-                    span: DUMMY_SP,
-                    declare: true,
-                    id: build_ident(&name),
-                    type_params,
-                    type_ann: Box::from(build_type(&scheme.t, ctx, checker)),
-                }))));
-
-            body.push(decl);
+        for decl in build_type_alias_decls(&name, true, ctx, checker)? {
+            body.push(ModuleItem::Stmt(Stmt::Decl(Decl::TsTypeAlias(Box::from(
+                decl,
+            )))));
         }
     }
 
@@ -161,7 +142,7 @@ fn build_d_ts(
             id: build_ident(&name),
             type_ann: Some(Box::from(TsTypeAnn {
                 span: DUMMY_SP,
-                type_ann: Box::from(build_type(&binding.index, ctx, checker)),
+                type_ann: Box::from(build_binding_type(&name, &binding, ctx, checker)),
             })),
         });
 
@@ -169,7 +150,11 @@ fn build_d_ts(
             span: DUMMY_SP,
             decl: Decl::Var(Box::from(VarDecl {
                 span: DUMMY_SP,
-                kind: VarDeclKind::Const,
+                // Bindings that can be reassigned are declared with `let`.
+                kind: match binding.is_var || binding.is_mut {
+                    true => VarDeclKind::Let,
+                    false => VarDeclKind::Const,
+                },
                 declare: true,
                 decls: vec![VarDeclarator {
                     span: DUMMY_SP,
@@ -183,6 +168,10 @@ fn build_d_ts(
         body.push(decl);
     }
 
+    if !global_decls.is_empty() {
+        body.push(build_global_decl(&global_decls, ctx, checker)?);
+    }
+
     Ok(Program::Module(Module {
         span: DUMMY_SP,
         body,
@@ -190,7 +179,179 @@ fn build_d_ts(
     }))
 }
 
+// Builds `declare global { ... }` from the decls in all of the `declare global`
+// blocks.  Interfaces only include the members from their own declarations
+// so that they're merged with existing globals, e.g. `Window`, instead of
+// replacing them.
+fn build_global_decl(
+    decls: &[&values::Decl],
+    ctx: &Context,
+    checker: &Checker,
+) -> core::result::Result<ModuleItem, TypeError> {
+    let mut body: Vec<ModuleItem> = vec![];
+
+    for decl in decls {
+        match &decl.kind {
+            values::DeclKind::TypeDecl(values::TypeDecl {
+                name,
+                type_ann,
+                is_interface: true,
+                ..
+            }) => {
+                let obj = match type_ann.inferred_type.map(|t| &checker.arena[t].kind) {
+                    Some(types::TypeKind::Object(obj)) => obj,
+                    _ => continue,
+                };
+                if let TsType::TsTypeLit(TsTypeLit { members, .. }) =
+                    build_obj_type(obj, ctx, checker)
+                {
+                    let scheme = ctx.get_scheme(name)?;
+                    body.push(ModuleItem::Stmt(Stmt::Decl(Decl::TsInterface(Box::from(
+                        TsInterfaceDecl {
+                            span: DUMMY_SP,
+                            id: build_ident(name),
+                            declare: false,
+                            type_params: build_type_params_from_type_params(
+                                scheme.type_params.as_ref(),
+                                ctx,
+                                checker,
+                            ),
+                            extends: vec![],
+                            body: TsInterfaceBody {
+                                span: DUMMY_SP,
+                                body: members,
+                            },
+                        },
+                    )))));
+                }
+            }
+            values::DeclKind::TypeDecl(values::TypeDecl { name, .. }) => {
+                for decl in build_type_alias_decls(name, false, ctx, checker)? {
+                    body.push(ModuleItem::Stmt(Stmt::Decl(Decl::TsTypeAlias(Box::from(
+                        decl,
+                    )))));
+                }
+            }
+            values::DeclKind::VarDecl(_) | values::DeclKind::NamespaceDecl(_) => {
+                // Declared namespaces are ambient objects, like variables.
+                let names = match &decl.kind {
+                    values::DeclKind::VarDecl(values::VarDecl { pattern, .. }) => {
+                        get_bindings(pattern)
+                    }
+                    values::DeclKind::NamespaceDecl(decl) => vec![decl.name.name.to_owned()],
+                    _ => unreachable!(),
+                };
+                for name in names {
+                    let binding = ctx.get_binding(&name)?;
+
+                    let pat = Pat::Ident(BindingIdent {
+                        id: build_ident(&name),
+                        type_ann: Some(Box::from(TsTypeAnn {
+                            span: DUMMY_SP,
+                            type_ann: Box::from(build_binding_type(&name, &binding, ctx, checker)),
+                        })),
+                    });
+
+                    let var_decl = VarDecl {
+                        span: DUMMY_SP,
+                        kind: match binding.is_var || binding.is_mut {
+                            true => VarDeclKind::Let,
+                            false => VarDeclKind::Const,
+                        },
+                        // Everything inside of `declare global` is already
+                        // ambient.
+                        declare: false,
+                        decls: vec![VarDeclarator {
+                            span: DUMMY_SP,
+                            name: pat,
+                            init: None,
+                            definite: false,
+                        }],
+                    };
+                    body.push(ModuleItem::Stmt(Stmt::Decl(Decl::Var(Box::from(var_decl)))));
+                }
+            }
+            values::DeclKind::GlobalDecl(_) | values::DeclKind::NamespaceAliasDecl(_) => {
+                unreachable!()
+            }
+        }
+    }
+
+    Ok(ModuleItem::Stmt(Stmt::Decl(Decl::TsModule(Box::from(
+        TsModuleDecl {
+            span: DUMMY_SP,
+            declare: true,
+            global: true,
+            id: TsModuleName::Ident(build_ident("global")),
+            body: Some(TsNamespaceBody::TsModuleBlock(TsModuleBlock {
+                span: DUMMY_SP,
+                body,
+            })),
+        },
+    )))))
+}
+
+/// Returns the type aliases for the type named `name`, object types also get
+/// a `Readonly` variant that's used by immutable references to them.
+pub fn build_type_alias_decls(
+    name: &str,
+    declare: bool,
+    ctx: &Context,
+    checker: &Checker,
+) -> core::result::Result<Vec<TsTypeAliasDecl>, TypeError> {
+    let scheme = ctx.get_scheme(name)?;
+
+    let type_params = build_type_params_from_type_params(scheme.type_params.as_ref(), ctx, checker);
+
+    let mut decls = vec![];
+
+    if let types::TypeKind::Object(obj) = &checker.arena[scheme.t].kind {
+        decls.push(TsTypeAliasDecl {
+            span: DUMMY_SP,
+            declare,
+            id: build_ident(name),
+            type_params: type_params.clone(),
+            type_ann: Box::from(build_obj_type(obj, ctx, checker)),
+        });
+
+        if !name.ends_with("Constructor") {
+            if let Some(obj) = immutable_obj_type(obj) {
+                decls.push(TsTypeAliasDecl {
+                    span: DUMMY_SP,
+                    declare,
+                    id: build_ident(format!("Readonly{name}").as_str()),
+                    type_params,
+                    type_ann: Box::from(build_obj_type(&obj, ctx, checker)),
+                });
+            }
+        }
+    } else {
+        decls.push(TsTypeAliasDecl {
+            span: DUMMY_SP,
+            declare,
+            id: build_ident(name),
+            type_params,
+            type_ann: Box::from(build_type(&scheme.t, ctx, checker)),
+        });
+    }
+
+    Ok(decls)
+}
+
 // TODO: create a trait for this and then provide multiple implementations
+fn get_namespace_type_names(decl: &values::NamespaceDecl) -> Vec<String> {
+    decl.decls
+        .iter()
+        .flat_map(|decl| match &decl.kind {
+            values::DeclKind::TypeDecl(values::TypeDecl { name, .. }) => vec![name.to_owned()],
+            values::DeclKind::NamespaceDecl(decl) => get_namespace_type_names(decl),
+            values::DeclKind::VarDecl(_)
+            | values::DeclKind::GlobalDecl(_)
+            | values::DeclKind::NamespaceAliasDecl(_) => vec![],
+        })
+        .collect()
+}
+
 pub fn build_ident(name: &str) -> Ident {
     Ident {
         span: DUMMY_SP,
@@ -199,6 +360,61 @@ pub fn build_ident(name: &str) -> Ident {
     }
 }
 
+// Properties keyed by symbols, e.g. `[Symbol.iterator]`, are computed keys
+// in TypeScript.
+fn build_prop_key(key: &types::TPropKey) -> (Expr, bool) {
+    let path = match key {
+        types::TPropKey::StringKey(key) | types::TPropKey::NumberKey(key) => {
+            return (Expr::from(build_ident(key)), false)
+        }
+        types::TPropKey::SymbolKey(types::UniqueSymbol { name, .. }) => name,
+    };
+
+    let mut names = path.split('.');
+    let mut expr = Expr::from(build_ident(names.next().unwrap_or_default()));
+    for name in names {
+        expr = Expr::Member(MemberExpr {
+            span: DUMMY_SP,
+            obj: Box::from(expr),
+            prop: MemberProp::Ident(build_ident(name)),
+        });
+    }
+    (expr, true)
+}
+
+// Builds a possibly qualified name, e.g. `Symbol.iterator`.
+fn build_entity_name(path: &str) -> TsEntityName {
+    let mut names = path.split('.');
+    let mut entity_name = TsEntityName::from(build_ident(names.next().unwrap_or_default()));
+    for name in names {
+        entity_name = TsEntityName::TsQualifiedName(Box::from(TsQualifiedName {
+            left: entity_name,
+            right: build_ident(name),
+        }));
+    }
+    entity_name
+}
+
+// The type of a unique symbol is `unique symbol` in the const that declares
+// it, TypeScript doesn't allow it anywhere else.
+fn build_binding_type(name: &str, binding: &Binding, ctx: &Context, checker: &Checker) -> TsType {
+    match &checker.arena[binding.index].kind {
+        types::TypeKind::UniqueSymbol(symbol)
+            if symbol.name == name && !(binding.is_var || binding.is_mut) =>
+        {
+            TsType::TsTypeOperator(TsTypeOperator {
+                span: DUMMY_SP,
+                op: TsTypeOperatorOp::Unique,
+                type_ann: Box::from(TsType::TsKeywordType(TsKeywordType {
+                    span: DUMMY_SP,
+                    kind: TsKeywordTypeKind::TsSymbolKeyword,
+                })),
+            })
+        }
+        _ => build_type(&binding.index, ctx, checker),
+    }
+}
+
 pub fn build_ts_pattern(pat: &types::TPat) -> Pat {
     match pat {
         types::TPat::Ident(bi) => Pat::Ident(BindingIdent {
@@ -361,6 +577,7 @@ pub fn build_type(
                 types::Keyword::Never => TsKeywordTypeKind::TsNeverKeyword,
                 types::Keyword::Object => TsKeywordTypeKind::TsObjectKeyword,
                 types::Keyword::Unknown => TsKeywordTypeKind::TsUnknownKeyword,
+                types::Keyword::Void => TsKeywordTypeKind::TsVoidKeyword,
                 // TODO:
                 // types::Keyword::Object => TsKeywordTypeKind::TsObjectKeyword,
                 // types::Keyword::Self_ => return TsType::TsThisType(TsThisType { span: DUMMY_SP }),
@@ -419,6 +636,28 @@ pub fn build_type(
                 lit,
             })
         }
+        types::TypeKind::TemplateLiteral(types::TemplateLitType { parts, types }) => {
+            TsType::TsLitType(TsLitType {
+                span: DUMMY_SP,
+                lit: TsLit::Tpl(TsTplLitType {
+                    span: DUMMY_SP,
+                    types: types
+                        .iter()
+                        .map(|t| Box::from(build_type(t, ctx, checker)))
+                        .collect(),
+                    quasis: parts
+                        .iter()
+                        .enumerate()
+                        .map(|(i, part)| TplElement {
+                            span: DUMMY_SP,
+                            tail: i == parts.len() - 1,
+                            cooked: Some(Atom::new(part.clone())),
+                            raw: Atom::new(part.clone()),
+                        })
+                        .collect(),
+                }),
+            })
+        }
         types::TypeKind::Function(types::Function {
             params,
             ret,
@@ -519,14 +758,20 @@ pub fn build_type(
                 t
             }
         }
-        types::TypeKind::Tuple(types::Tuple { types }) => {
+        types::TypeKind::Tuple(types::Tuple { types, labels }) => {
             let type_ann = TsType::TsTupleType(TsTupleType {
                 span: DUMMY_SP,
                 elem_types: types
                     .iter()
-                    .map(|t| TsTupleElement {
+                    .zip(labels.iter())
+                    .map(|(t, label)| TsTupleElement {
                         span: DUMMY_SP,
-                        label: None,
+                        label: label.as_ref().map(|label| {
+                            Pat::Ident(BindingIdent {
+                                id: build_ident(label),
+                                type_ann: None,
+                            })
+                        }),
                         ty: Box::from(build_type(t, ctx, checker)),
                     })
                     .collect(),
@@ -605,11 +850,28 @@ pub fn build_type(
             span: DUMMY_SP,
             kind: TsKeywordTypeKind::TsAnyKeyword,
         }),
+        // `unique symbol` is only allowed as the type of the const that
+        // declares the symbol, see `build_binding_type`.
+        types::TypeKind::UniqueSymbol(types::UniqueSymbol { name, .. }) => {
+            TsType::TsTypeQuery(TsTypeQuery {
+                span: DUMMY_SP,
+                expr_name: TsTypeQueryExpr::TsEntityName(build_entity_name(name)),
+                type_args: None,
+            })
+        }
         types::TypeKind::Binary(_) => {
             // Depending on the operator, we'll need to convert this to either
             // a `number` or `boolean` type.
             todo!()
         }
+        types::TypeKind::Predicate(types::Predicate { asserts, param, t }) => {
+            TsType::TsTypePredicate(TsTypePredicate {
+                span: DUMMY_SP,
+                asserts: *asserts,
+                param_name: TsThisTypeOrIdent::Ident(build_ident(param)),
+                type_ann: t.map(|t| Box::from(build_type_ann(&t, ctx, checker))),
+            })
+        }
     }
 }
 
@@ -651,16 +913,13 @@ fn build_obj_type(obj: &types::Object, ctx: &Context, checker: &Checker) -> TsTy
             types::TObjElem::Getter(_) => todo!(), // TODO
             types::TObjElem::Setter(_) => todo!(), // TODO
             types::TObjElem::Prop(prop) => {
-                let key = match &prop.name {
-                    types::TPropKey::StringKey(key) => key.to_owned(),
-                    types::TPropKey::NumberKey(key) => key.to_owned(),
-                };
+                let (key, computed) = build_prop_key(&prop.name);
 
                 let type_elem = TsTypeElement::TsPropertySignature(TsPropertySignature {
                     span: DUMMY_SP,
                     readonly: prop.readonly,
-                    key: Box::from(Expr::from(build_ident(&key))),
-                    computed: false,
+                    key: Box::from(key),
+                    computed,
                     optional: prop.optional,
                     init: None,
                     params: vec![],
@@ -675,13 +934,18 @@ fn build_obj_type(obj: &types::Object, ctx: &Context, checker: &Checker) -> TsTy
                 target, // TODO: make this an Ident
                 source,
                 optional: _, // TODO
+                readonly,
                 // TODO:
                 check: _,
                 extends: _,
             }) => {
+                let readonly = readonly.as_ref().map(|modifier| match modifier {
+                    types::MappedModifier::Add => TruePlusMinus::Plus,
+                    types::MappedModifier::Remove => TruePlusMinus::Minus,
+                });
                 let mapped = TsType::TsMappedType(TsMappedType {
                     span: DUMMY_SP,
-                    readonly: None, // TODO
+                    readonly,
                     optional: None, // TODO
                     name_type: Some(Box::new(build_type(key, ctx, checker))),
                     type_ann: Some(Box::new(build_type(value, ctx, checker))),
@@ -741,7 +1005,7 @@ fn build_obj_type(obj: &types::Object, ctx: &Context, checker: &Checker) -> TsTy
     }
 }
 
-fn build_type_ann(t: &Index, ctx: &Context, checker: &Checker) -> TsTypeAnn {
+pub fn build_type_ann(t: &Index, ctx: &Context, checker: &Checker) -> TsTypeAnn {
     TsTypeAnn {
         span: DUMMY_SP,
         type_ann: Box::from(build_type(t, ctx, checker)),
@@ -792,6 +1056,7 @@ pub fn immutable_obj_type(obj: &types::Object) -> Option<types::Object> {
     if changed {
         Some(types::Object {
             elems,
+            class: obj.class.to_owned(),
             // is_interface: obj.is_interface,
         })
     } else {
@@ -812,7 +1077,7 @@ impl Visitor for BindingsVisitor {
     }
 }
 
-fn get_bindings(pattern: &values::Pattern) -> Vec<String> {
+pub fn get_bindings(pattern: &values::Pattern) -> Vec<String> {
     let mut visitor = BindingsVisitor { bindings: vec![] };
     visitor.visit_pattern(pattern);
     visitor.bindings
@@ -1,3 +1,4 @@
+use std::collections::{HashMap, HashSet};
 use std::rc::Rc;
 
 use swc_atoms::*;
@@ -13,31 +14,173 @@ use swc_ecma_transforms_react::{react, Options, Runtime};
 use swc_ecma_visit::*;
 
 use escalier_ast::{self as values};
+use escalier_hm::checker::Checker;
+use escalier_hm::context::Context as TypeContext;
 
-pub struct Context {
+use crate::d_ts::{build_ident, build_type_alias_decls, build_type_ann, get_bindings};
+use crate::treeshake::{get_eager_read_names, get_read_names};
+
+pub struct Context<'a> {
     pub temp_id: u32,
+    // The results of type checking, when set top-level declarations are
+    // annotated with their types and type declarations are kept.
+    pub types: Option<(&'a TypeContext, &'a Checker)>,
+    pub minify: bool,
+    pub fold_constants: bool,
+    pub optimize_tailcalls: bool,
+    pub jsx: JsxRuntime,
+    pub scope: Scope,
+}
+
+// Locals that are renamed so that they don't collide with other bindings of
+// the same name, see `get_local_renames`.
+#[derive(Clone, Debug, Default)]
+pub struct Scope {
+    // The names that bindings are emitted as, by their original names.
+    renames: HashMap<String, String>,
+    // The names of the local functions that are declared later in the
+    // current block, function bodies can call them before they're declared.
+    hoisted_fns: HashMap<String, String>,
 }
 
-impl Context {
+impl<'a> Context<'a> {
     pub fn new_ident(&mut self) -> Ident {
+        let sym = match self.minify {
+            true => format!("${}", self.temp_id),
+            false => format!("$temp_{}", self.temp_id),
+        };
         let ident = Ident {
             span: DUMMY_SP,
-            sym: JsWord::from(format!("$temp_{}", self.temp_id)),
+            sym: JsWord::from(sym),
             optional: false,
         };
         self.temp_id += 1;
         ident
     }
+
+    pub fn new_local_name(&mut self, name: &str) -> String {
+        let name = format!("{name}${}", self.temp_id);
+        self.temp_id += 1;
+        name
+    }
+
+    // Returns `ident` with the name its binding is emitted as.
+    fn rename(&self, mut ident: Ident) -> Ident {
+        if let Some(name) = self.scope.renames.get(&*ident.sym) {
+            ident.sym = JsWord::from(name.as_str());
+        }
+        ident
+    }
+
+    // Brings the bindings in `pattern` into scope, `renames` are the names
+    // that they're emitted as if they've been renamed.
+    fn bind(&mut self, pattern: &values::Pattern, renames: &HashMap<String, String>) {
+        for name in get_bindings(pattern) {
+            match renames.get(&name) {
+                Some(new_name) => self.scope.renames.insert(name, new_name.to_owned()),
+                None => self.scope.renames.remove(&name),
+            };
+        }
+    }
+
+    // Starts the scope of a function's body and returns the current scope so
+    // that it can be restored after the function has been built.
+    fn enter_fn(&mut self, params: &[values::FuncParam]) -> Scope {
+        let scope = self.scope.clone();
+        let hoisted_fns = std::mem::take(&mut self.scope.hoisted_fns);
+        self.scope.renames.extend(hoisted_fns);
+        for param in params {
+            self.bind(&param.pattern, &HashMap::new());
+        }
+        scope
+    }
+}
+
+/// How JSX is lowered to function calls.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum JsxRuntime {
+    /// Calls `jsx` and `jsxs` which are imported from "react/jsx-runtime",
+    /// `key` is passed as a separate argument.
+    #[default]
+    Automatic,
+    /// Calls `React.createElement` which has to be in scope.
+    Classic,
+}
+
+#[derive(Debug, Clone, Default)]
+pub struct CodegenOptions {
+    // When true, temporary variables get shorter names and the output doesn't
+    // include any extra whitespace.  Exported names are never changed.
+    pub minify: bool,
+    // When true, operations on literals are replaced with their results and
+    // if-else expressions with constant conditions with the branch that's
+    // taken.
+    pub fold_constants: bool,
+    // When true, self-recursive calls in tail position are replaced with
+    // loops so that deep recursion doesn't overflow the stack.  This is off by
+    // default because those calls no longer appear in stack traces.
+    pub optimize_tailcalls: bool,
+    // Which functions JSX elements and fragments are turned into calls to.
+    pub jsx: JsxRuntime,
 }
 
 pub fn codegen_js(src: &str, program: &values::Script) -> (String, String) {
-    let mut ctx = Context { temp_id: 0 };
-    let program = build_js(program, &mut ctx);
+    codegen_js_with_options(src, program, &CodegenOptions::default())
+}
+
+pub fn codegen_js_with_options(
+    src: &str,
+    program: &values::Script,
+    options: &CodegenOptions,
+) -> (String, String) {
+    let mut ctx = Context {
+        temp_id: 0,
+        types: None,
+        minify: options.minify,
+        fold_constants: options.fold_constants,
+        optimize_tailcalls: options.optimize_tailcalls,
+        jsx: options.jsx,
+        scope: Scope::default(),
+    };
+    codegen(src, program, &mut ctx)
+}
+
+/// Like `codegen_js` but the output is TypeScript, top-level declarations are
+/// annotated with the types inferred by `checker` and type declarations are
+/// exported as type aliases.  Types are converted the same way they are for
+/// .d.ts files, e.g. `throws` clauses are dropped and immutable references to
+/// object types use their `Readonly` variant.
+pub fn codegen_ts(
+    src: &str,
+    program: &values::Script,
+    type_ctx: &TypeContext,
+    checker: &Checker,
+    options: &CodegenOptions,
+) -> (String, String) {
+    let mut ctx = Context {
+        temp_id: 0,
+        types: Some((type_ctx, checker)),
+        minify: options.minify,
+        fold_constants: options.fold_constants,
+        optimize_tailcalls: options.optimize_tailcalls,
+        jsx: options.jsx,
+        scope: Scope::default(),
+    };
+    codegen(src, program, &mut ctx)
+}
+
+fn codegen(src: &str, program: &values::Script, ctx: &mut Context) -> (String, String) {
+    let program = build_js(program, ctx);
+    let minify = ctx.minify;
 
     let cm = Rc::new(source_map::SourceMap::default());
     let comments: Option<SingleThreadedComments> = None;
+    let runtime = match ctx.jsx {
+        JsxRuntime::Automatic => Runtime::Automatic,
+        JsxRuntime::Classic => Runtime::Classic,
+    };
     let options = Options {
-        runtime: Some(Runtime::Automatic),
+        runtime: Some(runtime),
         ..Default::default()
     };
 
@@ -48,11 +191,11 @@ pub fn codegen_js(src: &str, program: &values::Script) -> (String, String) {
         let unresolved_mark = Mark::new();
         let mut v = react(cm, comments, options, top_level_mark, unresolved_mark);
         let program = program.fold_with(&mut v);
-        print_js(src, &program)
+        print_js(src, &program, minify)
     })
 }
 
-fn print_js(src: &str, program: &Program) -> (String, String) {
+fn print_js(src: &str, program: &Program, minify: bool) -> (String, String) {
     let mut buf = vec![];
     let mut src_map = vec![];
     let cm = Rc::new(source_map::SourceMap::new(FilePathMapping::empty()));
@@ -63,6 +206,7 @@ fn print_js(src: &str, program: &Program) -> (String, String) {
         let wr = text_writer::JsWriter::new(cm.clone(), "\n", &mut buf, Some(&mut src_map));
         let mut emitter = Emitter {
             cfg: swc_ecma_codegen::Config {
+                minify,
                 ..Default::default()
             },
             cm: cm.clone(),
@@ -89,54 +233,103 @@ fn build_js(program: &values::Script, ctx: &mut Context) -> Program {
             let mut stmts: Vec<Stmt> = vec![];
             let result = match &child.kind {
                 values::StmtKind::Decl(decl) => match &decl.kind {
-                    values::DeclKind::TypeDecl(_) => {
-                        ModuleItem::Stmt(Stmt::Empty(EmptyStmt { span: DUMMY_SP }))
+                    values::DeclKind::TypeDecl(values::TypeDecl { name, .. }) => {
+                        let aliases = match ctx.types {
+                            Some((type_ctx, checker)) => {
+                                build_type_alias_decls(name, false, type_ctx, checker)
+                                    .unwrap_or_default()
+                            }
+                            None => vec![],
+                        };
+                        match aliases.is_empty() {
+                            true => {
+                                vec![ModuleItem::Stmt(Stmt::Empty(EmptyStmt { span: DUMMY_SP }))]
+                            }
+                            false => aliases
+                                .into_iter()
+                                .map(|alias| {
+                                    ModuleItem::ModuleDecl(ModuleDecl::ExportDecl(ExportDecl {
+                                        span: DUMMY_SP,
+                                        decl: Decl::TsTypeAlias(Box::from(alias)),
+                                    }))
+                                })
+                                .collect(),
+                        }
                     }
                     values::DeclKind::VarDecl(values::VarDecl {
                         pattern,
                         expr: init,
                         is_declare: declare,
+                        is_var,
                         ..
                     }) => match declare {
-                        true => ModuleItem::Stmt(Stmt::Empty(EmptyStmt { span: DUMMY_SP })),
+                        true => vec![ModuleItem::Stmt(Stmt::Empty(EmptyStmt { span: DUMMY_SP }))],
                         false => {
                             // It should be okay to unwrap this here since any decl that isn't
                             // using `declare` should have an initial value.
                             let init = init.as_ref().unwrap();
 
-                            ModuleItem::ModuleDecl(ModuleDecl::ExportDecl(ExportDecl {
+                            let mut var_decl = build_var_decl(
+                                pattern,
+                                Some(init),
+                                *is_var,
+                                &HashMap::new(),
+                                &mut stmts,
+                                ctx,
+                            );
+                            annotate_var_decl(&mut var_decl, pattern, ctx);
+
+                            vec![ModuleItem::ModuleDecl(ModuleDecl::ExportDecl(ExportDecl {
                                 span: DUMMY_SP,
-                                decl: Decl::Var(Box::from(build_var_decl(
-                                    pattern,
-                                    Some(init),
-                                    &mut stmts,
-                                    ctx,
-                                ))),
-                            }))
+                                decl: Decl::Var(Box::from(var_decl)),
+                            }))]
                         }
                     },
+                    // Globals and declared namespaces are ambient so there's
+                    // nothing to emit.
+                    values::DeclKind::GlobalDecl(_)
+                    | values::DeclKind::NamespaceDecl(values::NamespaceDecl {
+                        is_declare: true,
+                        ..
+                    }) => {
+                        vec![ModuleItem::Stmt(Stmt::Empty(EmptyStmt { span: DUMMY_SP }))]
+                    }
+                    values::DeclKind::NamespaceDecl(decl) => {
+                        let obj = build_namespace(decl, &decl.name.name, &mut stmts, ctx);
+                        let var_decl = build_const_decl(Ident::from(&decl.name), obj);
+
+                        vec![ModuleItem::ModuleDecl(ModuleDecl::ExportDecl(ExportDecl {
+                            span: DUMMY_SP,
+                            decl: Decl::Var(Box::from(var_decl)),
+                        }))]
+                    }
+                    values::DeclKind::NamespaceAliasDecl(values::NamespaceAliasDecl {
+                        name,
+                        target,
+                    }) => {
+                        let target = build_expr(target, &mut stmts, ctx);
+                        let var_decl = build_const_decl(Ident::from(name), target);
+
+                        vec![ModuleItem::ModuleDecl(ModuleDecl::ExportDecl(ExportDecl {
+                            span: DUMMY_SP,
+                            decl: Decl::Var(Box::from(var_decl)),
+                        }))]
+                    }
                 },
                 values::StmtKind::Expr(values::ExprStmt { expr }) => {
-                    ModuleItem::Stmt(Stmt::Expr(ExprStmt {
+                    vec![ModuleItem::Stmt(Stmt::Expr(ExprStmt {
                         span: DUMMY_SP,
                         expr: Box::from(build_expr(expr, &mut stmts, ctx)),
-                    }))
+                    }))]
                 }
-                values::StmtKind::For(values::ForStmt { left, right, body }) => {
-                    let stmt = Stmt::ForOf(ForOfStmt {
-                        span: DUMMY_SP,
-                        is_await: false,
-                        left: ForHead::VarDecl(Box::from(build_var_decl(
-                            left, None, &mut stmts, ctx,
-                        ))),
-                        right: Box::from(build_expr(right, &mut stmts, ctx)),
-                        body: Box::from(Stmt::Block(build_body_block_stmt(
-                            body,
-                            &BlockFinalizer::ExprStmt,
-                            ctx,
-                        ))),
-                    });
-                    ModuleItem::Stmt(stmt)
+                values::StmtKind::For(values::ForStmt {
+                    left,
+                    right,
+                    body,
+                    is_await,
+                }) => {
+                    let stmt = build_for_of(left, right, body, *is_await, &mut stmts, ctx);
+                    vec![ModuleItem::Stmt(stmt)]
                 }
                 // values::StmtKind::ClassDecl(values::ClassDecl { class, ident, .. }) => {
                 //     let ident = Ident::from(ident);
@@ -174,7 +367,7 @@ fn build_js(program: &values::Script, ctx: &mut Context) -> Program {
                 .iter()
                 .map(|stmt| ModuleItem::Stmt(stmt.to_owned()))
                 .collect();
-            items.push(result);
+            items.extend(result);
 
             items
         })
@@ -187,25 +380,562 @@ fn build_js(program: &values::Script, ctx: &mut Context) -> Program {
     })
 }
 
+// The decls in a namespace are flattened into variables whose names are
+// prefixed with the namespace's, e.g. `bar` in `namespace foo { ... }` is
+// emitted as `foo__bar`.  Returns an object with a property for each of the
+// namespace's values.
+fn build_namespace(
+    decl: &values::NamespaceDecl,
+    prefix: &str,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Expr {
+    let scope = ctx.scope.clone();
+
+    // Functions in the namespace can call the ones declared after them.
+    for decl in &decl.decls {
+        if let values::DeclKind::VarDecl(decl) = &decl.kind {
+            if is_fn_decl(decl) {
+                for name in get_bindings(&decl.pattern) {
+                    let new_name = format!("{prefix}__{name}");
+                    ctx.scope.hoisted_fns.insert(name, new_name);
+                }
+            }
+        }
+    }
+
+    let mut names: Vec<String> = vec![];
+    for decl in &decl.decls {
+        match &decl.kind {
+            values::DeclKind::TypeDecl(values::TypeDecl { name, .. }) => {
+                if let Some((type_ctx, checker)) = ctx.types {
+                    let aliases =
+                        build_type_alias_decls(name, false, type_ctx, checker).unwrap_or_default();
+                    for alias in aliases {
+                        stmts.push(Stmt::Decl(Decl::TsTypeAlias(Box::from(alias))));
+                    }
+                }
+            }
+            values::DeclKind::GlobalDecl(_) => {}
+            values::DeclKind::VarDecl(values::VarDecl {
+                pattern,
+                expr: init,
+                is_declare,
+                is_var,
+                ..
+            }) => {
+                let bindings = get_bindings(pattern);
+                match is_declare {
+                    // Declared values are ambient so the namespace's
+                    // properties refer to them as they are.
+                    true => ctx.bind(pattern, &HashMap::new()),
+                    false => {
+                        let renames = bindings
+                            .iter()
+                            .map(|name| (name.to_owned(), format!("{prefix}__{name}")))
+                            .collect::<HashMap<_, _>>();
+                        let var_decl =
+                            build_var_decl(pattern, init.as_ref(), *is_var, &renames, stmts, ctx);
+                        for name in &bindings {
+                            ctx.scope.hoisted_fns.remove(name);
+                        }
+                        stmts.push(Stmt::Decl(Decl::Var(Box::from(var_decl))));
+                    }
+                }
+                names.extend(bindings);
+            }
+            // Declared namespaces are ambient so the namespace's property
+            // refers to it as it is.
+            values::DeclKind::NamespaceDecl(values::NamespaceDecl {
+                name,
+                is_declare: true,
+                ..
+            }) => {
+                ctx.scope.renames.remove(&name.name);
+                names.push(name.name.to_owned());
+            }
+            values::DeclKind::NamespaceDecl(decl) => {
+                let name = &decl.name.name;
+                let new_name = format!("{prefix}__{name}");
+                let obj = build_namespace(decl, &new_name, stmts, ctx);
+                let var_decl = build_const_decl(build_ident(&new_name), obj);
+                stmts.push(Stmt::Decl(Decl::Var(Box::from(var_decl))));
+                ctx.scope.renames.insert(name.to_owned(), new_name);
+                names.push(name.to_owned());
+            }
+            values::DeclKind::NamespaceAliasDecl(values::NamespaceAliasDecl { name, target }) => {
+                let name = &name.name;
+                let new_name = format!("{prefix}__{name}");
+                let target = build_expr(target, stmts, ctx);
+                let var_decl = build_const_decl(build_ident(&new_name), target);
+                stmts.push(Stmt::Decl(Decl::Var(Box::from(var_decl))));
+                ctx.scope.renames.insert(name.to_owned(), new_name);
+                names.push(name.to_owned());
+            }
+        }
+    }
+
+    let props = names
+        .iter()
+        .map(|name| {
+            let key = build_ident(name);
+            let value = ctx.rename(key.clone());
+            match value.sym == key.sym {
+                true => PropOrSpread::Prop(Box::from(Prop::Shorthand(key))),
+                false => PropOrSpread::Prop(Box::from(Prop::KeyValue(KeyValueProp {
+                    key: PropName::Ident(key),
+                    value: Box::from(Expr::from(value)),
+                }))),
+            }
+        })
+        .collect();
+
+    ctx.scope = scope;
+
+    Expr::Object(ObjectLit {
+        span: DUMMY_SP,
+        props,
+    })
+}
+
+fn build_const_decl(ident: Ident, init: Expr) -> VarDecl {
+    VarDecl {
+        span: DUMMY_SP,
+        kind: VarDeclKind::Const,
+        declare: false,
+        decls: vec![VarDeclarator {
+            span: DUMMY_SP,
+            name: Pat::Ident(BindingIdent {
+                id: ident,
+                type_ann: None,
+            }),
+            init: Some(Box::from(init)),
+            definite: false,
+        }],
+    }
+}
+
+// Adds the inferred type of the binding to a top-level declaration when we're
+// generating TypeScript.
+fn annotate_var_decl(var_decl: &mut VarDecl, pattern: &values::Pattern, ctx: &Context) {
+    let (type_ctx, checker) = match ctx.types {
+        Some(types) => types,
+        None => return,
+    };
+    // TODO: annotate destructuring patterns
+    let name = match &pattern.kind {
+        values::PatternKind::Ident(values::BindingIdent { name, .. }) => name,
+        _ => return,
+    };
+    let binding = match type_ctx.get_binding(name) {
+        Ok(binding) => binding,
+        Err(_) => return,
+    };
+    if let Some(VarDeclarator {
+        name: Pat::Ident(ident),
+        ..
+    }) = var_decl.decls.first_mut()
+    {
+        ident.type_ann = Some(Box::from(build_type_ann(&binding.index, type_ctx, checker)));
+    }
+}
+
+// Returns true if any of the identifiers bound by `pattern` are `mut`.
+fn has_mut_binding(pattern: &values::Pattern) -> bool {
+    match &pattern.kind {
+        values::PatternKind::Ident(ident) => ident.mutable,
+        values::PatternKind::Rest(values::RestPat { arg }) => has_mut_binding(arg),
+        values::PatternKind::Object(values::ObjectPat { props, .. }) => {
+            props.iter().any(|prop| match prop {
+                values::ObjectPatProp::KeyValue(values::KeyValuePatProp { value, .. }) => {
+                    has_mut_binding(value)
+                }
+                values::ObjectPatProp::Shorthand(values::ShorthandPatProp { ident, .. }) => {
+                    ident.mutable
+                }
+                values::ObjectPatProp::Rest(values::RestPat { arg }) => has_mut_binding(arg),
+            })
+        }
+        values::PatternKind::Tuple(values::TuplePat { elems, .. }) => elems
+            .iter()
+            .flatten()
+            .any(|elem| has_mut_binding(&elem.pattern)),
+        values::PatternKind::Is(values::IsPat { ident, .. }) => ident.mutable,
+        values::PatternKind::Lit(_)
+        | values::PatternKind::Range(_)
+        | values::PatternKind::Or(_)
+        | values::PatternKind::Wildcard => false,
+    }
+}
+
+// Bindings declared with `var` or `mut` can be reassigned so they can't be
+// `const` in the output.
+fn get_var_decl_kind(pattern: &values::Pattern, is_var: bool) -> VarDeclKind {
+    match is_var || has_mut_binding(pattern) {
+        true => VarDeclKind::Let,
+        false => VarDeclKind::Const,
+    }
+}
+
+// `renames` are the names that the bindings in `pattern` are emitted as if
+// they differ from their own.  The bindings aren't in scope until after `init`.
 fn build_var_decl(
     pattern: &values::Pattern,
     init: Option<&values::Expr>,
+    is_var: bool,
+    renames: &HashMap<String, String>,
     stmts: &mut Vec<Stmt>,
     ctx: &mut Context,
 ) -> VarDecl {
+    let init = init.map(|init| Box::from(build_init(pattern, init, stmts, ctx)));
+    ctx.bind(pattern, renames);
     VarDecl {
         span: DUMMY_SP,
-        kind: VarDeclKind::Const,
+        kind: get_var_decl_kind(pattern, is_var),
         declare: false,
         decls: vec![VarDeclarator {
             span: DUMMY_SP,
             name: build_pattern(pattern, stmts, ctx).unwrap(),
-            init: init.map(|init| Box::from(build_expr(init, stmts, ctx))),
+            init,
             definite: false,
         }],
     }
 }
 
+// Builds the initial value of a declaration, functions bound to `pattern`
+// that call themselves in tail position are turned into loops when
+// `optimize_tailcalls` is set.
+fn build_init(
+    pattern: &values::Pattern,
+    init: &values::Expr,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Expr {
+    if ctx.optimize_tailcalls {
+        if let values::PatternKind::Ident(values::BindingIdent { name, .. }) = &pattern.kind {
+            if let Some(func) = build_tail_recursive_fn(name, init, ctx) {
+                return func;
+            }
+        }
+    }
+    build_expr(init, stmts, ctx)
+}
+
+// Looks for things in a function's body that stop us from turning its
+// recursive calls into a loop: bindings that shadow the function's name and
+// closures, which could capture params that the loop reassigns.
+struct TailCallBlocker<'a> {
+    name: &'a str,
+    blocked: bool,
+}
+
+impl<'a> values::Visitor for TailCallBlocker<'a> {
+    fn visit_expr(&mut self, expr: &values::Expr) {
+        match &expr.kind {
+            values::ExprKind::Function(_) | values::ExprKind::Class(_) => self.blocked = true,
+            _ => values::walk_expr(self, expr),
+        }
+    }
+
+    fn visit_pattern(&mut self, pattern: &values::Pattern) {
+        if get_bindings(pattern).iter().any(|name| name == self.name) {
+            self.blocked = true;
+        }
+    }
+}
+
+// Returns a version of the function `init` that runs inside of a loop if it's
+// bound to `name` and returns the result of calling itself.  Those calls
+// reassign the params and continue the loop instead.
+fn build_tail_recursive_fn(name: &str, init: &values::Expr, ctx: &mut Context) -> Option<Expr> {
+    let (func_params, body, is_async) = match &init.kind {
+        values::ExprKind::Function(values::Function {
+            params,
+            body,
+            is_async,
+            is_gen: false,
+            ..
+        }) => (params, body, *is_async),
+        _ => return None,
+    };
+
+    // Only simple params can be reassigned.
+    let params: Vec<Pat> = func_params
+        .iter()
+        .map(|param| match &param.pattern.kind {
+            values::PatternKind::Ident(ident) if ident.name != name => {
+                Some(Pat::Ident(BindingIdent {
+                    id: Ident::from(ident),
+                    type_ann: None,
+                }))
+            }
+            _ => None,
+        })
+        .collect::<Option<_>>()?;
+
+    let mut blocker = TailCallBlocker {
+        name,
+        blocked: false,
+    };
+    values::walk_block_or_expr(&mut blocker, body);
+    if blocker.blocked {
+        return None;
+    }
+
+    let temp_id = ctx.temp_id;
+    let scope = ctx.enter_fn(func_params);
+    // The function's name is renamed if it shadows another local.
+    let name = ctx
+        .scope
+        .renames
+        .get(name)
+        .cloned()
+        .unwrap_or_else(|| name.to_owned());
+    let mut stmts: Vec<Stmt> = vec![];
+    match body {
+        values::BlockOrExpr::Block(body) => {
+            stmts = build_body_block_stmt(body, &BlockFinalizer::ExprStmt, ctx).stmts;
+        }
+        values::BlockOrExpr::Expr(expr) => build_return_stmts(expr, &mut stmts, ctx),
+    }
+    ctx.scope = scope;
+
+    let mut found = false;
+    let mut stmts = rewrite_tail_calls(stmts, &name, &params, &mut found);
+    if !found {
+        // The function will be built again without the loop.
+        ctx.temp_id = temp_id;
+        return None;
+    }
+    // Falling through to the end of the loop would call the function again.
+    if !stmts.last().map_or(false, always_returns) {
+        stmts.push(Stmt::Return(ReturnStmt {
+            span: DUMMY_SP,
+            arg: None,
+        }));
+    }
+
+    let span = swc_common::Span {
+        lo: BytePos(init.span.start as u32 + 1),
+        hi: BytePos(init.span.end as u32 + 1),
+        ctxt: SyntaxContext::empty(),
+    };
+
+    // (params) => { while (true) { ... } }
+    let body = BlockStmt {
+        span: DUMMY_SP,
+        stmts: vec![Stmt::While(WhileStmt {
+            span: DUMMY_SP,
+            test: Box::from(Expr::Lit(Lit::Bool(Bool {
+                span: DUMMY_SP,
+                value: true,
+            }))),
+            body: Box::from(Stmt::Block(BlockStmt {
+                span: DUMMY_SP,
+                stmts,
+            })),
+        })],
+    };
+
+    Some(Expr::Arrow(ArrowExpr {
+        span,
+        params,
+        body: Box::new(BlockStmtOrExpr::BlockStmt(body)),
+        is_async,
+        is_generator: false,
+        type_params: None,
+        return_type: None,
+    }))
+}
+
+// Builds statements that return the value of `expr`.  The branches of if-else
+// expressions return their values instead of assigning them to a temporary
+// variable so that calls in tail position end up in `return` statements.
+fn build_return_stmts(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) {
+    let cond_and_branches = match &expr.kind {
+        values::ExprKind::IfElse(values::IfElse {
+            cond,
+            consequent,
+            alternate,
+        }) => Some((cond, consequent, alternate)),
+        _ => None,
+    };
+    let (cond, consequent, alternate) = match cond_and_branches {
+        Some(value) => value,
+        None => {
+            let arg = build_expr(expr, stmts, ctx);
+            stmts.push(build_finalizer(&arg, &BlockFinalizer::Return));
+            return;
+        }
+    };
+
+    let span = swc_common::Span {
+        lo: BytePos(expr.span.start as u32 + 1),
+        hi: BytePos(expr.span.end as u32 + 1),
+        ctxt: SyntaxContext::empty(),
+    };
+
+    let test = Box::from(build_expr(cond.as_ref(), stmts, ctx));
+    let cons = Box::from(Stmt::Block(build_body_block_stmt(
+        consequent,
+        &BlockFinalizer::Return,
+        ctx,
+    )));
+    let alt = alternate.as_ref().map(|alt| {
+        Box::from(match alt {
+            values::BlockOrExpr::Block(alt) => {
+                Stmt::Block(build_body_block_stmt(alt, &BlockFinalizer::Return, ctx))
+            }
+            values::BlockOrExpr::Expr(alt) => {
+                let mut alt_stmts: Vec<Stmt> = vec![];
+                build_return_stmts(alt, &mut alt_stmts, ctx);
+                match alt_stmts.len() {
+                    1 => alt_stmts.pop().unwrap(),
+                    _ => Stmt::Block(BlockStmt {
+                        span: DUMMY_SP,
+                        stmts: alt_stmts,
+                    }),
+                }
+            }
+        })
+    });
+
+    stmts.push(Stmt::If(IfStmt {
+        span,
+        test,
+        cons,
+        alt,
+    }));
+}
+
+// Returns the args of `expr` if it's a call to `name` with an arg for each of
+// the `params`.
+fn get_self_call_args(expr: &Expr, name: &str, params: &[Pat]) -> Option<Vec<Expr>> {
+    match expr {
+        Expr::Call(CallExpr {
+            callee: Callee::Expr(callee),
+            args,
+            ..
+        }) if matches!(callee.as_ref(), Expr::Ident(ident) if &*ident.sym == name)
+            && args.len() == params.len()
+            && args.iter().all(|arg| arg.spread.is_none()) =>
+        {
+            Some(args.iter().map(|arg| *arg.expr.to_owned()).collect())
+        }
+        _ => None,
+    }
+}
+
+// Replaces each `return name(args)` with statements that assign the args to
+// the params and continue the loop.  Loops aren't searched since `continue`
+// would apply to them instead.
+fn rewrite_tail_calls(stmts: Vec<Stmt>, name: &str, params: &[Pat], found: &mut bool) -> Vec<Stmt> {
+    let rewrite_branch = |stmt: Stmt, found: &mut bool| {
+        let mut stmts = rewrite_tail_calls(vec![stmt], name, params, found);
+        match stmts.len() {
+            1 => stmts.pop().unwrap(),
+            _ => Stmt::Block(BlockStmt {
+                span: DUMMY_SP,
+                stmts,
+            }),
+        }
+    };
+
+    let mut new_stmts: Vec<Stmt> = vec![];
+    for stmt in stmts {
+        match stmt {
+            Stmt::Return(ReturnStmt {
+                arg: Some(arg),
+                span,
+            }) => match get_self_call_args(&arg, name, params) {
+                Some(mut args) => {
+                    *found = true;
+                    // [a, b] = [<arg_a>, <arg_b>]
+                    let (left, right) = match params {
+                        [] => (None, None),
+                        [param] => (Some(param.to_owned()), args.pop()),
+                        _ => (
+                            Some(Pat::Array(ArrayPat {
+                                span: DUMMY_SP,
+                                elems: params.iter().map(|param| Some(param.to_owned())).collect(),
+                                optional: false,
+                                type_ann: None,
+                            })),
+                            Some(Expr::Array(ArrayLit {
+                                span: DUMMY_SP,
+                                elems: args
+                                    .into_iter()
+                                    .map(|arg| {
+                                        Some(ExprOrSpread {
+                                            spread: None,
+                                            expr: Box::from(arg),
+                                        })
+                                    })
+                                    .collect(),
+                            })),
+                        ),
+                    };
+                    if let (Some(left), Some(right)) = (left, right) {
+                        new_stmts.push(Stmt::Expr(ExprStmt {
+                            span,
+                            expr: Box::from(Expr::Assign(AssignExpr {
+                                span,
+                                op: AssignOp::Assign,
+                                left: PatOrExpr::Pat(Box::from(left)),
+                                right: Box::from(right),
+                            })),
+                        }));
+                    }
+                    new_stmts.push(Stmt::Continue(ContinueStmt {
+                        span: DUMMY_SP,
+                        label: None,
+                    }));
+                }
+                None => new_stmts.push(Stmt::Return(ReturnStmt {
+                    arg: Some(arg),
+                    span,
+                })),
+            },
+            Stmt::If(IfStmt {
+                span,
+                test,
+                cons,
+                alt,
+            }) => {
+                let cons = Box::from(rewrite_branch(*cons, found));
+                let alt = alt.map(|alt| Box::from(rewrite_branch(*alt, found)));
+                new_stmts.push(Stmt::If(IfStmt {
+                    span,
+                    test,
+                    cons,
+                    alt,
+                }));
+            }
+            Stmt::Block(BlockStmt { span, stmts }) => new_stmts.push(Stmt::Block(BlockStmt {
+                span,
+                stmts: rewrite_tail_calls(stmts, name, params, found),
+            })),
+            stmt => new_stmts.push(stmt),
+        }
+    }
+
+    new_stmts
+}
+
+// Returns true if none of the paths through `stmt` reach the end of it.
+fn always_returns(stmt: &Stmt) -> bool {
+    match stmt {
+        Stmt::Return(_) | Stmt::Continue(_) => true,
+        Stmt::If(IfStmt {
+            cons,
+            alt: Some(alt),
+            ..
+        }) => always_returns(cons) && always_returns(alt),
+        Stmt::Block(BlockStmt { stmts, .. }) => stmts.last().map_or(false, always_returns),
+        _ => false,
+    }
+}
+
 // TODO: See if we can avoid returning an Option<> here so that we don't have
 // to unwrap() in when calling it from build_expr().
 fn build_pattern(
@@ -222,10 +952,11 @@ fn build_pattern(
     match &pattern.kind {
         // unassignable patterns
         values::PatternKind::Lit(_) => None,
+        values::PatternKind::Range(_) => None,
 
-        // TODO: we need to have something we can assign `_` to when it appears
-        // in object destructuring otherwise if there's a `...rest` that's also
-        // in the pattern we'll end up with the wrong items assigned to `rest`.
+        // Wildcards inside of tuple and object patterns are skipped, but we
+        // still need something to assign `_` to when it's used by itself,
+        // e.g. `let _ = foo()` or `fn (_, b) => b`.
         values::PatternKind::Wildcard => Some(Pat::Ident(BindingIdent {
             id: ctx.new_ident(),
             type_ann: None,
@@ -233,7 +964,7 @@ fn build_pattern(
 
         // assignable patterns
         values::PatternKind::Ident(binding_ident) => Some(Pat::Ident(BindingIdent {
-            id: Ident::from(binding_ident),
+            id: ctx.rename(Ident::from(binding_ident)),
             type_ann: None,
         })),
         values::PatternKind::Rest(values::RestPat { arg }) => {
@@ -251,11 +982,22 @@ fn build_pattern(
             }))
         }
         values::PatternKind::Object(values::ObjectPat { props, optional }) => {
+            // If there's a `...rest` we still need to assign `_` to something
+            // otherwise the property would end up in `rest`.
+            let has_rest = props
+                .iter()
+                .any(|p| matches!(p, values::ObjectPatProp::Rest(_)));
             let props: Vec<ObjectPatProp> = props
                 .iter()
                 .filter_map(|p| match p {
+                    values::ObjectPatProp::KeyValue(kvp)
+                        if kvp.value.kind == values::PatternKind::Wildcard && !has_rest =>
+                    {
+                        None
+                    }
                     values::ObjectPatProp::KeyValue(kvp) => {
                         build_pattern(kvp.value.as_ref(), stmts, ctx).map(|value| {
+                            let value = build_default(value, &kvp.init, stmts, ctx);
                             ObjectPatProp::KeyValue(KeyValuePatProp {
                                 key: PropName::Ident(Ident::from(&kvp.key)),
                                 value: Box::from(value),
@@ -266,13 +1008,28 @@ fn build_pattern(
                         ident,
                         init,
                         ..
-                    }) => Some(ObjectPatProp::Assign(AssignPatProp {
-                        span: DUMMY_SP,
-                        key: Ident::from(ident),
-                        value: init
-                            .clone()
-                            .map(|value| Box::from(build_expr(&value, stmts, ctx))),
-                    })),
+                    }) => {
+                        let key = Ident::from(ident);
+                        let value = ctx.rename(key.clone());
+                        // Renamed bindings can't use the shorthand syntax.
+                        if value.sym != key.sym {
+                            let value = Pat::Ident(BindingIdent {
+                                id: value,
+                                type_ann: None,
+                            });
+                            return Some(ObjectPatProp::KeyValue(KeyValuePatProp {
+                                key: PropName::Ident(key),
+                                value: Box::from(build_default(value, init, stmts, ctx)),
+                            }));
+                        }
+                        Some(ObjectPatProp::Assign(AssignPatProp {
+                            span: DUMMY_SP,
+                            key,
+                            value: init
+                                .clone()
+                                .map(|value| Box::from(build_expr(&value, stmts, ctx))),
+                        }))
+                    }
                     values::ObjectPatProp::Rest(values::RestPat { arg }) => {
                         let dot3_token = swc_common::Span {
                             lo: BytePos(pattern.span.start as u32 + 1),
@@ -302,14 +1059,23 @@ fn build_pattern(
             }))
         }
         values::PatternKind::Tuple(values::TuplePat { elems, optional }) => {
-            let elems: Vec<Option<Pat>> = elems
+            let mut elems: Vec<Option<Pat>> = elems
                 .iter()
                 .map(|elem| match elem {
-                    Some(elem) => build_pattern(&elem.pattern, stmts, ctx),
+                    // Skipping the element leaves a hole in the array pattern
+                    // so that the elements after it still line up.
+                    Some(elem) if elem.pattern.kind == values::PatternKind::Wildcard => None,
+                    Some(elem) => build_pattern(&elem.pattern, stmts, ctx)
+                        .map(|pat| build_default(pat, &elem.init, stmts, ctx)),
                     None => None,
                 })
                 .collect();
 
+            // Holes at the end don't line anything up, e.g. `[msg, 1]`.
+            while let Some(None) = elems.last() {
+                elems.pop();
+            }
+
             // TODO: If all elems are None, we can drop the array pattern.
             Some(Pat::Array(ArrayPat {
                 span,
@@ -318,13 +1084,34 @@ fn build_pattern(
                 type_ann: None, // because we're generating .js.
             }))
         }
+        // The alternatives can bind the same names in different positions so
+        // they're destructured by `build_or_pattern_bindings` instead.
+        values::PatternKind::Or(_) => None,
         values::PatternKind::Is(values::IsPat { ident, .. }) => Some(Pat::Ident(BindingIdent {
-            id: Ident::from(ident),
+            id: ctx.rename(Ident::from(ident)),
             type_ann: None,
         })),
     }
 }
 
+// Wraps `pat` in an assignment pattern if it has a default value, e.g. the
+// `x = 1` in `{a: x = 1}`.
+fn build_default(
+    pat: Pat,
+    init: &Option<Box<values::Expr>>,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Pat {
+    match init {
+        Some(init) => Pat::Assign(AssignPat {
+            span: DUMMY_SP,
+            left: Box::from(pat),
+            right: Box::from(build_expr(init, stmts, ctx)),
+        }),
+        None => pat,
+    }
+}
+
 fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) -> Expr {
     let span = swc_common::Span {
         lo: BytePos(expr.span.start as u32 + 1),
@@ -340,15 +1127,15 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
 
             let args: Vec<ExprOrSpread> = args
                 .iter()
-                // TODO: Support spreading args when calling functions
-                .map(|arg| ExprOrSpread {
-                    // spread: if arg.spread.is_some() {
-                    //     Some(DUMMY_SP)
-                    // } else {
-                    //     None
-                    // },
-                    spread: None,
-                    expr: Box::from(build_expr(arg, stmts, ctx)),
+                .map(|arg| match arg {
+                    values::ExprOrSpread::Expr(expr) => ExprOrSpread {
+                        spread: None,
+                        expr: Box::from(build_expr(expr, stmts, ctx)),
+                    },
+                    values::ExprOrSpread::Spread(spread) => ExprOrSpread {
+                        spread: Some(DUMMY_SP),
+                        expr: Box::from(build_expr(spread, stmts, ctx)),
+                    },
                 })
                 .collect();
 
@@ -383,18 +1170,61 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
         //         type_args: None,
         //     })
         // }
-        values::ExprKind::Ident(ident) => Expr::from(Ident::from(ident)),
+        values::ExprKind::Ident(ident) => Expr::from(ctx.rename(Ident::from(ident))),
         values::ExprKind::Function(values::Function {
             params: args,
             body,
             is_async,
+            is_gen,
             ..
         }) => {
+            let scope = ctx.enter_fn(args);
             let params: Vec<Pat> = args
                 .iter()
                 .map(|arg| build_pattern(&arg.pattern, stmts, ctx).unwrap())
                 .collect();
 
+            // Arrow functions can't be generators so we emit a function
+            // expression instead.
+            if *is_gen {
+                let body = match body {
+                    values::BlockOrExpr::Block(body) => {
+                        build_body_block_stmt(body, &BlockFinalizer::ExprStmt, ctx)
+                    }
+                    values::BlockOrExpr::Expr(expr) => BlockStmt {
+                        span: DUMMY_SP,
+                        stmts: vec![Stmt::Return(ReturnStmt {
+                            span: DUMMY_SP,
+                            arg: Some(Box::from(build_expr(expr, stmts, ctx))),
+                        })],
+                    },
+                };
+
+                let params: Vec<Param> = params
+                    .into_iter()
+                    .map(|pat| Param {
+                        span: DUMMY_SP,
+                        decorators: vec![],
+                        pat,
+                    })
+                    .collect();
+                ctx.scope = scope;
+
+                return Expr::Fn(FnExpr {
+                    ident: None,
+                    function: Box::from(Function {
+                        params,
+                        decorators: vec![],
+                        span,
+                        body: Some(body),
+                        is_generator: true,
+                        is_async: is_async.to_owned(),
+                        type_params: None,
+                        return_type: None,
+                    }),
+                });
+            }
+
             let body = match body {
                 values::BlockOrExpr::Block(body) => BlockStmtOrExpr::BlockStmt(
                     build_body_block_stmt(body, &BlockFinalizer::ExprStmt, ctx),
@@ -403,6 +1233,7 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
                     BlockStmtOrExpr::Expr(Box::from(build_expr(expr, stmts, ctx)))
                 }
             };
+            ctx.scope = scope;
 
             Expr::Arrow(ArrowExpr {
                 span,
@@ -477,6 +1308,8 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
                 values::BinaryOp::LessThanOrEqual => BinaryOp::LtEq,
                 values::BinaryOp::GreaterThan => BinaryOp::Gt,
                 values::BinaryOp::GreaterThanOrEqual => BinaryOp::GtEq,
+                values::BinaryOp::In => BinaryOp::In,
+                values::BinaryOp::InstanceOf => BinaryOp::InstanceOf,
                 _ => todo!(),
             };
 
@@ -489,6 +1322,12 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
 
             let right = Box::from(build_expr(right, stmts, ctx));
 
+            if ctx.fold_constants {
+                if let Some(expr) = fold_binary(op, &left, &right, span) {
+                    return expr;
+                }
+            }
+
             let wrap_right = match right.as_ref() {
                 Expr::Bin(right) => match (op, right.op) {
                     (BinaryOp::Div, BinaryOp::Div) => true,
@@ -538,15 +1377,30 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
             alternate,
             ..
         }) => {
-            // let $temp_n;
-            let temp_id = ctx.new_ident();
-            let temp_decl = build_let_decl_stmt(&temp_id);
-            stmts.push(temp_decl);
+            let (temp_id, test) = match ctx.fold_constants {
+                // The condition is built first so that we know whether the
+                // `if` can be left out.
+                true => {
+                    let test = build_expr(cond.as_ref(), stmts, ctx);
+                    if let Expr::Lit(Lit::Bool(Bool { value, .. })) = test {
+                        return build_const_if_else(value, consequent, alternate, stmts, ctx);
+                    }
+                    let temp_id = ctx.new_ident();
+                    stmts.push(build_let_decl_stmt(&temp_id));
+                    (temp_id, test)
+                }
+                false => {
+                    // let $temp_n;
+                    let temp_id = ctx.new_ident();
+                    stmts.push(build_let_decl_stmt(&temp_id));
+                    (temp_id, build_expr(cond.as_ref(), stmts, ctx))
+                }
+            };
 
             let finalizer = BlockFinalizer::Assign(temp_id.clone());
 
             // if (cond) { ...; $temp_n = <cons_res> } else { ...; $temp_n = <alt_res> }
-            let test = Box::from(build_expr(cond.as_ref(), stmts, ctx));
+            let test = Box::from(test);
             let cons = Box::from(Stmt::Block(build_body_block_stmt(
                 consequent, &finalizer, ctx,
             )));
@@ -569,7 +1423,17 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
                 .map(|prop| match prop {
                     values::PropOrSpread::Prop(prop) => match prop {
                         values::expr::Prop::Shorthand(ident) => {
-                            PropOrSpread::Prop(Box::from(Prop::Shorthand(Ident::from(ident))))
+                            let key = Ident::from(ident);
+                            let value = ctx.rename(key.clone());
+                            match value.sym == key.sym {
+                                true => PropOrSpread::Prop(Box::from(Prop::Shorthand(key))),
+                                false => {
+                                    PropOrSpread::Prop(Box::from(Prop::KeyValue(KeyValueProp {
+                                        key: PropName::Ident(key),
+                                        value: Box::from(Expr::from(value)),
+                                    })))
+                                }
+                            }
                         }
                         values::expr::Prop::Property { key, value } => {
                             PropOrSpread::Prop(Box::from(Prop::KeyValue(KeyValueProp {
@@ -591,7 +1455,9 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
         values::ExprKind::JSXElement(elem) => {
             Expr::JSXElement(Box::from(build_jsx_element(elem, stmts, ctx)))
         }
-        values::ExprKind::JSXFragment(_) => todo!(),
+        values::ExprKind::JSXFragment(frag) => {
+            Expr::JSXFragment(build_jsx_fragment(frag, stmts, ctx))
+        }
         values::ExprKind::Tuple(values::Tuple { elements: elems }) => Expr::Array(ArrayLit {
             span,
             elems: elems
@@ -611,9 +1477,18 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
         values::ExprKind::Member(values::Member {
             object: obj,
             property: prop,
+            is_private,
             ..
         }) => {
             let prop = match prop {
+                // The checker only marks accesses to the private members of
+                // the class that `obj` is an instance of.
+                values::MemberProp::Ident(ident) if *is_private => {
+                    MemberProp::PrivateName(PrivateName {
+                        span: DUMMY_SP,
+                        id: Ident::from(ident),
+                    })
+                }
                 values::MemberProp::Ident(ident) => MemberProp::Ident(Ident::from(ident)),
                 values::MemberProp::Computed(values::ComputedPropName { expr, .. }) => {
                     MemberProp::Computed(ComputedPropName {
@@ -723,14 +1598,11 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
                 class: Box::from(class),
             })
         }
-        // values::ExprKind::Regex(regex) => Expr::Lit(Lit::Regex(Regex {
-        //     span,
-        //     exp: Atom::new(regex.pattern.as_ref()),
-        //     flags: match &regex.flags {
-        //         Some(flags) => Atom::new(flags.as_ref()),
-        //         None => Atom::new(""),
-        //     },
-        // })),
+        values::ExprKind::Regex(regex) => Expr::Lit(Lit::Regex(Regex {
+            span,
+            exp: Atom::new(regex.pattern.clone()),
+            flags: Atom::new(regex.flags.clone().unwrap_or_default()),
+        })),
         values::ExprKind::Do(do_expr) => {
             let temp_id = ctx.new_ident();
             let temp_decl = build_let_decl_stmt(&temp_id);
@@ -744,11 +1616,145 @@ fn build_expr(expr: &values::Expr, stmts: &mut Vec<Stmt>, ctx: &mut Context) ->
             Expr::Ident(temp_id)
         }
         values::ExprKind::Try(_) => todo!(),
-        values::ExprKind::Yield(_) => todo!(),
+        values::ExprKind::Yield(values::Yield { arg }) => Expr::Yield(YieldExpr {
+            span,
+            arg: Some(Box::from(build_expr(arg, stmts, ctx))),
+            delegate: false,
+        }),
         values::ExprKind::Throw(_) => todo!(),
     }
 }
 
+// Numbers are only folded when the operands and the result are integers that
+// can be represented exactly so that folding never changes how a value is
+// rounded or printed.
+const MAX_SAFE_INTEGER: f64 = 9007199254740991.0;
+
+fn is_safe_integer(value: f64) -> bool {
+    value.fract() == 0.0 && value.abs() <= MAX_SAFE_INTEGER
+}
+
+// Returns the value of `expr` if it's a number literal or a negated one.
+fn get_num_lit(expr: &Expr) -> Option<f64> {
+    match expr {
+        Expr::Lit(Lit::Num(Number { value, .. })) => Some(*value),
+        Expr::Unary(UnaryExpr {
+            op: UnaryOp::Minus,
+            arg,
+            ..
+        }) => match arg.as_ref() {
+            Expr::Lit(Lit::Num(Number { value, .. })) => Some(-value),
+            _ => None,
+        },
+        _ => None,
+    }
+}
+
+fn build_num_lit(value: f64, span: swc_common::Span) -> Expr {
+    let lit = Expr::Lit(Lit::Num(Number {
+        span,
+        value: value.abs(),
+        raw: None,
+    }));
+    match value < 0.0 {
+        true => Expr::Unary(UnaryExpr {
+            span,
+            op: UnaryOp::Minus,
+            arg: Box::from(lit),
+        }),
+        false => lit,
+    }
+}
+
+// Returns the result of `left op right` if both operands are literals and the
+// operation can be done at compile time without changing what the program
+// does.  Division is never folded since it can produce fractions, infinities
+// or NaN.
+fn fold_binary(op: BinaryOp, left: &Expr, right: &Expr, span: swc_common::Span) -> Option<Expr> {
+    let bool_lit = |value: bool| Some(Expr::Lit(Lit::Bool(Bool { span, value })));
+
+    if let (Some(left), Some(right)) = (get_num_lit(left), get_num_lit(right)) {
+        if !is_safe_integer(left) || !is_safe_integer(right) {
+            return None;
+        }
+        let value = match op {
+            BinaryOp::Add => left + right,
+            BinaryOp::Sub => left - right,
+            BinaryOp::Mul => left * right,
+            BinaryOp::EqEqEq => return bool_lit(left == right),
+            BinaryOp::NotEqEq => return bool_lit(left != right),
+            BinaryOp::Lt => return bool_lit(left < right),
+            BinaryOp::LtEq => return bool_lit(left <= right),
+            BinaryOp::Gt => return bool_lit(left > right),
+            BinaryOp::GtEq => return bool_lit(left >= right),
+            _ => return None,
+        };
+        // `-0` would be printed as `0`.
+        return match is_safe_integer(value) && !(value == 0.0 && value.is_sign_negative()) {
+            true => Some(build_num_lit(value, span)),
+            false => None,
+        };
+    }
+
+    match (left, right) {
+        (Expr::Lit(Lit::Str(left)), Expr::Lit(Lit::Str(right))) => match op {
+            BinaryOp::Add => Some(Expr::Lit(Lit::Str(Str {
+                span,
+                value: JsWord::from(format!("{}{}", left.value, right.value)),
+                raw: None,
+            }))),
+            BinaryOp::EqEqEq => bool_lit(left.value == right.value),
+            BinaryOp::NotEqEq => bool_lit(left.value != right.value),
+            _ => None,
+        },
+        (Expr::Lit(Lit::Bool(left)), Expr::Lit(Lit::Bool(right))) => match op {
+            BinaryOp::EqEqEq => bool_lit(left.value == right.value),
+            BinaryOp::NotEqEq => bool_lit(left.value != right.value),
+            _ => None,
+        },
+        _ => None,
+    }
+}
+
+// Builds the branch of an if-else expression that's taken when its condition
+// is always `cond`.  Branches that are a single expression are inlined, other
+// blocks are kept so that their bindings stay scoped to them.
+fn build_const_if_else(
+    cond: bool,
+    consequent: &values::Block,
+    alternate: &Option<values::BlockOrExpr>,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Expr {
+    let block = match (cond, alternate) {
+        (true, _) => consequent,
+        (false, Some(values::BlockOrExpr::Block(block))) => block,
+        (false, Some(values::BlockOrExpr::Expr(expr))) => return build_expr(expr, stmts, ctx),
+        (false, None) => {
+            return Expr::Ident(Ident {
+                span: DUMMY_SP,
+                sym: JsWord::from("undefined"),
+                optional: false,
+            })
+        }
+    };
+
+    match block.stmts.as_slice() {
+        [values::Stmt {
+            kind: values::StmtKind::Expr(values::ExprStmt { expr }),
+            ..
+        }] => build_expr(expr, stmts, ctx),
+        _ => {
+            // let $temp_n; { ...; $temp_n = <res> }
+            let temp_id = ctx.new_ident();
+            stmts.push(build_let_decl_stmt(&temp_id));
+            let finalizer = BlockFinalizer::Assign(temp_id.clone());
+            stmts.push(Stmt::Block(build_body_block_stmt(block, &finalizer, ctx)));
+            Expr::Ident(temp_id)
+        }
+    }
+}
+
 fn build_alt(
     block_or_expr: &values::BlockOrExpr,
     finalizer: &BlockFinalizer,
@@ -824,6 +1830,7 @@ fn prop_name_from_object_key(key: &values::ObjectKey, ctx: &mut Context) -> Prop
 enum BlockFinalizer {
     ExprStmt,
     Assign(Ident),
+    Return,
 }
 
 fn build_finalizer(expr: &Expr, finalizer: &BlockFinalizer) -> Stmt {
@@ -844,9 +1851,90 @@ fn build_finalizer(expr: &Expr, finalizer: &BlockFinalizer) -> Stmt {
             span: DUMMY_SP,
             expr: Box::from(expr.to_owned()),
         }),
+        BlockFinalizer::Return => Stmt::Return(ReturnStmt {
+            span: DUMMY_SP,
+            arg: Some(Box::from(expr.to_owned())),
+        }),
     }
 }
 
+fn build_for_of(
+    left: &values::Pattern,
+    right: &values::Expr,
+    body: &values::Block,
+    is_await: bool,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Stmt {
+    let scope = ctx.scope.clone();
+    let right = build_expr(right, stmts, ctx);
+    let left = build_var_decl(left, None, false, &HashMap::new(), stmts, ctx);
+    let body = build_body_block_stmt(body, &BlockFinalizer::ExprStmt, ctx);
+    ctx.scope = scope;
+
+    Stmt::ForOf(ForOfStmt {
+        span: DUMMY_SP,
+        is_await,
+        left: ForHead::VarDecl(Box::from(left)),
+        right: Box::from(right),
+        body: Box::from(Stmt::Block(body)),
+    })
+}
+
+fn is_fn_decl(decl: &values::VarDecl) -> bool {
+    matches!(decl.pattern.kind, values::PatternKind::Ident(_))
+        && matches!(
+            &decl.expr,
+            Some(values::Expr {
+                kind: values::ExprKind::Function(_),
+                ..
+            })
+        )
+}
+
+// Returns the names that the bindings declared by each of `stmts` are emitted
+// as if they differ from their own.  Bindings in JavaScript are in scope for
+// the whole block they're declared in, so locals that shadow an earlier
+// binding in the same block, or a binding that's used earlier in the block
+// or by their own initializer, are renamed.  Local functions are hoisted so
+// uses of their names inside of earlier functions already refer to them.
+fn get_local_renames(stmts: &[values::Stmt], ctx: &mut Context) -> Vec<HashMap<String, String>> {
+    let mut declared: HashSet<String> = HashSet::new();
+    let mut read: HashSet<String> = HashSet::new();
+    let mut eagerly_read: HashSet<String> = HashSet::new();
+
+    stmts
+        .iter()
+        .map(|stmt| {
+            let mut renames = HashMap::new();
+            let read_names = get_read_names(stmt);
+            let eager_read_names = get_eager_read_names(stmt);
+            if let values::StmtKind::Decl(values::Decl {
+                kind: values::DeclKind::VarDecl(decl),
+                ..
+            }) = &stmt.kind
+            {
+                let (read_before, read_by_decl) = match is_fn_decl(decl) {
+                    true => (&eagerly_read, &eager_read_names),
+                    false => (&read, &read_names),
+                };
+                for name in get_bindings(&decl.pattern) {
+                    let is_shadowed = declared.contains(&name)
+                        || read_before.contains(&name)
+                        || read_by_decl.contains(&name);
+                    if is_shadowed && !decl.is_declare {
+                        renames.insert(name.to_owned(), ctx.new_local_name(&name));
+                    }
+                    declared.insert(name);
+                }
+            }
+            eagerly_read.extend(eager_read_names);
+            read.extend(read_names);
+            renames
+        })
+        .collect()
+}
+
 // NOTE: If an identifier has been specified in `assign_id` the last statement
 // in the block will assign the final expression to that identifier.  If it's
 // `None`, the last statement will be an actual return statement returning the
@@ -859,7 +1947,24 @@ fn build_body_block_stmt(
     let mut new_stmts: Vec<Stmt> = vec![];
     let len = body.stmts.len();
 
-    for (i, stmt) in body.stmts.iter().enumerate() {
+    let scope = ctx.scope.clone();
+    let local_renames = get_local_renames(&body.stmts, ctx);
+    for (stmt, renames) in body.stmts.iter().zip(&local_renames) {
+        if let values::StmtKind::Decl(values::Decl {
+            kind: values::DeclKind::VarDecl(decl),
+            ..
+        }) = &stmt.kind
+        {
+            if is_fn_decl(decl) {
+                for name in get_bindings(&decl.pattern) {
+                    let new_name = renames.get(&name).unwrap_or(&name).to_owned();
+                    ctx.scope.hoisted_fns.insert(name, new_name);
+                }
+            }
+        }
+    }
+
+    for (i, (stmt, renames)) in body.stmts.iter().zip(&local_renames).enumerate() {
         match &stmt.kind {
             values::StmtKind::Decl(values::Decl {
                 kind:
@@ -867,20 +1972,25 @@ fn build_body_block_stmt(
                         pattern,
                         type_ann: _,
                         expr: Some(init),
-                        is_declare: _,
+                        is_declare: false,
+                        is_var,
                         ..
                     }),
                 ..
             }) => {
-                let stmt = match build_pattern(pattern, &mut new_stmts, ctx) {
-                    Some(name) => {
-                        build_const_decl_stmt_with_pat(name, build_expr(init, &mut new_stmts, ctx))
-                    }
-                    None => todo!(),
-                };
-                new_stmts.push(stmt);
+                let var_decl =
+                    build_var_decl(pattern, Some(init), *is_var, renames, &mut new_stmts, ctx);
+                // Local functions are in scope once they've been declared.
+                for name in get_bindings(pattern) {
+                    ctx.scope.hoisted_fns.remove(&name);
+                }
+                new_stmts.push(Stmt::Decl(Decl::Var(Box::from(var_decl))));
             }
             values::StmtKind::Expr(values::ExprStmt { expr }) => {
+                if i == len - 1 && matches!(finalizer, BlockFinalizer::Return) {
+                    build_return_stmts(expr, &mut new_stmts, ctx);
+                    continue;
+                }
                 let expr = build_expr(expr, &mut new_stmts, ctx);
                 let stmt = if i == len - 1 {
                     build_finalizer(&expr, finalizer)
@@ -892,23 +2002,13 @@ fn build_body_block_stmt(
                 };
                 new_stmts.push(stmt);
             }
-            values::StmtKind::For(values::ForStmt { left, right, body }) => {
-                let stmt = Stmt::ForOf(ForOfStmt {
-                    span: DUMMY_SP,
-                    is_await: false,
-                    left: ForHead::VarDecl(Box::from(build_var_decl(
-                        left,
-                        None,
-                        &mut new_stmts,
-                        ctx,
-                    ))),
-                    right: Box::from(build_expr(right, &mut new_stmts, ctx)),
-                    body: Box::from(Stmt::Block(build_body_block_stmt(
-                        body,
-                        &BlockFinalizer::ExprStmt,
-                        ctx,
-                    ))),
-                });
+            values::StmtKind::For(values::ForStmt {
+                left,
+                right,
+                body,
+                is_await,
+            }) => {
+                let stmt = build_for_of(left, right, body, *is_await, &mut new_stmts, ctx);
                 new_stmts.push(stmt);
             }
             // values::StmtKind::Class { class, ident, .. } => {
@@ -955,6 +2055,8 @@ fn build_body_block_stmt(
         }
     }
 
+    ctx.scope = scope;
+
     if body.stmts.is_empty() {
         let undefined = swc_ecma_ast::Expr::Ident(swc_ecma_ast::Ident {
             span: DUMMY_SP,
@@ -1038,6 +2140,8 @@ fn build_arm(
 
     let cond = build_cond_for_pat(pat, id);
 
+    let scope = ctx.scope.clone();
+    ctx.bind(pat, &HashMap::new());
     let mut block = match body {
         values::BlockOrExpr::Block(body) => {
             build_body_block_stmt(body, &BlockFinalizer::Assign(ret_id.to_owned()), ctx)
@@ -1057,8 +2161,18 @@ fn build_arm(
         }
     };
 
-    // If pattern has assignables, assign them
-    if let Some(name) = build_pattern(pat, stmts, ctx) {
+    // If pattern has assignables, assign them.  A top-level `_` is a
+    // catch-all so there's nothing to assign.
+    let name = match &pat.kind {
+        values::PatternKind::Wildcard => None,
+        values::PatternKind::Or(values::OrPat { patterns }) => {
+            let bindings = build_or_pattern_bindings(patterns, id, stmts, ctx);
+            block.stmts.splice(0..0, bindings);
+            None
+        }
+        _ => build_pattern(pat, stmts, ctx),
+    };
+    if let Some(name) = name {
         let destructure = build_const_decl_stmt_with_pat(name, Expr::from(id.to_owned()));
         block.stmts.insert(0, destructure);
     }
@@ -1078,22 +2192,122 @@ fn build_arm(
         (None, Some(guard)) => Some(build_expr(guard, stmts, ctx)),
         (None, None) => None,
     };
+    ctx.scope = scope;
 
     (cond, block)
 }
 
+// Declares the names bound by an or-pattern and then destructures `id` using
+// whichever alternative matched, e.g. `[1, msg] | [msg, 1]` becomes:
+//
+// let msg;
+// if ($temp_1[0] === 1) {
+//     [, msg] = $temp_1;
+// } else {
+//     [msg] = $temp_1;
+// }
+fn build_or_pattern_bindings(
+    patterns: &[values::Pattern],
+    id: &Ident,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Vec<Stmt> {
+    let names = get_bindings(&patterns[0]);
+    if names.is_empty() {
+        return vec![];
+    }
+
+    let decl = Stmt::Decl(Decl::Var(Box::from(VarDecl {
+        span: DUMMY_SP,
+        kind: VarDeclKind::Let,
+        declare: false,
+        decls: names
+            .iter()
+            .map(|name| VarDeclarator {
+                span: DUMMY_SP,
+                name: Pat::Ident(BindingIdent::from(ctx.rename(Ident {
+                    span: DUMMY_SP,
+                    sym: JsWord::from(name.to_owned()),
+                    optional: false,
+                }))),
+                init: None,
+                definite: false,
+            })
+            .collect(),
+    })));
+
+    let mut branches: Vec<(Option<Expr>, Stmt)> = vec![];
+    for (i, pattern) in patterns.iter().enumerate() {
+        // The arm's condition already checked that one of the alternatives
+        // matched so the last one doesn't need to be checked again.
+        let cond = match i == patterns.len() - 1 {
+            true => None,
+            false => build_cond_for_pat(pattern, id),
+        };
+        let is_catchall = cond.is_none();
+
+        let left = build_pattern(pattern, stmts, ctx).unwrap();
+        let assign = Expr::Assign(AssignExpr {
+            span: DUMMY_SP,
+            op: AssignOp::Assign,
+            left: PatOrExpr::Pat(Box::from(left.clone())),
+            right: Box::from(Expr::Ident(id.to_owned())),
+        });
+        // Statements can't start with `{` so object patterns need parens.
+        let assign = match left {
+            Pat::Object(_) => Expr::Paren(ParenExpr {
+                span: DUMMY_SP,
+                expr: Box::from(assign),
+            }),
+            _ => assign,
+        };
+        let block = Stmt::Block(BlockStmt {
+            span: DUMMY_SP,
+            stmts: vec![Stmt::Expr(ExprStmt {
+                span: DUMMY_SP,
+                expr: Box::from(assign),
+            })],
+        });
+
+        branches.push((cond, block));
+        if is_catchall {
+            break;
+        }
+    }
+
+    let if_else = branches
+        .into_iter()
+        .rev()
+        .fold(None, |alt: Option<Stmt>, (cond, block)| match cond {
+            Some(cond) => Some(Stmt::If(IfStmt {
+                span: DUMMY_SP,
+                test: Box::from(cond),
+                cons: Box::from(block),
+                alt: alt.map(Box::from),
+            })),
+            None => Some(block),
+        });
+
+    vec![decl, if_else.unwrap()]
+}
+
 fn build_jsx_element(
     elem: &values::JSXElement,
     stmts: &mut Vec<Stmt>,
     ctx: &mut Context,
 ) -> JSXElement {
+    // Lowercase names are intrinsic elements which are passed as strings so
+    // they aren't renamed.
     let name = match &elem.opening.name {
-        values::JSXElementName::Ident(name) => JSXElementName::Ident(Ident {
-            span: DUMMY_SP,
-            sym: JsWord::from(name.name.to_owned()),
-            optional: false,
-        }),
-        values::JSXElementName::JSXMemberExpr(_) => todo!(),
+        values::JSXElementName::Ident(name)
+            if name.name.starts_with(|c: char| c.is_ascii_lowercase()) =>
+        {
+            JSXElementName::Ident(Ident::from(name))
+        }
+        values::JSXElementName::Ident(name) => JSXElementName::Ident(ctx.rename(Ident::from(name))),
+        values::JSXElementName::JSXMemberExpr(member) => {
+            JSXElementName::JSXMemberExpr(build_jsx_member_expr(member, ctx))
+        }
     };
 
     let elem = JSXElement {
@@ -1137,34 +2351,7 @@ fn build_jsx_element(
             self_closing: false,
             type_args: None,
         },
-        children: elem
-            .children
-            .iter()
-            .map(|child| {
-                let result: JSXElementChild = match child {
-                    values::JSXElementChild::Text(values::JSXText { value, .. }) => {
-                        JSXElementChild::JSXText(JSXText {
-                            span: DUMMY_SP,
-                            value: Atom::new(value.clone()),
-                            raw: Atom::new(value.clone()),
-                        })
-                    }
-                    values::JSXElementChild::ExprContainer(values::JSXExprContainer {
-                        expr,
-                        ..
-                    }) => JSXElementChild::JSXExprContainer(JSXExprContainer {
-                        span: DUMMY_SP,
-                        expr: JSXExpr::Expr(Box::from(build_expr(expr, stmts, ctx))),
-                    }),
-                    values::JSXElementChild::Element(elem) => {
-                        JSXElementChild::JSXElement(Box::from(build_jsx_element(elem, stmts, ctx)))
-                    }
-                    values::JSXElementChild::SpreadChild(_) => todo!(),
-                    values::JSXElementChild::Fragment(_) => todo!(),
-                };
-                result
-            })
-            .collect(),
+        children: build_jsx_children(&elem.children, stmts, ctx),
         closing: Some(JSXClosingElement {
             span: DUMMY_SP,
             name,
@@ -1174,12 +2361,80 @@ fn build_jsx_element(
     elem
 }
 
+fn build_jsx_member_expr(member: &values::JSXMemberExpr, ctx: &Context) -> JSXMemberExpr {
+    let obj = match &member.obj {
+        values::JSXObject::Ident(ident) => JSXObject::Ident(ctx.rename(Ident::from(ident))),
+        values::JSXObject::JSXMemberExpr(member) => {
+            JSXObject::JSXMemberExpr(Box::from(build_jsx_member_expr(member, ctx)))
+        }
+    };
+    JSXMemberExpr {
+        obj,
+        prop: Ident::from(&member.prop),
+    }
+}
+
+fn build_jsx_fragment(
+    frag: &values::JSXFragment,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> JSXFragment {
+    JSXFragment {
+        span: DUMMY_SP,
+        opening: JSXOpeningFragment { span: DUMMY_SP },
+        children: build_jsx_children(&frag.children, stmts, ctx),
+        closing: JSXClosingFragment { span: DUMMY_SP },
+    }
+}
+
+fn build_jsx_children(
+    children: &[values::JSXElementChild],
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Vec<JSXElementChild> {
+    children
+        .iter()
+        .map(|child| match child {
+            values::JSXElementChild::Text(values::JSXText { value, .. }) => {
+                JSXElementChild::JSXText(JSXText {
+                    span: DUMMY_SP,
+                    value: Atom::new(value.clone()),
+                    raw: Atom::new(value.clone()),
+                })
+            }
+            values::JSXElementChild::ExprContainer(values::JSXExprContainer { expr, .. }) => {
+                JSXElementChild::JSXExprContainer(JSXExprContainer {
+                    span: DUMMY_SP,
+                    expr: JSXExpr::Expr(Box::from(build_expr(expr, stmts, ctx))),
+                })
+            }
+            values::JSXElementChild::SpreadChild(values::JSXSpreadChild { expr }) => {
+                JSXElementChild::JSXSpreadChild(JSXSpreadChild {
+                    span: DUMMY_SP,
+                    expr: Box::from(build_expr(expr, stmts, ctx)),
+                })
+            }
+            values::JSXElementChild::Element(elem) => {
+                JSXElementChild::JSXElement(Box::from(build_jsx_element(elem, stmts, ctx)))
+            }
+            values::JSXElementChild::Fragment(frag) => {
+                JSXElementChild::JSXFragment(build_jsx_fragment(frag, stmts, ctx))
+            }
+        })
+        .collect()
+}
+
+// Private members are lowered to JavaScript's `#` private names, the checker
+// marks which member accesses refer to them.
 fn build_class(class: &values::Class, stmts: &mut Vec<Stmt>, ctx: &mut Context) -> Class {
     let body: Vec<ClassMember> = class
         .body
         .iter()
         .filter_map(|member| match member {
+            // Abstract methods are only used for type checking.
+            values::ClassMember::Method(method) if method.is_abstract => None,
             values::ClassMember::Method(method) => {
+                let scope = ctx.enter_fn(&method.function.params);
                 // TODO: check if `name` is `constructor`
                 let body = match &method.function.body {
                     values::BlockOrExpr::Block(block) => {
@@ -1206,28 +2461,71 @@ fn build_class(class: &values::Class, stmts: &mut Vec<Stmt>, ctx: &mut Context)
                         }
                     })
                     .collect();
+                ctx.scope = scope;
+
+                let function = Box::from(Function {
+                    params,
+                    decorators: vec![],
+                    span: DUMMY_SP, // TODO
+                    body: Some(body),
+                    is_generator: method.function.is_gen,
+                    is_async: false,   // TODO
+                    type_params: None, // TODO
+                    return_type: None,
+                });
+
+                if let (true, values::PropName::Ident(ident)) = (method.is_private, &method.name) {
+                    return Some(ClassMember::PrivateMethod(PrivateMethod {
+                        span: DUMMY_SP, // TODO
+                        key: PrivateName {
+                            span: DUMMY_SP,
+                            id: Ident::from(ident),
+                        },
+                        function,
+                        kind: MethodKind::Method,
+                        is_static: method.is_static,
+                        accessibility: None,
+                        is_abstract: false,
+                        is_optional: false,
+                        is_override: false,
+                    }));
+                }
 
                 Some(ClassMember::Method(ClassMethod {
                     span: DUMMY_SP, // TODO
                     key: prop_name_from_prop_name(&method.name, ctx),
-                    function: Box::from(Function {
-                        params,
-                        decorators: vec![],
-                        span: DUMMY_SP, // TODO
-                        body: Some(body),
-                        is_generator: false,
-                        is_async: false,   // TODO
-                        type_params: None, // TODO
-                        return_type: None,
-                    }),
+                    function,
                     kind: MethodKind::Method,
-                    is_static: false,
+                    is_static: method.is_static,
                     accessibility: None,
                     is_abstract: false,
                     is_optional: false,
                     is_override: false,
                 }))
             }
+            values::ClassMember::Field(prop) if prop.is_private => {
+                // Unlike public fields, private fields must always be declared
+                // in JavaScript, even if they don't have an initializer.
+                Some(ClassMember::PrivateProp(PrivateProp {
+                    span: DUMMY_SP, // TODO
+                    value: prop
+                        .init
+                        .as_ref()
+                        .map(|value| Box::from(build_expr(value, stmts, ctx))),
+                    key: PrivateName {
+                        span: DUMMY_SP,
+                        id: Ident::from(&prop.name),
+                    },
+                    type_ann: None,
+                    is_static: prop.is_static,
+                    decorators: vec![],
+                    accessibility: None,
+                    is_optional: false, // TODO,
+                    is_override: false,
+                    readonly: false, // TODO
+                    definite: false,
+                }))
+            }
             values::ClassMember::Field(prop) => {
                 if prop.init.is_some() {
                     Some(ClassMember::ClassProp(ClassProp {
@@ -1252,15 +2550,48 @@ fn build_class(class: &values::Class, stmts: &mut Vec<Stmt>, ctx: &mut Context)
                     None
                 }
             }
-            values::ClassMember::Getter(_) => todo!(),
-            values::ClassMember::Setter(_) => todo!(),
+            values::ClassMember::Getter(values::Getter {
+                name,
+                is_private,
+                params,
+                body,
+                ..
+            }) => {
+                let function = build_accessor_fn(params, body, stmts, ctx);
+                Some(build_accessor(
+                    name,
+                    function,
+                    MethodKind::Getter,
+                    *is_private,
+                    ctx,
+                ))
+            }
+            values::ClassMember::Setter(values::Setter {
+                name,
+                is_private,
+                params,
+                body,
+                ..
+            }) => {
+                let function = build_accessor_fn(params, body, stmts, ctx);
+                Some(build_accessor(
+                    name,
+                    function,
+                    MethodKind::Setter,
+                    *is_private,
+                    ctx,
+                ))
+            }
         })
         .collect();
 
     Class {
         span: DUMMY_SP, // TODO
         decorators: vec![],
-        super_class: None,
+        super_class: class
+            .super_class
+            .as_ref()
+            .map(|ident| Box::from(Expr::Ident(ctx.rename(Ident::from(ident))))),
         is_abstract: false,
         super_type_params: None,
         type_params: None,
@@ -1269,6 +2600,76 @@ fn build_class(class: &values::Class, stmts: &mut Vec<Stmt>, ctx: &mut Context)
     }
 }
 
+// Unlike methods, getters and setters declare `self` as their first param.
+// It's `this` in JavaScript which is implicit so it's dropped.
+fn build_accessor_fn(
+    params: &[values::FuncParam],
+    body: &values::Block,
+    stmts: &mut Vec<Stmt>,
+    ctx: &mut Context,
+) -> Box<Function> {
+    let params = params.get(1..).unwrap_or_default();
+    let scope = ctx.enter_fn(params);
+    let body = build_body_block_stmt(body, &BlockFinalizer::ExprStmt, ctx);
+    let params: Vec<Param> = params
+        .iter()
+        .map(|param| Param {
+            span: DUMMY_SP,
+            decorators: vec![],
+            pat: build_pattern(&param.pattern, stmts, ctx).unwrap(),
+        })
+        .collect();
+    ctx.scope = scope;
+
+    Box::from(Function {
+        params,
+        decorators: vec![],
+        span: DUMMY_SP, // TODO
+        body: Some(body),
+        is_generator: false,
+        is_async: false,
+        type_params: None,
+        return_type: None,
+    })
+}
+
+fn build_accessor(
+    name: &values::PropName,
+    function: Box<Function>,
+    kind: MethodKind,
+    is_private: bool,
+    ctx: &mut Context,
+) -> ClassMember {
+    if let (true, values::PropName::Ident(ident)) = (is_private, name) {
+        return ClassMember::PrivateMethod(PrivateMethod {
+            span: DUMMY_SP, // TODO
+            key: PrivateName {
+                span: DUMMY_SP,
+                id: Ident::from(ident),
+            },
+            function,
+            kind,
+            is_static: false,
+            accessibility: None,
+            is_abstract: false,
+            is_optional: false,
+            is_override: false,
+        });
+    }
+
+    ClassMember::Method(ClassMethod {
+        span: DUMMY_SP, // TODO
+        key: prop_name_from_prop_name(name, ctx),
+        function,
+        kind,
+        is_static: false,
+        accessibility: None,
+        is_abstract: false,
+        is_optional: false,
+        is_override: false,
+    })
+}
+
 fn prop_name_from_prop_name(prop_name: &values::PropName, ctx: &mut Context) -> PropName {
     match prop_name {
         values::PropName::Ident(ident) => PropName::Ident(Ident::from(ident)),
@@ -1280,6 +2681,24 @@ fn prop_name_from_prop_name(prop_name: &values::PropName, ctx: &mut Context) ->
 }
 
 fn build_cond_for_pat(pat: &values::Pattern, id: &Ident) -> Option<Expr> {
+    if let values::PatternKind::Or(values::OrPat { patterns }) = &pat.kind {
+        // If any of the alternatives is irrefutable then so is the whole
+        // pattern.
+        let mut conds: Vec<Expr> = vec![];
+        for pattern in patterns {
+            conds.push(build_cond_for_pat(pattern, id)?);
+        }
+
+        return conds.into_iter().reduce(|prev, next| {
+            Expr::Bin(BinExpr {
+                span: DUMMY_SP,
+                op: BinaryOp::LogicalOr,
+                left: Box::from(prev),
+                right: Box::from(next),
+            })
+        });
+    }
+
     // TODO: implmenent `is_refutable`
     if is_refutable(pat) {
         // Right now the only refutable pattern we support is LitPat.
@@ -1319,8 +2738,12 @@ fn is_refutable(pat: &values::Pattern) -> bool {
 
         // refutable
         values::PatternKind::Lit(_) => true,
+        values::PatternKind::Range(_) => true,
         values::PatternKind::Is(_) => true,
 
+        // refutable if all of the alternatives are refutable
+        values::PatternKind::Or(values::OrPat { patterns }) => patterns.iter().all(is_refutable),
+
         // refutable if at least one sub-pattern is refutable
         values::PatternKind::Object(values::ObjectPat { props, .. }) => {
             props.iter().any(|prop| match prop {
@@ -1390,6 +2813,8 @@ enum Check {
     EqualLit(values::Literal),
     Typeof(String), // limit this to primitives: "number", "string", "boolean"
     Instanceof(values::Ident),
+    // start, end, inclusive
+    InRange(values::Literal, values::Literal, bool),
     // TODO: array length
 }
 
@@ -1439,6 +2864,16 @@ fn get_conds_for_pat(pat: &values::Pattern, conds: &mut Vec<Condition>, path: &m
                 check: Check::EqualLit(lit.to_owned()),
             });
         }
+        values::PatternKind::Range(values::RangePat {
+            start,
+            end,
+            inclusive,
+        }) => {
+            conds.push(Condition {
+                path: path.to_owned(),
+                check: Check::InRange(start.to_owned(), end.to_owned(), *inclusive),
+            });
+        }
         values::PatternKind::Is(values::IsPat { is_id, .. }) => match is_id.name.as_ref() {
             "string" | "number" | "boolean" => {
                 conds.push(Condition {
@@ -1454,6 +2889,9 @@ fn get_conds_for_pat(pat: &values::Pattern, conds: &mut Vec<Condition>, path: &m
                 });
             }
         },
+        // The parser only allows or-patterns at the top-level of match arms
+        // and those are handled by `build_cond_for_pat`.
+        values::PatternKind::Or(_) => todo!("nested or-patterns"),
     }
 }
 
@@ -1513,6 +2951,25 @@ fn cond_to_expr(cond: &Condition, id: &Ident) -> Expr {
             left: Box::from(left),
             right: Box::from(Expr::Ident(Ident::from(id))),
         }),
+        Check::InRange(start, end, inclusive) => Expr::Bin(BinExpr {
+            span: DUMMY_SP,
+            op: BinaryOp::LogicalAnd,
+            left: Box::from(Expr::Bin(BinExpr {
+                span: DUMMY_SP,
+                op: BinaryOp::GtEq,
+                left: Box::from(left.to_owned()),
+                right: Box::from(Expr::from(start)),
+            })),
+            right: Box::from(Expr::Bin(BinExpr {
+                span: DUMMY_SP,
+                op: match inclusive {
+                    true => BinaryOp::LtEq,
+                    false => BinaryOp::Lt,
+                },
+                left: Box::from(left),
+                right: Box::from(Expr::from(end)),
+            })),
+        }),
     }
 }
 
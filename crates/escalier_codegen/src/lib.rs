@@ -1,5 +1,8 @@
 pub mod d_ts;
 pub mod js;
+pub mod treeshake;
 
 pub use d_ts::codegen_d_ts;
 pub use js::codegen_js;
+pub use js::codegen_ts;
+pub use treeshake::treeshake;
@@ -0,0 +1,322 @@
+use std::collections::{HashMap, HashSet};
+
+use escalier_ast::visitor::*;
+use escalier_ast::{self as values};
+
+// Collects the names of all of the identifiers that are read.  Local bindings
+// that shadow top-level ones aren't tracked, this only needs to find every
+// declaration that could be used.
+#[derive(Default)]
+struct IdentCollector {
+    names: HashSet<String>,
+    // Whether to skip the bodies of functions, which don't run until they're
+    // called.
+    skip_fns: bool,
+}
+
+impl IdentCollector {
+    fn visit_params(&mut self, params: &[values::FuncParam]) {
+        for param in params {
+            self.visit_pattern(&param.pattern);
+        }
+    }
+
+    fn visit_jsx_children(&mut self, children: &[values::JSXElementChild]) {
+        for child in children {
+            match child {
+                values::JSXElementChild::Text(_) => {}
+                values::JSXElementChild::ExprContainer(values::JSXExprContainer { expr })
+                | values::JSXElementChild::SpreadChild(values::JSXSpreadChild { expr }) => {
+                    self.visit_expr(expr)
+                }
+                values::JSXElementChild::Element(elem) => self.visit_jsx_element(elem),
+                values::JSXElementChild::Fragment(frag) => self.visit_jsx_children(&frag.children),
+            }
+        }
+    }
+
+    fn visit_jsx_element(&mut self, elem: &values::JSXElement) {
+        let mut obj = match &elem.opening.name {
+            values::JSXElementName::Ident(ident) => {
+                self.names.insert(ident.name.to_owned());
+                None
+            }
+            values::JSXElementName::JSXMemberExpr(member) => Some(&member.obj),
+        };
+        while let Some(jsx_obj) = obj {
+            obj = match jsx_obj {
+                values::JSXObject::Ident(ident) => {
+                    self.names.insert(ident.name.to_owned());
+                    None
+                }
+                values::JSXObject::JSXMemberExpr(member) => Some(&member.obj),
+            };
+        }
+
+        for attr in &elem.opening.attrs {
+            if let Some(values::JSXAttrValue::ExprContainer(container)) = &attr.value {
+                self.visit_expr(&container.expr);
+            }
+        }
+
+        self.visit_jsx_children(&elem.children);
+    }
+}
+
+impl Visitor for IdentCollector {
+    fn visit_expr(&mut self, expr: &values::Expr) {
+        match &expr.kind {
+            values::ExprKind::Function(_) if self.skip_fns => return,
+            values::ExprKind::Ident(ident) => {
+                self.names.insert(ident.name.to_owned());
+            }
+            values::ExprKind::Object(values::Object { properties }) => {
+                for prop in properties {
+                    if let values::PropOrSpread::Prop(values::expr::Prop::Shorthand(ident)) = prop {
+                        self.names.insert(ident.name.to_owned());
+                    }
+                }
+            }
+            values::ExprKind::Class(values::Class {
+                super_class, body, ..
+            }) => {
+                if let Some(super_class) = super_class {
+                    self.names.insert(super_class.name.to_owned());
+                }
+                // `walk_expr` doesn't visit class members yet.
+                for member in body {
+                    match member {
+                        values::ClassMember::Method(values::Method { function, .. }) => {
+                            self.visit_params(&function.params);
+                            walk_block_or_expr(self, &function.body);
+                        }
+                        values::ClassMember::Getter(values::Getter { params, body, .. })
+                        | values::ClassMember::Setter(values::Setter { params, body, .. }) => {
+                            self.visit_params(params);
+                            walk_block(self, body);
+                        }
+                        values::ClassMember::Field(values::Field { init, .. }) => {
+                            if let Some(init) = init {
+                                self.visit_expr(init);
+                            }
+                        }
+                    }
+                }
+            }
+            values::ExprKind::JSXElement(elem) => self.visit_jsx_element(elem),
+            values::ExprKind::JSXFragment(frag) => self.visit_jsx_children(&frag.children),
+            _ => {}
+        }
+        walk_expr(self, expr);
+    }
+
+    fn visit_pattern(&mut self, pattern: &values::Pattern) {
+        if let values::PatternKind::Object(values::ObjectPat { props, .. }) = &pattern.kind {
+            for prop in props {
+                if let values::ObjectPatProp::Shorthand(values::ShorthandPatProp {
+                    init: Some(init),
+                    ..
+                }) = prop
+                {
+                    self.visit_expr(init);
+                }
+            }
+        }
+        walk_pattern(self, pattern);
+    }
+}
+
+/// Returns the names of the identifiers that `stmt` reads, including the ones
+/// inside of functions that haven't been called yet.
+pub fn get_read_names(stmt: &values::Stmt) -> HashSet<String> {
+    let mut collector = IdentCollector::default();
+    collector.visit_stmt(stmt);
+    collector.names
+}
+
+/// Returns the names of the identifiers that are read when `stmt` runs, which
+/// doesn't include the ones inside of functions.
+pub fn get_eager_read_names(stmt: &values::Stmt) -> HashSet<String> {
+    let mut collector = IdentCollector {
+        skip_fns: true,
+        ..IdentCollector::default()
+    };
+    collector.visit_stmt(stmt);
+    collector.names
+}
+
+// Returns true if evaluating `expr` could do something other than produce a
+// value, in which case the declaration it initializes has to be kept even if
+// nothing uses it.  This errs on the side of caution, e.g. all calls are
+// assumed to have side effects.
+fn has_side_effects(expr: &values::Expr) -> bool {
+    match &expr.kind {
+        values::ExprKind::Ident(_)
+        | values::ExprKind::Num(_)
+        | values::ExprKind::Str(_)
+        | values::ExprKind::Regex(_)
+        | values::ExprKind::Bool(_)
+        | values::ExprKind::Null(_)
+        | values::ExprKind::Undefined(_)
+        | values::ExprKind::Function(_) => false,
+        values::ExprKind::TemplateLiteral(values::TemplateLiteral { exprs, .. }) => {
+            exprs.iter().any(has_side_effects)
+        }
+        // Spreading an object can call getters and spreading into a tuple
+        // runs an iterator so both are treated as side effects.
+        values::ExprKind::Object(values::Object { properties }) => {
+            properties.iter().any(|prop| match prop {
+                values::PropOrSpread::Prop(values::expr::Prop::Shorthand(_)) => false,
+                values::PropOrSpread::Prop(values::expr::Prop::Property { key, value }) => {
+                    matches!(key, values::ObjectKey::Computed(_)) || has_side_effects(value)
+                }
+                values::PropOrSpread::Spread(_) => true,
+            })
+        }
+        values::ExprKind::Tuple(values::Tuple { elements }) => {
+            elements.iter().any(|elem| match elem {
+                values::ExprOrSpread::Expr(expr) => has_side_effects(expr),
+                values::ExprOrSpread::Spread(_) => true,
+            })
+        }
+        values::ExprKind::Unary(values::Unary { right, .. }) => has_side_effects(right),
+        values::ExprKind::Binary(values::Binary { left, right, .. }) => {
+            has_side_effects(left) || has_side_effects(right)
+        }
+        // Static fields and computed member names are evaluated when the
+        // class is.
+        values::ExprKind::Class(values::Class { body, .. }) => {
+            body.iter().any(|member| match member {
+                values::ClassMember::Field(values::Field {
+                    is_static: true,
+                    init: Some(init),
+                    ..
+                }) => has_side_effects(init),
+                values::ClassMember::Method(values::Method { name, .. })
+                | values::ClassMember::Getter(values::Getter { name, .. })
+                | values::ClassMember::Setter(values::Setter { name, .. }) => {
+                    matches!(name, values::PropName::Computed(_))
+                }
+                values::ClassMember::Field(_) => false,
+            })
+        }
+        _ => true,
+    }
+}
+
+/// Returns a copy of `program` without the top-level variable declarations
+/// that can't be reached from the declarations binding `entry_points`.
+/// Statements and declarations with side effects are always kept along with
+/// everything they use.  Since every top-level declaration is exported, they
+/// are all entry points when `entry_points` is empty.
+pub fn treeshake(program: &values::Script, entry_points: &[String]) -> values::Script {
+    let entry_points: HashSet<&String> = entry_points.iter().collect();
+
+    // The statements that have to be kept and the names each statement reads.
+    // Only variable declarations can be removed.
+    let mut roots: Vec<usize> = vec![];
+    let mut removable: HashSet<usize> = HashSet::new();
+    let mut deps: Vec<HashSet<String>> = vec![];
+    let mut stmts_for_name: HashMap<String, Vec<usize>> = HashMap::new();
+
+    for (i, stmt) in program.stmts.iter().enumerate() {
+        deps.push(get_read_names(stmt));
+
+        let decl = match &stmt.kind {
+            values::StmtKind::Decl(values::Decl {
+                kind: values::DeclKind::VarDecl(decl),
+                ..
+            }) if !decl.is_declare => decl,
+            // Namespaces and their aliases are always kept along with
+            // everything they use.
+            values::StmtKind::Decl(values::Decl {
+                kind:
+                    values::DeclKind::NamespaceDecl(values::NamespaceDecl {
+                        is_declare: false, ..
+                    })
+                    | values::DeclKind::NamespaceAliasDecl(_),
+                ..
+            }) => {
+                roots.push(i);
+                continue;
+            }
+            values::StmtKind::Decl(_) => continue,
+            _ => {
+                roots.push(i);
+                continue;
+            }
+        };
+
+        let names = get_binding_names(&decl.pattern);
+        let is_entry_point =
+            entry_points.is_empty() || names.iter().any(|name| entry_points.contains(name));
+        // Destructuring can call getters or run iterators.
+        let is_ident = matches!(decl.pattern.kind, values::PatternKind::Ident(_));
+        let has_side_effects = !is_ident || decl.expr.as_ref().map_or(false, has_side_effects);
+        match is_entry_point || has_side_effects {
+            true => roots.push(i),
+            false => {
+                removable.insert(i);
+            }
+        }
+
+        for name in names {
+            stmts_for_name.entry(name).or_default().push(i);
+        }
+    }
+
+    let mut reachable: HashSet<usize> = HashSet::new();
+    let mut stack = roots;
+    while let Some(i) = stack.pop() {
+        if !reachable.insert(i) {
+            continue;
+        }
+        for name in &deps[i] {
+            if let Some(stmts) = stmts_for_name.get(name) {
+                stack.extend(stmts.iter().filter(|j| !reachable.contains(j)));
+            }
+        }
+    }
+
+    let stmts = program
+        .stmts
+        .iter()
+        .enumerate()
+        .filter(|(i, _)| !removable.contains(i) || reachable.contains(i))
+        .map(|(_, stmt)| stmt.to_owned())
+        .collect();
+
+    values::Script {
+        stmts,
+        comments: program.comments.to_owned(),
+    }
+}
+
+fn get_binding_names(pattern: &values::Pattern) -> Vec<String> {
+    match &pattern.kind {
+        values::PatternKind::Ident(ident) => vec![ident.name.to_owned()],
+        values::PatternKind::Rest(values::RestPat { arg }) => get_binding_names(arg),
+        values::PatternKind::Object(values::ObjectPat { props, .. }) => props
+            .iter()
+            .flat_map(|prop| match prop {
+                values::ObjectPatProp::KeyValue(values::KeyValuePatProp { value, .. }) => {
+                    get_binding_names(value)
+                }
+                values::ObjectPatProp::Shorthand(values::ShorthandPatProp { ident, .. }) => {
+                    vec![ident.name.to_owned()]
+                }
+                values::ObjectPatProp::Rest(values::RestPat { arg }) => get_binding_names(arg),
+            })
+            .collect(),
+        values::PatternKind::Tuple(values::TuplePat { elems, .. }) => elems
+            .iter()
+            .flatten()
+            .flat_map(|elem| get_binding_names(&elem.pattern))
+            .collect(),
+        values::PatternKind::Is(values::IsPat { ident, .. }) => vec![ident.name.to_owned()],
+        values::PatternKind::Lit(_)
+        | values::PatternKind::Range(_)
+        | values::PatternKind::Or(_)
+        | values::PatternKind::Wildcard => vec![],
+    }
+}
@@ -1,5 +1,6 @@
 use escalier_codegen::d_ts::codegen_d_ts;
-use escalier_codegen::js::codegen_js;
+use escalier_codegen::js::{codegen_js, codegen_js_with_options, codegen_ts, CodegenOptions};
+use escalier_codegen::treeshake::treeshake;
 use escalier_hm::checker::Checker;
 use escalier_hm::context::Context;
 use escalier_hm::type_error::TypeError;
@@ -32,6 +33,52 @@ fn unary_minus() {
 ");
 }
 
+#[test]
+fn in_operator() {
+    let src = r#"
+    let hasKind = fn (obj) => "kind" in obj
+    "#;
+
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export const hasKind = (obj)=>"kind" in obj;
+    "###);
+}
+
+#[test]
+fn instanceof_operator() {
+    let src = r#"
+    let isPoint = fn (obj) => obj instanceof Point
+    "#;
+
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export const isPoint = (obj)=>obj instanceof Point;
+    "###);
+}
+
+#[test]
+fn call_with_spread_args() {
+    let src = r#"
+    let add = fn (a, b, c) => a + b + c
+    let rest = [2, 3]
+    let sum = add(1, ...rest)
+    "#;
+
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export const add = (a, b, c)=>a + b + c;
+    export const rest = [
+        2,
+        3
+    ];
+    export const sum = add(1, ...rest);
+    "###);
+}
+
 #[test]
 fn fn_with_block_without_return() {
     let src = r#"
@@ -70,6 +117,106 @@ fn fn_with_block_with_return() {
     "###);
 }
 
+#[test]
+fn fn_with_shadowed_locals() {
+    let src = r#"
+    let x = 1
+    let foo = fn (y) {
+        let a = x + y
+        let x = a * 2
+        let x = x + 1
+        let isEven = fn (n) => n == 0 || isOdd(n - 1)
+        let isOdd = fn (n) => n != 0 && isEven(n - 1)
+        return {x, a, isEven}
+    }
+    "#;
+
+    let (js, _) = compile(src);
+
+    // Locals that would collide with another binding in JavaScript are
+    // renamed, local functions are hoisted so they don't need to be.
+    insta::assert_snapshot!(js, @r###"
+    export const x = 1;
+    export const foo = (y)=>{
+        const a = x + y;
+        const x$0 = a * 2;
+        const x$1 = x$0 + 1;
+        const isEven = (n)=>n === 0 || isOdd(n - 1);
+        const isOdd = (n)=>n !== 0 && isEven(n - 1);
+        return {
+            x: x$1,
+            a,
+            isEven
+        };
+    };
+    "###);
+}
+
+#[test]
+fn namespaces_are_flattened() {
+    let src = r#"
+    namespace Num {
+        let zero = 0
+        let isEven = fn (n) => n == zero || isOdd(n - 1)
+        let isOdd = fn (n) => n != zero && isEven(n - 1)
+        namespace Util {
+            let double = fn (n) => n * 2
+            let two = double(isEven(zero))
+        }
+    }
+    let two = Num.Util.two
+    "#;
+
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    const Num__zero = 0;
+    const Num__isEven = (n)=>n === Num__zero || Num__isOdd(n - 1);
+    const Num__isOdd = (n)=>n !== Num__zero && Num__isEven(n - 1);
+    const Num__Util__double = (n)=>n * 2;
+    const Num__Util__two = Num__Util__double(Num__isEven(Num__zero));
+    const Num__Util = {
+        double: Num__Util__double,
+        two: Num__Util__two
+    };
+    export const Num = {
+        zero: Num__zero,
+        isEven: Num__isEven,
+        isOdd: Num__isOdd,
+        Util: Num__Util
+    };
+    export const two = Num.Util.two;
+    "###);
+}
+
+#[test]
+fn namespace_aliases() {
+    let src = r#"
+    namespace Very {
+        namespace Long {
+            let x = 5
+        }
+        namespace Short = Long
+    }
+    namespace Short = Very.Long
+    "#;
+
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    const Very__Long__x = 5;
+    const Very__Long = {
+        x: Very__Long__x
+    };
+    const Very__Short = Very__Long;
+    export const Very = {
+        Long: Very__Long,
+        Short: Very__Short
+    };
+    export const Short = Very.Long;
+    "###);
+}
+
 #[test]
 fn template_literals() {
     let src = r#"
@@ -136,7 +283,6 @@ fn pattern_matching() {
         console.log(`n = ${n}`);
         $temp_0 = "a few";
     } else {
-        const $temp_2 = $temp_1;
         console.log("fallthrough");
         $temp_0 = "many";
     }
@@ -144,6 +290,35 @@ fn pattern_matching() {
     "###);
 }
 
+#[test]
+fn declared_bindings_are_not_emitted() {
+    let src = r#"
+    declare let foo: number
+    declare fn bar(x: number) -> string
+    declare class Baz {
+        fn qux(self) -> number
+    }
+    declare namespace NS {
+        let x: number
+    }
+    let f = fn () {
+        declare let y: number
+        return foo + y
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    ;
+    ;
+    ;
+    ;
+    export const f = ()=>{
+        return foo + y;
+    };
+    "###);
+}
+
 #[test]
 fn pattern_matching_with_disjoint_union() -> Result<(), TypeError> {
     let src = r#"
@@ -193,6 +368,115 @@ fn pattern_matching_with_disjoint_union() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn pattern_matching_with_literals() {
+    let src = r#"
+    let result = match (value) {
+        -1 => "minus one",
+        "hello" => "string",
+        true => "boolean",
+        null => "null",
+        undefined => "undefined",
+        _ => "other"
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    let $temp_0;
+    const $temp_1 = value;
+    if ($temp_1 === -1) {
+        $temp_0 = "minus one";
+    } else if ($temp_1 === "hello") {
+        $temp_0 = "string";
+    } else if ($temp_1 === true) {
+        $temp_0 = "boolean";
+    } else if ($temp_1 === null) {
+        $temp_0 = "null";
+    } else if ($temp_1 === undefined) {
+        $temp_0 = "undefined";
+    } else {
+        $temp_0 = "other";
+    }
+    export const result = $temp_0;
+    "###);
+}
+
+#[test]
+fn pattern_matching_with_or_patterns() {
+    let src = r#"
+    let result = match (value) {
+        1 | 2 | 3 => "small",
+        _ => "big"
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    let $temp_0;
+    const $temp_1 = value;
+    if ($temp_1 === 1 || $temp_1 === 2 || $temp_1 === 3) {
+        $temp_0 = "small";
+    } else {
+        $temp_0 = "big";
+    }
+    export const result = $temp_0;
+    "###);
+}
+
+#[test]
+fn pattern_matching_with_or_patterns_binding_different_positions() {
+    let src = r#"
+    let result = match (value) {
+        [1, msg] | [msg, 1] => msg,
+        _ => "none"
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    let $temp_0;
+    const $temp_1 = value;
+    if ($temp_1[0] === 1 || $temp_1[1] === 1) {
+        let msg;
+        if ($temp_1[0] === 1) {
+            [, msg] = $temp_1;
+        } else {
+            [msg] = $temp_1;
+        }
+        $temp_0 = msg;
+    } else {
+        $temp_0 = "none";
+    }
+    export const result = $temp_0;
+    "###);
+}
+
+#[test]
+fn pattern_matching_with_ranges() {
+    let src = r#"
+    let result = match (value) {
+        0..=9 => "digit",
+        10..100 => "two digits",
+        _ => "other"
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    let $temp_0;
+    const $temp_1 = value;
+    if ($temp_1 >= 0 && $temp_1 <= 9) {
+        $temp_0 = "digit";
+    } else if ($temp_1 >= 10 && $temp_1 < 100) {
+        $temp_0 = "two digits";
+    } else {
+        $temp_0 = "other";
+    }
+    export const result = $temp_0;
+    "###);
+}
+
 #[test]
 // TODO: Have a better error message when there's multiple catch-alls
 #[should_panic = "Catchall must appear last in match"]
@@ -244,6 +528,172 @@ fn simple_if_else() {
     "###);
 }
 
+#[test]
+fn minified_if_else() {
+    let src = r#"
+    let result = if (cond) {
+        console.log("true")
+        5
+    } else {
+        console.log("false")
+        10
+    }
+    "#;
+    let program = parse(src).unwrap();
+    let options = CodegenOptions {
+        minify: true,
+        ..CodegenOptions::default()
+    };
+    let (js, _) = codegen_js_with_options(src, &program, &options);
+
+    insta::assert_snapshot!(js, @r###"let $0;if(cond){console.log("true");$0=5;}else{console.log("false");$0=10;}export const result=$0;"###);
+}
+
+#[test]
+fn fold_constants() {
+    let src = r#"
+    let a = 1 + 2 * 3
+    let b = 2 - 5
+    let c = "hello, " + "world"
+    let d = 1 / 3
+    let e = 0.1 + 0.2
+    let f = if (a > 5) { "big" } else { "small" }
+    let g = if (b == 0) {
+        a
+    } else {
+        let x = c
+        x
+    }
+    "#;
+    let program = parse(src).unwrap();
+    let options = CodegenOptions {
+        fold_constants: true,
+        ..CodegenOptions::default()
+    };
+    let (js, _) = codegen_js_with_options(src, &program, &options);
+
+    insta::assert_snapshot!(js, @r###"
+    export const a = 7;
+    export const b = -3;
+    export const c = "hello, world";
+    export const d = 1 / 3;
+    export const e = 0.1 + 0.2;
+    let $temp_0;
+    if (a > 5) {
+        $temp_0 = "big";
+    } else {
+        $temp_0 = "small";
+    }
+    export const f = $temp_0;
+    let $temp_1;
+    if (b === 0) {
+        $temp_1 = a;
+    } else {
+        const x = c;
+        $temp_1 = x;
+    }
+    export const g = $temp_1;
+    "###);
+
+    let src = r#"
+    let a = if (1 < 2) { "yes" } else { "no" }
+    let b = if ("a" == "b") {
+        "same"
+    } else {
+        let x = 5 * 5
+        x
+    }
+    let c = if (false) { 5 }
+    "#;
+    let program = parse(src).unwrap();
+    let (js, _) = codegen_js_with_options(src, &program, &options);
+
+    insta::assert_snapshot!(js, @r###"
+    export const a = "yes";
+    let $temp_0;
+    {
+        const x = 25;
+        $temp_0 = x;
+    }
+    export const b = $temp_0;
+    export const c = undefined;
+    "###);
+}
+
+#[test]
+fn optimize_tailcalls() {
+    let src = r#"
+    let fact = fn (n, acc) => if (n == 0) {
+        acc
+    } else {
+        fact(n - 1, n * acc)
+    }
+    let countdown = fn (n) => if (n > 0) { countdown(n - 1) } else { "done" }
+    let fib = fn (n) => if (n < 2) { n } else { fib(n - 1) + fib(n - 2) }
+    "#;
+    let program = parse(src).unwrap();
+    let options = CodegenOptions {
+        optimize_tailcalls: true,
+        ..CodegenOptions::default()
+    };
+    let (js, _) = codegen_js_with_options(src, &program, &options);
+
+    // `fib` isn't changed since its recursive calls aren't in tail position.
+    insta::assert_snapshot!(js, @r###"
+    export const fact = (n, acc)=>{
+        while(true){
+            if (n === 0) {
+                return acc;
+            } else {
+                [n, acc] = [
+                    n - 1,
+                    n * acc
+                ];
+                continue;
+            }
+        }
+    };
+    export const countdown = (n)=>{
+        while(true){
+            if (n > 0) {
+                n = n - 1;
+                continue;
+            } else {
+                return "done";
+            }
+        }
+    };
+    let $temp_0;
+    if (n < 2) {
+        $temp_0 = n;
+    } else {
+        $temp_0 = fib(n - 1) + fib(n - 2);
+    }
+    export const fib = (n)=>$temp_0;
+    "###);
+}
+
+#[test]
+fn treeshake_unreachable_decls() {
+    let src = r#"
+    let a = 5
+    let b = fn () => a
+    let unused = fn () => b()
+    let logged = console.log("hello")
+    let main = fn () => b()
+    "#;
+    let program = parse(src).unwrap();
+    let program = treeshake(&program, &[String::from("main")]);
+    let (js, _) = codegen_js(src, &program);
+
+    insta::assert_snapshot!(js, @r###"
+    export const a = 5;
+    export const b = ()=>a;
+    export const logged = console.log("hello");
+    export const main = ()=>b();
+    "###);
+}
+
 #[test]
 fn simple_if_else_inside_fn() {
     let src = r#"
@@ -480,6 +930,32 @@ fn codegen_block_with_multiple_non_let_lines() {
     "###);
 }
 
+#[test]
+fn block_expr_inside_fn() {
+    let src = r#"
+    let foo = fn (x) {
+        let y = {
+            let z = x * 2
+            z + 1
+        }
+        return y
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export const foo = (x)=>{
+        let $temp_0;
+        {
+            const z = x * 2;
+            $temp_0 = z + 1;
+        }
+        const y = $temp_0;
+        return y;
+    };
+    "###);
+}
+
 #[test]
 fn destructuring_function_object_params() -> Result<(), TypeError> {
     let src = r#"
@@ -506,6 +982,20 @@ fn destructuring_function_object_params() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn destructuring_with_defaults_renames_and_rest() {
+    let src = r#"
+    let {a: x = 1, b: {c = "two"}, ...rest} = obj
+    let foo = fn ([p, q = 5]) => p + q
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export const { a: x = 1, b: { c = "two" }, ...rest } = obj;
+    export const foo = ([p, q = 5])=>p + q;
+    "###);
+}
+
 #[test]
 fn destructuring_function_array_params() -> Result<(), TypeError> {
     let src = r#"
@@ -528,6 +1018,24 @@ fn destructuring_function_array_params() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn labeled_tuple() -> Result<(), TypeError> {
+    let src = r#"
+    let point: [x: number, y: number] = [5, 10]
+    "#;
+
+    let mut program = parse(src).unwrap();
+    let mut checker = Checker::default();
+    let mut ctx = Context::default();
+    checker.infer_script(&mut program, &mut ctx)?;
+    let result = codegen_d_ts(&program, &ctx, &checker)?;
+
+    insta::assert_snapshot!(result, @"export declare const point: readonly [x: number, y: number];
+");
+
+    Ok(())
+}
+
 #[test]
 fn function_with_rest_param() -> Result<(), TypeError> {
     let src = r#"
@@ -673,6 +1181,17 @@ fn variable_declaration_with_destructuring() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn variable_declaration_with_wildcard() {
+    let src = r#"
+    let [_, b] = pair
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @"export const [, b] = pair;
+");
+}
+
 #[test]
 fn computed_property() {
     let src = r#"
@@ -724,7 +1243,7 @@ fn mutable_array() -> Result<(), TypeError> {
     let (js, _) = compile(src);
 
     insta::assert_snapshot!(js, @r###"
-    export const arr = [
+    export let arr = [
         1,
         2,
         3
@@ -737,12 +1256,66 @@ fn mutable_array() -> Result<(), TypeError> {
     checker.infer_script(&mut program, &mut ctx)?;
     let result = codegen_d_ts(&program, &ctx, &checker)?;
 
-    insta::assert_snapshot!(result, @"export declare const arr: readonly number[];
+    insta::assert_snapshot!(result, @"export declare let arr: readonly number[];
 ");
 
     Ok(())
 }
 
+#[test]
+fn reassignable_bindings() {
+    let src = r#"
+    var count: number = 0
+    let limit = 10
+    let inc = fn () {
+        var step: number = 1
+        let max = limit
+        count = count + step
+    }
+    "#;
+    let (js, _) = compile(src);
+
+    insta::assert_snapshot!(js, @r###"
+    export let count = 0;
+    export const limit = 10;
+    export const inc = ()=>{
+        let step = 1;
+        const max = limit;
+        count = count + step;
+    };
+    "###);
+}
+
+#[test]
+fn ts_annotates_top_level_decls() -> Result<(), TypeError> {
+    let src = r#"
+    type Point = {x: number, y: number}
+    let add = fn (a: number, b: number) => a + b
+    let msg = "hello"
+    "#;
+
+    let mut program = parse(src).unwrap();
+    let mut checker = Checker::default();
+    let mut ctx = Context::default();
+    checker.infer_script(&mut program, &mut ctx)?;
+    let (ts, _) = codegen_ts(src, &program, &ctx, &checker, &CodegenOptions::default());
+
+    insta::assert_snapshot!(ts, @r###"
+    export type Point = {
+        x: number;
+        y: number;
+    };
+    export type ReadonlyPoint = {
+        readonly x: number;
+        readonly y: number;
+    };
+    export const add: (a: number, b: number) => number = (a, b)=>a + b;
+    export const msg: "hello" = "hello";
+    "###);
+
+    Ok(())
+}
+
 #[test]
 fn mutable_obj() -> Result<(), TypeError> {
     let src = r#"
@@ -796,6 +1369,31 @@ fn mutable_indexer() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn unique_symbols() -> Result<(), TypeError> {
+    let src = r#"
+    declare let sym: unique symbol
+    let alias = sym
+    let obj = {[sym]: 5}
+    "#;
+
+    let mut program = parse(src).unwrap();
+    let mut checker = Checker::default();
+    let mut ctx = Context::default();
+    checker.infer_script(&mut program, &mut ctx)?;
+    let result = codegen_d_ts(&program, &ctx, &checker)?;
+
+    insta::assert_snapshot!(result, @r###"
+    export declare const alias: typeof sym;
+    export declare const obj: {
+        [sym]: 5;
+    };
+    export declare const sym: unique symbol;
+    "###);
+
+    Ok(())
+}
+
 // TODO: finish porting class handling code
 #[test]
 #[ignore]
@@ -832,6 +1430,82 @@ fn class_with_methods() {
     "###);
 }
 
+#[test]
+fn class_with_private_members() -> Result<(), TypeError> {
+    let src = r#"
+    let Point = class {
+        private x: number
+        fn constructor(mut self, x: number) {
+            self.x = x
+        }
+        fn equals(self, other: Self) -> boolean {
+            return self.x == other.x
+        }
+        fn distance(self, other: {x: number}) -> number {
+            return other.x - self.x
+        }
+        private get double(self) {
+            return self.x * 2
+        }
+    }
+    "#;
+
+    // The checker marks which member accesses are private.
+    let mut program = parse(src).unwrap();
+    let mut checker = Checker::default();
+    let mut ctx = Context::default();
+    checker.infer_script(&mut program, &mut ctx)?;
+    let (js, _) = codegen_js(src, &program);
+
+    insta::assert_snapshot!(js, @r###"
+    export const Point = class {
+        #x;
+        constructor(x) {
+            self.#x = x;
+        }
+        equals(other) {
+            return self.#x === other.#x;
+        }
+        distance(other) {
+            return other.x - self.#x;
+        }
+        get #double() {
+            return self.#x * 2;
+        }
+    };
+    "###);
+
+    Ok(())
+}
+
+#[test]
+fn class_with_generator_method() {
+    let src = r#"
+    let Range = class {
+        static fn empty() -> number {
+            return 0
+        }
+        gen fn [Symbol.iterator](self) {
+            yield 1
+            yield 2
+        }
+    }
+    "#;
+
+    let (js, _) = compile(src);
+    insta::assert_snapshot!(js, @r###"
+    export const Range = class {
+        static empty() {
+            return 0;
+        }
+        *[Symbol.iterator]() {
+            yield 1;
+            yield 2;
+        }
+    };
+    "###);
+}
+
 #[test]
 fn for_loop() -> Result<(), TypeError> {
     let src = r#"
@@ -900,6 +1574,34 @@ fn for_loop_inside_fn() -> Result<(), TypeError> {
     Ok(())
 }
 
+#[test]
+fn async_generator_with_for_await() {
+    let src = r#"
+    let gen = async gen fn () {
+        yield 1
+        yield 2
+    }
+    let main = async fn () {
+        for await (num in gen()) {
+            console.log(num)
+        }
+    }
+    "#;
+
+    let (js, _) = compile(src);
+    insta::assert_snapshot!(js, @r###"
+    export const gen = async function*() {
+        yield 1;
+        yield 2;
+    };
+    export const main = async ()=>{
+        for await (const num of gen()){
+            console.log(num);
+        }
+    };
+    "###);
+}
+
 #[test]
 fn type_decl_inside_block() -> Result<(), TypeError> {
     let src = r#"
@@ -0,0 +1,190 @@
+use generational_arena::Index;
+
+use escalier_ast::{self as syntax, *};
+
+use crate::checker::Checker;
+use crate::context::Context;
+use crate::types::{self, *};
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct TextEdit {
+    pub offset: usize,
+    pub text: String,
+}
+
+#[derive(Debug, Clone, Default)]
+pub struct AnnotateOptions {
+    // When true, `let x = 5` is annotated as `let x: number = 5` instead of
+    // `let x: 5 = 5`.
+    pub widen_literals: bool,
+}
+
+impl Checker {
+    /// Returns the edits needed to add the inferred types as annotations to
+    /// all top-level declarations in `script` that are missing them.  The
+    /// script must be inferred before calling this method.
+    pub fn get_annotation_edits(
+        &mut self,
+        src: &str,
+        script: &Script,
+        ctx: &Context,
+        options: &AnnotateOptions,
+    ) -> Vec<TextEdit> {
+        let mut edits: Vec<TextEdit> = vec![];
+
+        for stmt in &script.stmts {
+            if let StmtKind::Decl(Decl {
+                kind: DeclKind::VarDecl(decl),
+                ..
+            }) = &stmt.kind
+            {
+                edits.extend(self.get_decl_annotation_edits(src, decl, ctx, options));
+            }
+        }
+
+        edits
+    }
+
+    /// Returns the edits needed to add the inferred types as annotations to
+    /// the top-level declaration `decl`, this is empty if it's already
+    /// annotated or its type isn't known.
+    pub fn get_decl_annotation_edits(
+        &mut self,
+        src: &str,
+        decl: &VarDecl,
+        ctx: &Context,
+        options: &AnnotateOptions,
+    ) -> Vec<TextEdit> {
+        let mut edits: Vec<TextEdit> = vec![];
+
+        if decl.is_declare || decl.type_ann.is_some() {
+            return edits;
+        }
+
+        let name = match &decl.pattern.kind {
+            PatternKind::Ident(BindingIdent { name, .. }) => name,
+            // TODO: annotate destructuring patterns
+            _ => return edits,
+        };
+
+        let binding = match ctx.values.get(name) {
+            Some(binding) => binding,
+            None => return edits,
+        };
+
+        match &decl.expr {
+            Some(Expr {
+                kind: ExprKind::Function(func),
+                span,
+                ..
+            }) => {
+                let t = self.prune(binding.index);
+                if let TypeKind::Function(func_t) = &self.arena[t].kind {
+                    let func_t = func_t.to_owned();
+                    self.get_func_edits(src, span, func, &func_t, &mut edits);
+                }
+            }
+            _ => {
+                let text = match options.widen_literals {
+                    true => self.print_widened_type(binding.index),
+                    false => self.print_type(&binding.index),
+                };
+                edits.push(TextEdit {
+                    offset: decl.pattern.span.end,
+                    text: format!(": {text}"),
+                });
+            }
+        }
+
+        edits
+    }
+
+    fn get_func_edits(
+        &mut self,
+        src: &str,
+        span: &Span,
+        func: &syntax::Function,
+        func_t: &types::Function,
+        edits: &mut Vec<TextEdit>,
+    ) {
+        if func.type_params.is_none() {
+            if let Some(type_params) = &func_t.type_params {
+                // Type params go right before the params.
+                if let Some(offset) = src[span.start..].find('(') {
+                    let type_params = type_params
+                        .iter()
+                        .map(|tp| match &tp.constraint {
+                            Some(constraint) => {
+                                format!("{}: {}", tp.name, self.print_type(constraint))
+                            }
+                            None => tp.name.to_owned(),
+                        })
+                        .collect::<Vec<_>>();
+                    edits.push(TextEdit {
+                        offset: span.start + offset,
+                        text: format!("<{}>", type_params.join(", ")),
+                    });
+                }
+            }
+        }
+
+        let mut offset = span.start;
+        for (param, param_t) in func.params.iter().zip(func_t.params.iter()) {
+            offset = param.pattern.span.end;
+            if param.type_ann.is_some() {
+                continue;
+            }
+            edits.push(TextEdit {
+                offset,
+                text: format!(": {}", self.print_type(&param_t.t)),
+            });
+        }
+
+        if func.type_ann.is_none() {
+            // The return type is placed before the `throws` clause if there
+            // is one, otherwise it goes before the body.
+            let end = match (&func.throws, &func.body) {
+                (Some(throws), _) => src[offset..throws.span.start]
+                    .rfind("throws")
+                    .map(|index| offset + index),
+                (None, BlockOrExpr::Block(block)) => Some(block.span.start),
+                (None, BlockOrExpr::Expr(expr)) => src[offset..expr.get_span().start]
+                    .rfind("=>")
+                    .map(|index| offset + index),
+            };
+            if let Some(end) = end {
+                edits.push(TextEdit {
+                    offset: end,
+                    text: format!("-> {} ", self.print_type(&func_t.ret)),
+                });
+            }
+        }
+    }
+
+    fn print_widened_type(&mut self, t: Index) -> String {
+        let t = self.prune(t);
+        match &self.arena[t].kind {
+            TypeKind::Literal(Literal::Number(_)) => "number".to_string(),
+            TypeKind::Literal(Literal::String(_)) => "string".to_string(),
+            TypeKind::Literal(Literal::Boolean(_)) => "boolean".to_string(),
+            _ => self.print_type(&t),
+        }
+    }
+}
+
+/// Applies edits produced by `get_annotation_edits` to `src`.
+pub fn apply_edits(src: &str, edits: &[TextEdit]) -> String {
+    let mut edits = edits.to_vec();
+    edits.sort_by_key(|edit| edit.offset);
+
+    let mut result = String::new();
+    let mut start = 0;
+    for edit in edits {
+        result.push_str(&src[start..edit.offset]);
+        result.push_str(&edit.text);
+        start = edit.offset;
+    }
+    result.push_str(&src[start..]);
+
+    result
+}
@@ -1,5 +1,7 @@
+use escalier_ast::expr::Prop;
 use escalier_ast::*;
 use generational_arena::Index;
+use std::cmp::Ordering;
 
 struct ReturnVisitor {
     pub returns: Vec<Expr>,
@@ -37,6 +39,40 @@ pub fn find_returns(body: &BlockOrExpr) -> Vec<Expr> {
     visitor.returns
 }
 
+struct YieldVisitor {
+    pub yields: Vec<Expr>,
+}
+
+impl Visitor for YieldVisitor {
+    fn visit_expr(&mut self, expr: &Expr) {
+        match &expr.kind {
+            // Don't walk into functions, since we don't want to include yields
+            // from nested generators
+            ExprKind::Function(_) => {}
+            ExprKind::Yield(Yield { arg }) => {
+                self.yields.push(arg.as_ref().to_owned());
+                walk_expr(self, expr);
+            }
+            _ => walk_expr(self, expr),
+        }
+    }
+}
+
+pub fn find_yields(body: &BlockOrExpr) -> Vec<Expr> {
+    let mut visitor = YieldVisitor { yields: vec![] };
+
+    match body {
+        BlockOrExpr::Block(block) => {
+            for stmt in &block.stmts {
+                visitor.visit_stmt(stmt);
+            }
+        }
+        BlockOrExpr::Expr(expr) => visitor.visit_expr(expr),
+    }
+
+    visitor.yields
+}
+
 struct ThrowsVisitor {
     pub throws: Vec<Index>,
 }
@@ -114,3 +150,238 @@ pub fn find_throws_in_block(block: &Block) -> Vec<Index> {
 
     visitor.throws
 }
+
+struct BindingIdentVisitor {
+    pub idents: Vec<BindingIdent>,
+}
+
+impl Visitor for BindingIdentVisitor {
+    fn visit_pattern(&mut self, pattern: &Pattern) {
+        match &pattern.kind {
+            PatternKind::Ident(ident) => self.idents.push(ident.to_owned()),
+            PatternKind::Is(IsPat { ident, .. }) => self.idents.push(ident.to_owned()),
+            // All of the alternatives bind the same names.
+            PatternKind::Or(OrPat { patterns }) => {
+                if let Some(pattern) = patterns.first() {
+                    self.visit_pattern(pattern);
+                }
+            }
+            PatternKind::Object(ObjectPat { props, .. }) => {
+                for prop in props {
+                    if let ObjectPatProp::Shorthand(ShorthandPatProp { ident, .. }) = prop {
+                        self.idents.push(ident.to_owned());
+                    }
+                }
+                walk_pattern(self, pattern);
+            }
+            _ => walk_pattern(self, pattern),
+        }
+    }
+    // Don't walk into default values, since they can't introduce bindings
+    fn visit_expr(&mut self, _expr: &Expr) {}
+}
+
+/// Returns the name of the well-known symbol that `expr` refers to, e.g.
+/// `iterator` for `Symbol.iterator`.
+pub fn get_well_known_symbol(expr: &Expr) -> Option<&str> {
+    if let ExprKind::Member(Member {
+        object,
+        property: MemberProp::Ident(Ident { name, .. }),
+        opt_chain: false,
+        ..
+    }) = &expr.kind
+    {
+        if let ExprKind::Ident(Ident { name: symbol, .. }) = &object.kind {
+            if symbol == "Symbol" {
+                return Some(name);
+            }
+        }
+    }
+    None
+}
+
+pub fn find_binding_idents(pattern: &Pattern) -> Vec<BindingIdent> {
+    let mut visitor = BindingIdentVisitor { idents: vec![] };
+
+    visitor.visit_pattern(pattern);
+
+    visitor.idents
+}
+
+struct EagerIdentVisitor {
+    pub idents: Vec<Ident>,
+    pub local_names: Vec<String>,
+}
+
+impl Visitor for EagerIdentVisitor {
+    fn visit_pattern(&mut self, pattern: &Pattern) {
+        for ident in find_binding_idents(pattern) {
+            self.local_names.push(ident.name);
+        }
+        walk_pattern(self, pattern);
+    }
+    fn visit_expr(&mut self, expr: &Expr) {
+        match &expr.kind {
+            ExprKind::Ident(ident) => self.idents.push(ident.to_owned()),
+            ExprKind::Object(Object { properties }) => {
+                for prop in properties {
+                    if let PropOrSpread::Prop(Prop::Shorthand(ident)) = prop {
+                        self.idents.push(ident.to_owned());
+                    }
+                }
+                walk_expr(self, expr);
+            }
+            // Don't walk into functions or classes, since the identifiers they
+            // reference aren't read until they're called or instantiated.
+            ExprKind::Function(_) | ExprKind::Class(_) => {}
+            _ => walk_expr(self, expr),
+        }
+    }
+}
+
+/// Returns the identifiers that are read when `expr` is evaluated.
+/// Identifiers with the same name as a binding inside of `expr`, e.g. one
+/// from a match arm's pattern, are skipped since they likely refer to it.
+pub fn find_eager_idents(expr: &Expr) -> Vec<Ident> {
+    let mut visitor = EagerIdentVisitor {
+        idents: vec![],
+        local_names: vec![],
+    };
+
+    visitor.visit_expr(expr);
+
+    let EagerIdentVisitor {
+        idents,
+        local_names,
+    } = visitor;
+    idents
+        .into_iter()
+        .filter(|ident| !local_names.contains(&ident.name))
+        .collect()
+}
+
+// A statement diverges if control can never reach the statement after it.
+fn stmt_diverges(stmt: &Stmt, is_never: &dyn Fn(&Expr) -> bool) -> bool {
+    match &stmt.kind {
+        StmtKind::Return(_) => true,
+        StmtKind::Expr(ExprStmt { expr }) => expr_diverges(expr, is_never),
+        _ => false,
+    }
+}
+
+fn expr_diverges(expr: &Expr, is_never: &dyn Fn(&Expr) -> bool) -> bool {
+    if is_never(expr) {
+        return true;
+    }
+    match &expr.kind {
+        ExprKind::Throw(_) => true,
+        ExprKind::Match(Match { arms, .. }) => {
+            // TODO: use the types of the patterns to determine exhaustiveness,
+            // for now we require a catch-all arm.
+            let is_exhaustive = arms.iter().any(|arm| {
+                arm.guard.is_none()
+                    && matches!(
+                        arm.pattern.kind,
+                        PatternKind::Ident(_) | PatternKind::Wildcard
+                    )
+            });
+            is_exhaustive
+                && arms.iter().all(|arm| match &arm.body {
+                    BlockOrExpr::Block(block) => {
+                        block.stmts.iter().any(|stmt| stmt_diverges(stmt, is_never))
+                    }
+                    BlockOrExpr::Expr(expr) => expr_diverges(expr, is_never),
+                })
+        }
+        _ => false,
+    }
+}
+
+/// Returns the first statement in `stmts` that follows a diverging statement,
+/// i.e. a `return`, a `throw`, an exhaustive `match` whose arms all diverge,
+/// or an expression that `is_never` returns true for, e.g. a call to a function
+/// that returns `never`.
+pub fn find_unreachable_stmt<'a>(
+    stmts: &'a [Stmt],
+    is_never: &dyn Fn(&Expr) -> bool,
+) -> Option<&'a Stmt> {
+    let index = stmts
+        .iter()
+        .position(|stmt| stmt_diverges(stmt, is_never))?;
+    stmts.get(index + 1)
+}
+
+// The values matched by a literal or range pattern, `end` is always inclusive
+// for literal patterns.
+struct PatRange<'a> {
+    start: &'a Literal,
+    end: &'a Literal,
+    inclusive: bool,
+}
+
+fn get_pat_ranges(pattern: &Pattern) -> Vec<PatRange<'_>> {
+    match &pattern.kind {
+        PatternKind::Lit(LitPat { lit }) => vec![PatRange {
+            start: lit,
+            end: lit,
+            inclusive: true,
+        }],
+        PatternKind::Range(RangePat {
+            start,
+            end,
+            inclusive,
+        }) => vec![PatRange {
+            start,
+            end,
+            inclusive: *inclusive,
+        }],
+        PatternKind::Or(OrPat { patterns }) => patterns.iter().flat_map(get_pat_ranges).collect(),
+        _ => vec![],
+    }
+}
+
+fn compare_lits(a: &Literal, b: &Literal) -> Option<Ordering> {
+    match (a, b) {
+        (Literal::Number(a), Literal::Number(b)) => {
+            a.parse::<f64>().ok()?.partial_cmp(&b.parse::<f64>().ok()?)
+        }
+        (Literal::String(a), Literal::String(b)) => Some(a.cmp(b)),
+        _ => None,
+    }
+}
+
+fn range_covers(outer: &PatRange, inner: &PatRange) -> bool {
+    let starts_before = matches!(
+        compare_lits(outer.start, inner.start),
+        Some(Ordering::Less | Ordering::Equal)
+    );
+    let ends_after = match compare_lits(outer.end, inner.end) {
+        Some(Ordering::Greater) => true,
+        Some(Ordering::Equal) => outer.inclusive || !inner.inclusive,
+        _ => false,
+    };
+    starts_before && ends_after
+}
+
+// Returns the arms whose literal and range patterns only match values that
+// are already matched by earlier arms without guards.
+pub fn find_redundant_arms(arms: &[MatchArm]) -> Vec<&MatchArm> {
+    let mut covered: Vec<PatRange> = vec![];
+    let mut redundant: Vec<&MatchArm> = vec![];
+
+    for arm in arms {
+        let ranges = get_pat_ranges(&arm.pattern);
+        if !ranges.is_empty()
+            && ranges
+                .iter()
+                .all(|range| covered.iter().any(|prev| range_covers(prev, range)))
+        {
+            redundant.push(arm);
+        }
+        if arm.guard.is_none() {
+            covered.extend(ranges);
+        }
+    }
+
+    redundant
+}
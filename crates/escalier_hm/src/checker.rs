@@ -1,15 +1,34 @@
-use generational_arena::Arena;
+use generational_arena::{Arena, Index};
+use std::collections::{HashMap, HashSet};
 use std::fmt;
 use std::mem;
 
+use escalier_ast::Span;
+
 use crate::diagnostic::Diagnostic;
-use crate::types::Type;
+use crate::types::{PrintOptions, Type, TypeAliases};
 
 #[derive(Default, Clone, Debug)]
 pub struct Report {
     pub diagnostics: Vec<Diagnostic>,
 }
 
+impl Report {
+    // Warnings and info diagnostics don't prevent a program from compiling.
+    pub fn has_errors(&self) -> bool {
+        self.diagnostics
+            .iter()
+            .any(|diagnostic| diagnostic.is_error())
+    }
+
+    // Puts the diagnostics in source order, some of them are only reported
+    // once the checker reaches the end of a scope.  The sort is stable so
+    // diagnostics with the same span stay in the order they were reported.
+    pub fn sort(&mut self) {
+        self.diagnostics.sort_by(Diagnostic::cmp_by_span);
+    }
+}
+
 impl fmt::Display for Report {
     fn fmt(&self, fmt: &mut fmt::Formatter<'_>) -> fmt::Result {
         for diagnostic in &self.diagnostics {
@@ -24,9 +43,57 @@ pub struct Checker {
     pub arena: Arena<Type>,
     pub current_report: Report,
     pub parent_reports: Vec<Report>,
+    // The indexes of all bindings that have been looked up.
+    pub used_bindings: HashSet<Index>,
+    // Bindings declared in the blocks currently being inferred, see
+    // `report_unused_bindings`.
+    pub declared_bindings: Vec<DeclaredBinding>,
+    // Type refs that have already been expanded by `expand_type`, keyed by
+    // the index of the type ref.  See expand_cache.rs.
+    pub expanded_type_refs: HashMap<Index, ExpandedTypeRef>,
+    // The number of steps type expansion can take in each script or module
+    // before giving up, there's no limit if this is `None`.  See
+    // `use_expansion_fuel`.
+    pub expansion_budget: Option<usize>,
+    pub expansion_fuel_used: usize,
+    // The number of ids handed out by `new_id`.
+    pub id_count: usize,
+    // The ids of the well-known symbols that have been used, e.g.
+    // `Symbol.iterator`, see `well_known_symbol`.
+    pub well_known_symbols: HashMap<String, usize>,
+    // How types are printed by `print_type`, this includes the types in
+    // error messages.
+    pub print_options: PrintOptions,
+    // The type aliases that have been declared, see `add_type_alias`.
+    pub type_aliases: TypeAliases,
+    // The object types of namespaces, these are the only values that can be
+    // aliased by `namespace Short = Very.Long.Name`.
+    pub namespace_types: HashSet<Index>,
+}
+
+#[derive(Clone, Debug)]
+pub struct ExpandedTypeRef {
+    // The type of the scheme the type ref's name referred to, the expansion
+    // can only be reused while the name refers to the same scheme.
+    pub scheme_t: Index,
+    pub expanded: Index,
+}
+
+#[derive(Clone, Debug)]
+pub struct DeclaredBinding {
+    pub name: String,
+    pub span: Span,
+    pub index: Index,
 }
 
 impl Checker {
+    // Returns an id that hasn't been used before.  They're used to tell apart
+    // declarations that have the same name, e.g. classes.
+    pub fn new_id(&mut self) -> usize {
+        self.id_count += 1;
+        self.id_count
+    }
+
     pub fn push_report(&mut self) {
         let mut report = Report::default();
         std::mem::swap(&mut report, &mut self.current_report);
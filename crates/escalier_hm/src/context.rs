@@ -13,6 +13,11 @@ use crate::types::*;
 pub struct Binding {
     pub index: Index,
     pub is_mut: bool,
+    // Set for bindings declared with `var`, they can be reassigned like `mut`
+    // bindings.
+    pub is_var: bool,
+    // The reason given by a `@deprecated` annotation on the declaration.
+    pub deprecated: Option<String>,
 }
 
 #[derive(Clone, Debug, Default)]
@@ -28,6 +33,33 @@ pub struct Context {
     pub non_generic: HashSet<Index>,
     // Whether we're in an async function body or not.
     pub is_async: bool,
+    // The ids of the classes whose bodies we're in.  Private members can
+    // only be accessed from within the body of the class that declares them.
+    pub class_ids: HashSet<usize>,
+    // The names of all of the interfaces in `schemes`.  Interfaces can be
+    // declared multiple times, each declaration adds more members.
+    pub interfaces: HashSet<String>,
+    // Whether the member expression being inferred is the target of an
+    // assignment.  Setter-only properties can only be used as lvalues.
+    pub is_lvalue: bool,
+    // The type that the expression being inferred is expected to have, e.g.
+    // the type annotation of the variable it initializes.  Function
+    // expressions use it to infer the types of params without annotations.
+    // It's cleared at the start of each expression so that subexpressions
+    // don't see it.
+    pub expected_type: Option<Index>,
+    // Whether accessing properties on values that may be `null` or `undefined`
+    // is an error.  Optional chaining can be used to access them instead.
+    pub strict_null_checks: bool,
+    // Local functions that are declared later in the current block.  They're
+    // only visible inside of function bodies since those don't run until
+    // after the block has declared them.
+    pub hoisted_fns: HashMap<String, Binding>,
+    // The bindings in the global scope, i.e. the ones that were in scope
+    // before the top-level of a script or module was checked and the ones
+    // from `declare global { ... }`.  `globalThis.foo` is looked up here so
+    // that it isn't affected by local bindings that shadow `foo`.
+    pub globals: HashMap<String, Binding>,
 }
 
 impl Context {
@@ -40,6 +72,13 @@ impl Context {
         }
     }
 
+    /// Makes the local functions that were hoisted in the current block
+    /// visible, this should be called when entering a function body.
+    pub fn hoist_fns(&mut self) {
+        let hoisted_fns = std::mem::take(&mut self.hoisted_fns);
+        self.values.extend(hoisted_fns);
+    }
+
     pub fn get_binding(&self, name: &str) -> Result<Binding, TypeError> {
         match self.values.get(name) {
             Some(binding) => Ok(binding.to_owned()),
@@ -62,8 +101,13 @@ impl Checker {
     ///         environment.
     pub fn get_type(&mut self, name: &str, ctx: &Context) -> Result<Index, TypeError> {
         if let Some(value) = ctx.values.get(name) {
+            self.used_bindings.insert(value.index);
             let result = self.fresh(&value.index, ctx);
             Ok(result)
+        } else if ctx.hoisted_fns.contains_key(name) {
+            Err(TypeError {
+                message: format!("{name} can't be used before it's declared"),
+            })
         } else {
             Err(TypeError {
                 message: format!("Undefined symbol {:?}", name),
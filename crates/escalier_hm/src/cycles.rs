@@ -0,0 +1,134 @@
+use std::collections::{BTreeSet, HashMap, HashSet};
+
+use escalier_ast::{Ident, VarDecl};
+
+use crate::ast_utils::{find_binding_idents, find_eager_idents};
+use crate::checker::Checker;
+use crate::diagnostic::{codes, Diagnostic, Severity};
+
+// The state of each decl while searching for cycles.
+#[derive(Clone, Copy, PartialEq, Eq)]
+enum Visit {
+    NotVisited,
+    InProgress,
+    Done,
+}
+
+struct CycleFinder<'a> {
+    // For each decl, the decls its initializer reads along with the
+    // identifier that was used to read them.
+    deps: &'a [Vec<(usize, Ident)>],
+    visits: Vec<Visit>,
+    // The decls currently being visited and the identifier used to get to
+    // each of them.
+    stack: Vec<(usize, Option<String>)>,
+    cycles: Vec<(Vec<String>, Ident)>,
+    reported: BTreeSet<BTreeSet<usize>>,
+}
+
+impl<'a> CycleFinder<'a> {
+    fn visit(&mut self, decl: usize, via: Option<String>) {
+        self.visits[decl] = Visit::InProgress;
+        self.stack.push((decl, via));
+
+        for (dep, ident) in self.deps[decl].iter() {
+            match self.visits[*dep] {
+                Visit::NotVisited => self.visit(*dep, Some(ident.name.to_owned())),
+                Visit::InProgress => {
+                    let start = self.stack.iter().position(|(decl, _)| decl == dep).unwrap();
+                    let members = self.stack[start..]
+                        .iter()
+                        .map(|(decl, _)| *decl)
+                        .collect::<BTreeSet<_>>();
+                    if !self.reported.insert(members) {
+                        continue;
+                    }
+
+                    let mut chain = vec![ident.name.to_owned()];
+                    for (_, via) in &self.stack[start + 1..] {
+                        chain.extend(via.to_owned());
+                    }
+                    chain.push(ident.name.to_owned());
+                    self.cycles.push((chain, ident.to_owned()));
+                }
+                Visit::Done => {}
+            }
+        }
+
+        self.stack.pop();
+        self.visits[decl] = Visit::Done;
+    }
+}
+
+impl Checker {
+    /// Reports top-level variable decls whose initializers depend on each
+    /// other, e.g. `let a = b + 1` and `let b = a + 1`, since there's no order
+    /// they can be initialized in.  Identifiers inside of functions aren't
+    /// read until the function is called which is why mutually recursive
+    /// functions are still allowed.
+    ///
+    /// Returns the names bound by decls that are part of a cycle.
+    pub fn report_init_cycles(&mut self, decls: &[&VarDecl]) -> HashSet<String> {
+        let mut decl_for_name: HashMap<String, usize> = HashMap::new();
+        for (i, decl) in decls.iter().enumerate() {
+            for ident in find_binding_idents(&decl.pattern) {
+                decl_for_name.insert(ident.name, i);
+            }
+        }
+
+        let deps = decls
+            .iter()
+            .map(|decl| match &decl.expr {
+                Some(expr) => find_eager_idents(expr)
+                    .into_iter()
+                    .filter_map(|ident| Some((*decl_for_name.get(&ident.name)?, ident)))
+                    .collect(),
+                None => vec![],
+            })
+            .collect::<Vec<_>>();
+
+        let mut finder = CycleFinder {
+            deps: &deps,
+            visits: vec![Visit::NotVisited; decls.len()],
+            stack: vec![],
+            cycles: vec![],
+            reported: BTreeSet::new(),
+        };
+        for decl in 0..decls.len() {
+            if finder.visits[decl] == Visit::NotVisited {
+                finder.visit(decl, None);
+            }
+        }
+
+        let mut cyclic_names: HashSet<String> = HashSet::new();
+        for members in &finder.reported {
+            for decl in members {
+                for ident in find_binding_idents(&decls[*decl].pattern) {
+                    cyclic_names.insert(ident.name);
+                }
+            }
+        }
+
+        for (chain, ident) in finder.cycles {
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::CYCLIC_INITIALIZATION,
+                severity: Severity::Error,
+                message: format!(
+                    "Variables can't depend on themselves during initialization: {}",
+                    chain.join(" -> ")
+                ),
+                reasons: vec![],
+                span: Some(ident.span),
+            });
+        }
+
+        cyclic_names
+    }
+}
+
+/// Whether `decl` binds any of the names returned by `report_init_cycles`.
+pub fn is_in_init_cycle(decl: &VarDecl, cyclic_names: &HashSet<String>) -> bool {
+    find_binding_idents(&decl.pattern)
+        .iter()
+        .any(|ident| cyclic_names.contains(&ident.name))
+}
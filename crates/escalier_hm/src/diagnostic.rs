@@ -1,17 +1,103 @@
+use std::cmp::Ordering;
 use std::fmt;
 
+use escalier_ast::Span;
+
 use crate::type_error::TypeError;
 
+#[derive(Clone, Copy, Debug, PartialEq, Eq, PartialOrd, Ord)]
+pub enum Severity {
+    Info,
+    Warning,
+    Error,
+}
+
+impl fmt::Display for Severity {
+    fn fmt(&self, fmt: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Severity::Info => write!(fmt, "info"),
+            Severity::Warning => write!(fmt, "warning"),
+            Severity::Error => write!(fmt, "error"),
+        }
+    }
+}
+
+/// Stable codes for each kind of diagnostic.  Codes must never be reused or
+/// renumbered since they're used by editors, documentation, and suppression
+/// comments.  Errors are in the 1000s and warnings are in the 2000s.
+pub mod codes {
+    pub const INCORRECT_FUNCTION_ARGS: u32 = 1000;
+    pub const INCORRECT_TYPE_ARG_COUNT: u32 = 1001;
+    pub const INCORRECTLY_IMPLEMENTS: u32 = 1002;
+    pub const EXCESS_PROPERTY: u32 = 1003;
+    pub const WRONG_ARG_COUNT: u32 = 1004;
+    pub const CYCLIC_INITIALIZATION: u32 = 1005;
+    pub const INVALID_JSX_CHILD: u32 = 1006;
+    pub const INCORRECT_JSX_PROPS: u32 = 1007;
+
+    pub const DEPRECATED: u32 = 2000;
+    pub const UNUSED_BINDING: u32 = 2001;
+    pub const UNREACHABLE_CODE: u32 = 2002;
+    pub const UNREACHABLE_MATCH_ARM: u32 = 2003;
+    pub const UNUSED_SUPPRESSION: u32 = 2004;
+    pub const UNSUPPORTED_DECLARATION: u32 = 2005;
+
+    /// All of the codes above, new codes must be added here as well.
+    pub const ALL: &[u32] = &[
+        INCORRECT_FUNCTION_ARGS,
+        INCORRECT_TYPE_ARG_COUNT,
+        INCORRECTLY_IMPLEMENTS,
+        EXCESS_PROPERTY,
+        WRONG_ARG_COUNT,
+        CYCLIC_INITIALIZATION,
+        INVALID_JSX_CHILD,
+        INCORRECT_JSX_PROPS,
+        DEPRECATED,
+        UNUSED_BINDING,
+        UNREACHABLE_CODE,
+        UNREACHABLE_MATCH_ARM,
+        UNUSED_SUPPRESSION,
+        UNSUPPORTED_DECLARATION,
+    ];
+}
+
 #[derive(Clone, Debug, PartialEq, Eq)]
 pub struct Diagnostic {
     pub code: u32,
+    pub severity: Severity,
     pub message: String,
     pub reasons: Vec<TypeError>,
+    pub span: Option<Span>,
+}
+
+impl Diagnostic {
+    pub fn is_error(&self) -> bool {
+        self.severity == Severity::Error
+    }
+
+    /// The code as it's shown to users, e.g. `ESC_1000`.
+    pub fn code_name(&self) -> String {
+        format!("ESC_{}", self.code)
+    }
+
+    /// Orders diagnostics by where they appear in the source, diagnostics
+    /// without a span come after all of the others.
+    pub fn cmp_by_span(&self, other: &Self) -> Ordering {
+        match (&self.span, &other.span) {
+            (Some(a), Some(b)) => (a.start, a.end).cmp(&(b.start, b.end)),
+            (Some(_), None) => Ordering::Less,
+            (None, Some(_)) => Ordering::Greater,
+            (None, None) => Ordering::Equal,
+        }
+    }
 }
 
 impl fmt::Display for Diagnostic {
     fn fmt(&self, fmt: &mut fmt::Formatter<'_>) -> fmt::Result {
-        writeln!(fmt, "ESC_{} - {}:", self.code, self.message)?;
+        if self.reasons.is_empty() {
+            return writeln!(fmt, "{} - {}", self.code_name(), self.message);
+        }
+        writeln!(fmt, "{} - {}:", self.code_name(), self.message)?;
         let len = self.reasons.len();
         for (i, reason) in self.reasons.iter().enumerate() {
             if i < len - 1 {
@@ -0,0 +1,144 @@
+use generational_arena::Index;
+use std::collections::BTreeMap;
+
+use escalier_ast::expr::Prop;
+use escalier_ast::{Expr, ExprKind, ObjectKey, PropOrSpread};
+
+use crate::checker::Checker;
+use crate::context::Context;
+use crate::diagnostic::{codes, Diagnostic, Severity};
+use crate::type_error::TypeError;
+use crate::types::*;
+
+impl Checker {
+    /// Reports properties in the object literal `expr` that don't exist in
+    /// `t`, e.g. `y` in `let p: {x: number} = {x: 1, y: 2}`.  Only fresh
+    /// object literals are checked, `let q = {x: 1, y: 2}; let p: {x: number} = q`
+    /// is still allowed since `q` could be used somewhere else that needs `y`.
+    pub fn check_excess_properties(
+        &mut self,
+        ctx: &Context,
+        expr: &Expr,
+        t: Index,
+    ) -> Result<(), TypeError> {
+        let props = match &expr.kind {
+            ExprKind::Object(object) => &object.properties,
+            _ => return Ok(()),
+        };
+
+        let known_props = match self.get_known_props(ctx, t)? {
+            Some(known_props) => known_props,
+            None => return Ok(()),
+        };
+
+        for prop_or_spread in props {
+            let (name, span, value) = match prop_or_spread {
+                PropOrSpread::Spread(_) => continue,
+                PropOrSpread::Prop(Prop::Shorthand(ident)) => {
+                    (ident.name.to_owned(), ident.span, None)
+                }
+                PropOrSpread::Prop(Prop::Property { key, value }) => match key {
+                    ObjectKey::Ident(ident) => (ident.name.to_owned(), ident.span, Some(value)),
+                    ObjectKey::String(name) => (name.to_owned(), value.span, Some(value)),
+                    ObjectKey::Number(name) => (name.to_owned(), value.span, Some(value)),
+                    ObjectKey::Computed(_) => continue,
+                },
+            };
+
+            match known_props.get(&name) {
+                Some(prop_types) => {
+                    // Nested object literals are fresh too.
+                    if let (Some(value), false) = (value, prop_types.is_empty()) {
+                        let prop_t = self.new_union_type(prop_types);
+                        self.check_excess_properties(ctx, value, prop_t)?;
+                    }
+                }
+                None => {
+                    let message = format!(
+                        "Object literal may only specify known properties, but {name} does not exist in {}",
+                        self.print_type(&t)
+                    );
+                    self.current_report.diagnostics.push(Diagnostic {
+                        code: codes::EXCESS_PROPERTY,
+                        severity: Severity::Error,
+                        message,
+                        reasons: vec![],
+                        span: Some(span),
+                    });
+                }
+            }
+        }
+
+        Ok(())
+    }
+
+    // Returns the types of all of the properties in `t` by name.  Returns
+    // `None` if `t` can have properties that we don't know about, e.g. if it's
+    // a type variable or has an indexer.
+    fn get_known_props(
+        &mut self,
+        ctx: &Context,
+        t: Index,
+    ) -> Result<Option<BTreeMap<String, Vec<Index>>>, TypeError> {
+        if self.is_interface(ctx, t) {
+            return Ok(None);
+        }
+        let t = self.expand_type(ctx, t)?;
+
+        let members = match &self.arena[t].kind {
+            TypeKind::Object(_) => vec![t],
+            TypeKind::Union(Union { types }) | TypeKind::Intersection(Intersection { types }) => {
+                types.to_owned()
+            }
+            _ => return Ok(None),
+        };
+
+        let mut known_props: BTreeMap<String, Vec<Index>> = BTreeMap::new();
+        let mut has_object = false;
+
+        for member in members {
+            if self.is_interface(ctx, member) {
+                return Ok(None);
+            }
+            let member = self.expand_type(ctx, member)?;
+            let elems = match &self.arena[member].kind {
+                TypeKind::Object(Object { elems, .. }) => elems.to_owned(),
+                // Literals and primitives, e.g. `null`, don't add any properties.
+                TypeKind::Literal(_) | TypeKind::Primitive(_) => continue,
+                _ => return Ok(None),
+            };
+            has_object = true;
+
+            for elem in elems {
+                let (name, prop_t) = match elem {
+                    TObjElem::Call(_) | TObjElem::Constructor(_) => continue,
+                    TObjElem::Mapped(_) => return Ok(None),
+                    TObjElem::Method(TMethod { name, .. }) => (name, None),
+                    TObjElem::Getter(TGetter { name, ret, .. }) => (name, Some(ret)),
+                    TObjElem::Setter(TSetter { name, param, .. }) => (name, Some(param.t)),
+                    TObjElem::Prop(TProp { name, t, .. }) => (name, Some(t)),
+                };
+
+                let prop_types = known_props.entry(name.to_string()).or_default();
+                if let Some(prop_t) = prop_t {
+                    prop_types.push(prop_t);
+                }
+            }
+        }
+
+        match has_object {
+            true => Ok(Some(known_props)),
+            false => Ok(None),
+        }
+    }
+
+    // Interfaces can be declared multiple times so later declarations can
+    // add properties that we don't know about yet.
+    fn is_interface(&mut self, ctx: &Context, t: Index) -> bool {
+        let t = self.prune(t);
+        match &self.arena[t].kind {
+            TypeKind::TypeRef(TypeRef { name, .. }) => ctx.interfaces.contains(name),
+            _ => false,
+        }
+    }
+}
@@ -0,0 +1,106 @@
+use generational_arena::{Arena, Index};
+
+use crate::checker::{Checker, ExpandedTypeRef};
+use crate::context::Context;
+use crate::key_value_store::KeyValueStore;
+use crate::types::*;
+use crate::visitor::{self, Visitor};
+
+// Looks for type variables that haven't been bound yet and references to type
+// params, the expansion of types containing either can change later on or
+// depends on where they're expanded.
+struct UnresolvedVisitor<'a> {
+    arena: &'a mut Arena<Type>,
+    ctx: &'a Context,
+    found: bool,
+}
+
+impl<'a> KeyValueStore<Index, Type> for UnresolvedVisitor<'a> {
+    fn get_type(&mut self, idx: &Index) -> Type {
+        self.arena[*idx].clone()
+    }
+    fn put_type(&mut self, t: Type) -> Index {
+        self.arena.insert(t)
+    }
+}
+
+impl<'a> Visitor for UnresolvedVisitor<'a> {
+    fn visit_index(&mut self, index: &Index) {
+        if self.found {
+            return;
+        }
+        let t = self.get_type(index);
+        match &t.kind {
+            TypeKind::TypeVar(TypeVar { instance: None, .. }) => self.found = true,
+            TypeKind::TypeRef(TypeRef {
+                name, scheme: None, ..
+            }) if matches!(
+                self.ctx.schemes.get(name),
+                Some(Scheme {
+                    is_type_param: true,
+                    ..
+                })
+            ) =>
+            {
+                self.found = true
+            }
+            _ => visitor::walk_index(self, index),
+        }
+    }
+}
+
+impl Checker {
+    // Returns the type of the scheme that the type ref `t` refers to in `ctx`.
+    fn get_type_ref_scheme_t(&self, ctx: &Context, t: Index) -> Option<Index> {
+        match &self.arena[t].kind {
+            TypeKind::TypeRef(TypeRef {
+                scheme: Some(scheme),
+                ..
+            }) => Some(scheme.t),
+            TypeKind::TypeRef(TypeRef { name, .. }) => ctx.schemes.get(name).map(|scheme| scheme.t),
+            _ => None,
+        }
+    }
+
+    fn is_unresolved(&mut self, ctx: &Context, t: Index) -> bool {
+        let mut visitor = UnresolvedVisitor {
+            arena: &mut self.arena,
+            ctx,
+            found: false,
+        };
+        visitor.visit_index(&t);
+        visitor.found
+    }
+
+    /// Returns the expansion of the type ref `t` if it's already been
+    /// expanded in a context where its name referred to the same scheme.
+    pub fn get_expanded_type_ref(&self, ctx: &Context, t: Index) -> Option<Index> {
+        let entry = self.expanded_type_refs.get(&t)?;
+        match self.get_type_ref_scheme_t(ctx, t) {
+            Some(scheme_t) if scheme_t == entry.scheme_t => Some(entry.expanded),
+            _ => None,
+        }
+    }
+
+    /// Remembers that the type ref `t` expands to `expanded` in `ctx`.  Type
+    /// refs whose type args or expansion contain unbound type variables or
+    /// type params aren't cached since expanding them again could produce a
+    /// different type.
+    pub fn cache_expanded_type_ref(&mut self, ctx: &Context, t: Index, expanded: Index) {
+        let scheme_t = match self.get_type_ref_scheme_t(ctx, t) {
+            Some(scheme_t) => scheme_t,
+            None => return,
+        };
+        if self.is_unresolved(ctx, t) || self.is_unresolved(ctx, expanded) {
+            return;
+        }
+        self.expanded_type_refs
+            .insert(t, ExpandedTypeRef { scheme_t, expanded });
+    }
+
+    /// Forgets all of the cached expansions, this is done before and after
+    /// each script or module is inferred and whenever a type is declared.
+    pub fn clear_expanded_type_refs(&mut self) {
+        self.expanded_type_refs.clear();
+    }
+}
@@ -77,14 +77,17 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
 
             TypeKind::Intersection(Intersection { types: new_types })
         }
-        TypeKind::Tuple(Tuple { types }) => {
+        TypeKind::Tuple(Tuple { types, labels }) => {
             let new_types = walk_indexes(folder, types);
 
             if new_types == *types {
                 return *index;
             }
 
-            TypeKind::Tuple(Tuple { types: new_types })
+            TypeKind::Tuple(Tuple {
+                types: new_types,
+                labels: labels.to_owned(),
+            })
         }
         TypeKind::Array(Array { t }) => {
             let new_t = folder.fold_index(t);
@@ -98,8 +101,20 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
         TypeKind::Keyword(_) => return *index,
         TypeKind::Primitive(_) => return *index,
         TypeKind::Literal(_) => return *index,
+        TypeKind::TemplateLiteral(TemplateLitType { parts, types }) => {
+            let new_types = walk_indexes(folder, types);
+
+            if new_types == *types {
+                return *index;
+            }
+
+            TypeKind::TemplateLiteral(TemplateLitType {
+                parts: parts.to_owned(),
+                types: new_types,
+            })
+        }
         TypeKind::Function(function) => TypeKind::Function(walk_function(folder, function)),
-        TypeKind::Object(Object { elems }) => {
+        TypeKind::Object(Object { elems, class }) => {
             let elems: Vec<_> = elems
                 .iter()
                 .map(|elem| match elem {
@@ -113,6 +128,7 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
                         target,
                         source,
                         optional,
+                        readonly,
                         check,
                         extends,
                     }) => {
@@ -129,6 +145,7 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
                             target: target.to_owned(),
                             source: new_source,
                             optional: optional.to_owned(),
+                            readonly: readonly.to_owned(),
                             check: new_check,
                             extends: new_extends,
                         })
@@ -136,10 +153,12 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
                     TObjElem::Method(TMethod {
                         name,
                         mutates,
+                        is_abstract,
                         function,
                     }) => TObjElem::Method(TMethod {
                         name: name.to_owned(),
                         mutates: *mutates,
+                        is_abstract: *is_abstract,
                         function: walk_function(folder, function),
                     }),
                     TObjElem::Getter(TGetter { name, ret, throws }) => TObjElem::Getter(TGetter {
@@ -163,7 +182,10 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
                 })
                 .collect();
 
-            TypeKind::Object(Object { elems })
+            TypeKind::Object(Object {
+                elems,
+                class: class.to_owned(),
+            })
         }
         TypeKind::Rest(Rest { arg }) => {
             let new_arg = folder.fold_index(arg);
@@ -183,11 +205,14 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
 
             TypeKind::KeyOf(KeyOf { t: new_t })
         }
-        TypeKind::IndexedAccess(IndexedAccess { obj, index }) => {
+        TypeKind::IndexedAccess(IndexedAccess {
+            obj,
+            index: obj_index,
+        }) => {
             let new_obj = folder.fold_index(obj);
-            let new_index = folder.fold_index(index);
+            let new_index = folder.fold_index(obj_index);
 
-            if new_obj == *obj && new_index == *index {
+            if new_obj == *obj && new_index == *obj_index {
                 return *index;
             }
 
@@ -224,6 +249,7 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
         }
         TypeKind::Infer(_) => return *index,
         TypeKind::Wildcard => return *index,
+        TypeKind::UniqueSymbol(_) => return *index,
         TypeKind::Binary(BinaryT { op, left, right }) => {
             let new_left = folder.fold_index(left);
             let new_right = folder.fold_index(right);
@@ -238,6 +264,19 @@ pub fn walk_index<F: Folder>(folder: &mut F, index: &Index) -> Index {
                 right: new_right,
             })
         }
+        TypeKind::Predicate(Predicate { asserts, param, t }) => {
+            let new_t = t.map(|t| folder.fold_index(&t));
+
+            if new_t == *t {
+                return *index;
+            }
+
+            TypeKind::Predicate(Predicate {
+                asserts: *asserts,
+                param: param.to_owned(),
+                t: new_t,
+            })
+        }
     };
 
     folder.put_type(Type {
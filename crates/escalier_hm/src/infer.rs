@@ -4,15 +4,21 @@ use std::collections::{BTreeMap, HashMap, HashSet};
 
 use escalier_ast::{self as syntax, *};
 
-use crate::ast_utils::{find_returns, find_throws, find_throws_in_block};
+use crate::ast_utils::{
+    find_redundant_arms, find_returns, find_throws, find_throws_in_block, find_unreachable_stmt,
+    find_yields, get_well_known_symbol,
+};
 use crate::checker::Checker;
 use crate::context::*;
+use crate::cycles::is_in_init_cycle;
+use crate::diagnostic::{codes, Diagnostic, Severity};
 use crate::folder::{self, Folder};
 use crate::infer_pattern::*;
 use crate::key_value_store::KeyValueStore;
 use crate::provenance::Provenance;
 use crate::type_error::TypeError;
 use crate::types::{self, *};
+use crate::unify::CallTypeArgs;
 use crate::util::*;
 
 impl Checker {
@@ -42,10 +48,30 @@ impl Checker {
         node: &mut Expr,
         ctx: &mut Context,
     ) -> Result<Index, TypeError> {
+        let expected_t = ctx.expected_type.take();
         self.with_report(|checker| -> Result<Index, TypeError> {
             let idx: Index =
                 match &mut node.kind {
-                    ExprKind::Ident(Ident { name, .. }) => checker.get_type(name, ctx)?,
+                    ExprKind::Ident(Ident { name, span }) => {
+                        if let Some(Binding {
+                            deprecated: Some(reason),
+                            ..
+                        }) = ctx.values.get(name)
+                        {
+                            let message = match reason.is_empty() {
+                                true => format!("{name} is deprecated"),
+                                false => format!("{name} is deprecated: {reason}"),
+                            };
+                            checker.current_report.diagnostics.push(Diagnostic {
+                                code: codes::DEPRECATED,
+                                severity: Severity::Warning,
+                                message,
+                                reasons: vec![],
+                                span: Some(*span),
+                            });
+                        }
+                        checker.get_type(name, ctx)?
+                    }
                     ExprKind::Str(str) => checker.arena.insert(Type::from(TypeKind::Literal(
                         syntax::Literal::String(str.value.to_owned()),
                     ))),
@@ -55,6 +81,7 @@ impl Checker {
                     ExprKind::Bool(bool) => checker.arena.insert(Type::from(TypeKind::Literal(
                         syntax::Literal::Boolean(bool.value),
                     ))),
+                    ExprKind::Regex(regex) => checker.get_regex_type(regex, ctx)?,
                     ExprKind::Null(_) => checker
                         .arena
                         .insert(Type::from(TypeKind::Literal(syntax::Literal::Null))),
@@ -66,11 +93,35 @@ impl Checker {
                     }) => {
                         let mut element_types = vec![];
                         for element in elems.iter_mut() {
-                            let t = match element {
-                                ExprOrSpread::Expr(expr) => checker.infer_expression(expr, ctx)?,
-                                ExprOrSpread::Spread(_) => todo!(), // TODO: handle spreads
+                            match element {
+                                ExprOrSpread::Expr(expr) => {
+                                    element_types.push(checker.infer_expression(expr, ctx)?);
+                                }
+                                ExprOrSpread::Spread(expr) => {
+                                    let spread_t = checker.infer_expression(expr, ctx)?;
+                                    let spread_t = checker.expand_type(ctx, spread_t)?;
+                                    match &checker.arena[spread_t].kind {
+                                        TypeKind::Tuple(tuple) => {
+                                            element_types.extend(tuple.types.clone());
+                                        }
+                                        TypeKind::Array(_) => {
+                                            element_types.push(checker.new_rest_type(spread_t));
+                                        }
+                                        _ => {
+                                            let elem_t = checker
+                                                .get_iterator_elem_type(ctx, spread_t)?
+                                                .ok_or_else(|| TypeError {
+                                                    message: format!(
+                                                        "{} is not iterable",
+                                                        checker.print_type(&spread_t)
+                                                    ),
+                                                })?;
+                                            let array_t = checker.new_array_type(elem_t);
+                                            element_types.push(checker.new_rest_type(array_t));
+                                        }
+                                    }
+                                }
                             };
-                            element_types.push(t);
                         }
                         checker.new_tuple_type(&element_types)
                     }
@@ -91,28 +142,30 @@ impl Checker {
                                         }));
                                     }
                                     expr::Prop::Property { key, value } => {
-                                        let prop = match key {
-                                            ObjectKey::Ident(ident) => types::TProp {
-                                                name: TPropKey::StringKey(ident.name.to_owned()),
-                                                readonly: false,
-                                                optional: false,
-                                                t: checker.infer_expression(value, ctx)?,
-                                            },
-                                            ObjectKey::String(name) => types::TProp {
-                                                name: TPropKey::StringKey(name.to_owned()),
-                                                readonly: false,
-                                                optional: false,
-                                                t: checker.infer_expression(value, ctx)?,
-                                            },
-                                            ObjectKey::Number(name) => types::TProp {
-                                                name: TPropKey::StringKey(name.to_owned()),
-                                                readonly: false,
-                                                optional: false,
-                                                t: checker.infer_expression(value, ctx)?,
-                                            },
-                                            ObjectKey::Computed(_) => todo!(),
+                                        let name = match key {
+                                            ObjectKey::Ident(ident) => {
+                                                TPropKey::StringKey(ident.name.to_owned())
+                                            }
+                                            ObjectKey::String(name) | ObjectKey::Number(name) => {
+                                                TPropKey::StringKey(name.to_owned())
+                                            }
+                                            ObjectKey::Computed(key) => {
+                                                let key_t = checker.infer_computed_key(key, ctx)?;
+                                                checker.get_computed_prop_key(key_t)?
+                                            }
                                         };
-                                        prop_types.push(types::TObjElem::Prop(prop));
+                                        // The values get their expected types
+                                        // from the object's expected type.
+                                        if let Some(expected_t) = expected_t {
+                                            ctx.expected_type = checker
+                                                .get_expected_prop_type(ctx, expected_t, &name);
+                                        }
+                                        prop_types.push(types::TObjElem::Prop(types::TProp {
+                                            name,
+                                            readonly: false,
+                                            optional: false,
+                                            t: checker.infer_expression(value, ctx)?,
+                                        }));
                                     }
                                 },
                             }
@@ -128,6 +181,7 @@ impl Checker {
                     }) => {
                         // TODO: Check if the callee in an object with a callable signature.
                         let mut func_idx = checker.infer_expression(callee, ctx)?;
+                        checker.check_not_unknown(func_idx, "call")?;
                         let mut has_undefined = false;
                         if *opt_chain {
                             if let TypeKind::Union(union) = &checker.arena[func_idx].kind {
@@ -144,15 +198,40 @@ impl Checker {
                                     .map(|type_arg| checker.infer_type_ann(type_arg, ctx))
                                     .collect::<Result<Vec<_>, _>>()?;
 
-                                checker.unify_call(ctx, args, Some(&type_args), false, func_idx)?
+                                let type_args = CallTypeArgs {
+                                    types: &type_args,
+                                    callee: get_callee_name(callee),
+                                };
+                                checker.unify_call(
+                                    ctx,
+                                    args,
+                                    node.span,
+                                    Some(&type_args),
+                                    false,
+                                    func_idx,
+                                )?
+                            }
+                            None => {
+                                checker.unify_call(ctx, args, node.span, None, false, func_idx)?
                             }
-                            None => checker.unify_call(ctx, args, None, false, func_idx)?,
                         };
 
                         if let Some(new_throws) = new_throws {
                             throws.replace(new_throws);
                         }
 
+                        // Type guards return a `boolean` and assertions return `void`,
+                        // their predicates are only used for narrowing.
+                        let result = match &checker.arena[result].kind {
+                            TypeKind::Predicate(Predicate { asserts: false, .. }) => {
+                                checker.new_primitive(Primitive::Boolean)
+                            }
+                            TypeKind::Predicate(Predicate { asserts: true, .. }) => {
+                                checker.new_keyword(Keyword::Void)
+                            }
+                            _ => result,
+                        };
+
                         match *opt_chain && has_undefined {
                             true => {
                                 let undefined = checker.new_lit_type(&Literal::Undefined);
@@ -184,6 +263,7 @@ impl Checker {
                         // TODO: Check if the callee in an object with a newable signature.
 
                         let func_idx = checker.infer_expression(callee, ctx)?;
+                        checker.check_not_unknown(func_idx, "instantiate")?;
                         // let func_idx = checker.expand_type(ctx, func_idx)?;
                         // eprintln!("func_idx = {}", checker.print_type(&func_idx));
 
@@ -194,9 +274,22 @@ impl Checker {
                                     .map(|type_arg| checker.infer_type_ann(type_arg, ctx))
                                     .collect::<Result<Vec<_>, _>>()?;
 
-                                checker.unify_call(ctx, args, Some(&type_args), true, func_idx)?
+                                let type_args = CallTypeArgs {
+                                    types: &type_args,
+                                    callee: get_callee_name(callee),
+                                };
+                                checker.unify_call(
+                                    ctx,
+                                    args,
+                                    node.span,
+                                    Some(&type_args),
+                                    true,
+                                    func_idx,
+                                )?
+                            }
+                            None => {
+                                checker.unify_call(ctx, args, node.span, None, true, func_idx)?
                             }
-                            None => checker.unify_call(ctx, args, None, true, func_idx)?,
                         };
 
                         if let Some(new_throws) = new_throws {
@@ -209,31 +302,45 @@ impl Checker {
                         params,
                         body,
                         is_async,
-                        is_gen: _,
+                        is_gen,
                         type_params,
                         type_ann: return_type,
                         throws: sig_throws,
                     }) => {
                         let mut sig_ctx = ctx.clone();
+                        sig_ctx.hoist_fns();
 
                         let mut func_params: Vec<types::FuncParam> = vec![];
 
                         let type_params = checker.infer_type_params(type_params, &mut sig_ctx)?;
 
-                        for syntax::FuncParam {
-                            pattern,
-                            type_ann,
-                            optional,
-                        } in params.iter_mut()
-                        {
-                            let type_ann_t = match type_ann {
-                                Some(type_ann) => checker.infer_type_ann(type_ann, &mut sig_ctx)?,
-                                None => checker.new_type_var(None),
+                        // Params without type annotations get their types from
+                        // the expected type, e.g. `e` is a `MouseEvent` in
+                        // `let handler: fn (e: MouseEvent) -> void = fn (e) {...}`.
+                        let expected_params = match expected_t {
+                            Some(expected_t) => checker.get_expected_param_types(ctx, expected_t),
+                            None => vec![],
+                        };
+
+                        for (i, param) in params.iter_mut().enumerate() {
+                            let syntax::FuncParam {
+                                pattern,
+                                type_ann,
+                                optional,
+                            } = param;
+                            let type_ann_t = match (type_ann, expected_params.get(i)) {
+                                (Some(type_ann), _) => {
+                                    checker.infer_type_ann(type_ann, &mut sig_ctx)?
+                                }
+                                (None, Some(expected_t)) => *expected_t,
+                                (None, None) => checker.new_type_var(None),
                             };
                             pattern.inferred_type = Some(type_ann_t);
 
-                            let (assumps, param_t) = checker.infer_pattern(pattern, &sig_ctx)?;
-                            checker.unify(&sig_ctx, param_t, type_ann_t)?;
+                            let (mut assumps, param_t) = checker.infer_pattern(pattern, &sig_ctx)?;
+                            let binding_t = checker.get_param_binding_type(type_ann_t, *optional);
+                            checker.unify(&sig_ctx, param_t, binding_t)?;
+                            checker.infer_pattern_defaults(pattern, &mut assumps, &mut sig_ctx)?;
 
                             for (name, binding) in assumps {
                                 sig_ctx.non_generic.insert(binding.index);
@@ -246,13 +353,16 @@ impl Checker {
                                 optional: *optional,
                             });
                         }
+                        check_optional_params(&func_params)?;
 
                         let mut body_ctx = sig_ctx.clone();
                         body_ctx.is_async = *is_async;
+                        let decls_start = checker.declared_bindings.len();
 
                         let mut body_t = 'outer: {
                             match body {
                                 BlockOrExpr::Block(Block { stmts, .. }) => {
+                                    checker.hoist_local_fns(stmts, &mut body_ctx);
                                     for stmt in stmts.iter_mut() {
                                         body_ctx = body_ctx.clone();
                                         checker.infer_statement(stmt, &mut body_ctx)?;
@@ -262,7 +372,6 @@ impl Checker {
                                                 .filter_map(|ret| ret.inferred_type)
                                                 .collect();
 
-                                            // TODO: warn about unreachable code.
                                             break 'outer checker.new_union_type(&ret_types);
                                         }
                                     }
@@ -278,6 +387,11 @@ impl Checker {
                             }
                         };
 
+                        if let BlockOrExpr::Block(Block { stmts, .. }) = body {
+                            checker.report_unreachable_code(stmts);
+                        }
+                        checker.report_unused_bindings(decls_start);
+
                         let body_throws = find_throws(body);
                         let body_throws = if body_throws.is_empty() {
                             None
@@ -312,10 +426,32 @@ impl Checker {
                             None => checker.new_type_var(None),
                         };
 
-                        // TODO: Make the return type `Promise<body_t, throws>` if the function
-                        // is async.  Async functions cannot throw.  They can only return a
-                        // rejected promise.
-                        if *is_async && !is_promise(&checker.arena[body_t]) {
+                        if *is_gen {
+                            let yield_types: Vec<Index> = find_yields(body)
+                                .iter()
+                                .filter_map(|arg| arg.inferred_type)
+                                .unique()
+                                .collect();
+                            let yield_t = checker.new_union_type(&yield_types);
+                            let next_t = checker.new_keyword(Keyword::Unknown);
+
+                            // Async generators report errors by rejecting the
+                            // promise returned by `.next()` so they can't throw.
+                            let (name, throws) = match is_async {
+                                true => ("AsyncGenerator", None),
+                                false => ("Generator", throws),
+                            };
+                            // NOTE: `None` means that we'll need to look up the
+                            // type whenever it's used.
+                            let gen_t =
+                                checker.new_type_ref(name, None, &[yield_t, body_t, next_t]);
+
+                            checker.unify(&sig_ctx, gen_t, ret_t)?;
+                            checker.new_func_type(&func_params, ret_t, &type_params, throws)
+                        } else if *is_async && !is_promise(&checker.arena[body_t]) {
+                            // TODO: Make the return type `Promise<body_t, throws>` if the function
+                            // is async.  Async functions cannot throw.  They can only return a
+                            // rejected promise.
                             let never = checker.new_keyword(Keyword::Never);
                             let throws_t = throws.unwrap_or(never);
                             // NOTE: `None` means that we'll need to look up the
@@ -331,7 +467,7 @@ impl Checker {
                             // TODO: add sig_ctx which is a copy of ctx but with all of
                             // the type params added to sig_ctx.schemes so that they can
                             // be looked up.
-                            checker.unify(&sig_ctx, body_t, ret_t)?;
+                            checker.unify_body_with_ret(&sig_ctx, body_t, ret_t, &func_params)?;
                             checker.new_func_type(&func_params, ret_t, &type_params, throws)
                         }
                     }
@@ -343,7 +479,11 @@ impl Checker {
                         let cond_type = checker.infer_expression(cond, ctx)?;
                         let bool_type = checker.new_primitive(Primitive::Boolean);
                         checker.unify(ctx, cond_type, bool_type)?;
-                        let consequent_type = checker.infer_block(consequent, ctx)?;
+                        let mut consequent_ctx = ctx.clone();
+                        checker.refine_bindings_with_guard(cond, &mut consequent_ctx);
+                        checker.refine_bindings_with_predicate(cond, &mut consequent_ctx);
+                        let consequent_type =
+                            checker.infer_block(consequent, &mut consequent_ctx)?;
                         let alternate_type = match alternate {
                             Some(alternate) => match alternate {
                                 BlockOrExpr::Block(block) => checker.infer_block(block, ctx)?,
@@ -355,12 +495,22 @@ impl Checker {
                         // consequent_type
                         checker.new_union_type(&[consequent_type, alternate_type])
                     }
+                    ExprKind::Member(Member {
+                        object: obj,
+                        property: MemberProp::Ident(Ident { name, .. }),
+                        ..
+                    }) if is_global_this(obj, ctx) => checker.get_global_type(name, ctx)?,
                     ExprKind::Member(Member {
                         object: obj,
                         property: prop,
                         opt_chain,
+                        is_private,
                     }) => {
+                        // Only the outermost member of an assignment target
+                        // is an lvalue, e.g. `b` isn't an lvalue in `a.b.c = 5`.
+                        let is_lvalue = std::mem::take(&mut ctx.is_lvalue);
                         let mut obj_idx = checker.infer_expression(obj, ctx)?;
+                        checker.check_not_unknown(obj_idx, "access properties on")?;
                         let is_mut = is_expr_mutable(ctx, obj)?;
                         let mut has_undefined = false;
                         if *opt_chain {
@@ -375,13 +525,18 @@ impl Checker {
                             MemberProp::Ident(Ident { name, .. }) => {
                                 let key_idx =
                                     checker.new_lit_type(&Literal::String(name.to_owned()));
-                                checker.get_ident_member(ctx, obj_idx, key_idx, is_mut)?
+                                ctx.is_lvalue = is_lvalue;
+                                let t = checker.get_ident_member(ctx, obj_idx, key_idx, is_mut)?;
+                                *is_private = checker.is_private_member(ctx, obj_idx, name)?;
+                                t
                             }
                             MemberProp::Computed(ComputedPropName { expr, .. }) => {
-                                let prop_type = checker.infer_expression(expr, ctx)?;
+                                let prop_type = checker.infer_computed_key(expr, ctx)?;
+                                ctx.is_lvalue = is_lvalue;
                                 checker.get_computed_member(ctx, obj_idx, prop_type, is_mut)?
                             }
                         };
+                        ctx.is_lvalue = false;
 
                         match *opt_chain && has_undefined {
                             true => {
@@ -406,16 +561,66 @@ impl Checker {
                             false => result,
                         }
                     }
-                    ExprKind::JSXElement(_) => todo!(),
+                    ExprKind::JSXElement(elem) => checker.infer_jsx_element(elem, ctx)?,
                     ExprKind::Assign(Assign { left, op: _, right }) => {
-                        if !is_expr_mutable(ctx, left)? {
-                            return Err(TypeError {
-                                message: "Cannot assign to immutable lvalue".to_string(),
-                            });
+                        match &left.kind {
+                            ExprKind::Ident(Ident { name, .. }) => {
+                                let binding = ctx.get_binding(name)?;
+                                if !binding.is_var && !binding.is_mut {
+                                    return Err(TypeError {
+                                        message: format!(
+                                            "Cannot reassign {name}, only `var` and `mut` bindings can be reassigned"
+                                        ),
+                                    });
+                                }
+                            }
+                            _ => {
+                                if !is_expr_mutable(ctx, left)? {
+                                    return Err(TypeError {
+                                        message: "Cannot assign to immutable lvalue".to_string(),
+                                    });
+                                }
+                            }
+                        }
+
+                        let (l_t, r_t) = match checker.add_prop_to_func_binding(ctx, left, right)? {
+                            Some(r_t) => (checker.infer_lvalue(left, ctx)?, r_t),
+                            None => {
+                                let l_t = checker.infer_lvalue(left, ctx)?;
+                                let r_t = checker.infer_expression(right, ctx)?;
+                                (l_t, r_t)
+                            }
+                        };
+
+                        match &left.kind {
+                            ExprKind::Member(Member {
+                                object,
+                                property: MemberProp::Ident(Ident { name, .. }),
+                                ..
+                            }) => {
+                                if let Some(obj_t) = object.inferred_type {
+                                    checker.check_prop_assignment(ctx, obj_t, name)?;
+                                }
+                            }
+                            // Computed keys can only be checked when they're
+                            // literals, e.g. `obj["x"]`.
+                            ExprKind::Member(Member {
+                                object,
+                                property: MemberProp::Computed(ComputedPropName { expr, .. }),
+                                ..
+                            }) => {
+                                if let (Some(obj_t), Some(key_t)) =
+                                    (object.inferred_type, expr.inferred_type)
+                                {
+                                    if let Ok(key) = checker.get_computed_prop_key(key_t) {
+                                        let name = key.to_string();
+                                        checker.check_prop_assignment(ctx, obj_t, &name)?;
+                                    }
+                                }
+                            }
+                            _ => {}
                         }
 
-                        let l_t = checker.infer_expression(left, ctx)?;
-                        let r_t = checker.infer_expression(right, ctx)?;
                         checker.unify(ctx, r_t, l_t)?;
 
                         r_t
@@ -432,6 +637,8 @@ impl Checker {
                             | BinaryOp::Times
                             | BinaryOp::Divide
                             | BinaryOp::Modulo => {
+                                checker.check_not_unknown(left_type, "do arithmetic with")?;
+                                checker.check_not_unknown(right_type, "do arithmetic with")?;
                                 match (
                                     &checker.arena[left_type].kind,
                                     &checker.arena[right_type].kind,
@@ -493,11 +700,38 @@ impl Checker {
                                     }
                                 }
                             }
-                            BinaryOp::And | BinaryOp::Or => {
+                            // `cond && value` evaluates to `false` or `value`,
+                            // this is mostly used for conditional JSX children.
+                            BinaryOp::And => {
+                                checker.unify(ctx, left_type, boolean)?;
+                                match checker.unify(ctx, right_type, boolean) {
+                                    Ok(_) => boolean,
+                                    Err(_) => {
+                                        let false_type =
+                                            checker.new_lit_type(&Literal::Boolean(false));
+                                        checker.new_union_type(&[false_type, right_type])
+                                    }
+                                }
+                            }
+                            BinaryOp::Or => {
                                 checker.unify(ctx, left_type, boolean)?;
                                 checker.unify(ctx, right_type, boolean)?;
                                 boolean
                             }
+                            BinaryOp::In => {
+                                let string = checker.new_primitive(Primitive::String);
+                                let symbol = checker.new_primitive(Primitive::Symbol);
+                                let key = checker.new_union_type(&[string, number, symbol]);
+                                checker.unify(ctx, left_type, key)?;
+                                checker.check_not_unknown(right_type, "use `in` with")?;
+                                checker.check_in_operand(right_type)?;
+                                boolean
+                            }
+                            BinaryOp::InstanceOf => {
+                                checker.check_not_unknown(right_type, "use `instanceof` with")?;
+                                checker.get_instance_type(ctx, right_type)?;
+                                boolean
+                            }
                             BinaryOp::Equals | BinaryOp::NotEquals => {
                                 match (
                                     &checker.arena[left_type].kind,
@@ -548,10 +782,12 @@ impl Checker {
 
                         match op {
                             UnaryOp::Minus => {
+                                checker.check_not_unknown(arg_type, "do arithmetic with")?;
                                 checker.unify(ctx, arg_type, number)?;
                                 number
                             }
                             UnaryOp::Plus => {
+                                checker.check_not_unknown(arg_type, "do arithmetic with")?;
                                 checker.unify(ctx, arg_type, number)?;
                                 number
                             }
@@ -594,7 +830,7 @@ impl Checker {
                     }) => {
                         let tag = checker.infer_expression(tag, ctx)?;
 
-                        let mut args = vec![Expr {
+                        let mut args = vec![ExprOrSpread::Expr(Expr {
                             kind: ExprKind::Tuple(syntax::Tuple {
                                 elements: parts
                                     .iter()
@@ -613,11 +849,19 @@ impl Checker {
                             }),
                             span: Span { start: 0, end: 0 },
                             inferred_type: None,
-                        }];
-                        args.extend(exprs.clone());
+                        })];
+                        args.extend(exprs.iter().cloned().map(ExprOrSpread::Expr));
 
                         let (call_result, call_throws) =
-                            checker.unify_call(ctx, &mut args, None, false, tag)?;
+                            checker.unify_call(ctx, &mut args, node.span, None, false, tag)?;
+
+                        // The args are copies of the template's expressions so
+                        // their inferred types have to be copied back.
+                        for (expr, arg) in exprs.iter_mut().zip(args.into_iter().skip(1)) {
+                            if let ExprOrSpread::Expr(arg) = arg {
+                                *expr = arg;
+                            }
+                        }
 
                         if let Some(call_throws) = call_throws {
                             throws.replace(call_throws);
@@ -630,12 +874,11 @@ impl Checker {
                         let expr_idx = checker.infer_expression(expr, ctx)?;
                         let mut body_types: Vec<Index> = vec![];
 
-                        for arm in arms.iter_mut() {
-                            let (pat_bindings, pat_idx) =
-                                checker.infer_pattern(&mut arm.pattern, ctx)?;
+                        checker.report_redundant_arms(arms);
 
-                            // Checks that the pattern is a sub-type of expr
-                            checker.unify(ctx, pat_idx, expr_idx)?;
+                        for arm in arms.iter_mut() {
+                            let pat_bindings =
+                                checker.infer_match_pattern(&mut arm.pattern, expr_idx, ctx)?;
 
                             let mut new_ctx = ctx.clone();
                             for (name, binding) in pat_bindings {
@@ -644,6 +887,20 @@ impl Checker {
                                 new_ctx.values.insert(name, binding);
                             }
 
+                            if let Some(guard) = &mut arm.guard {
+                                let guard_t = checker.infer_expression(guard, &mut new_ctx)?;
+                                let boolean = checker.new_primitive(Primitive::Boolean);
+                                if checker.unify(&new_ctx, guard_t, boolean).is_err() {
+                                    return Err(TypeError {
+                                        message: format!(
+                                            "Match guards must be booleans, found {}",
+                                            checker.print_type(&guard_t)
+                                        ),
+                                    });
+                                }
+                                checker.refine_bindings_with_guard(guard, &mut new_ctx);
+                            }
+
                             let body_type = match arm.body {
                                 BlockOrExpr::Block(ref mut block) => {
                                     checker.infer_block(block, &mut new_ctx)?
@@ -655,12 +912,6 @@ impl Checker {
                             body_types.push(body_type);
                         }
 
-                        let t0 = checker.prune(body_types[0]);
-                        eprintln!("t0 = {}", checker.print_type(&t0));
-
-                        let t1 = checker.prune(body_types[1]);
-                        eprintln!("t1 = {}", checker.print_type(&t1));
-
                         checker.new_union_type(&body_types)
                     }
                     ExprKind::Class(class) => checker.infer_class(class, ctx)?,
@@ -697,12 +948,19 @@ impl Checker {
                             None => body_t,
                         }
                     }
-                    ExprKind::Yield(_) => todo!(),
+                    ExprKind::Yield(Yield { arg }) => {
+                        checker.infer_expression(arg, ctx)?;
+                        // TODO: infer the type of the value passed to `.next()`
+                        checker.new_keyword(Keyword::Unknown)
+                    }
                     ExprKind::Throw(Throw { arg, throws }) => {
                         throws.replace(checker.infer_expression(arg, ctx)?);
                         checker.new_keyword(Keyword::Never)
                     }
-                    ExprKind::JSXFragment(_) => todo!(),
+                    ExprKind::JSXFragment(JSXFragment { children, .. }) => {
+                        checker.infer_jsx_children(children, ctx)?;
+                        checker.get_jsx_element_type(ctx)?
+                    }
                 };
 
             let t = &mut checker.arena[idx];
@@ -714,6 +972,351 @@ impl Checker {
         })
     }
 
+    /// Infers the type of the target of an assignment.
+    fn infer_lvalue(&mut self, node: &mut Expr, ctx: &mut Context) -> Result<Index, TypeError> {
+        ctx.is_lvalue = true;
+        let result = self.infer_expression(node, ctx);
+        ctx.is_lvalue = false;
+        result
+    }
+
+    /// Narrows immutable bindings that are compared against values with a
+    /// narrower type in a match guard or `if` condition, e.g. `n if n == 0 => ...`
+    /// gives `n` the type `0` inside of the arm's body and `if (a == b) { ... }`
+    /// gives `a` the type `string` inside of the consequent when `a` is a
+    /// `string | number` and `b` is a `string`.
+    fn refine_bindings_with_guard(&mut self, guard: &Expr, ctx: &mut Context) {
+        let (op, left, right) = match &guard.kind {
+            ExprKind::Binary(Binary { op, left, right }) => (op, left, right),
+            _ => return,
+        };
+
+        match op {
+            BinaryOp::And => {
+                self.refine_bindings_with_guard(left, ctx);
+                self.refine_bindings_with_guard(right, ctx);
+            }
+            BinaryOp::Equals => {
+                self.refine_binding_with_equal_expr(left, right, ctx);
+                self.refine_binding_with_equal_expr(right, left, ctx);
+            }
+            BinaryOp::In => self.refine_binding_with_in(left, right, ctx),
+            BinaryOp::InstanceOf => self.refine_binding_with_instanceof(left, right, ctx),
+            _ => (),
+        }
+    }
+
+    // Narrows `obj` to the members of its union type that have the property
+    // `key`, e.g. `"kind" in obj`.
+    fn refine_binding_with_in(&mut self, key: &Expr, obj: &Expr, ctx: &mut Context) {
+        let name = match &obj.kind {
+            ExprKind::Ident(Ident { name, .. }) => name,
+            _ => return,
+        };
+
+        let (binding, key_t) = match (ctx.values.get(name), key.inferred_type) {
+            (Some(binding), Some(t)) if !binding.is_mut => (binding.to_owned(), t),
+            _ => return,
+        };
+
+        let key_t = self.prune(key_t);
+        if !matches!(
+            self.arena[key_t].kind,
+            TypeKind::Literal(Literal::String(_) | Literal::Number(_))
+        ) {
+            return;
+        }
+        let t = self.prune(binding.index);
+        let types = match &self.arena[t].kind {
+            TypeKind::Union(Union { types }) => types.to_owned(),
+            _ => return,
+        };
+
+        let mut members: Vec<Index> = vec![];
+        for member in types {
+            let has_prop = match self.expand_type(ctx, member) {
+                Ok(expanded) => self.get_prop_value(ctx, expanded, key_t, false).is_ok(),
+                // Keep members that we don't know anything about.
+                Err(_) => true,
+            };
+            if has_prop {
+                members.push(member);
+            }
+        }
+
+        ctx.values.insert(
+            name.to_owned(),
+            Binding {
+                index: self.new_union_type(&members),
+                ..binding
+            },
+        );
+    }
+
+    // Narrows `expr` to the instances of the class `class`, e.g. `obj` has the
+    // type `Point` inside of `if (obj instanceof Point) { ... }` when `obj` is
+    // a `Point | Event`.  If none of the members of `expr`'s type are
+    // instances of the class, e.g. when it's `unknown`, it's narrowed to the
+    // class' instance type.
+    fn refine_binding_with_instanceof(&mut self, expr: &Expr, class: &Expr, ctx: &mut Context) {
+        let name = match &expr.kind {
+            ExprKind::Ident(Ident { name, .. }) => name,
+            _ => return,
+        };
+
+        let (binding, class_t) = match (ctx.values.get(name), class.inferred_type) {
+            (Some(binding), Some(t)) if !binding.is_mut => (binding.to_owned(), t),
+            _ => return,
+        };
+
+        let instance_t = match self.get_instance_type(ctx, class_t) {
+            Ok(instance_t) => instance_t,
+            Err(_) => return,
+        };
+        // The instances of every class are `Self` type refs so we have to
+        // compare their expanded types instead.
+        let expanded_instance_t = match self.expand_type(ctx, instance_t) {
+            Ok(t) => t,
+            Err(_) => return,
+        };
+        let class_id = match &self.arena[expanded_instance_t].kind {
+            TypeKind::Object(types::Object {
+                class: Some(class_info),
+                ..
+            }) => Some(class_info.id),
+            _ => None,
+        };
+
+        let t = self.prune(binding.index);
+        let types = match &self.arena[t].kind {
+            TypeKind::Union(Union { types }) => types.to_owned(),
+            _ => vec![t],
+        };
+
+        let mut members: Vec<Index> = vec![];
+        for member in types {
+            let member = self.prune(member);
+            if matches!(
+                self.arena[member].kind,
+                TypeKind::TypeVar(_) | TypeKind::Keyword(Keyword::Unknown)
+            ) {
+                continue;
+            }
+            let expanded = match self.expand_type(ctx, member) {
+                Ok(expanded) => expanded,
+                Err(_) => continue,
+            };
+            // Instances of classes declared in Escalier are matched by the
+            // identity of their class, including any classes they extend.
+            // Other classes, e.g. the ones from lib.es5.d.ts, are matched
+            // structurally.
+            let is_instance = match (&self.arena[expanded].kind, class_id) {
+                (
+                    TypeKind::Object(types::Object {
+                        class: Some(member_info),
+                        ..
+                    }),
+                    Some(class_id),
+                ) => member_info.id == class_id || member_info.ancestors.contains(&class_id),
+                (_, Some(_)) => false,
+                (_, None) => self.unify(ctx, expanded, expanded_instance_t).is_ok(),
+            };
+            if is_instance {
+                members.push(member);
+            }
+        }
+
+        let index = match members.is_empty() {
+            true => instance_t,
+            false => self.new_union_type(&members),
+        };
+        ctx.values
+            .insert(name.to_owned(), Binding { index, ..binding });
+    }
+
+    // Narrows `expr` to the type of `other` if `expr` is an immutable binding
+    // and the type of `other` is a subtype of its type.
+    // TODO: intersect the types when neither is a subtype of the other, e.g.
+    // `string | number` and `string | boolean` should narrow to `string`.
+    fn refine_binding_with_equal_expr(&mut self, expr: &Expr, other: &Expr, ctx: &mut Context) {
+        let name = match &expr.kind {
+            ExprKind::Ident(Ident { name, .. }) => name,
+            _ => return,
+        };
+
+        let (binding, other_t) = match (ctx.values.get(name), other.inferred_type) {
+            (Some(binding), Some(t)) if !binding.is_mut => (binding.to_owned(), t),
+            _ => return,
+        };
+
+        let other_t = self.prune(other_t);
+        let t = self.prune(binding.index);
+        if matches!(self.arena[t].kind, TypeKind::TypeVar(_))
+            || matches!(self.arena[other_t].kind, TypeKind::TypeVar(_))
+        {
+            return;
+        }
+
+        // Comparisons that can never be true don't narrow anything.
+        if self.unify(ctx, other_t, t).is_ok() {
+            ctx.values.insert(
+                name.to_owned(),
+                Binding {
+                    index: other_t,
+                    ..binding
+                },
+            );
+        }
+    }
+
+    /// Narrows immutable bindings that are passed to type guards in an `if`
+    /// condition, e.g. `x` has the type `string` inside of the consequent of
+    /// `if (isString(x)) { ... }` when `isString` returns `x is string`.
+    fn refine_bindings_with_predicate(&mut self, cond: &Expr, ctx: &mut Context) {
+        if let ExprKind::Binary(Binary {
+            op: BinaryOp::And,
+            left,
+            right,
+        }) = &cond.kind
+        {
+            self.refine_bindings_with_predicate(left, ctx);
+            self.refine_bindings_with_predicate(right, ctx);
+            return;
+        }
+
+        if let Some((
+            arg,
+            Predicate {
+                asserts: false,
+                t: Some(t),
+                ..
+            },
+        )) = self.get_predicate_arg(cond)
+        {
+            narrow_binding(ctx, arg, t);
+        }
+    }
+
+    /// Narrows immutable bindings for the rest of the scope after a call to
+    /// an assertion function, e.g. `x` has the type `string` after
+    /// `assertString(x)` when `assertString` returns `asserts x is string`.
+    /// The arg passed to functions returning `asserts cond` is treated like
+    /// the condition of an `if`, e.g. `assert(x == 5)`.
+    fn refine_bindings_with_assertion(&mut self, expr: &Expr, ctx: &mut Context) {
+        let (arg, predicate) = match self.get_predicate_arg(expr) {
+            Some((arg, predicate)) if predicate.asserts => (arg, predicate),
+            _ => return,
+        };
+
+        match predicate.t {
+            Some(t) => narrow_binding(ctx, arg, t),
+            None => {
+                self.refine_bindings_with_guard(arg, ctx);
+                self.refine_bindings_with_predicate(arg, ctx);
+
+                // `assert(x)` means that `x` isn't `null` or `undefined`.
+                let arg_t = match arg.inferred_type {
+                    Some(arg_t) => self.prune(arg_t),
+                    None => return,
+                };
+                if let TypeKind::Union(Union { types }) = &self.arena[arg_t].kind {
+                    let non_nullables = filter_nullables(&self.arena, types);
+                    if non_nullables.len() != types.len() {
+                        let t = self.new_union_type(&non_nullables);
+                        narrow_binding(ctx, arg, t);
+                    }
+                }
+            }
+        }
+    }
+
+    // Returns the arg that the predicate returned by the call `expr` refers
+    // to, e.g. `x` in `isString(x)`, along with the predicate.
+    fn get_predicate_arg<'a>(&mut self, expr: &'a Expr) -> Option<(&'a Expr, Predicate)> {
+        let (callee, args) = match &expr.kind {
+            ExprKind::Call(syntax::Call { callee, args, .. }) => (callee, args),
+            _ => return None,
+        };
+
+        let func_t = self.prune(callee.inferred_type?);
+        // TODO: handle generic type guards and assertions
+        let (params, ret) = match &self.arena[func_t].kind {
+            TypeKind::Function(types::Function {
+                params,
+                ret,
+                type_params: None,
+                ..
+            }) => (params.to_owned(), *ret),
+            _ => return None,
+        };
+        let ret = self.prune(ret);
+        let predicate = match &self.arena[ret].kind {
+            TypeKind::Predicate(predicate) => predicate.to_owned(),
+            _ => return None,
+        };
+
+        // Spreads make it impossible to know which arg is passed to which
+        // param.
+        if args
+            .iter()
+            .any(|arg| matches!(arg, ExprOrSpread::Spread(_)))
+        {
+            return None;
+        }
+        match args.get(find_param(&params, &predicate.param)?)? {
+            ExprOrSpread::Expr(arg) => Some((arg, predicate)),
+            ExprOrSpread::Spread(_) => None,
+        }
+    }
+
+    /// Unifies the type returned by a function's body with its return type.
+    /// Type guards, e.g. `fn (x: unknown) -> x is string`, return a `boolean`
+    /// and assertions, e.g. `fn (x: unknown) -> asserts x is string`, don't
+    /// return anything.
+    pub fn unify_body_with_ret(
+        &mut self,
+        ctx: &Context,
+        body_t: Index,
+        ret_t: Index,
+        params: &[types::FuncParam],
+    ) -> Result<(), TypeError> {
+        self.check_predicate_param(ret_t, params)?;
+
+        let ret_t = self.prune(ret_t);
+        match &self.arena[ret_t].kind {
+            TypeKind::Predicate(Predicate { asserts: false, .. }) => {
+                let boolean = self.new_primitive(Primitive::Boolean);
+                self.unify(ctx, body_t, boolean)
+            }
+            TypeKind::Predicate(Predicate { asserts: true, .. }) => {
+                let void = self.new_keyword(Keyword::Void);
+                self.unify(ctx, body_t, void)
+            }
+            _ => self.unify(ctx, body_t, ret_t),
+        }
+    }
+
+    // The target of a type predicate, e.g. `x` in `x is string`, must be one
+    // of the function's params.
+    fn check_predicate_param(
+        &mut self,
+        ret_t: Index,
+        params: &[types::FuncParam],
+    ) -> Result<(), TypeError> {
+        let ret_t = self.prune(ret_t);
+        if let TypeKind::Predicate(Predicate { param, .. }) = &self.arena[ret_t].kind {
+            if find_param(params, param).is_none() {
+                return Err(TypeError {
+                    message: format!(
+                        "{param} in {} isn't one of the function's params",
+                        self.print_type(&ret_t)
+                    ),
+                });
+            }
+        }
+        Ok(())
+    }
+
     pub fn infer_block(
         &mut self,
         block: &mut Block,
@@ -721,87 +1324,607 @@ impl Checker {
     ) -> Result<Index, TypeError> {
         let mut new_ctx = ctx.clone();
         let mut result_t = self.new_lit_type(&Literal::Undefined);
+        let decls_start = self.declared_bindings.len();
 
+        self.hoist_local_fns(&block.stmts, &mut new_ctx);
         for stmt in &mut block.stmts.iter_mut() {
             result_t = self.infer_statement(stmt, &mut new_ctx)?;
         }
 
+        self.report_unreachable_code(&block.stmts);
+        self.report_unused_bindings(decls_start);
+
         Ok(result_t)
     }
 
-    pub fn infer_type_ann(
-        &mut self,
-        type_ann: &mut TypeAnn,
-        ctx: &mut Context,
-    ) -> Result<Index, TypeError> {
-        let idx = match &mut type_ann.kind {
-            TypeAnnKind::Function(func_type) => {
-                let function = self.infer_function_type(func_type, ctx)?;
-                self.arena.insert(Type::from(TypeKind::Function(function)))
+    // Functions declared in `stmts`, e.g. `let f = fn () => 5`, can be called
+    // by functions declared before them in the same block, which allows local
+    // functions to be mutually recursive.  Other uses of the function, and all
+    // uses of other local bindings, must come after the declaration.
+    pub fn hoist_local_fns(&mut self, stmts: &[Stmt], ctx: &mut Context) {
+        for stmt in stmts {
+            if let StmtKind::Decl(decl) = &stmt.kind {
+                self.hoist_local_fn(decl, ctx);
             }
+        }
+    }
 
-            TypeAnnKind::NumLit(value) => {
-                self.arena
-                    .insert(Type::from(TypeKind::Literal(syntax::Literal::Number(
-                        value.to_owned(),
-                    ))))
-            }
-            TypeAnnKind::StrLit(value) => {
-                self.arena
-                    .insert(Type::from(TypeKind::Literal(syntax::Literal::String(
-                        value.to_owned(),
-                    ))))
+    fn hoist_local_fn(&mut self, decl: &Decl, ctx: &mut Context) {
+        if let DeclKind::VarDecl(VarDecl {
+            pattern:
+                Pattern {
+                    kind: PatternKind::Ident(BindingIdent { name, .. }),
+                    ..
+                },
+            expr: Some(expr),
+            is_declare: false,
+            is_var,
+            ..
+        }) = &decl.kind
+        {
+            if let ExprKind::Function(_) = &expr.kind {
+                let t = self.new_type_var(None);
+                ctx.non_generic.insert(t);
+                let binding = Binding {
+                    index: t,
+                    is_mut: false,
+                    is_var: *is_var,
+                    deprecated: None,
+                };
+                ctx.hoisted_fns.insert(name.to_owned(), binding);
             }
-            TypeAnnKind::BoolLit(value) => {
-                self.arena
-                    .insert(Type::from(TypeKind::Literal(syntax::Literal::Boolean(
-                        value.to_owned(),
-                    ))))
+        }
+    }
+
+    // Functions declared before the ones in `bindings` may have called them
+    // using their hoisted bindings.  The hoisted bindings are non-generic so
+    // that those calls agree with each other, but the functions are generic
+    // from here on.
+    fn unify_hoisted_fns(&mut self, bindings: &Assump, ctx: &mut Context) -> Result<(), TypeError> {
+        for (name, binding) in bindings {
+            if let Some(hoisted) = ctx.hoisted_fns.remove(name) {
+                ctx.non_generic.remove(&hoisted.index);
+                if self.used_bindings.contains(&hoisted.index) {
+                    self.unify(ctx, hoisted.index, binding.index)?;
+                    self.used_bindings.insert(binding.index);
+                }
             }
+        }
 
-            TypeAnnKind::Number => self.new_primitive(Primitive::Number),
-            TypeAnnKind::Boolean => self.new_primitive(Primitive::Boolean),
-            TypeAnnKind::String => self.new_primitive(Primitive::String),
-            TypeAnnKind::Symbol => self.new_primitive(Primitive::Symbol),
+        Ok(())
+    }
 
-            TypeAnnKind::Null => self.new_lit_type(&Literal::Null),
-            TypeAnnKind::Undefined => self.new_lit_type(&Literal::Undefined),
+    // Must be called after `stmts` have been inferred so that calls to
+    // functions returning `never` can be detected.
+    pub fn report_unreachable_code(&mut self, stmts: &[Stmt]) {
+        let is_never = |expr: &Expr| match expr.inferred_type {
+            Some(t) => self.is_never(t),
+            None => false,
+        };
+        if let Some(stmt) = find_unreachable_stmt(stmts, &is_never) {
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::UNREACHABLE_CODE,
+                severity: Severity::Warning,
+                message: "Unreachable code detected".to_string(),
+                reasons: vec![],
+                span: Some(stmt.span),
+            });
+        }
+    }
 
-            TypeAnnKind::Unknown => self.new_keyword(Keyword::Unknown),
-            TypeAnnKind::Never => self.new_keyword(Keyword::Never),
+    // Values of type `unknown` must be narrowed before they can be used, e.g.
+    // with an `is` pattern in a `match`.
+    fn check_not_unknown(&mut self, t: Index, action: &str) -> Result<(), TypeError> {
+        let t = self.prune(t);
+        match &self.arena[t].kind {
+            TypeKind::Keyword(Keyword::Unknown) => Err(TypeError {
+                message: format!(
+                    "Can't {action} a value of type unknown, it must be narrowed first"
+                ),
+            }),
+            _ => Ok(()),
+        }
+    }
 
-            // TODO: How we make sure that create a fresh type variable for this
-            // whenever it's used?  Maybe we can have an actual TypeKind::Wildcard
-            // instead of creating a type variable here.
-            TypeAnnKind::Wildcard => self.new_wildcard_type(),
-            TypeAnnKind::Infer(name) => self.new_infer_type(name),
+    // The right side of `in` must be an object, e.g. `"length" in "hello"` is
+    // an error because `in` throws when used with primitives.
+    fn check_in_operand(&mut self, t: Index) -> Result<(), TypeError> {
+        let t = self.prune(t);
+        let types = match &self.arena[t].kind {
+            TypeKind::Union(Union { types }) => types.to_owned(),
+            _ => vec![t],
+        };
+        for member in types {
+            let member = self.prune(member);
+            if let TypeKind::Primitive(_) | TypeKind::Literal(_) = &self.arena[member].kind {
+                return Err(TypeError {
+                    message: format!(
+                        "The right side of `in` must be an object, found {}",
+                        self.print_type(&t)
+                    ),
+                });
+            }
+        }
+        Ok(())
+    }
 
-            TypeAnnKind::Object(obj) => {
-                let mut props: Vec<types::TObjElem> = Vec::new();
-                let mut obj_ctx = ctx.clone();
-                let self_idx = self.new_type_var(None);
-                obj_ctx.schemes.insert(
-                    "Self".to_string(),
-                    Scheme {
-                        type_params: None,
-                        t: self_idx,
-                        is_type_param: false,
-                    },
-                );
-                for elem in obj.iter_mut() {
-                    match elem {
-                        ObjectProp::Mapped(Mapped {
-                            key,
-                            value,
-                            target,
-                            source,
-                            optional,
-                            check,
-                            extends,
-                        }) => {
-                            let mut type_ctx = ctx.clone();
+    // Returns the type of the instances created by the constructor `t`.  The
+    // right side of `instanceof` must be a class, or some other object with a
+    // constructor signature, e.g. `5 instanceof 5` is an error.
+    fn get_instance_type(&mut self, ctx: &Context, t: Index) -> Result<Index, TypeError> {
+        let expanded_t = self.expand_type(ctx, t)?;
+        if let TypeKind::Object(types::Object { elems, .. }) = &self.arena[expanded_t].kind {
+            let ctor = elems.iter().find_map(|elem| match elem {
+                TObjElem::Constructor(ctor) => Some(ctor.ret),
+                _ => None,
+            });
+            if let Some(instance_t) = ctor {
+                return Ok(instance_t);
+            }
+        }
 
-                            let source = self.infer_type_ann(source, &mut type_ctx)?;
+        Err(TypeError {
+            message: format!(
+                "The right side of `instanceof` must be a class, found {}",
+                self.print_type(&t)
+            ),
+        })
+    }
+
+    /// Returns the types of the params of the function type `t` that a
+    /// function expression is expected to have.  Only the params before a
+    /// rest param are included and there aren't any if `t` isn't a
+    /// non-generic function.
+    /// Returns the type of the property `name` in `t`, the expected type of an
+    /// object literal or the props of a JSX element.
+    pub fn get_expected_prop_type(
+        &mut self,
+        ctx: &Context,
+        t: Index,
+        name: &TPropKey,
+    ) -> Option<Index> {
+        let t = self.expand_type(ctx, t).ok()?;
+        match &self.arena[t].kind {
+            TypeKind::Object(types::Object { elems, .. }) => {
+                elems.iter().find_map(|elem| match elem {
+                    TObjElem::Prop(prop) if &prop.name == name => Some(prop.t),
+                    _ => None,
+                })
+            }
+            _ => None,
+        }
+    }
+
+    pub fn get_expected_param_types(&mut self, ctx: &Context, t: Index) -> Vec<Index> {
+        let t = match self.expand_type(ctx, t) {
+            Ok(t) => t,
+            Err(_) => return vec![],
+        };
+        match &self.arena[t].kind {
+            TypeKind::Function(types::Function {
+                params,
+                type_params,
+                ..
+            }) if type_params.as_ref().map_or(true, |tps| tps.is_empty()) => params
+                .iter()
+                .filter(|param| !param.is_self())
+                .take_while(|param| !matches!(param.pattern, TPat::Rest(_)))
+                .map(|param| param.t)
+                .collect(),
+            _ => vec![],
+        }
+    }
+
+    /// Returns the type of the bindings in an optional param's pattern, e.g.
+    /// `b` is a `string | undefined` inside of `fn (a: number, b?: string)`
+    /// since it's `undefined` when it's omitted.
+    pub fn get_param_binding_type(&mut self, t: Index, optional: bool) -> Index {
+        match optional {
+            true => {
+                let undefined = self.new_lit_type(&Literal::Undefined);
+                self.new_union_type(&[t, undefined])
+            }
+            false => t,
+        }
+    }
+
+    fn is_never(&self, t: Index) -> bool {
+        match &self.arena[t].kind {
+            TypeKind::TypeVar(TypeVar {
+                instance: Some(instance),
+                ..
+            }) => self.is_never(*instance),
+            TypeKind::Keyword(Keyword::Never) => true,
+            _ => false,
+        }
+    }
+
+    fn report_redundant_arms(&mut self, arms: &[MatchArm]) {
+        for arm in find_redundant_arms(arms) {
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::UNREACHABLE_MATCH_ARM,
+                severity: Severity::Warning,
+                message: "This arm is unreachable because earlier arms match all of its values"
+                    .to_string(),
+                reasons: vec![],
+                span: Some(arm.pattern.span),
+            });
+        }
+    }
+
+    fn infer_jsx_element(
+        &mut self,
+        elem: &mut JSXElement,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        // Lowercase names are intrinsic elements like `div` and everything
+        // else refers to a component that has to be in scope, including
+        // members like `Foo.Bar`.
+        let mut component = None;
+        match &elem.opening.name {
+            JSXElementName::Ident(Ident { name, .. }) => {
+                if !name.starts_with(|c: char| c.is_ascii_lowercase()) {
+                    component = Some(self.get_type(name, ctx)?);
+                }
+            }
+            JSXElementName::JSXMemberExpr(member) => {
+                component = Some(self.infer_jsx_member_expr(member, ctx)?);
+            }
+        }
+
+        // Attributes without a value are `true`.  The values get their expected
+        // types from the component's props.
+        let props = component.and_then(|component| self.get_jsx_props_type(component));
+        let mut attrs: Vec<TObjElem> = vec![];
+        for attr in elem.opening.attrs.iter_mut() {
+            let t = match &mut attr.value {
+                Some(JSXAttrValue::Str(value)) => {
+                    self.new_lit_type(&Literal::String(value.to_owned()))
+                }
+                Some(JSXAttrValue::ExprContainer(JSXExprContainer { expr })) => {
+                    if let Some(props) = props {
+                        let name = TPropKey::StringKey(attr.name.to_owned());
+                        ctx.expected_type = self.get_expected_prop_type(ctx, props, &name);
+                    }
+                    self.infer_expression(expr, ctx)?
+                }
+                None => self.new_lit_type(&Literal::Boolean(true)),
+            };
+            attrs.push(TObjElem::Prop(TProp {
+                name: TPropKey::StringKey(attr.name.to_owned()),
+                optional: false,
+                readonly: false,
+                t,
+            }));
+        }
+
+        // The element's children are passed to the component as the
+        // `children` prop unless it's passed as an attribute.
+        let children = self.infer_jsx_children(&mut elem.children, ctx)?;
+        if let Some(children) = children {
+            let has_children_attr = elem
+                .opening
+                .attrs
+                .iter()
+                .any(|attr| attr.name == "children");
+            if !has_children_attr {
+                attrs.push(TObjElem::Prop(TProp {
+                    name: TPropKey::StringKey("children".to_string()),
+                    optional: false,
+                    readonly: false,
+                    t: children,
+                }));
+            }
+        }
+
+        if let Some(component) = component {
+            let attrs = self.new_object_type(&attrs);
+            self.check_jsx_props(ctx, elem.span, component, attrs)?;
+        }
+
+        self.get_jsx_element_type(ctx)
+    }
+
+    fn infer_jsx_member_expr(
+        &mut self,
+        member: &JSXMemberExpr,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let obj = match &member.obj {
+            JSXObject::JSXMemberExpr(obj) => self.infer_jsx_member_expr(obj, ctx)?,
+            JSXObject::Ident(Ident { name, .. }) => self.get_type(name, ctx)?,
+        };
+        let key_idx = self.new_lit_type(&Literal::String(member.prop.name.to_owned()));
+        self.get_ident_member(ctx, obj, key_idx, false)
+    }
+
+    // Checks the attributes of an element against the props of its component.
+    // Props that appear in the component's `defaultProps` are optional, e.g.
+    // `Btn.defaultProps = {variant: "primary"}` means `<Btn/>` doesn't have
+    // to pass `variant`.  Components that aren't functions with a props param
+    // aren't checked.
+    fn check_jsx_props(
+        &mut self,
+        ctx: &mut Context,
+        span: Span,
+        component: Index,
+        attrs: Index,
+    ) -> Result<(), TypeError> {
+        let component = self.prune(component);
+        let props = match self.get_jsx_props_type(component) {
+            Some(props) => props,
+            None => return Ok(()),
+        };
+
+        let mut optional: HashSet<String> = HashSet::new();
+        let key_idx = self.new_lit_type(&Literal::String("defaultProps".to_string()));
+        if let Ok(defaults) = self.get_ident_member(ctx, component, key_idx, false) {
+            let defaults = self.expand_type(ctx, defaults)?;
+            if let TypeKind::Object(types::Object { elems, .. }) = &self.arena[defaults].kind {
+                for elem in elems {
+                    if let TObjElem::Prop(TProp {
+                        name: TPropKey::StringKey(name),
+                        ..
+                    }) = elem
+                    {
+                        optional.insert(name.to_owned());
+                    }
+                }
+            }
+        }
+
+        let props = self.expand_type(ctx, props)?;
+        let props = match self.arena[props].kind.clone() {
+            TypeKind::Object(types::Object { elems, .. }) if !optional.is_empty() => {
+                let elems: Vec<TObjElem> = elems
+                    .into_iter()
+                    .map(|elem| match elem {
+                        TObjElem::Prop(prop) => {
+                            let has_default = match &prop.name {
+                                TPropKey::StringKey(name) => optional.contains(name),
+                                TPropKey::NumberKey(_) | TPropKey::SymbolKey(_) => false,
+                            };
+                            TObjElem::Prop(TProp {
+                                optional: prop.optional || has_default,
+                                ..prop
+                            })
+                        }
+                        elem => elem,
+                    })
+                    .collect();
+                self.new_object_type(&elems)
+            }
+            _ => props,
+        };
+
+        if let Err(error) = self.unify(ctx, attrs, props) {
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::INCORRECT_JSX_PROPS,
+                severity: Severity::Error,
+                message: format!(
+                    "{} is not assignable to the props {}",
+                    self.print_type(&attrs),
+                    self.print_type(&props)
+                ),
+                reasons: vec![error],
+                span: Some(span),
+            });
+        }
+        Ok(())
+    }
+
+    // Returns the type of the props param of a component, the first function
+    // in an intersection is used for components with overloads.
+    fn get_jsx_props_type(&mut self, component: Index) -> Option<Index> {
+        let component = self.prune(component);
+        let func = match &self.arena[component].kind {
+            TypeKind::Function(func) => func,
+            TypeKind::Intersection(Intersection { types }) => {
+                types.iter().find_map(|t| match &self.arena[*t].kind {
+                    TypeKind::Function(func) => Some(func),
+                    _ => None,
+                })?
+            }
+            _ => return None,
+        };
+        func.params
+            .iter()
+            .find(|param| !param.is_self())
+            .map(|param| param.t)
+    }
+
+    // Children inside of braces have to be something that can be rendered,
+    // i.e. elements, strings, numbers, booleans, null, undefined, or arrays
+    // of these.  Spread children have to be arrays.  Returns the type of the
+    // `children` prop, a single child is passed as is and multiple children
+    // are passed as an array.  Text that's only whitespace and line breaks
+    // isn't rendered so it's not a child.
+    fn infer_jsx_children(
+        &mut self,
+        children: &mut [JSXElementChild],
+        ctx: &mut Context,
+    ) -> Result<Option<Index>, TypeError> {
+        let mut child_types = vec![
+            self.get_jsx_element_type(ctx)?,
+            self.new_primitive(Primitive::String),
+            self.new_primitive(Primitive::Number),
+            self.new_primitive(Primitive::Boolean),
+            self.new_lit_type(&Literal::Null),
+            self.new_lit_type(&Literal::Undefined),
+        ];
+        let array_type = self.new_union_type(&child_types);
+        let array_type = self.new_array_type(array_type);
+        child_types.push(array_type);
+        let child_type = self.new_union_type(&child_types);
+
+        let mut types: Vec<Index> = vec![];
+        for child in children.iter_mut() {
+            let t = match child {
+                JSXElementChild::Text(JSXText { value, .. }) => {
+                    if value.trim().is_empty() && value.contains('\n') {
+                        continue;
+                    }
+                    self.new_primitive(Primitive::String)
+                }
+                JSXElementChild::ExprContainer(JSXExprContainer { expr }) => {
+                    let t = self.infer_expression(expr, ctx)?;
+                    self.check_jsx_child(ctx, expr.span, t, child_type);
+                    t
+                }
+                JSXElementChild::SpreadChild(JSXSpreadChild { expr }) => {
+                    let t = self.infer_expression(expr, ctx)?;
+                    self.check_jsx_child(ctx, expr.span, t, array_type);
+                    t
+                }
+                JSXElementChild::Element(elem) => self.infer_jsx_element(elem, ctx)?,
+                JSXElementChild::Fragment(frag) => {
+                    self.infer_jsx_children(&mut frag.children, ctx)?;
+                    self.get_jsx_element_type(ctx)?
+                }
+            };
+            types.push(t);
+        }
+
+        match types.as_slice() {
+            [] => Ok(None),
+            [t] => Ok(Some(*t)),
+            types => {
+                let mut elem_types: Vec<Index> = vec![];
+                for t in types {
+                    if !elem_types.iter().any(|other| self.equals(t, other)) {
+                        elem_types.push(*t);
+                    }
+                }
+                let t = self.new_union_type(&elem_types);
+                Ok(Some(self.new_array_type(t)))
+            }
+        }
+    }
+
+    fn check_jsx_child(&mut self, ctx: &Context, span: Span, t: Index, child_type: Index) {
+        if let Err(error) = self.unify(ctx, t, child_type) {
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::INVALID_JSX_CHILD,
+                severity: Severity::Error,
+                message: format!("{} is not a valid JSX child", self.print_type(&t)),
+                reasons: vec![error],
+                span: Some(span),
+            });
+        }
+    }
+
+    // JSX elements and fragments all have the same type, the `JSXElement`
+    // type has to be declared in order to use them.
+    fn get_jsx_element_type(&mut self, ctx: &Context) -> Result<Index, TypeError> {
+        let scheme = ctx.get_scheme("JSXElement")?;
+        Ok(self.new_type_ref("JSXElement", Some(scheme), &[]))
+    }
+
+    // Regex literals are `RegExp<"pattern", "flags">` so that the named groups
+    // in the pattern can be added to the results of matching it, see
+    // `add_named_groups`.
+    fn get_regex_type(&mut self, regex: &Regex, ctx: &Context) -> Result<Index, TypeError> {
+        let scheme = ctx.get_scheme("RegExp")?;
+        let type_args = match &scheme.type_params {
+            Some(_) => vec![
+                self.new_lit_type(&Literal::String(regex.pattern.to_owned())),
+                self.new_lit_type(&Literal::String(regex.flags.clone().unwrap_or_default())),
+            ],
+            None => vec![],
+        };
+        Ok(self.new_type_ref("RegExp", Some(scheme), &type_args))
+    }
+
+    pub fn infer_type_ann(
+        &mut self,
+        type_ann: &mut TypeAnn,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let idx = match &mut type_ann.kind {
+            TypeAnnKind::Function(func_type) => {
+                let function = self.infer_function_type(func_type, ctx)?;
+                self.arena.insert(Type::from(TypeKind::Function(function)))
+            }
+
+            TypeAnnKind::NumLit(value) => {
+                self.arena
+                    .insert(Type::from(TypeKind::Literal(syntax::Literal::Number(
+                        value.to_owned(),
+                    ))))
+            }
+            TypeAnnKind::StrLit(value) => {
+                self.arena
+                    .insert(Type::from(TypeKind::Literal(syntax::Literal::String(
+                        value.to_owned(),
+                    ))))
+            }
+            TypeAnnKind::BoolLit(value) => {
+                self.arena
+                    .insert(Type::from(TypeKind::Literal(syntax::Literal::Boolean(
+                        value.to_owned(),
+                    ))))
+            }
+
+            TypeAnnKind::Number => self.new_primitive(Primitive::Number),
+            TypeAnnKind::Boolean => self.new_primitive(Primitive::Boolean),
+            TypeAnnKind::String => self.new_primitive(Primitive::String),
+            TypeAnnKind::Symbol => self.new_primitive(Primitive::Symbol),
+            TypeAnnKind::UniqueSymbol => {
+                return Err(TypeError {
+                    message: "unique symbol types are only allowed on immutable variables"
+                        .to_string(),
+                })
+            }
+            TypeAnnKind::Regex(regex) => self.get_regex_type(regex, ctx)?,
+            TypeAnnKind::TemplateLiteral(TemplateLitTypeAnn { parts, types }) => {
+                let parts: Vec<String> = parts.iter().map(|part| part.value.to_owned()).collect();
+                let mut idxs: Vec<Index> = vec![];
+                for type_ann in types.iter_mut() {
+                    idxs.push(self.infer_type_ann(type_ann, ctx)?);
+                }
+                self.new_template_lit_type(&parts, &idxs)
+            }
+
+            TypeAnnKind::Null => self.new_lit_type(&Literal::Null),
+            TypeAnnKind::Undefined => self.new_lit_type(&Literal::Undefined),
+
+            TypeAnnKind::Unknown => self.new_keyword(Keyword::Unknown),
+            TypeAnnKind::Never => self.new_keyword(Keyword::Never),
+            TypeAnnKind::Void => self.new_keyword(Keyword::Void),
+
+            // TODO: How we make sure that create a fresh type variable for this
+            // whenever it's used?  Maybe we can have an actual TypeKind::Wildcard
+            // instead of creating a type variable here.
+            TypeAnnKind::Wildcard => self.new_wildcard_type(),
+            TypeAnnKind::Infer(name) => self.new_infer_type(name),
+
+            TypeAnnKind::Object(obj) => {
+                let mut props: Vec<types::TObjElem> = Vec::new();
+                let mut obj_ctx = ctx.clone();
+                let self_idx = self.new_type_var(None);
+                obj_ctx.schemes.insert(
+                    "Self".to_string(),
+                    Scheme {
+                        type_params: None,
+                        t: self_idx,
+                        is_type_param: false,
+                    },
+                );
+                for elem in obj.iter_mut() {
+                    match elem {
+                        ObjectProp::Mapped(Mapped {
+                            key,
+                            value,
+                            target,
+                            source,
+                            optional,
+                            readonly,
+                            check,
+                            extends,
+                        }) => {
+                            let mut type_ctx = ctx.clone();
+
+                            let source = self.infer_type_ann(source, &mut type_ctx)?;
                             let scheme = Scheme {
                                 type_params: None,
                                 t: source,
@@ -816,6 +1939,10 @@ impl Checker {
                                 syntax::MappedModifier::Add => types::MappedModifier::Add,
                                 syntax::MappedModifier::Remove => types::MappedModifier::Remove,
                             });
+                            let readonly = readonly.as_ref().map(|modifier| match modifier {
+                                syntax::MappedModifier::Add => types::MappedModifier::Add,
+                                syntax::MappedModifier::Remove => types::MappedModifier::Remove,
+                            });
 
                             let check = match check {
                                 Some(check) => Some(self.infer_type_ann(check, &mut type_ctx)?),
@@ -832,6 +1959,7 @@ impl Checker {
                                 target: target.to_owned(),
                                 source,
                                 optional,
+                                readonly,
                                 check,
                                 extends,
                             }));
@@ -849,7 +1977,7 @@ impl Checker {
                                         optional: param.optional,
                                     })
                                 })
-                                .collect::<Result<Vec<_>, _>>()?;
+                                .collect::<Result<Vec<_>, TypeError>>()?;
 
                             let type_params =
                                 self.infer_type_params(&mut method.type_params, &mut obj_ctx)?;
@@ -864,6 +1992,7 @@ impl Checker {
                             props.push(types::TObjElem::Method(types::TMethod {
                                 name: TPropKey::StringKey(method.name.to_owned()),
                                 mutates: method.mutates,
+                                is_abstract: false,
                                 function: types::Function {
                                     params,
                                     ret,
@@ -899,7 +2028,7 @@ impl Checker {
                         }
                         ObjectProp::Prop(prop) => {
                             props.push(types::TObjElem::Prop(types::TProp {
-                                name: TPropKey::StringKey(prop.name.to_owned()),
+                                name: self.get_prop_key(prop, &obj_ctx)?,
                                 readonly: prop.readonly,
                                 optional: prop.optional,
                                 t: self.infer_type_ann(&mut prop.type_ann, &mut obj_ctx)?,
@@ -913,7 +2042,12 @@ impl Checker {
                         )),
                     }
                 }
-                self.new_object_type(&props)
+                let t = self.new_object_type(&props);
+                // `Self` refers to the object type itself, binding it here
+                // means methods that return `Self` still return this type
+                // after they've been detached from the object.
+                self.bind(ctx, self_idx, t)?;
+                t
             }
             TypeAnnKind::TypeRef(name, type_args) if name == "Array" => match type_args {
                 Some(type_args) => {
@@ -926,8 +2060,27 @@ impl Checker {
                     })
                 }
             },
+            // `Match<S, R>` is built in unless it's been shadowed, it's
+            // expanded once its type args are known.
+            TypeAnnKind::TypeRef(name, type_args)
+                if name == "Match" && ctx.get_scheme(name).is_err() =>
+            {
+                let mut type_args_idxs = Vec::new();
+                for type_arg in type_args.iter_mut().flatten() {
+                    type_args_idxs.push(self.infer_type_ann(type_arg, ctx)?);
+                }
+                if type_args_idxs.len() != 2 {
+                    return Err(TypeError {
+                        message: format!(
+                            "Match expects 2 type args, but was passed {}",
+                            type_args_idxs.len()
+                        ),
+                    });
+                }
+                self.new_type_ref(name, None, &type_args_idxs)
+            }
             TypeAnnKind::TypeRef(name, type_args) => {
-                let type_args = match type_args {
+                let mut type_args = match type_args {
                     Some(type_args) => {
                         let mut type_args_idxs = Vec::new();
                         for type_arg in type_args.iter_mut() {
@@ -945,16 +2098,38 @@ impl Checker {
                     None => vec![],
                 };
 
-                if type_params.len() != type_args.len() {
-                    return Err(TypeError {
-                        message: format!(
-                            "{name} expects {} type args, but was passed {}",
-                            type_params.len(),
-                            type_args.len()
-                        ),
+                // The count is checked here, instead of when the type ref is
+                // expanded, so that the error can point to the type ref.
+                if let Err(error) = self.check_type_arg_count(&type_params, type_args.len(), name) {
+                    self.current_report.diagnostics.push(Diagnostic {
+                        code: codes::INCORRECT_TYPE_ARG_COUNT,
+                        severity: Severity::Error,
+                        message: error.message,
+                        reasons: vec![],
+                        span: Some(type_ann.span),
                     });
+
+                    // We recover by dropping any extra type args and using
+                    // the defaults, or `unknown` for type params without one,
+                    // for any missing ones.
+                    type_args.truncate(type_params.len());
+                    let mut mapping: HashMap<String, Index> = type_params
+                        .iter()
+                        .zip(type_args.iter())
+                        .map(|(type_param, type_arg)| (type_param.name.to_owned(), *type_arg))
+                        .collect();
+                    for type_param in &type_params[type_args.len()..] {
+                        let type_arg = match type_param.default {
+                            Some(default) => self.instantiate_type(&default, &mapping),
+                            None => self.new_keyword(Keyword::Unknown),
+                        };
+                        mapping.insert(type_param.name.to_owned(), type_arg);
+                        type_args.push(type_arg);
+                    }
                 }
 
+                let type_args = self.fill_type_args(&type_params, &type_args, name)?;
+
                 // Contraints can reference other type params so we need make
                 // sure that definitions for each type param are in scope where
                 // each type param is defined to be the corresponding type arg.
@@ -971,16 +2146,21 @@ impl Checker {
                 }
 
                 for (param, arg) in type_params.iter().zip(type_args.iter()) {
-                    if let Some(constraint) = param.constraint {
-                        self.unify(&sig_ctx, *arg, constraint)?;
-                    }
+                    self.check_type_arg_constraint(&sig_ctx, param, *arg)?;
                 }
 
                 // NOTE: If the scheme we get was created from a type param
                 // we can't use it as the new type ref's scheme because it
                 // need to be able to lookup the type param's type arg.
                 let scheme = ctx.get_scheme(name)?;
-                if scheme.is_type_param {
+                // References to types that are still being declared, e.g.
+                // recursive references, look up the type's scheme when
+                // they're expanded since its placeholder will be replaced.
+                let is_placeholder = matches!(
+                    &self.arena[scheme.t].kind,
+                    TypeKind::Keyword(Keyword::Unknown)
+                );
+                if scheme.is_type_param || is_placeholder {
                     self.new_type_ref(name, None, &type_args)
                 } else {
                     self.new_type_ref(name, Some(scheme), &type_args)
@@ -1000,12 +2180,14 @@ impl Checker {
                 }
                 self.new_intersection_type(&idxs)
             }
-            TypeAnnKind::Tuple(types) => {
+            TypeAnnKind::Tuple(elems) => {
                 let mut idxs = Vec::new();
-                for type_ann in types.iter_mut() {
-                    idxs.push(self.infer_type_ann(type_ann, ctx)?);
+                let mut labels = Vec::new();
+                for elem in elems.iter_mut() {
+                    idxs.push(self.infer_type_ann(&mut elem.type_ann, ctx)?);
+                    labels.push(elem.label.as_ref().map(|label| label.name.to_owned()));
                 }
-                self.new_tuple_type(&idxs)
+                self.new_labeled_tuple_type(&idxs, &labels)
             }
             TypeAnnKind::Rest(rest) => {
                 let idx = self.infer_type_ann(rest, ctx)?;
@@ -1022,6 +2204,7 @@ impl Checker {
             }
             TypeAnnKind::TypeOf(arg) => {
                 let arg = ctx.values.get(&arg.name).unwrap();
+                self.used_bindings.insert(arg.index);
                 arg.index
             }
             // TODO: Create types for all of these
@@ -1085,6 +2268,17 @@ impl Checker {
 
                 cond_type
             }
+            TypeAnnKind::Predicate(PredicateTypeAnn {
+                asserts,
+                param,
+                type_ann,
+            }) => {
+                let t = type_ann
+                    .as_mut()
+                    .map(|type_ann| self.infer_type_ann(type_ann, ctx))
+                    .transpose()?;
+                self.new_predicate_type(*asserts, &param.name, t)
+            }
             TypeAnnKind::Binary(BinaryTypeAnn { left, op, right }) => {
                 let left = self.infer_type_ann(left, ctx)?;
                 let right = self.infer_type_ann(right, ctx)?;
@@ -1107,6 +2301,8 @@ impl Checker {
                     BinaryOp::GreaterThanOrEqual => todo!(),
                     BinaryOp::Or => todo!(),
                     BinaryOp::And => todo!(),
+                    BinaryOp::In => todo!(),
+                    BinaryOp::InstanceOf => todo!(),
                 };
 
                 self.arena
@@ -1151,9 +2347,11 @@ impl Checker {
                     optional: param.optional,
                 })
             })
-            .collect::<Result<Vec<_>, _>>()?;
+            .collect::<Result<Vec<_>, TypeError>>()?;
+        check_optional_params(&func_params)?;
 
         let ret_idx = self.infer_type_ann(ret.as_mut(), &mut sig_ctx)?;
+        self.check_predicate_param(ret_idx, &func_params)?;
 
         let throws = throws
             .as_mut()
@@ -1175,14 +2373,48 @@ impl Checker {
     ) -> Result<Index, TypeError> {
         self.with_report(|checker| -> Result<Index, TypeError> {
             let t = match &mut statement.kind {
-                StmtKind::Expr(ExprStmt { expr }) => checker.infer_expression(expr, ctx)?,
-                StmtKind::For(ForStmt { left, right, body }) => {
+                StmtKind::Expr(ExprStmt { expr }) => {
+                    let t = checker.infer_expression(expr, ctx)?;
+                    checker.refine_bindings_with_assertion(expr, ctx);
+                    t
+                }
+                StmtKind::For(ForStmt {
+                    left,
+                    right,
+                    body,
+                    is_await,
+                }) => {
                     let right_t = checker.infer_expression(right, ctx)?;
-                    let (bindings, left_t) = checker.infer_pattern(left, ctx)?;
-                    let array_t = checker.new_array_type(left_t);
-                    // The expression we're iterating over must be assignable
-                    // to an array.
-                    checker.unify(ctx, right_t, array_t)?;
+                    let (mut bindings, left_t) = checker.infer_pattern(left, ctx)?;
+                    if *is_await {
+                        if !ctx.is_async {
+                            return Err(TypeError {
+                                message: "Can't use `for await` outside of an async function"
+                                    .to_string(),
+                            });
+                        }
+                        // The expression we're iterating over must be assignable
+                        // to an async generator whose elements are the type of
+                        // the pattern.
+                        let return_t = checker.new_type_var(None);
+                        let next_t = checker.new_type_var(None);
+                        let gen_t = checker.new_type_ref(
+                            "AsyncGenerator",
+                            None,
+                            &[left_t, return_t, next_t],
+                        );
+                        checker.unify(ctx, right_t, gen_t)?;
+                    } else if let Some(elem_t) = checker.get_iterator_elem_type(ctx, right_t)? {
+                        // Iterables that aren't arrays provide their elements
+                        // using their `[Symbol.iterator]` method.
+                        checker.unify(ctx, elem_t, left_t)?;
+                    } else {
+                        let array_t = checker.new_array_type(left_t);
+                        // The expression we're iterating over must be assignable
+                        // to an array.
+                        checker.unify(ctx, right_t, array_t)?;
+                    }
+                    checker.infer_pattern_defaults(left, &mut bindings, ctx)?;
 
                     let mut new_ctx = ctx.clone();
 
@@ -1190,11 +2422,11 @@ impl Checker {
                         new_ctx.values.insert(name, binding);
                     }
 
-                    checker.infer_block(body, &mut new_ctx)?
+                    checker.infer_block(body, &mut new_ctx)?;
+                    checker.new_lit_type(&Literal::Undefined)
                 }
                 StmtKind::Return(ReturnStmt { arg: expr }) => {
                     // TODO: handle multiple return statements
-                    // TODO: warn about unreachable code after a return statement
                     match expr {
                         Some(expr) => checker.infer_expression(expr, ctx)?,
                         None => {
@@ -1203,12 +2435,31 @@ impl Checker {
                         }
                     }
                 }
-                StmtKind::Decl(decl) => match &mut decl.kind {
-                    DeclKind::TypeDecl(decl) => checker.infer_type_decl(decl, ctx)?,
+                StmtKind::Decl(Decl {
+                    kind, annotations, ..
+                }) => match kind {
+                    DeclKind::TypeDecl(decl) => {
+                        checker.infer_type_decl(decl, ctx)?;
+                        checker.new_lit_type(&Literal::Undefined)
+                    }
                     DeclKind::VarDecl(decl) => {
-                        checker.infer_var_decl(decl, ctx)?;
+                        let bindings = checker.infer_var_decl(decl, ctx)?;
+                        checker.unify_hoisted_fns(&bindings, ctx)?;
+                        apply_annotations(ctx, &bindings, annotations);
+                        checker.declare_bindings(&decl.pattern, &bindings);
                         checker.new_lit_type(&Literal::Undefined)
                     }
+                    DeclKind::GlobalDecl(_) => {
+                        return Err(TypeError {
+                            message: "`declare global` is only allowed at the top-level"
+                                .to_string(),
+                        })
+                    }
+                    DeclKind::NamespaceDecl(_) | DeclKind::NamespaceAliasDecl(_) => {
+                        return Err(TypeError {
+                            message: "namespaces are only allowed at the top-level".to_string(),
+                        })
+                    }
                     // DeclKind::ClassDecl(_) => todo!(),
                     // DeclKind::StructDecl(_) => todo!(),
                 },
@@ -1227,31 +2478,56 @@ impl Checker {
     ) -> Result<Assump, TypeError> {
         let VarDecl {
             is_declare,
+            is_var,
             pattern,
             expr: init,
             type_ann,
             ..
         } = decl;
 
-        let (pat_bindings, pat_type) = self.infer_pattern(pattern, ctx)?;
+        let (mut pat_bindings, pat_type) = self.infer_pattern(pattern, ctx)?;
+        for binding in pat_bindings.values_mut() {
+            binding.is_var = *is_var;
+        }
         // let undefined = self.new_lit_type(&Literal::Undefined);
 
         match (is_declare, init, type_ann) {
             (false, Some(init), type_ann) => {
+                // Function expressions and object literals are inferred after
+                // the type annotation so that the params of functions, including
+                // the ones in the object, can get their types from it.
+                let mut type_ann_idx = None;
+                if let (Some(type_ann), ExprKind::Function(_) | ExprKind::Object(_)) =
+                    (type_ann.as_mut(), &init.kind)
+                {
+                    let t = self.infer_var_decl_type_ann(pattern, type_ann, ctx)?;
+                    ctx.expected_type = Some(t);
+                    type_ann_idx = Some(t);
+                }
+
                 let init_idx = self.infer_expression(init, ctx)?;
                 let tpat = pattern_to_tpat(pattern, false);
                 let mutability = check_mutability(ctx, &tpat, init)?;
 
                 let idx = match type_ann {
                     Some(type_ann) => {
-                        let type_ann_idx = self.infer_type_ann(type_ann, ctx)?;
+                        let type_ann_idx = match type_ann_idx {
+                            Some(type_ann_idx) => type_ann_idx,
+                            None => self.infer_var_decl_type_ann(pattern, type_ann, ctx)?,
+                        };
 
                         // The initializer must conform to the type annotation's
-                        // inferred type.
+                        // inferred type.  Unique symbols are initialized with
+                        // `Symbol()` which returns a `symbol`.
+                        let expected_idx = match &self.arena[type_ann_idx].kind {
+                            TypeKind::UniqueSymbol(_) => self.new_primitive(Primitive::Symbol),
+                            _ => type_ann_idx,
+                        };
                         match mutability {
-                            true => self.unify_mut(ctx, init_idx, type_ann_idx)?,
-                            false => self.unify(ctx, init_idx, type_ann_idx)?,
+                            true => self.unify_mut(ctx, init_idx, expected_idx)?,
+                            false => self.unify(ctx, init_idx, expected_idx)?,
                         };
+                        self.check_excess_properties(ctx, init, type_ann_idx)?;
 
                         // Results in bindings introduced by the LHS pattern
                         // having their types inferred.
@@ -1278,6 +2554,8 @@ impl Checker {
                     }
                 };
 
+                self.infer_pattern_defaults(pattern, &mut pat_bindings, ctx)?;
+
                 for (name, binding) in &pat_bindings {
                     ctx.values.insert(name.clone(), binding.clone());
                 }
@@ -1291,7 +2569,29 @@ impl Checker {
                     .to_string(),
             }),
             (true, None, Some(type_ann)) => {
-                let idx = self.infer_type_ann(type_ann, ctx)?;
+                let idx = self.infer_var_decl_type_ann(pattern, type_ann, ctx)?;
+
+                self.unify(ctx, idx, pat_type)?;
+
+                for (name, binding) in &pat_bindings {
+                    ctx.values.insert(name.clone(), binding.clone());
+                }
+
+                Ok(pat_bindings)
+            }
+            // `declare class Foo { ... }` is parsed as a declared variable
+            // that's initialized with the class.  Its type only comes from
+            // the signatures of its members.
+            (true, Some(init), None)
+                if matches!(
+                    init.kind,
+                    ExprKind::Class(Class {
+                        is_declare: true,
+                        ..
+                    })
+                ) =>
+            {
+                let idx = self.infer_expression(init, ctx)?;
 
                 self.unify(ctx, idx, pat_type)?;
 
@@ -1299,6 +2599,8 @@ impl Checker {
                     ctx.values.insert(name.clone(), binding.clone());
                 }
 
+                pattern.inferred_type = Some(idx);
+
                 Ok(pat_bindings)
             }
             (true, Some(_), _) => Err(TypeError {
@@ -1312,22 +2614,131 @@ impl Checker {
         }
     }
 
-    pub fn infer_type_decl(
+    // `unique symbol` types can only be used on variables that can't be
+    // reassigned, the symbol is named after the variable.
+    fn infer_var_decl_type_ann(
         &mut self,
-        decl: &mut TypeDecl,
+        pattern: &Pattern,
+        type_ann: &mut TypeAnn,
         ctx: &mut Context,
     ) -> Result<Index, TypeError> {
-        let TypeDecl {
-            name,
-            type_ann,
-            type_params,
-        } = decl;
-
-        // NOTE: We clone `ctx` so that type params don't escape the signature
-        let mut sig_ctx = ctx.clone();
+        if let (
+            TypeAnnKind::UniqueSymbol,
+            PatternKind::Ident(BindingIdent {
+                name,
+                mutable: false,
+                ..
+            }),
+        ) = (&type_ann.kind, &pattern.kind)
+        {
+            let idx = self.new_unique_symbol_type(name);
+            type_ann.inferred_type = Some(idx);
+            return Ok(idx);
+        }
+        self.infer_type_ann(type_ann, ctx)
+    }
 
-        let type_params = self.infer_type_params(type_params, &mut sig_ctx)?;
-        let t = self.infer_type_ann(type_ann, &mut sig_ctx)?;
+    // Well-known symbols, e.g. `Symbol.iterator`, are unique symbols.  They're
+    // handled specially so that they can be used as keys without `Symbol`
+    // being in scope.
+    fn infer_computed_key(
+        &mut self,
+        expr: &mut Expr,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        match get_well_known_symbol(expr) {
+            Some(name) => {
+                let symbol = self.well_known_symbol(name);
+                Ok(self.from_type_kind(TypeKind::UniqueSymbol(symbol)))
+            }
+            None => self.infer_expression(expr, ctx),
+        }
+    }
+
+    // Returns the key for a property whose name was computed from an
+    // expression of type `key_t`.
+    fn get_computed_prop_key(&mut self, key_t: Index) -> Result<TPropKey, TypeError> {
+        let key_t = self.prune(key_t);
+        match &self.arena[key_t].kind {
+            TypeKind::Literal(Literal::String(name)) => Ok(TPropKey::StringKey(name.to_owned())),
+            TypeKind::Literal(Literal::Number(name)) => Ok(TPropKey::NumberKey(name.to_owned())),
+            TypeKind::UniqueSymbol(symbol) => Ok(TPropKey::SymbolKey(symbol.to_owned())),
+            _ => Err(TypeError {
+                message: format!(
+                    "{} can't be used as a property key",
+                    self.print_type(&key_t)
+                ),
+            }),
+        }
+    }
+
+    // Properties in object types that are keyed by a variable, e.g. `[sym]`,
+    // are keyed by the unique symbol that the variable refers to.
+    fn get_prop_key(
+        &mut self,
+        prop: &syntax::type_ann::Prop,
+        ctx: &Context,
+    ) -> Result<TPropKey, TypeError> {
+        let ident = match (prop.computed, prop.name.strip_prefix("Symbol.")) {
+            (false, _) => return Ok(TPropKey::StringKey(prop.name.to_owned())),
+            (true, Some(name)) => return Ok(TPropKey::SymbolKey(self.well_known_symbol(name))),
+            (true, None) => &prop.name,
+        };
+        let binding = ctx.get_binding(ident)?;
+        let t = self.prune(binding.index);
+        match &self.arena[t].kind {
+            TypeKind::UniqueSymbol(_) => self.get_computed_prop_key(t),
+            _ => Err(TypeError {
+                message: format!("{ident} must be a unique symbol to be used as a property key"),
+            }),
+        }
+    }
+
+    pub fn infer_type_decl(
+        &mut self,
+        decl: &mut TypeDecl,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let TypeDecl {
+            name,
+            type_ann,
+            type_params,
+            is_interface,
+        } = decl;
+
+        // Type refs that have already been expanded may refer to this type.
+        self.clear_expanded_type_refs();
+
+        // NOTE: We clone `ctx` so that type params don't escape the signature
+        let mut sig_ctx = ctx.clone();
+
+        let type_params = self.infer_type_params(type_params, &mut sig_ctx)?;
+        // Recursive references to the type need its type params to check
+        // their type args.
+        if let Some(scheme) = sig_ctx.schemes.get_mut(name) {
+            if let TypeKind::Keyword(Keyword::Unknown) = &self.arena[scheme.t].kind {
+                scheme.type_params = type_params.clone();
+            }
+        }
+        let t = self.infer_type_ann(type_ann, &mut sig_ctx)?;
+
+        if !*is_interface {
+            ctx.interfaces.remove(name);
+        } else if ctx.interfaces.contains(name) {
+            if let Some(scheme) = ctx.schemes.get(name).cloned() {
+                let t = self.merge_interfaces(ctx, name, &scheme, t, &type_params)?;
+                let scheme = Scheme {
+                    t,
+                    type_params,
+                    is_type_param: false,
+                };
+                self.add_type_alias(name, &scheme);
+                ctx.schemes.insert(name.to_owned(), scheme);
+                return Ok(t);
+            }
+        } else {
+            ctx.interfaces.insert(name.to_owned());
+        }
 
         // TODO: generalize type `t` into a scheme
         let scheme = Scheme {
@@ -1336,16 +2747,109 @@ impl Checker {
             is_type_param: false,
         };
 
+        self.add_type_alias(name, &scheme);
         ctx.schemes.insert(name.to_owned(), scheme);
 
         Ok(t)
     }
 
+    fn merge_interfaces(
+        &mut self,
+        ctx: &Context,
+        name: &str,
+        scheme: &Scheme,
+        t: Index,
+        type_params: &Option<Vec<types::TypeParam>>,
+    ) -> Result<Index, TypeError> {
+        // The placeholder for the first declaration hasn't been replaced yet.
+        if let TypeKind::Keyword(Keyword::Unknown) = &self.arena[scheme.t].kind {
+            return Ok(t);
+        }
+
+        let names = |type_params: &Option<Vec<types::TypeParam>>| match type_params {
+            Some(type_params) => type_params.iter().map(|tp| tp.name.to_owned()).collect(),
+            None => vec![],
+        };
+        if names(&scheme.type_params) != names(type_params) {
+            return Err(TypeError {
+                message: format!("all declarations of {name} must have identical type params"),
+            });
+        }
+
+        let (prev_elems, elems) = match (&self.arena[scheme.t].kind, &self.arena[t].kind) {
+            (TypeKind::Object(prev), TypeKind::Object(obj)) => {
+                (prev.elems.clone(), obj.elems.clone())
+            }
+            _ => {
+                return Err(TypeError {
+                    message: format!("{name} must be an object type to be merged"),
+                })
+            }
+        };
+
+        // Properties that appear in multiple declarations must have the same
+        // type.  Methods with the same name become overloads.
+        for elem in &elems {
+            if let TObjElem::Prop(prop) = elem {
+                for prev_elem in &prev_elems {
+                    if let TObjElem::Prop(prev_prop) = prev_elem {
+                        if prev_prop.name == prop.name
+                            && (self.unify(ctx, prop.t, prev_prop.t).is_err()
+                                || self.unify(ctx, prev_prop.t, prop.t).is_err())
+                        {
+                            return Err(TypeError {
+                                message: format!(
+                                    "subsequent declarations of {name}.{} must have the same type",
+                                    prop.name
+                                ),
+                            });
+                        }
+                    }
+                }
+            }
+        }
+
+        let prev_prop_names = prev_elems
+            .iter()
+            .filter_map(|elem| match elem {
+                TObjElem::Prop(prop) => Some(prop.name.to_owned()),
+                _ => None,
+            })
+            .collect::<Vec<_>>();
+        let elems = prev_elems
+            .into_iter()
+            .chain(elems.into_iter().filter(|elem| match elem {
+                TObjElem::Prop(prop) => !prev_prop_names.contains(&prop.name),
+                _ => true,
+            }))
+            .collect::<Vec<_>>();
+
+        Ok(self.new_object_type(&elems))
+    }
+
     // TODO: write tests for this
     pub fn infer_module(&mut self, node: &mut Module, ctx: &mut Context) -> Result<(), TypeError> {
+        self.clear_expanded_type_refs();
+        self.expansion_fuel_used = 0;
+        let result = self.infer_module_items(node, ctx);
+        self.clear_expanded_type_refs();
+        self.current_report.sort();
+        result
+    }
+
+    fn infer_module_items(
+        &mut self,
+        node: &mut Module,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
         // Prebindings are used to handle recursive and mutually recursive
         // function declarations.
         let mut prebindings: HashMap<String, Binding> = HashMap::new();
+        // Everything that's in scope before the top-level decls, e.g. the
+        // bindings from lib.es5.d.ts, is global.
+        let globals = ctx.values.clone();
+        ctx.globals.extend(globals);
+        let decls_start = self.declared_bindings.len();
 
         for item in &mut node.items {
             match &mut item.kind {
@@ -1353,24 +2857,14 @@ impl Checker {
                     // TODO: handle imports
                 }
                 ModuleItemKind::Export(_) => (),
+                ModuleItemKind::ReExport(ReExport { source, .. }) => {
+                    // TODO: handle re-exports once imports are handled
+                    return Err(TypeError {
+                        message: format!("re-exports from \"{source}\" aren't supported yet"),
+                    });
+                }
                 ModuleItemKind::Decl(decl) => match &mut decl.kind {
-                    DeclKind::TypeDecl(TypeDecl { name, .. }) => {
-                        let placeholder_scheme = Scheme {
-                            t: self.new_keyword(Keyword::Unknown),
-                            type_params: None,
-                            is_type_param: false,
-                        };
-                        let name = name.to_owned();
-                        if ctx
-                            .schemes
-                            .insert(name.clone(), placeholder_scheme)
-                            .is_some()
-                        {
-                            return Err(TypeError {
-                                message: format!("{name} cannot be redeclared at the top-level"),
-                            });
-                        }
-                    }
+                    DeclKind::TypeDecl(decl) => self.prebind_type_decl(decl, ctx)?,
                     DeclKind::VarDecl(VarDecl { pattern, .. }) => {
                         let (bindings, _) = self.infer_pattern(pattern, ctx)?;
 
@@ -1386,23 +2880,65 @@ impl Checker {
                             }
                         }
                     }
+                    DeclKind::GlobalDecl(decl) => {
+                        self.prebind_global_decl(decl, ctx, &mut prebindings)?
+                    }
+                    DeclKind::NamespaceDecl(decl) => {
+                        self.prebind_namespace_decl(decl, ctx, &mut prebindings)?
+                    }
+                    DeclKind::NamespaceAliasDecl(decl) => {
+                        self.prebind_namespace(&decl.name.name, ctx, &mut prebindings)?
+                    }
                 },
             }
         }
 
+        let var_decls = node
+            .items
+            .iter()
+            .filter_map(|item| match &item.kind {
+                ModuleItemKind::Decl(Decl {
+                    kind: DeclKind::VarDecl(decl),
+                    ..
+                }) => Some(decl),
+                _ => None,
+            })
+            .collect::<Vec<_>>();
+        let cyclic_names = self.report_init_cycles(&var_decls);
+
         let mut bindings = BTreeMap::<String, Binding>::new();
 
         for item in &mut node.items.iter_mut() {
             // TODO: handle imports and exports
-            if let ModuleItemKind::Decl(decl) = &mut item.kind {
-                match &mut decl.kind {
+            if let ModuleItemKind::Decl(Decl {
+                kind, annotations, ..
+            }) = &mut item.kind
+            {
+                match kind {
                     DeclKind::TypeDecl(decl) => {
                         // NOTE: This updates ctx.schemes.
                         self.infer_type_decl(decl, ctx)?;
                     }
+                    // Decls in an initialization cycle have already been
+                    // reported, their bindings are left as they were.
+                    DeclKind::VarDecl(decl) if is_in_init_cycle(decl, &cyclic_names) => {}
                     DeclKind::VarDecl(decl) => {
                         // TODO: figure out how to avoid parsing patterns twice
-                        bindings.append(&mut self.infer_var_decl(decl, ctx)?);
+                        let mut decl_bindings = self.infer_var_decl(decl, ctx)?;
+                        apply_annotations(ctx, &decl_bindings, annotations);
+                        // Exported decls are skipped above so they're never
+                        // reported as unused.
+                        self.declare_bindings(&decl.pattern, &decl_bindings);
+                        bindings.append(&mut decl_bindings);
+                    }
+                    DeclKind::GlobalDecl(decl) => {
+                        self.infer_global_decl(decl, &prebindings, ctx)?;
+                    }
+                    DeclKind::NamespaceDecl(decl) => {
+                        self.infer_namespace_decl(decl, &prebindings, ctx)?;
+                    }
+                    DeclKind::NamespaceAliasDecl(decl) => {
+                        self.infer_namespace_alias_decl(decl, &prebindings, ctx)?;
                     }
                 }
             };
@@ -1437,6 +2973,17 @@ impl Checker {
             }
         }
 
+        // Uses of a decl that appear before it in the module are uses of its
+        // prebinding.
+        for (name, binding) in &bindings {
+            if self.used_bindings.contains(&prebindings[name].index) {
+                self.used_bindings.insert(binding.index);
+            }
+        }
+
+        // TODO: report unused imports once imports are handled
+        self.report_unused_bindings(decls_start);
+
         Ok(())
     }
 
@@ -1445,9 +2992,26 @@ impl Checker {
     // should.  `infer_script` can still allow mutual recursion that occurs within
     // a single statment (variable declaration).
     pub fn infer_script(&mut self, node: &mut Script, ctx: &mut Context) -> Result<(), TypeError> {
+        self.clear_expanded_type_refs();
+        self.expansion_fuel_used = 0;
+        let result = self.infer_script_stmts(node, ctx);
+        self.clear_expanded_type_refs();
+        self.current_report.sort();
+        result
+    }
+
+    fn infer_script_stmts(
+        &mut self,
+        node: &mut Script,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
         // Prebindings are used to handle recursive and mutually recursive
         // function declarations.
         let mut prebindings: HashMap<String, Binding> = HashMap::new();
+        // Everything that's in scope before the top-level decls, e.g. the
+        // bindings from lib.es5.d.ts, is global.
+        let globals = ctx.values.clone();
+        ctx.globals.extend(globals);
 
         for stmt in &mut node.stmts {
             match &mut stmt.kind {
@@ -1457,23 +3021,7 @@ impl Checker {
                 StmtKind::For(_) => (),
                 StmtKind::Return(_) => (),
                 StmtKind::Decl(decl) => match &mut decl.kind {
-                    DeclKind::TypeDecl(TypeDecl { name, .. }) => {
-                        let placeholder_scheme = Scheme {
-                            t: self.new_keyword(Keyword::Unknown),
-                            type_params: None,
-                            is_type_param: false,
-                        };
-                        let name = name.to_owned();
-                        if ctx
-                            .schemes
-                            .insert(name.clone(), placeholder_scheme)
-                            .is_some()
-                        {
-                            return Err(TypeError {
-                                message: format!("{name} cannot be redeclared at the top-level"),
-                            });
-                        }
-                    }
+                    DeclKind::TypeDecl(decl) => self.prebind_type_decl(decl, ctx)?,
                     DeclKind::VarDecl(VarDecl { pattern, .. }) => {
                         let (bindings, _) = self.infer_pattern(pattern, ctx)?;
 
@@ -1489,18 +3037,48 @@ impl Checker {
                             }
                         }
                     }
+                    DeclKind::GlobalDecl(decl) => {
+                        self.prebind_global_decl(decl, ctx, &mut prebindings)?
+                    }
+                    DeclKind::NamespaceDecl(decl) => {
+                        self.prebind_namespace_decl(decl, ctx, &mut prebindings)?
+                    }
+                    DeclKind::NamespaceAliasDecl(decl) => {
+                        self.prebind_namespace(&decl.name.name, ctx, &mut prebindings)?
+                    }
                 },
             }
         }
 
+        let var_decls = node
+            .stmts
+            .iter()
+            .filter_map(|stmt| match &stmt.kind {
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::VarDecl(decl),
+                    ..
+                }) => Some(decl),
+                _ => None,
+            })
+            .collect::<Vec<_>>();
+        let cyclic_names = self.report_init_cycles(&var_decls);
+
         for stmt in &mut node.stmts.iter_mut() {
             match &mut stmt.kind {
+                // Decls in an initialization cycle have already been
+                // reported, their bindings are left as they were.
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::VarDecl(decl),
+                    ..
+                }) if is_in_init_cycle(decl, &cyclic_names) => {}
                 StmtKind::Decl(Decl {
                     kind: DeclKind::VarDecl(decl),
+                    annotations,
                     ..
                 }) => {
                     // TODO: figure out how to avoid parsing patterns twice
                     let bindings = self.infer_var_decl(decl, ctx)?;
+                    apply_annotations(ctx, &bindings, annotations);
 
                     // Unify each binding with its prebinding
                     for (name, binding) in &bindings {
@@ -1528,15 +3106,459 @@ impl Checker {
                         }
                     }
                 }
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::GlobalDecl(decl),
+                    ..
+                }) => {
+                    self.infer_global_decl(decl, &prebindings, ctx)?;
+                }
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::NamespaceDecl(decl),
+                    ..
+                }) => {
+                    self.infer_namespace_decl(decl, &prebindings, ctx)?;
+                }
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::NamespaceAliasDecl(decl),
+                    ..
+                }) => {
+                    self.infer_namespace_alias_decl(decl, &prebindings, ctx)?;
+                }
                 _ => {
                     self.infer_statement(stmt, ctx)?;
                 }
             };
         }
 
+        // Top-level decls in scripts aren't reported as unused since codegen
+        // exports all of them.
+        Ok(())
+    }
+
+    // Returns the type of `globalThis.name`.
+    fn get_global_type(&mut self, name: &str, ctx: &Context) -> Result<Index, TypeError> {
+        if !ctx.globals.contains_key(name) {
+            return Err(TypeError {
+                message: format!("Couldn't find property {name} on globalThis"),
+            });
+        }
+        let global_ctx = Context {
+            values: ctx.globals.clone(),
+            ..ctx.clone()
+        };
+        self.get_type(name, &global_ctx)
+    }
+
+    fn prebind_type_decl(&mut self, decl: &TypeDecl, ctx: &mut Context) -> Result<(), TypeError> {
+        let TypeDecl {
+            name, is_interface, ..
+        } = decl;
+
+        // Interfaces can be declared more than once.
+        if *is_interface && ctx.interfaces.contains(name) {
+            return Ok(());
+        }
+        if *is_interface && !ctx.schemes.contains_key(name) {
+            ctx.interfaces.insert(name.to_owned());
+        }
+        let placeholder_scheme = Scheme {
+            t: self.new_keyword(Keyword::Unknown),
+            type_params: None,
+            is_type_param: false,
+        };
+        if ctx
+            .schemes
+            .insert(name.to_owned(), placeholder_scheme)
+            .is_some()
+        {
+            return Err(TypeError {
+                message: format!("{name} cannot be redeclared at the top-level"),
+            });
+        }
+
+        Ok(())
+    }
+
+    // The decls in `declare global { ... }` are prebound along with the other
+    // top-level decls.  Their variables can't shadow any of the existing
+    // globals, e.g. the ones from lib.es5.d.ts, but interfaces are merged
+    // which is how globals like `Window` are extended.
+    fn prebind_global_decl(
+        &mut self,
+        decl: &mut GlobalDecl,
+        ctx: &mut Context,
+        prebindings: &mut HashMap<String, Binding>,
+    ) -> Result<(), TypeError> {
+        for decl in &mut decl.decls {
+            match &mut decl.kind {
+                DeclKind::TypeDecl(decl) => self.prebind_type_decl(decl, ctx)?,
+                DeclKind::VarDecl(VarDecl { pattern, .. }) => {
+                    let (bindings, _) = self.infer_pattern(pattern, ctx)?;
+
+                    for (name, binding) in bindings {
+                        if ctx.values.contains_key(&name) {
+                            return Err(TypeError {
+                                message: format!("{name} is already declared in the global scope"),
+                            });
+                        }
+                        prebindings.insert(name.to_owned(), binding.clone());
+                        ctx.non_generic.insert(binding.index);
+                        ctx.values.insert(name.to_owned(), binding.clone());
+                        ctx.globals.insert(name, binding);
+                    }
+                }
+                DeclKind::NamespaceDecl(decl) if decl.is_declare => {
+                    let name = &decl.name.name;
+                    if ctx.values.contains_key(name) {
+                        return Err(TypeError {
+                            message: format!("{name} is already declared in the global scope"),
+                        });
+                    }
+                    self.prebind_namespace_decl(decl, ctx, prebindings)?;
+                    ctx.globals
+                        .insert(name.to_owned(), prebindings[name].clone());
+                }
+                DeclKind::GlobalDecl(_) => {
+                    return Err(TypeError {
+                        message: "`declare global` can't be nested".to_string(),
+                    })
+                }
+                DeclKind::NamespaceDecl(_) | DeclKind::NamespaceAliasDecl(_) => {
+                    return Err(TypeError {
+                        message: "only declared namespaces are allowed in `declare global`"
+                            .to_string(),
+                    })
+                }
+            }
+        }
+
+        Ok(())
+    }
+
+    fn infer_global_decl(
+        &mut self,
+        decl: &mut GlobalDecl,
+        prebindings: &HashMap<String, Binding>,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
+        for Decl {
+            kind, annotations, ..
+        } in &mut decl.decls
+        {
+            match kind {
+                DeclKind::TypeDecl(decl) => {
+                    self.infer_type_decl(decl, ctx)?;
+                }
+                DeclKind::VarDecl(decl) => {
+                    let bindings = self.infer_var_decl(decl, ctx)?;
+                    apply_annotations(ctx, &bindings, annotations);
+
+                    for (name, binding) in &bindings {
+                        self.unify(ctx, prebindings[name].index, binding.index)?;
+                        let pruned_index = self.prune(binding.index);
+                        self.bind(ctx, binding.index, pruned_index)?;
+                        if let Some(binding) = ctx.values.get(name).cloned() {
+                            ctx.globals.insert(name.to_owned(), binding);
+                        }
+                    }
+                }
+                DeclKind::NamespaceDecl(decl) => {
+                    self.infer_namespace_decl(decl, prebindings, ctx)?;
+                    if let Some(binding) = ctx.values.get(&decl.name.name).cloned() {
+                        ctx.globals.insert(decl.name.name.to_owned(), binding);
+                    }
+                }
+                DeclKind::GlobalDecl(_) | DeclKind::NamespaceAliasDecl(_) => unreachable!(),
+            }
+        }
+
+        Ok(())
+    }
+
+    // Types declared in a namespace are shared with the enclosing scope using
+    // their qualified names, e.g. `Foo.T`, they're prebound along with the
+    // other top-level types so that they can be used before the namespace.
+    fn prebind_namespace_decl(
+        &mut self,
+        decl: &NamespaceDecl,
+        ctx: &mut Context,
+        prebindings: &mut HashMap<String, Binding>,
+    ) -> Result<(), TypeError> {
+        for name in get_namespace_type_names(decl) {
+            let placeholder_scheme = Scheme {
+                t: self.new_keyword(Keyword::Unknown),
+                type_params: None,
+                is_type_param: false,
+            };
+            ctx.schemes
+                .insert(format!("{}.{name}", decl.name.name), placeholder_scheme);
+        }
+
+        self.prebind_namespace(&decl.name.name, ctx, prebindings)
+    }
+
+    fn prebind_namespace(
+        &mut self,
+        name: &str,
+        ctx: &mut Context,
+        prebindings: &mut HashMap<String, Binding>,
+    ) -> Result<(), TypeError> {
+        let binding = Binding {
+            index: self.new_type_var(None),
+            is_mut: false,
+            is_var: false,
+            deprecated: None,
+        };
+
+        prebindings.insert(name.to_owned(), binding.clone());
+        ctx.non_generic.insert(binding.index);
+        if ctx.values.insert(name.to_owned(), binding).is_some() {
+            return Err(TypeError {
+                message: format!("{name} cannot be redeclared at the top-level"),
+            });
+        }
+
         Ok(())
     }
 
+    fn infer_namespace_decl(
+        &mut self,
+        decl: &mut NamespaceDecl,
+        prebindings: &HashMap<String, Binding>,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
+        let t = self.infer_namespace_type(decl, ctx)?;
+        let prebinding = &prebindings[&decl.name.name];
+        self.unify(ctx, prebinding.index, t)?;
+        let pruned_index = self.prune(prebinding.index);
+        self.bind(ctx, prebinding.index, pruned_index)?;
+
+        Ok(())
+    }
+
+    fn infer_namespace_alias_decl(
+        &mut self,
+        decl: &mut NamespaceAliasDecl,
+        prebindings: &HashMap<String, Binding>,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
+        let t = self.infer_namespace_alias_type(decl, ctx)?;
+        let prebinding = &prebindings[&decl.name.name];
+        self.unify(ctx, prebinding.index, t)?;
+        let pruned_index = self.prune(prebinding.index);
+        self.bind(ctx, prebinding.index, pruned_index)?;
+
+        Ok(())
+    }
+
+    // An alias has the same type as the namespace it refers to.  The types in
+    // the namespace can also be referred to using the alias, e.g. `Short.T`.
+    fn infer_namespace_alias_type(
+        &mut self,
+        decl: &mut NamespaceAliasDecl,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let t = self.infer_expression(&mut decl.target, ctx)?;
+        let t = match self.get_namespace(&decl.target, ctx) {
+            Some(t) => t,
+            None => {
+                return Err(TypeError {
+                    message: format!(
+                        "{} can't be aliased since it isn't a namespace",
+                        self.print_type(&t)
+                    ),
+                })
+            }
+        };
+
+        if let Some(target) = get_qualified_name(&decl.target) {
+            let prefix = format!("{target}.");
+            let schemes = ctx
+                .schemes
+                .iter()
+                .filter_map(|(name, scheme)| {
+                    let name = name.strip_prefix(&prefix)?;
+                    Some((format!("{}.{name}", decl.name.name), scheme.to_owned()))
+                })
+                .collect::<Vec<_>>();
+            ctx.schemes.extend(schemes);
+        }
+
+        Ok(t)
+    }
+
+    // Looks up the namespace `expr` refers to, e.g. `Very.Long.Name`.  Member
+    // types aren't used since they may be copies of the namespace's type.
+    fn get_namespace(&mut self, expr: &Expr, ctx: &Context) -> Option<Index> {
+        let t = match &expr.kind {
+            ExprKind::Ident(Ident { name, .. }) => ctx.values.get(name)?.index,
+            ExprKind::Member(Member {
+                object,
+                property: MemberProp::Ident(Ident { name, .. }),
+                ..
+            }) => {
+                let obj = self.get_namespace(object, ctx)?;
+                match &self.arena[obj].kind {
+                    TypeKind::Object(types::Object { elems, .. }) => {
+                        elems.iter().find_map(|elem| match elem {
+                            TObjElem::Prop(prop)
+                                if prop.name == TPropKey::StringKey(name.to_owned()) =>
+                            {
+                                Some(prop.t)
+                            }
+                            _ => None,
+                        })?
+                    }
+                    _ => return None,
+                }
+            }
+            _ => return None,
+        };
+        let t = self.prune(t);
+        self.namespace_types.contains(&t).then_some(t)
+    }
+
+    // The decls inside of a namespace are inferred in their own scope like
+    // the ones in a block.  The namespace is an object type with a property
+    // for each of its values, including nested namespaces.
+    fn infer_namespace_type(
+        &mut self,
+        decl: &mut NamespaceDecl,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let mut ns_ctx = ctx.clone();
+        let mut members = BTreeMap::<String, Binding>::new();
+        for decl in &decl.decls {
+            self.hoist_local_fn(decl, &mut ns_ctx);
+        }
+
+        for Decl {
+            kind, annotations, ..
+        } in &mut decl.decls
+        {
+            let bindings = match kind {
+                DeclKind::TypeDecl(decl) => {
+                    self.infer_type_decl(decl, &mut ns_ctx)?;
+                    continue;
+                }
+                DeclKind::VarDecl(decl) => {
+                    let bindings = self.infer_var_decl(decl, &mut ns_ctx)?;
+                    self.unify_hoisted_fns(&bindings, &mut ns_ctx)?;
+                    apply_annotations(&mut ns_ctx, &bindings, annotations);
+                    bindings
+                }
+                DeclKind::NamespaceDecl(decl) => {
+                    let binding = Binding {
+                        index: self.infer_namespace_type(decl, &mut ns_ctx)?,
+                        is_mut: false,
+                        is_var: false,
+                        deprecated: None,
+                    };
+                    ns_ctx
+                        .values
+                        .insert(decl.name.name.to_owned(), binding.clone());
+                    BTreeMap::from([(decl.name.name.to_owned(), binding)])
+                }
+                DeclKind::NamespaceAliasDecl(decl) => {
+                    let binding = Binding {
+                        index: self.infer_namespace_alias_type(decl, &mut ns_ctx)?,
+                        is_mut: false,
+                        is_var: false,
+                        deprecated: None,
+                    };
+                    ns_ctx
+                        .values
+                        .insert(decl.name.name.to_owned(), binding.clone());
+                    BTreeMap::from([(decl.name.name.to_owned(), binding)])
+                }
+                DeclKind::GlobalDecl(_) => {
+                    return Err(TypeError {
+                        message: "`declare global` is only allowed at the top-level".to_string(),
+                    })
+                }
+            };
+
+            for (name, binding) in bindings {
+                if members.contains_key(&name) {
+                    return Err(TypeError {
+                        message: format!(
+                            "{name} cannot be redeclared in namespace {}",
+                            decl.name.name
+                        ),
+                    });
+                }
+                members.insert(name, binding);
+            }
+        }
+
+        let schemes = get_namespace_type_names(decl)
+            .into_iter()
+            .map(|name| {
+                let scheme = ns_ctx.schemes[&name].to_owned();
+                (name, scheme)
+            })
+            .collect();
+
+        Ok(self.new_namespace_type(&decl.name.name, members, schemes, ctx))
+    }
+
+    /// Creates the type of the namespace `name`, an object type with a
+    /// property for each of its `members`.  The types in the namespace are
+    /// only in scope outside of it using their qualified names, e.g. `Foo.T`,
+    /// so `schemes` are added to `ctx` using those names and references to
+    /// them in the members and schemes are replaced.
+    pub fn new_namespace_type(
+        &mut self,
+        name: &str,
+        members: BTreeMap<String, Binding>,
+        schemes: BTreeMap<String, Scheme>,
+        ctx: &mut Context,
+    ) -> Index {
+        let names: HashMap<String, String> = schemes
+            .keys()
+            .map(|type_name| (type_name.to_owned(), format!("{name}.{type_name}")))
+            .collect();
+
+        for (type_name, scheme) in schemes {
+            let qualified_name = &names[&type_name];
+            let mut names = names.clone();
+            for tp in scheme.type_params.iter().flatten() {
+                names.remove(&tp.name);
+            }
+            let scheme = Scheme {
+                t: QualifyTypeRefs {
+                    checker: self,
+                    names: &names,
+                }
+                .fold_index(&scheme.t),
+                ..scheme
+            };
+            self.type_aliases.remove(&type_name);
+            self.add_type_alias(qualified_name, &scheme);
+            ctx.schemes.insert(qualified_name.to_owned(), scheme);
+        }
+
+        let elems = members
+            .into_iter()
+            .map(|(name, binding)| {
+                TObjElem::Prop(TProp {
+                    name: TPropKey::StringKey(name),
+                    optional: false,
+                    readonly: !binding.is_var,
+                    t: QualifyTypeRefs {
+                        checker: self,
+                        names: &names,
+                    }
+                    .fold_index(&binding.index),
+                })
+            })
+            .collect::<Vec<_>>();
+
+        let t = self.new_object_type(&elems);
+        self.namespace_types.insert(t);
+        t
+    }
+
     fn get_ident_member(
         &mut self,
         ctx: &mut Context,
@@ -1549,6 +3571,8 @@ impl Checker {
             // declare let obj: {x: number} | {x: string}
             // obj.x; // number | string
             TypeKind::Union(union) => {
+                self.check_nullable_member_access(ctx, obj_idx, union)?;
+
                 let mut result_types = vec![];
                 let mut undefined_count = 0;
                 for idx in &union.types {
@@ -1576,23 +3600,38 @@ impl Checker {
                     Ok(self.new_union_type(&result_types))
                 }
             }
-            TypeKind::TypeRef(types::TypeRef {
-                name,
-                scheme,
-                type_args,
-                ..
-            }) => {
-                let obj_idx = match scheme {
-                    Some(scheme) => self.expand_scheme(ctx, scheme, type_args, name)?,
-                    None => self.expand_alias(ctx, name, type_args)?,
-                };
+            // declare let obj: {x: number} & {y: string}
+            // obj.x; // number
+            TypeKind::Intersection(intersection) => {
+                let mut result_types = vec![];
+                for idx in &intersection.types {
+                    // TODO: check what the error is, we may want to propagate
+                    // certain errors
+                    if let Ok(t) = self.get_ident_member(ctx, *idx, key_idx, is_mut) {
+                        result_types.push(t);
+                    }
+                }
+                match result_types.len() {
+                    0 => Err(TypeError {
+                        message: format!(
+                            "Couldn't find property {} on object",
+                            self.print_type(&key_idx),
+                        ),
+                    }),
+                    1 => Ok(result_types[0]),
+                    _ => Ok(self.new_intersection_type(&result_types)),
+                }
+            }
+            TypeKind::TypeRef(_) => {
+                // `expand_type` reuses previous expansions of `obj_idx`.
+                let obj_idx = self.expand_type(ctx, obj_idx)?;
                 self.get_ident_member(ctx, obj_idx, key_idx, is_mut)
             }
             TypeKind::Array(types::Array { t }) => {
                 let obj_idx = self.expand_alias(ctx, "Array", &[*t])?;
                 self.get_ident_member(ctx, obj_idx, key_idx, is_mut)
             }
-            TypeKind::Tuple(types::Tuple { types }) => {
+            TypeKind::Tuple(types::Tuple { types, labels: _ }) => {
                 let t = self.new_union_type(types);
                 let obj_idx = self.expand_alias(ctx, "Array", &[t])?;
                 self.get_ident_member(ctx, obj_idx, key_idx, is_mut)
@@ -1619,6 +3658,92 @@ impl Checker {
         }
     }
 
+    // Assigning a property that doesn't exist yet to a mutable binding of a
+    // function, e.g. `f.meta = "x"`, adds that property to the binding's type
+    // which becomes `(() -> undefined) & {meta: "x"}`.  Returns the type of the
+    // right-hand side if the property was added.
+    fn add_prop_to_func_binding(
+        &mut self,
+        ctx: &mut Context,
+        left: &Expr,
+        right: &mut Expr,
+    ) -> Result<Option<Index>, TypeError> {
+        let (name, prop) = match &left.kind {
+            ExprKind::Member(Member {
+                object,
+                property: MemberProp::Ident(Ident { name: prop, .. }),
+                opt_chain: false,
+                ..
+            }) => match &object.kind {
+                ExprKind::Ident(Ident { name, .. }) => (name, prop),
+                _ => return Ok(None),
+            },
+            _ => return Ok(None),
+        };
+
+        let binding = match ctx.values.get(name) {
+            Some(binding) => binding.to_owned(),
+            None => return Ok(None),
+        };
+
+        let t = self.prune(binding.index);
+        let types = match &self.arena[t].kind {
+            TypeKind::Function(_) => vec![t],
+            TypeKind::Intersection(Intersection { types })
+                if types
+                    .iter()
+                    .any(|t| matches!(self.arena[*t].kind, TypeKind::Function(_))) =>
+            {
+                types.to_owned()
+            }
+            _ => return Ok(None),
+        };
+
+        let key_idx = self.new_lit_type(&Literal::String(prop.to_owned()));
+        if self
+            .get_ident_member(ctx, t, key_idx, binding.is_mut)
+            .is_ok()
+        {
+            return Ok(None);
+        }
+
+        let r_t = self.infer_expression(right, ctx)?;
+        let prop = TObjElem::Prop(TProp {
+            name: TPropKey::StringKey(prop.to_owned()),
+            optional: false,
+            readonly: false,
+            t: r_t,
+        });
+
+        // Properties are added to the existing object type if there is one.
+        let mut new_types = vec![];
+        let mut prop = Some(prop);
+        for t in types {
+            match (self.arena[t].kind.clone(), prop.take()) {
+                (TypeKind::Object(types::Object { mut elems, .. }), Some(prop)) => {
+                    elems.push(prop);
+                    new_types.push(self.new_object_type(&elems));
+                }
+                (_, maybe_prop) => {
+                    prop = maybe_prop;
+                    new_types.push(t);
+                }
+            }
+        }
+        if let Some(prop) = prop {
+            new_types.push(self.new_object_type(&[prop]));
+        }
+
+        // The binding's type is replaced so we mark the original type as used
+        // to avoid reporting the binding as unused.
+        self.used_bindings.insert(binding.index);
+        let index = self.new_intersection_type(&new_types);
+        let binding = Binding { index, ..binding };
+        ctx.values.insert(name.to_owned(), binding);
+
+        Ok(Some(r_t))
+    }
+
     pub fn infer_type_params(
         &mut self,
         type_params: &mut Option<Vec<syntax::TypeParam>>,
@@ -1683,6 +3808,38 @@ impl Checker {
     }
 }
 
+// `globalThis` refers to the global scope unless it's been shadowed.
+fn is_global_this(expr: &Expr, ctx: &Context) -> bool {
+    match &expr.kind {
+        ExprKind::Ident(Ident { name, .. }) => {
+            name == "globalThis" && ctx.values.get(name) == ctx.globals.get(name)
+        }
+        _ => false,
+    }
+}
+
+// Updates the bindings introduced by a declaration in `ctx` with any
+// information provided by the declaration's annotations.
+fn apply_annotations(ctx: &mut Context, bindings: &Assump, annotations: &[Annotation]) {
+    for annotation in annotations {
+        // TODO: report unknown annotations
+        if annotation.name == "deprecated" {
+            let reason = match annotation.args.first() {
+                Some(Expr {
+                    kind: ExprKind::Str(Str { value, .. }),
+                    ..
+                }) => value.to_owned(),
+                _ => "".to_string(),
+            };
+            for name in bindings.keys() {
+                if let Some(binding) = ctx.values.get_mut(name) {
+                    binding.deprecated = Some(reason.clone());
+                }
+            }
+        }
+    }
+}
+
 fn is_promise(t: &Type) -> bool {
     matches!(
         t,
@@ -1693,6 +3850,36 @@ fn is_promise(t: &Type) -> bool {
     )
 }
 
+// Narrows `arg` to `t` if it's an immutable binding.
+fn narrow_binding(ctx: &mut Context, arg: &Expr, t: Index) {
+    let name = match &arg.kind {
+        ExprKind::Ident(Ident { name, .. }) => name,
+        _ => return,
+    };
+
+    match ctx.values.get(name) {
+        Some(binding) if !binding.is_mut => {
+            let binding = Binding {
+                index: t,
+                ..binding.to_owned()
+            };
+            ctx.values.insert(name.to_owned(), binding);
+        }
+        _ => (),
+    }
+}
+
+// Returns the position of the param called `name`.  Only params that are
+// identifiers can be the target of a type predicate.
+fn find_param(params: &[types::FuncParam], name: &str) -> Option<usize> {
+    params.iter().position(|param| match &param.pattern {
+        TPat::Ident(BindingIdent {
+            name: param_name, ..
+        }) => param_name == name,
+        _ => false,
+    })
+}
+
 // NOTE: It's possible to have a mix of mutable and immutable bindings be
 // introduced.  In that situation, we only need to check certain parts of
 // the initializer for mutability.
@@ -1741,7 +3928,7 @@ fn find_identifiers(expr: &Expr) -> Result<Vec<Ident>, TypeError> {
 fn is_expr_mutable(ctx: &Context, expr: &Expr) -> Result<bool, TypeError> {
     match &expr.kind {
         ExprKind::Ident(ident) => {
-            let binding = ctx.values.get(&ident.name).unwrap();
+            let binding = ctx.get_binding(&ident.name)?;
             Ok(binding.is_mut)
         }
         ExprKind::Member(member) => is_expr_mutable(ctx, &member.object),
@@ -1751,6 +3938,57 @@ fn is_expr_mutable(ctx: &Context, expr: &Expr) -> Result<bool, TypeError> {
     }
 }
 
+// Renames the type refs with a name in `names`, see `infer_namespace_type`.
+struct QualifyTypeRefs<'a, 'b> {
+    checker: &'a mut Checker,
+    names: &'b HashMap<String, String>,
+}
+
+impl<'a, 'b> KeyValueStore<Index, Type> for QualifyTypeRefs<'a, 'b> {
+    fn get_type(&mut self, index: &Index) -> Type {
+        self.checker.arena[*index].clone()
+    }
+    fn put_type(&mut self, t: Type) -> Index {
+        self.checker.arena.insert(t)
+    }
+}
+
+impl<'a, 'b> Folder for QualifyTypeRefs<'a, 'b> {
+    fn fold_index(&mut self, index: &Index) -> Index {
+        let index = self.checker.prune(*index);
+        let t = self.get_type(&index);
+
+        match &t.kind {
+            TypeKind::TypeRef(TypeRef {
+                name,
+                scheme,
+                type_args,
+            }) => {
+                let new_type_args = folder::walk_indexes(self, type_args);
+                match self.names.get(name) {
+                    Some(qualified_name) => {
+                        self.checker
+                            .new_type_ref(qualified_name, scheme.to_owned(), &new_type_args)
+                    }
+                    None if &new_type_args != type_args => {
+                        self.checker
+                            .new_type_ref(name, scheme.to_owned(), &new_type_args)
+                    }
+                    None => index,
+                }
+            }
+            _ => {
+                let t = folder::walk_index(self, &index);
+                // Nested namespaces are copied when their types are qualified.
+                if self.checker.namespace_types.contains(&index) {
+                    self.checker.namespace_types.insert(t);
+                }
+                t
+            }
+        }
+    }
+}
+
 struct Generalize<'a, 'b> {
     checker: &'a mut Checker,
     mapping: &'b mut BTreeMap<Index, String>,
@@ -1801,6 +4039,68 @@ impl<'a, 'b> Folder for Generalize<'a, 'b> {
     }
 }
 
+// Optional params can only be followed by other optional params or a rest
+// param, otherwise there'd be no way to omit them.
+pub fn check_optional_params(params: &[types::FuncParam]) -> Result<(), TypeError> {
+    let mut has_optional = false;
+    for param in params {
+        if param.optional {
+            has_optional = true;
+        } else if has_optional && !matches!(param.pattern, TPat::Rest(_)) {
+            return Err(TypeError {
+                message: "A required param can't follow an optional param".to_string(),
+            });
+        }
+    }
+    Ok(())
+}
+
+// Returns the name of the function called by a call or `new` expression, e.g.
+// `foo` for `foo()` and `bar` for `foo.bar()`.
+fn get_callee_name(callee: &Expr) -> Option<&str> {
+    match &callee.kind {
+        ExprKind::Ident(Ident { name, .. }) => Some(name),
+        ExprKind::Member(Member {
+            property: MemberProp::Ident(Ident { name, .. }),
+            ..
+        }) => Some(name),
+        _ => None,
+    }
+}
+
+// Returns the dotted name that `expr` refers to if it's an identifier or a
+// chain of member accesses, e.g. `Very.Long.Name` for the target of
+// `namespace Short = Very.Long.Name`.
+fn get_qualified_name(expr: &Expr) -> Option<String> {
+    match &expr.kind {
+        ExprKind::Ident(Ident { name, .. }) => Some(name.to_owned()),
+        ExprKind::Member(Member {
+            object,
+            property: MemberProp::Ident(Ident { name, .. }),
+            ..
+        }) => Some(format!("{}.{name}", get_qualified_name(object)?)),
+        _ => None,
+    }
+}
+
+// The names of the types declared in the namespace `decl`, the types in
+// nested namespaces are qualified with the names of those namespaces.
+fn get_namespace_type_names(decl: &NamespaceDecl) -> Vec<String> {
+    decl.decls
+        .iter()
+        .flat_map(|decl| match &decl.kind {
+            DeclKind::TypeDecl(decl) => vec![decl.name.to_owned()],
+            DeclKind::NamespaceDecl(decl) => get_namespace_type_names(decl)
+                .into_iter()
+                .map(|name| format!("{}.{name}", decl.name.name))
+                .collect(),
+            DeclKind::VarDecl(_) | DeclKind::GlobalDecl(_) | DeclKind::NamespaceAliasDecl(_) => {
+                vec![]
+            }
+        })
+        .collect()
+}
+
 pub fn generalize_func(checker: &mut Checker, func: &types::Function) -> types::Function {
     // A mapping of TypeVariables to TypeVariables
     let mut mapping = BTreeMap::default();
@@ -4,10 +4,11 @@ use std::collections::HashMap;
 
 use escalier_ast::{self as syntax, *};
 
-use crate::ast_utils::{find_returns, find_throws};
+use crate::ast_utils::{find_returns, find_throws, find_yields, get_well_known_symbol};
 use crate::checker::Checker;
 use crate::context::*;
-use crate::infer::generalize_func;
+use crate::diagnostic::{codes, Diagnostic, Severity};
+use crate::infer::{check_optional_params, generalize_func};
 use crate::infer_pattern::pattern_to_tpat;
 use crate::key_value_store::KeyValueStore;
 use crate::type_error::TypeError;
@@ -20,12 +21,36 @@ impl Checker {
         class: &mut Class,
         ctx: &mut Context,
     ) -> Result<Index, TypeError> {
-        let mut cls_ctx = ctx.clone();
-
         // TODO: mutate the instance_scheme since only the methods need
         // further type checking.
-        // TODO: unify _static_type with the static type of the class
-        let (instance_scheme, _static_type) = self.infer_class_interface(class, &mut cls_ctx)?;
+        // TODO: unify interface_static_type with the static type of the class
+        let super_class = match class.super_class.clone() {
+            Some(name) => Some(self.get_super_class(&name, ctx)?),
+            None => None,
+        };
+
+        let ancestors = match &super_class {
+            Some((_, super_instance_t, _)) => match &self.arena[*super_instance_t].kind {
+                TypeKind::Object(types::Object {
+                    class: Some(super_info),
+                    ..
+                }) => std::iter::once(super_info.id)
+                    .chain(super_info.ancestors.iter().cloned())
+                    .collect(),
+                _ => vec![],
+            },
+            None => vec![],
+        };
+        let class_info = ClassInfo {
+            id: self.new_id(),
+            is_abstract: class.is_abstract,
+            ancestors,
+        };
+        let mut cls_ctx = ctx.clone();
+        cls_ctx.class_ids.insert(class_info.id);
+
+        let (instance_scheme, interface_static_type) =
+            self.infer_class_interface(class, &class_info, &mut cls_ctx, super_class.as_ref())?;
 
         cls_ctx
             .schemes
@@ -34,14 +59,22 @@ impl Checker {
         let mut static_elems: Vec<TObjElem> = vec![];
         let mut instance_elems: Vec<TObjElem> = vec![];
 
-        for member in &mut class.body {
+        // Declared classes don't have any bodies to check, their types come
+        // entirely from the signatures of their members.
+        let members = match class.is_declare {
+            true => &mut [][..],
+            false => &mut class.body[..],
+        };
+
+        for member in members {
             match member {
                 ClassMember::Method(Method {
                     span: _,
                     name,
-                    is_public: _, // TODO
+                    is_private,
                     is_mutating,
                     is_static,
+                    is_abstract,
                     function:
                         syntax::Function {
                             type_params,
@@ -50,9 +83,15 @@ impl Checker {
                             type_ann: return_type,
                             throws: sig_throws,
                             is_async,
-                            is_gen: _,
+                            is_gen,
                         },
                 }) => {
+                    // Abstract methods don't have a body to check and their
+                    // signatures are already part of `instance_scheme`.
+                    if *is_abstract {
+                        continue;
+                    }
+
                     let mut sig_ctx = cls_ctx.clone();
 
                     let mut func_params: Vec<types::FuncParam> = vec![];
@@ -63,8 +102,39 @@ impl Checker {
                         let binding = Binding {
                             index: self.new_type_ref("Self", Some(instance_scheme.clone()), &[]),
                             is_mut: *is_mutating,
+                            is_var: false,
+                            deprecated: None,
                         };
                         sig_ctx.values.insert("self".to_string(), binding);
+
+                        // Within the constructor, `super` is the super class'
+                        // constructor, elsewhere it's used to access members
+                        // of the super class that have been overridden.
+                        if let Some((super_ctor, super_instance_t, _)) = &super_class {
+                            let is_constructor = matches!(
+                                name,
+                                PropName::Ident(Ident { name, .. }) if name == "constructor"
+                            );
+                            let index = match is_constructor {
+                                true => {
+                                    let undefined = self.new_lit_type(&Literal::Undefined);
+                                    self.new_func_type(
+                                        &super_ctor.params,
+                                        undefined,
+                                        &super_ctor.type_params,
+                                        super_ctor.throws,
+                                    )
+                                }
+                                false => *super_instance_t,
+                            };
+                            let binding = Binding {
+                                index,
+                                is_mut: *is_mutating,
+                                is_var: false,
+                                deprecated: None,
+                            };
+                            sig_ctx.values.insert("super".to_string(), binding);
+                        }
                     }
 
                     for syntax::FuncParam {
@@ -79,8 +149,10 @@ impl Checker {
                         };
                         pattern.inferred_type = Some(type_ann_t);
 
-                        let (assumps, param_t) = self.infer_pattern(pattern, &sig_ctx)?;
-                        self.unify(&sig_ctx, param_t, type_ann_t)?;
+                        let (mut assumps, param_t) = self.infer_pattern(pattern, &sig_ctx)?;
+                        let binding_t = self.get_param_binding_type(type_ann_t, *optional);
+                        self.unify(&sig_ctx, param_t, binding_t)?;
+                        self.infer_pattern_defaults(pattern, &mut assumps, &mut sig_ctx)?;
 
                         for (name, binding) in assumps {
                             sig_ctx.non_generic.insert(binding.index);
@@ -93,14 +165,17 @@ impl Checker {
                             optional: *optional,
                         });
                     }
+                    check_optional_params(&func_params)?;
 
                     let mut body_ctx = sig_ctx.clone();
                     body_ctx.is_async = *is_async;
+                    let decls_start = self.declared_bindings.len();
 
                     // TODO: dedupe with infer_expression
                     let body_t = 'outer: {
                         match body {
                             BlockOrExpr::Block(Block { stmts, .. }) => {
+                                self.hoist_local_fns(stmts, &mut body_ctx);
                                 for stmt in stmts.iter_mut() {
                                     body_ctx = body_ctx.clone();
                                     self.infer_statement(stmt, &mut body_ctx)?;
@@ -110,7 +185,6 @@ impl Checker {
                                             .filter_map(|ret| ret.inferred_type)
                                             .collect();
 
-                                        // TODO: warn about unreachable code.
                                         break 'outer self.new_union_type(&ret_types);
                                     }
                                 }
@@ -126,6 +200,29 @@ impl Checker {
                         }
                     };
 
+                    if let BlockOrExpr::Block(Block { stmts, .. }) = body {
+                        self.report_unreachable_code(stmts);
+                    }
+                    self.report_unused_bindings(decls_start);
+
+                    let body_t = match *is_gen {
+                        true => {
+                            let yield_types: Vec<Index> = find_yields(body)
+                                .iter()
+                                .filter_map(|arg| arg.inferred_type)
+                                .unique()
+                                .collect();
+                            let yield_t = self.new_union_type(&yield_types);
+                            let next_t = self.new_keyword(Keyword::Unknown);
+                            let name = match *is_async {
+                                true => "AsyncGenerator",
+                                false => "Generator",
+                            };
+                            self.new_type_ref(name, None, &[yield_t, body_t, next_t])
+                        }
+                        false => body_t,
+                    };
+
                     let body_throws = find_throws(body);
                     let body_throws = if body_throws.is_empty() {
                         None
@@ -153,12 +250,14 @@ impl Checker {
                         (None, None) => None,
                     };
 
-                    let name = match name {
-                        PropName::Ident(Ident { name, span: _ }) => name.to_owned(),
-                        PropName::Computed(_) => todo!(),
+                    let (name, is_constructor) = match name {
+                        PropName::Ident(Ident { name, span: _ }) => {
+                            (member_key(name, *is_private), name == "constructor")
+                        }
+                        PropName::Computed(expr) => (computed_member_key(self, expr)?, false),
                     };
 
-                    if &name == "constructor" {
+                    if is_constructor {
                         static_elems.push(TObjElem::Constructor(types::Function {
                             params: func_params,
                             ret: self.new_type_ref("Self", Some(instance_scheme.clone()), &[]),
@@ -173,11 +272,12 @@ impl Checker {
                         None => self.new_type_var(None),
                     };
 
-                    self.unify(&sig_ctx, body_t, ret_t)?;
+                    self.unify_body_with_ret(&sig_ctx, body_t, ret_t, &func_params)?;
 
                     let method = TObjElem::Method(TMethod {
-                        name: TPropKey::StringKey(name.clone()),
+                        name,
                         mutates: *is_mutating,
+                        is_abstract: false,
                         function: types::Function {
                             type_params,
                             params: func_params,
@@ -195,12 +295,75 @@ impl Checker {
                         false => instance_elems.push(method),
                     };
                 }
-                ClassMember::Getter(_) => todo!(),
-                ClassMember::Setter(_) => todo!(),
+                ClassMember::Getter(Getter {
+                    span: _,
+                    name,
+                    is_private,
+                    type_ann: _, // getters don't have return type annotations
+                    params: _,   // should only contain `self`
+                    body,
+                }) => {
+                    let mut body_ctx = cls_ctx.clone();
+                    let self_t = self.new_type_ref("Self", Some(instance_scheme.clone()), &[]);
+                    body_ctx.values.insert(
+                        "self".to_string(),
+                        Binding {
+                            index: self_t,
+                            is_mut: false,
+                            is_var: false,
+                            deprecated: None,
+                        },
+                    );
+
+                    let ret = self.infer_accessor_body(body, &mut body_ctx)?;
+                    let name = match name {
+                        PropName::Ident(Ident { name, span: _ }) => member_key(name, *is_private),
+                        PropName::Computed(expr) => computed_member_key(self, expr)?,
+                    };
+
+                    instance_elems.push(TObjElem::Getter(TGetter {
+                        name,
+                        ret,
+                        throws: None, // TODO
+                    }));
+                }
+                ClassMember::Setter(Setter {
+                    span: _,
+                    name,
+                    is_private,
+                    type_ann: _, // should always be `undefined` or `void`
+                    params,
+                    body,
+                }) => {
+                    let mut body_ctx = cls_ctx.clone();
+                    let self_t = self.new_type_ref("Self", Some(instance_scheme.clone()), &[]);
+                    body_ctx.values.insert(
+                        "self".to_string(),
+                        Binding {
+                            index: self_t,
+                            is_mut: true,
+                            is_var: false,
+                            deprecated: None,
+                        },
+                    );
+
+                    let param = self.infer_func_param(get_setter_param(params)?, &mut body_ctx)?;
+                    self.infer_accessor_body(body, &mut body_ctx)?;
+                    let name = match name {
+                        PropName::Ident(Ident { name, span: _ }) => member_key(name, *is_private),
+                        PropName::Computed(expr) => computed_member_key(self, expr)?,
+                    };
+
+                    instance_elems.push(TObjElem::Setter(TSetter {
+                        name,
+                        param,
+                        throws: None, // TODO
+                    }));
+                }
                 ClassMember::Field(Field {
                     span: _,
                     name,
-                    is_public: _, // TODO
+                    is_private,
                     is_static,
                     type_ann,
                     init: _, // TODO: unify in `infer_class`
@@ -215,7 +378,7 @@ impl Checker {
                     };
 
                     let field = TObjElem::Prop(TProp {
-                        name: TPropKey::StringKey(name.name.to_owned()),
+                        name: member_key(&name.name, *is_private),
                         t: type_ann_t,
                         optional: false, // TODO
                         readonly: false, // TODO
@@ -229,15 +392,24 @@ impl Checker {
             }
         }
 
-        let mut map: HashMap<String, &TMethod> = HashMap::new();
+        let mut map: HashMap<TPropKey, &TMethod> = HashMap::new();
+        let mut getters: HashMap<TPropKey, &TGetter> = HashMap::new();
+        let mut setters: HashMap<TPropKey, &TSetter> = HashMap::new();
 
         let instance_kind: &TypeKind = &self.arena[instance_scheme.t].kind.clone();
         if let TypeKind::Object(obj) = instance_kind {
             for elem in &obj.elems {
-                if let TObjElem::Method(method) = elem {
-                    if let TPropKey::StringKey(name) = &method.name {
-                        map.insert(name.to_owned(), method);
+                match elem {
+                    TObjElem::Method(method) => {
+                        map.insert(method.name.to_owned(), method);
+                    }
+                    TObjElem::Getter(getter) => {
+                        getters.insert(getter.name.to_owned(), getter);
                     }
+                    TObjElem::Setter(setter) => {
+                        setters.insert(setter.name.to_owned(), setter);
+                    }
+                    _ => (),
                 }
             }
         }
@@ -245,7 +417,7 @@ impl Checker {
         // Unify methods
         for elem in instance_elems.iter_mut() {
             if let TObjElem::Method(method) = elem {
-                let m = map.get_mut(&method.name.to_string()).unwrap();
+                let m = map.get_mut(&method.name).unwrap();
 
                 for (param_1, param_2) in
                     method.function.params.iter().zip(m.function.params.iter())
@@ -259,6 +431,23 @@ impl Checker {
             }
         }
 
+        // Unify getters and setters
+        for elem in &instance_elems {
+            match elem {
+                TObjElem::Getter(getter) => {
+                    if let Some(g) = getters.get(&getter.name) {
+                        self.unify(ctx, getter.ret, g.ret)?;
+                    }
+                }
+                TObjElem::Setter(setter) => {
+                    if let Some(s) = setters.get(&setter.name) {
+                        self.unify(ctx, s.param.t, setter.param.t)?;
+                    }
+                }
+                _ => (),
+            }
+        }
+
         // We generalize methods after all of them have been inferred so
         // that mutually recursive method calls can be handled correctly.
         let mut instance_type = self.arena[instance_scheme.t].clone();
@@ -272,7 +461,15 @@ impl Checker {
         }
 
         let instance_type = self.arena.insert(instance_type);
-        let static_type = self.new_object_type(&static_elems);
+        let static_type = match class.is_declare {
+            true => interface_static_type,
+            false => {
+                if let Some((_, _, super_static_t)) = &super_class {
+                    self.inherit_members(&mut static_elems, *super_static_t, &cls_ctx)?;
+                }
+                self.new_class_object_type(&static_elems, &class_info)
+            }
+        };
 
         let self_scheme = Scheme {
             type_params: None,
@@ -283,17 +480,63 @@ impl Checker {
         replace_self_type_refs(&mut self.arena, &instance_type, &self_scheme);
         replace_self_type_refs(&mut self.arena, &static_type, &self_scheme);
 
+        for type_ann in class.implements.iter_mut() {
+            let interface_t = self.infer_type_ann(type_ann, &mut cls_ctx)?;
+            let reasons = self.check_implements(&cls_ctx, instance_type, interface_t)?;
+            if !reasons.is_empty() {
+                self.current_report.diagnostics.push(Diagnostic {
+                    code: codes::INCORRECTLY_IMPLEMENTS,
+                    severity: Severity::Error,
+                    message: format!(
+                        "class incorrectly implements {}",
+                        self.print_type(&interface_t)
+                    ),
+                    reasons,
+                    span: Some(type_ann.span),
+                });
+            }
+        }
+
         Ok(static_type)
     }
 
+    /// Returns the constructor of the class `name` along with the type of its
+    /// instances and its static type.
+    fn get_super_class(
+        &mut self,
+        name: &Ident,
+        ctx: &Context,
+    ) -> Result<(types::Function, Index, Index), TypeError> {
+        let static_t = self.get_type(&name.name, ctx)?;
+        let static_t = self.expand_type(ctx, static_t)?;
+
+        if let TypeKind::Object(obj) = &self.arena[static_t].kind {
+            let ctor = obj.elems.iter().find_map(|elem| match elem {
+                TObjElem::Constructor(ctor) => Some(ctor.to_owned()),
+                _ => None,
+            });
+            if let Some(ctor) = ctor {
+                let instance_t = self.expand_type(ctx, ctor.ret)?;
+                return Ok((ctor, instance_t, static_t));
+            }
+        }
+
+        Err(TypeError {
+            message: format!("{} is not a class", name.name),
+        })
+    }
+
     fn infer_class_interface(
         &mut self,
         class: &mut Class,
+        class_info: &ClassInfo,
         ctx: &mut Context,
+        super_class: Option<&(types::Function, Index, Index)>,
     ) -> Result<(Scheme, Index), TypeError> {
         let mut instance_elems: Vec<TObjElem> = vec![];
         let mut static_elems: Vec<TObjElem> = vec![];
 
+        let is_abstract_class = class.is_abstract;
         let self_type = self.new_type_var(None);
 
         let mut cls_ctx = ctx.clone();
@@ -313,9 +556,10 @@ impl Checker {
                 ClassMember::Method(Method {
                     span: _,
                     name,
-                    is_public: _,
+                    is_private,
                     is_mutating,
                     is_static,
+                    is_abstract,
                     function:
                         syntax::Function {
                             type_params,
@@ -343,7 +587,7 @@ impl Checker {
                                 optional: param.optional,
                             })
                         })
-                        .collect::<Result<Vec<_>, _>>()?;
+                        .collect::<Result<Vec<_>, TypeError>>()?;
 
                     let ret = match return_type {
                         Some(return_type) => self.infer_type_ann(return_type, &mut sig_ctx)?,
@@ -361,24 +605,36 @@ impl Checker {
                             if name == "constructor" {
                                 is_constructor = true;
                             }
-                            TPropKey::StringKey(name.to_string())
+                            member_key(name, *is_private)
                         }
-                        PropName::Computed(_) => todo!(),
+                        PropName::Computed(expr) => computed_member_key(self, expr)?,
                     };
 
+                    // Constructors return instances of the class, this is
+                    // what declared classes use since their constructors
+                    // don't have bodies.
                     if is_constructor {
                         static_elems.push(TObjElem::Constructor(types::Function {
                             params: func_params,
-                            ret,
+                            ret: self.new_type_ref("Self", None, &[]),
                             type_params,
                             throws,
                         }));
                         continue;
                     }
 
+                    if *is_abstract && !is_abstract_class {
+                        return Err(TypeError {
+                            message: format!(
+                                "abstract method {name} can only appear in an abstract class"
+                            ),
+                        });
+                    }
+
                     let method = TObjElem::Method(TMethod {
                         name,
                         mutates: *is_mutating,
+                        is_abstract: *is_abstract,
                         function: types::Function {
                             type_params,
                             params: func_params,
@@ -395,7 +651,7 @@ impl Checker {
                 ClassMember::Getter(Getter {
                     span: _,
                     name,
-                    is_public: _,
+                    is_private,
                     type_ann,
                     params: _, // should be empty for getters
                     body: _,   // TODO: unify in `infer_class`
@@ -408,10 +664,8 @@ impl Checker {
                     };
 
                     let name: TPropKey = match name {
-                        PropName::Ident(Ident { name, span: _ }) => {
-                            TPropKey::StringKey(name.to_string())
-                        }
-                        PropName::Computed(_) => todo!(),
+                        PropName::Ident(Ident { name, span: _ }) => member_key(name, *is_private),
+                        PropName::Computed(expr) => computed_member_key(self, expr)?,
                     };
 
                     let getter = TObjElem::Getter(TGetter {
@@ -424,23 +678,21 @@ impl Checker {
                 ClassMember::Setter(Setter {
                     span: _,
                     name,
-                    is_public: _, // TODO: change to private
-                    type_ann: _,  // should always be `undefined` or `void`
+                    is_private,
+                    type_ann: _, // should always be `undefined` or `void`
                     params,
                     body: _, // TODO: unify in `infer_class`
                 }) => {
                     let mut sig_ctx = cls_ctx.clone();
 
                     let name: TPropKey = match name {
-                        PropName::Ident(Ident { name, span: _ }) => {
-                            TPropKey::StringKey(name.to_string())
-                        }
-                        PropName::Computed(_) => todo!(),
+                        PropName::Ident(Ident { name, span: _ }) => member_key(name, *is_private),
+                        PropName::Computed(expr) => computed_member_key(self, expr)?,
                     };
 
                     let setter = TObjElem::Setter(TSetter {
                         name,
-                        param: self.infer_func_param(&mut params[0], &mut sig_ctx)?,
+                        param: self.infer_func_param(get_setter_param(params)?, &mut sig_ctx)?,
                         throws: None, // TODO
                     });
                     instance_elems.push(setter);
@@ -448,7 +700,7 @@ impl Checker {
                 ClassMember::Field(Field {
                     span: _,
                     name,
-                    is_public: _, // TODO
+                    is_private,
                     is_static,
                     type_ann,
                     init: _, // TODO: unify in `infer_class`
@@ -461,7 +713,7 @@ impl Checker {
                     };
 
                     let field = TObjElem::Prop(TProp {
-                        name: TPropKey::StringKey(name.name.to_owned()),
+                        name: member_key(&name.name, *is_private),
                         t: type_ann_t,
                         optional: false, // TODO
                         readonly: false, // TODO
@@ -475,8 +727,31 @@ impl Checker {
             }
         }
 
+        if let Some((_, super_instance_t, super_static_t)) = super_class {
+            self.inherit_members(&mut instance_elems, *super_instance_t, &cls_ctx)?;
+            self.inherit_members(&mut static_elems, *super_static_t, &cls_ctx)?;
+        }
+
+        if !is_abstract_class {
+            for elem in &instance_elems {
+                if let TObjElem::Method(TMethod {
+                    name,
+                    is_abstract: true,
+                    ..
+                }) = elem
+                {
+                    return Err(TypeError {
+                        message: format!(
+                            "non-abstract class must implement abstract method {name}"
+                        ),
+                    });
+                }
+            }
+        }
+
+        let instance_t = self.new_class_object_type(&instance_elems, class_info);
         let instance_scheme = Scheme {
-            t: self.new_object_type(&instance_elems),
+            t: instance_t,
             // TODO: add type params
             // I don't think this is something that can be inferred, by
             // default, each function gets its own type params
@@ -484,12 +759,149 @@ impl Checker {
             is_type_param: false,
         };
 
-        let static_type = self.new_object_type(&static_elems);
+        let static_type = self.new_class_object_type(&static_elems, class_info);
 
         // TODO: How do we keep track of the relationship between these two?
         Ok((instance_scheme, static_type))
     }
 
+    /// Adds the members of the super class' instance or static type `super_t`
+    /// that haven't been overridden to `elems` and checks that overridden
+    /// methods are compatible with the methods they override.  Private members
+    /// are only accessible within the class that declares them so they aren't
+    /// inherited.
+    fn inherit_members(
+        &mut self,
+        elems: &mut Vec<TObjElem>,
+        super_t: Index,
+        ctx: &Context,
+    ) -> Result<(), TypeError> {
+        // Inherited members use `Self` to refer to the subclass instead of
+        // the super class.
+        let mut mapping = std::collections::HashMap::new();
+        mapping.insert("Self".to_string(), self.new_type_ref("Self", None, &[]));
+        let super_t = self.instantiate_type(&super_t, &mapping);
+
+        let super_elems = match &self.arena[super_t].kind {
+            TypeKind::Object(obj) => obj.elems.clone(),
+            _ => vec![],
+        };
+
+        for super_elem in super_elems {
+            let name = match get_elem_name(&super_elem) {
+                Some(name) if !name.starts_with('#') => name,
+                _ => continue,
+            };
+
+            let overrides = elems
+                .iter()
+                .filter(|elem| get_elem_name(elem).as_ref() == Some(&name))
+                .cloned()
+                .collect::<Vec<_>>();
+
+            if overrides.is_empty() {
+                elems.push(super_elem);
+                continue;
+            }
+
+            if let TObjElem::Method(super_method) = &super_elem {
+                let super_func = &super_method.function;
+                let super_t = self.new_func_type(
+                    &super_func.params,
+                    super_func.ret,
+                    &super_func.type_params,
+                    super_func.throws,
+                );
+
+                for elem in overrides {
+                    if let TObjElem::Method(TMethod { function: func, .. }) = elem {
+                        let t = self.new_func_type(
+                            &func.params,
+                            func.ret,
+                            &func.type_params,
+                            func.throws,
+                        );
+                        self.unify(ctx, t, super_t).map_err(|_| TypeError {
+                            message: format!(
+                                "{name} is not compatible with the method it overrides"
+                            ),
+                        })?;
+                    }
+                }
+            }
+        }
+
+        Ok(())
+    }
+
+    /// Checks that the instance type of a class is assignable to the interface
+    /// in its `implements` clause.  Each member of the interface is checked
+    /// separately so that all of the missing or mismatched members can be
+    /// reported.
+    fn check_implements(
+        &mut self,
+        ctx: &Context,
+        instance_t: Index,
+        interface_t: Index,
+    ) -> Result<Vec<TypeError>, TypeError> {
+        let expanded_t = self.expand_type(ctx, interface_t)?;
+
+        let elems = match &self.arena[expanded_t].kind {
+            TypeKind::Object(obj) => obj.elems.clone(),
+            _ => {
+                return Ok(match self.unify(ctx, instance_t, interface_t) {
+                    Ok(_) => vec![],
+                    Err(error) => vec![error],
+                })
+            }
+        };
+
+        let mut reasons = vec![];
+        for elem in elems {
+            let elem_t = self.new_object_type(&[elem]);
+            if let Err(error) = self.unify(ctx, instance_t, elem_t) {
+                reasons.push(error);
+            }
+        }
+
+        Ok(reasons)
+    }
+
+    fn new_class_object_type(&mut self, elems: &[TObjElem], class_info: &ClassInfo) -> Index {
+        self.arena
+            .insert(Type::from(TypeKind::Object(types::Object {
+                elems: elems.to_vec(),
+                class: Some(class_info.to_owned()),
+            })))
+    }
+
+    /// Infers the type of a getter's or setter's body, it's the union of the
+    /// types that it returns.
+    fn infer_accessor_body(
+        &mut self,
+        body: &mut Block,
+        ctx: &mut Context,
+    ) -> Result<Index, TypeError> {
+        let decls_start = self.declared_bindings.len();
+
+        for stmt in body.stmts.iter_mut() {
+            self.infer_statement(stmt, ctx)?;
+        }
+
+        self.report_unreachable_code(&body.stmts);
+        self.report_unused_bindings(decls_start);
+
+        let ret_types: Vec<Index> = find_returns(&BlockOrExpr::Block(body.to_owned()))
+            .iter()
+            .filter_map(|ret| ret.inferred_type)
+            .collect();
+
+        Ok(match ret_types.is_empty() {
+            true => self.new_lit_type(&Literal::Undefined),
+            false => self.new_union_type(&ret_types),
+        })
+    }
+
     fn infer_func_param(
         &mut self,
         param: &mut syntax::FuncParam,
@@ -501,8 +913,9 @@ impl Checker {
         };
         param.pattern.inferred_type = Some(type_ann_t);
 
-        let (assumps, param_t) = self.infer_pattern(&mut param.pattern, sig_ctx)?;
+        let (mut assumps, param_t) = self.infer_pattern(&mut param.pattern, sig_ctx)?;
         self.unify(sig_ctx, param_t, type_ann_t)?;
+        self.infer_pattern_defaults(&mut param.pattern, &mut assumps, sig_ctx)?;
 
         for (name, binding) in assumps {
             sig_ctx.non_generic.insert(binding.index);
@@ -544,6 +957,47 @@ impl<'a> Visitor for ReplaceVisitor<'a> {
     }
 }
 
+// Private members are stored using a `#` prefix, like private names in
+// JavaScript, so that they can't be accessed as if they were public.
+fn member_key(name: &str, is_private: bool) -> TPropKey {
+    match is_private {
+        true => TPropKey::StringKey(format!("#{name}")),
+        false => TPropKey::StringKey(name.to_owned()),
+    }
+}
+
+// Only well-known symbols, e.g. `[Symbol.iterator]`, are supported as
+// computed member names for now.
+fn computed_member_key(checker: &mut Checker, expr: &Expr) -> Result<TPropKey, TypeError> {
+    match get_well_known_symbol(expr) {
+        Some(name) => Ok(TPropKey::SymbolKey(checker.well_known_symbol(name))),
+        None => Err(TypeError {
+            message: "Computed member names must be well-known symbols".to_string(),
+        }),
+    }
+}
+
+// Setters declare `self` as their first param, the value being set is the
+// second one.
+fn get_setter_param(params: &mut [syntax::FuncParam]) -> Result<&mut syntax::FuncParam, TypeError> {
+    match params.get_mut(1) {
+        Some(param) => Ok(param),
+        None => Err(TypeError {
+            message: "setters must have a param for the value being set".to_string(),
+        }),
+    }
+}
+
+fn get_elem_name(elem: &TObjElem) -> Option<String> {
+    match elem {
+        TObjElem::Method(TMethod { name, .. })
+        | TObjElem::Getter(TGetter { name, .. })
+        | TObjElem::Setter(TSetter { name, .. })
+        | TObjElem::Prop(TProp { name, .. }) => Some(name.to_string()),
+        TObjElem::Constructor(_) | TObjElem::Call(_) | TObjElem::Mapped(_) => None,
+    }
+}
+
 pub fn replace_self_type_refs(arena: &mut Arena<Type>, t: &Index, scheme: &Scheme) {
     let mut replace_visitor = ReplaceVisitor { arena, scheme };
 
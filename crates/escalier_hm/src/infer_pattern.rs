@@ -32,6 +32,8 @@ impl Checker {
                             Binding {
                                 index: t,
                                 is_mut: *mutable,
+                                is_var: false,
+                                deprecated: None,
                             },
                         )
                         .is_some()
@@ -53,27 +55,24 @@ impl Checker {
                     for prop in props.iter_mut() {
                         match prop {
                             // re-assignment, e.g. {x: new_x, y: new_y} = point
-                            ObjectPatProp::KeyValue(KeyValuePatProp { key, value, .. }) => {
-                                // We ignore `init` for now, we can come back later to handle
-                                // default values.
-                                // TODO: handle default values
-
+                            ObjectPatProp::KeyValue(KeyValuePatProp {
+                                key, value, init, ..
+                            }) => {
                                 // TODO: bubble the error up from infer_patter_rec() if there is one.
                                 let value_type =
                                     infer_pattern_rec(checker, value.as_mut(), assump, ctx)?;
+                                value.inferred_type = Some(value_type);
 
+                                // Properties with default values can be left out,
+                                // the defaults are checked by `infer_pattern_defaults`.
                                 elems.push(types::TObjElem::Prop(types::TProp {
                                     name: TPropKey::StringKey(key.name.to_owned()),
-                                    optional: false,
+                                    optional: init.is_some(),
                                     readonly: false,
                                     t: value_type,
                                 }))
                             }
-                            ObjectPatProp::Shorthand(ShorthandPatProp { ident, .. }) => {
-                                // We ignore `init` for now, we can come back later to handle
-                                // default values.
-                                // TODO: handle default values
-
+                            ObjectPatProp::Shorthand(ShorthandPatProp { ident, init, .. }) => {
                                 let t = checker.new_type_var(None);
                                 if assump
                                     .insert(
@@ -81,6 +80,8 @@ impl Checker {
                                         Binding {
                                             index: t,
                                             is_mut: false,
+                                            is_var: false,
+                                            deprecated: None,
                                         },
                                     )
                                     .is_some()
@@ -90,7 +91,7 @@ impl Checker {
 
                                 elems.push(types::TObjElem::Prop(types::TProp {
                                     name: TPropKey::StringKey(ident.name.to_owned()),
-                                    optional: false,
+                                    optional: init.is_some(),
                                     readonly: false,
                                     t,
                                 }))
@@ -127,10 +128,10 @@ impl Checker {
                     for elem in elems.iter_mut() {
                         let t = match elem {
                             Some(elem) => {
-                                // TODO:
-                                // - handle elem.init
-                                // - check for multiple rest patterns
-                                infer_pattern_rec(checker, &mut elem.pattern, assump, ctx)?
+                                // TODO: check for multiple rest patterns
+                                let t = infer_pattern_rec(checker, &mut elem.pattern, assump, ctx)?;
+                                elem.pattern.inferred_type = Some(t);
+                                t
                             }
                             None => checker.new_lit_type(&Literal::Undefined),
                         };
@@ -140,6 +141,20 @@ impl Checker {
                     checker.new_tuple_type(&elem_types)
                 }
                 PatternKind::Lit(LitPat { lit }) => checker.new_lit_type(lit),
+                PatternKind::Range(RangePat { start, end, .. }) => match (start, end) {
+                    (Literal::Number(_), Literal::Number(_)) => {
+                        checker.new_primitive(Primitive::Number)
+                    }
+                    (Literal::String(_), Literal::String(_)) => {
+                        checker.new_primitive(Primitive::String)
+                    }
+                    _ => {
+                        return Err(TypeError {
+                            message: "Range pattern bounds must both be numbers or strings"
+                                .to_string(),
+                        })
+                    }
+                },
                 PatternKind::Is(IsPat { ident, is_id }) => {
                     let t = match is_id.name.as_str() {
                         "number" => checker.new_primitive(Primitive::Number),
@@ -153,11 +168,49 @@ impl Checker {
                         Binding {
                             index: t,
                             is_mut: false,
+                            is_var: false,
+                            deprecated: None,
                         },
                     );
 
                     t
                 }
+                PatternKind::Or(OrPat { patterns }) => {
+                    let mut alt_assumps: Vec<Assump> = vec![];
+                    let mut alt_types: Vec<Index> = vec![];
+
+                    for pattern in patterns.iter_mut() {
+                        let mut alt_assump = Assump::default();
+                        let t = infer_pattern_rec(checker, pattern, &mut alt_assump, ctx)?;
+                        alt_assumps.push(alt_assump);
+                        alt_types.push(t);
+                    }
+
+                    let (first, rest) = alt_assumps.split_first().unwrap();
+
+                    for alt_assump in rest {
+                        if !alt_assump.keys().eq(first.keys()) {
+                            return Err(TypeError {
+                                message:
+                                    "All alternatives in an or-pattern must bind the same names"
+                                        .to_string(),
+                            });
+                        }
+                        for (name, binding) in alt_assump {
+                            checker.unify(ctx, binding.index, first[name].index)?;
+                        }
+                    }
+
+                    for (name, binding) in first {
+                        if assump.insert(name.to_owned(), binding.to_owned()).is_some() {
+                            return Err(TypeError {
+                                message: "Duplicate identifier in pattern".to_string(),
+                            });
+                        }
+                    }
+
+                    checker.new_union_type(&alt_types)
+                }
                 PatternKind::Wildcard => checker.new_type_var(None),
             };
 
@@ -169,6 +222,167 @@ impl Checker {
 
         Ok((assump, pat_type))
     }
+
+    /// Infers the type of a match arm's `pattern` and checks it against the
+    /// type of the value being matched, `expr_t`.  Object patterns select the
+    /// members of a union that are structurally compatible with them, e.g.
+    /// `{x, y}` matches `Point` but not `Event` in `Point | Event`, and each
+    /// binding is the union of its types in the selected members.
+    pub fn infer_match_pattern(
+        &mut self,
+        pattern: &mut Pattern,
+        expr_t: Index,
+        ctx: &Context,
+    ) -> Result<Assump, TypeError> {
+        let (assump, pat_t) = self.infer_pattern(pattern, ctx)?;
+
+        let expr_t = self.prune(expr_t);
+        let members = match (&pattern.kind, &self.arena[expr_t].kind) {
+            (PatternKind::Object(_), TypeKind::Union(Union { types })) => types.to_owned(),
+            _ => {
+                // Checks that the pattern is a sub-type of expr
+                self.unify(ctx, pat_t, expr_t)?;
+                return Ok(assump);
+            }
+        };
+
+        // Each member is checked against its own copy of the pattern so that
+        // the types of the bindings in one member don't leak into the others.
+        // Members that don't match are left as they were.
+        let mut matches: Vec<Assump> = vec![];
+        for member in members {
+            let (member_assump, member_pat_t) = self.infer_pattern(&mut pattern.clone(), ctx)?;
+            if self.try_unify(ctx, member, member_pat_t) {
+                matches.push(member_assump);
+                continue;
+            }
+
+            // Literals in the pattern can be more specific than the member,
+            // e.g. `{kind: "click"}` matches `{kind: string}`.
+            let (member_assump, member_pat_t) = self.infer_pattern(&mut pattern.clone(), ctx)?;
+            if self.try_unify(ctx, member_pat_t, member) {
+                matches.push(member_assump);
+            }
+        }
+
+        if matches.is_empty() {
+            // This reports that the pattern doesn't match any of the members.
+            self.unify(ctx, pat_t, expr_t)?;
+            return Ok(assump);
+        }
+
+        for (name, binding) in &assump {
+            let types: Vec<Index> = matches.iter().map(|m| m[name].index).collect();
+            let t = self.new_union_type(&types);
+            self.unify(ctx, t, binding.index)?;
+        }
+
+        Ok(assump)
+    }
+
+    /// Checks that the default values in `pattern`, e.g. the `5` in `{x = 5}`,
+    /// can be assigned to the bindings they're the defaults for.  This must be
+    /// called after the value being destructured has been unified with the
+    /// pattern's type so that the bindings have the types of the properties
+    /// they're bound to.  `assump` are the bindings returned by
+    /// `infer_pattern`, bindings with defaults are updated to exclude
+    /// `undefined` since the default is used in its place.
+    pub fn infer_pattern_defaults(
+        &mut self,
+        pattern: &mut Pattern,
+        assump: &mut Assump,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
+        match &mut pattern.kind {
+            PatternKind::Rest(ast::RestPat { arg }) => {
+                self.infer_pattern_defaults(arg, assump, ctx)?;
+            }
+            PatternKind::Object(ast::ObjectPat { props, .. }) => {
+                for prop in props.iter_mut() {
+                    match prop {
+                        ObjectPatProp::KeyValue(KeyValuePatProp { value, init, .. }) => {
+                            if let Some(init) = init {
+                                self.infer_default(value, init, assump, ctx)?;
+                            }
+                            self.infer_pattern_defaults(value, assump, ctx)?;
+                        }
+                        ObjectPatProp::Shorthand(ShorthandPatProp { ident, init, .. }) => {
+                            if let (Some(init), Some(binding)) = (init, assump.get_mut(&ident.name))
+                            {
+                                let t = remove_undefined(self, binding.index);
+                                binding.index = t;
+                                let init_t = self.infer_expression(init, ctx)?;
+                                self.unify(ctx, init_t, t)?;
+                            }
+                        }
+                        ObjectPatProp::Rest(ast::RestPat { arg }) => {
+                            self.infer_pattern_defaults(arg, assump, ctx)?;
+                        }
+                    }
+                }
+            }
+            PatternKind::Tuple(ast::TuplePat { elems, .. }) => {
+                for elem in elems.iter_mut().flatten() {
+                    if let Some(init) = &mut elem.init {
+                        self.infer_default(&mut elem.pattern, init, assump, ctx)?;
+                    }
+                    self.infer_pattern_defaults(&mut elem.pattern, assump, ctx)?;
+                }
+            }
+            PatternKind::Ident(_)
+            | PatternKind::Lit(_)
+            | PatternKind::Is(_)
+            | PatternKind::Or(_)
+            | PatternKind::Range(_)
+            | PatternKind::Wildcard => {}
+        }
+
+        Ok(())
+    }
+
+    // Checks `init` against the type of `pattern`, which is the default for a
+    // property or tuple element.
+    fn infer_default(
+        &mut self,
+        pattern: &mut Pattern,
+        init: &mut Expr,
+        assump: &mut Assump,
+        ctx: &mut Context,
+    ) -> Result<(), TypeError> {
+        let t = match pattern.inferred_type {
+            Some(t) => remove_undefined(self, t),
+            None => return Ok(()),
+        };
+        pattern.inferred_type = Some(t);
+        if let PatternKind::Ident(BindingIdent { name, .. }) = &pattern.kind {
+            if let Some(binding) = assump.get_mut(name) {
+                binding.index = t;
+            }
+        }
+
+        let init_t = self.infer_expression(init, ctx)?;
+        self.unify(ctx, init_t, t)
+    }
+}
+
+fn remove_undefined(checker: &mut Checker, t: Index) -> Index {
+    let t = checker.prune(t);
+    match &checker.arena[t].kind {
+        TypeKind::Union(Union { types }) => {
+            let types: Vec<_> = types
+                .iter()
+                .filter(|t| {
+                    !matches!(
+                        checker.arena[**t].kind,
+                        TypeKind::Literal(Literal::Undefined)
+                    )
+                })
+                .cloned()
+                .collect();
+            checker.new_union_type(&types)
+        }
+        _ => t,
+    }
 }
 
 pub fn pattern_to_tpat(pattern: &Pattern, is_func_param: bool) -> TPat {
@@ -242,6 +456,23 @@ pub fn pattern_to_tpat(pattern: &Pattern, is_func_param: bool) -> TPat {
                 })
             }
         }
+        PatternKind::Range(_) => {
+            if is_func_param {
+                panic!("Range patterns not allowed in function params")
+            } else {
+                // Range patterns don't bind any names.
+                TPat::Wildcard
+            }
+        }
+        PatternKind::Or(OrPat { patterns }) => {
+            if is_func_param {
+                panic!("Or-patterns not allowed in function params")
+            } else {
+                // All of the alternatives bind the same names so the first
+                // one is representative of the whole pattern.
+                pattern_to_tpat(&patterns[0], is_func_param)
+            }
+        }
         PatternKind::Wildcard => {
             if is_func_param {
                 panic!("Wildcard patterns not allowed in function params")
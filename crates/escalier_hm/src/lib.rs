@@ -1,13 +1,19 @@
 // Based on https://github.com/tcr/rust-hindley-milner/blob/master/src/lib.rs
 mod ast_utils;
+mod cycles;
+mod excess;
+mod expand_cache;
 mod folder;
 mod infer_class;
 mod infer_pattern;
 mod key_value_store;
 mod provenance;
+mod suppress;
 mod unify;
+mod unused;
 mod visitor;
 
+pub mod annotate;
 pub mod checker;
 pub mod context;
 pub mod diagnostic;
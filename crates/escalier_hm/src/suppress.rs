@@ -0,0 +1,95 @@
+use escalier_ast::Comment;
+
+use crate::checker::Checker;
+use crate::diagnostic::{codes, Diagnostic, Severity};
+
+// A `// escalier-ignore` or `// escalier-ignore-next-line` comment, optionally
+// followed by the codes to suppress, e.g. `// escalier-ignore ESC_2001`.
+struct Suppression<'a> {
+    comment: &'a Comment,
+    // The line that diagnostics are suppressed on.
+    line: usize,
+    // When empty, all diagnostics on the line are suppressed.
+    codes: Vec<u32>,
+    is_used: bool,
+}
+
+fn parse_suppression<'a>(src: &str, comment: &'a Comment) -> Option<Suppression<'a>> {
+    let text = comment.text.trim();
+    let rest = text
+        .strip_prefix("escalier-ignore-next-line")
+        .or_else(|| text.strip_prefix("escalier-ignore"))?;
+
+    if !rest.is_empty() && !rest.starts_with(char::is_whitespace) {
+        return None;
+    }
+
+    let codes = rest
+        .split_whitespace()
+        .map(|code| code.strip_prefix("ESC_")?.parse::<u32>().ok())
+        .collect::<Option<Vec<_>>>()?;
+
+    Some(Suppression {
+        comment,
+        line: get_line(src, comment.span.start) + 1,
+        codes,
+        is_used: false,
+    })
+}
+
+fn get_line(src: &str, offset: usize) -> usize {
+    src[..offset.min(src.len())].matches('\n').count()
+}
+
+impl Checker {
+    /// Removes diagnostics on lines that follow a suppression comment.  A
+    /// warning is reported for each suppression comment that didn't match any
+    /// diagnostics.  This should be called after the program has been inferred.
+    pub fn apply_suppressions(&mut self, src: &str, comments: &[Comment]) {
+        let mut suppressions = comments
+            .iter()
+            .filter_map(|comment| parse_suppression(src, comment))
+            .collect::<Vec<_>>();
+
+        if suppressions.is_empty() {
+            return;
+        }
+
+        self.current_report.diagnostics.retain(|diagnostic| {
+            let span = match diagnostic.span {
+                Some(span) => span,
+                None => return true,
+            };
+            let line = get_line(src, span.start);
+
+            let suppression = suppressions.iter_mut().find(|suppression| {
+                suppression.line == line
+                    && (suppression.codes.is_empty()
+                        || suppression.codes.contains(&diagnostic.code))
+            });
+
+            match suppression {
+                Some(suppression) => {
+                    suppression.is_used = true;
+                    false
+                }
+                None => true,
+            }
+        });
+
+        for suppression in suppressions {
+            if !suppression.is_used {
+                self.current_report.diagnostics.push(Diagnostic {
+                    code: codes::UNUSED_SUPPRESSION,
+                    severity: Severity::Warning,
+                    message:
+                        "Unused suppression, there are no matching diagnostics on the next line"
+                            .to_string(),
+                    reasons: vec![],
+                    span: Some(suppression.comment.span),
+                });
+            }
+        }
+        self.current_report.sort();
+    }
+}
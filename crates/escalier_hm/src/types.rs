@@ -1,5 +1,6 @@
 // Types and type constructors
 use generational_arena::Index;
+use std::collections::HashMap;
 use std::convert::From;
 use std::fmt;
 
@@ -32,6 +33,9 @@ pub enum Keyword {
     Never,
     Object,
     Unknown,
+    // Only used as a return type, callbacks returning `void` can return any
+    // value since the caller ignores it.
+    Void,
 }
 
 impl fmt::Display for Keyword {
@@ -40,6 +44,7 @@ impl fmt::Display for Keyword {
             Self::Never => "never",
             Self::Object => "object",
             Self::Unknown => "unknown",
+            Self::Void => "void",
         };
         write!(f, "{result}")
     }
@@ -184,6 +189,7 @@ pub struct Call {
 pub struct TMethod {
     pub name: TPropKey,
     pub mutates: bool,
+    pub is_abstract: bool,
     pub function: Function,
 }
 
@@ -211,6 +217,7 @@ pub struct TIndexKey {
 pub enum TPropKey {
     StringKey(String),
     NumberKey(String),
+    SymbolKey(UniqueSymbol),
 }
 
 impl fmt::Display for TPropKey {
@@ -218,6 +225,7 @@ impl fmt::Display for TPropKey {
         match self {
             TPropKey::StringKey(key) => write!(f, "{key}"),
             TPropKey::NumberKey(key) => write!(f, "{key}"),
+            TPropKey::SymbolKey(UniqueSymbol { name, .. }) => write!(f, "[{name}]"),
         }
     }
 }
@@ -265,6 +273,7 @@ pub struct MappedType {
     pub target: String,
     pub source: Index,
     pub optional: Option<MappedModifier>,
+    pub readonly: Option<MappedModifier>,
 
     // First half of a Conditional
     pub check: Option<Index>,
@@ -288,6 +297,20 @@ pub enum TObjElem {
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
 pub struct Object {
     pub elems: Vec<TObjElem>,
+    // Only set for the instance and static types of classes.
+    pub class: Option<ClassInfo>,
+}
+
+// Each class declaration gets its own `id` so that instances of different
+// classes can be told apart even if they have the same members.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct ClassInfo {
+    pub id: usize,
+    // Abstract classes can't be instantiated with `new`.
+    pub is_abstract: bool,
+    // The ids of the classes this class extends, starting with its own super
+    // class.  Instances of a class are also instances of all of these.
+    pub ancestors: Vec<usize>,
 }
 
 // NOTE: this is only used for the rest element in array patterns since we
@@ -328,6 +351,8 @@ pub struct Intersection {
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
 pub struct Tuple {
     pub types: Vec<Index>,
+    // Labels are purely documentary and are ignored when unifying tuples.
+    pub labels: Vec<Option<String>>,
 }
 
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
@@ -340,6 +365,16 @@ pub struct KeyOf {
     pub t: Index,
 }
 
+// The return type of a user-defined type guard, e.g. `x is string`, or of an
+// assertion function, e.g. `asserts x is string` or `asserts x`.  Calling a
+// type guard returns a `boolean` and calling an assertion returns `void`.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct Predicate {
+    pub asserts: bool,
+    pub param: String,
+    pub t: Option<Index>,
+}
+
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
 pub struct IndexedAccess {
     pub obj: Index,
@@ -364,6 +399,17 @@ pub struct Infer {
 #[derive(Debug, Clone, PartialEq, Eq, Hash)]
 pub struct Wildcard {}
 
+// Each unique symbol gets its own `id` when the variable it's declared on is,
+// e.g. `sym` in `declare let sym: unique symbol`, so that symbols declared in
+// different scopes are different even if they have the same name.  `name` is
+// only used when printing.  Well-known symbols are named after where they
+// live, e.g. `Symbol.iterator`.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct UniqueSymbol {
+    pub id: usize,
+    pub name: String,
+}
+
 #[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
 pub enum TBinaryOp {
     Add,
@@ -381,6 +427,14 @@ pub struct BinaryT {
     pub right: Index,
 }
 
+// `parts` always has one more element than `types`, e.g. `a${T}b${U}c` has
+// parts "a", "b", and "c".
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+pub struct TemplateLitType {
+    pub parts: Vec<String>,
+    pub types: Vec<Index>,
+}
+
 #[derive(Debug, Clone, Hash)]
 pub enum TypeKind {
     TypeVar(TypeVar),
@@ -391,7 +445,9 @@ pub enum TypeKind {
     Tuple(Tuple),
     Keyword(Keyword),
     Primitive(Primitive),
+    UniqueSymbol(UniqueSymbol),
     Literal(Lit),
+    TemplateLiteral(TemplateLitType),
     Function(Function),
     Object(Object),
     Rest(Rest), // Why is this its own type?
@@ -401,6 +457,7 @@ pub enum TypeKind {
     Infer(Infer),
     Wildcard,
     Binary(BinaryT),
+    Predicate(Predicate),
 }
 
 #[derive(Debug, Clone)]
@@ -430,6 +487,129 @@ pub struct Scheme {
 /// All type variables have a unique id, but names are
 /// only assigned lazily, when required.
 
+/// Options for printing types, see `Checker::print_options` and
+/// `Checker::print_type_with_options`.  The defaults are the ones used for
+/// error messages, aliases are used and types are never cut short.
+#[derive(Clone, Debug)]
+pub struct PrintOptions {
+    /// Unions and object types are cut short with `... N more` once they're
+    /// wider than this many characters.  Their first member is always printed.
+    pub max_width: Option<usize>,
+    /// Whether to print types that are the same as a non-generic type alias
+    /// using the alias' name, e.g. `User` instead of `{id: string}` when
+    /// there's a `type User = {id: string}`.  The shortest name is used when
+    /// there's more than one.
+    pub use_aliases: bool,
+}
+
+impl Default for PrintOptions {
+    fn default() -> Self {
+        PrintOptions {
+            max_width: None,
+            use_aliases: true,
+        }
+    }
+}
+
+// Only structural types are printed using aliases, otherwise something like
+// `type Name = string` would cause every `string` to be printed as `Name`.
+// Empty objects and tuples are left out for the same reason.
+// Types can only be the same as an alias with the same key so the key is used
+// to look up the aliases that need to be compared with a type.
+#[derive(Clone, Copy, Debug, PartialEq, Eq, Hash)]
+pub enum AliasKey {
+    Union(usize),
+    Intersection(usize),
+    Tuple(usize),
+    Function(usize),
+    Object(usize),
+}
+
+impl AliasKey {
+    pub fn from_kind(kind: &TypeKind) -> Option<AliasKey> {
+        match kind {
+            TypeKind::Union(Union { types }) => Some(AliasKey::Union(types.len())),
+            TypeKind::Intersection(Intersection { types }) => {
+                Some(AliasKey::Intersection(types.len()))
+            }
+            TypeKind::Tuple(Tuple { types, .. }) if !types.is_empty() => {
+                Some(AliasKey::Tuple(types.len()))
+            }
+            TypeKind::Function(func) => Some(AliasKey::Function(func.params.len())),
+            TypeKind::Object(Object { elems, .. }) if !elems.is_empty() => {
+                Some(AliasKey::Object(elems.len()))
+            }
+            _ => None,
+        }
+    }
+}
+
+pub(crate) fn is_aliasable(kind: &TypeKind) -> bool {
+    AliasKey::from_kind(kind).is_some()
+}
+
+/// The non-generic type aliases that types can be printed as, see
+/// `PrintOptions::use_aliases`.
+#[derive(Clone, Debug, Default)]
+pub struct TypeAliases {
+    // Shortest name first, ties are broken alphabetically so that the output
+    // doesn't depend on the order in which aliases were declared.
+    aliases: HashMap<AliasKey, Vec<(String, Index)>>,
+}
+
+impl TypeAliases {
+    pub fn insert(&mut self, name: &str, key: AliasKey, t: Index) {
+        self.remove(name);
+        let aliases = self.aliases.entry(key).or_default();
+        let pos = aliases
+            .iter()
+            .position(|(other, _)| (other.len(), other.as_str()) > (name.len(), name))
+            .unwrap_or(aliases.len());
+        aliases.insert(pos, (name.to_owned(), t));
+    }
+
+    pub fn remove(&mut self, name: &str) {
+        for aliases in self.aliases.values_mut() {
+            aliases.retain(|(other, _)| other != name);
+        }
+    }
+
+    fn get(&self, key: &AliasKey) -> &[(String, Index)] {
+        match self.aliases.get(key) {
+            Some(aliases) => aliases,
+            None => &[],
+        }
+    }
+}
+
+struct Printer<'a> {
+    aliases: Option<&'a TypeAliases>,
+    max_width: Option<usize>,
+}
+
+impl Printer<'_> {
+    // Joins `items` with `sep`, leaving out the items that don't fit within
+    // `max_width`.  `items` is only advanced as far as needed so that the
+    // items that are left out are never printed, `len` is the total number
+    // of items.
+    fn join(&self, items: impl Iterator<Item = String>, len: usize, sep: &str) -> String {
+        let mut result = String::new();
+        for (i, item) in items.enumerate() {
+            if i > 0 {
+                result.push_str(sep);
+                if let Some(max_width) = self.max_width {
+                    if result.len() + item.len() > max_width {
+                        result.push_str(&format!("... {} more", len - i));
+                        break;
+                    }
+                }
+            }
+            result.push_str(&item);
+        }
+        result
+    }
+}
+
 impl Checker {
     pub fn print_scheme(&self, scheme: &Scheme) -> String {
         let mut result = String::default();
@@ -451,21 +631,86 @@ impl Checker {
 
     // TODO: support pretty printing of types
     pub fn print_type(&self, index: &Index) -> String {
+        self.print_type_with_options(index, &self.print_options)
+    }
+
+    /// Prints the type at `index` the way `options` asks for instead of using
+    /// `self.print_options`, e.g. to leave out the end of large unions and
+    /// object types.
+    pub fn print_type_with_options(&self, index: &Index, options: &PrintOptions) -> String {
+        self.print_type_rec(index, &self.printer(options))
+    }
+
+    fn printer(&self, options: &PrintOptions) -> Printer {
+        Printer {
+            aliases: options.use_aliases.then_some(&self.type_aliases),
+            max_width: options.max_width,
+        }
+    }
+
+    /// Adds the type alias `name` to the aliases that are used when printing
+    /// types, generic aliases and aliases of non-structural types are skipped.
+    pub fn add_type_alias(&mut self, name: &str, scheme: &Scheme) {
+        let is_generic = matches!(&scheme.type_params, Some(tps) if !tps.is_empty());
+        match AliasKey::from_kind(&self.arena[scheme.t].kind) {
+            Some(key) if !is_generic => self.type_aliases.insert(name, key, scheme.t),
+            _ => self.type_aliases.remove(name),
+        }
+    }
+
+    fn print_type_rec(&self, index: &Index, printer: &Printer) -> String {
+        if let (Some(aliases), Some(key)) = (
+            printer.aliases,
+            AliasKey::from_kind(&self.arena[*index].kind),
+        ) {
+            let alias = aliases
+                .get(&key)
+                .iter()
+                .find(|(_, t)| t != index && self.equals(t, index));
+            if let Some((name, _)) = alias {
+                return name.to_owned();
+            }
+        }
+
         match &self.arena[*index].kind {
             TypeKind::TypeVar(TypeVar {
                 instance: Some(inst),
                 ..
-            }) => self.print_type(inst),
+            }) => self.print_type_rec(inst, printer),
             TypeKind::TypeVar(TypeVar { id, constraint, .. }) => match constraint {
-                Some(constraint) => format!("t{id}:{}", self.print_type(constraint)),
+                Some(constraint) => format!("t{id}:{}", self.print_type_rec(constraint, printer)),
                 None => format!("t{id}"),
             },
-            TypeKind::Union(Union { types }) => self.print_types(types).join(" | "),
-            TypeKind::Intersection(Intersection { types }) => self.print_types(types).join(" & "),
-            TypeKind::Tuple(Tuple { types }) => {
-                format!("[{}]", self.print_types(types).join(", "))
+            TypeKind::Union(Union { types }) => printer.join(
+                types.iter().map(|t| self.print_type_rec(t, printer)),
+                types.len(),
+                " | ",
+            ),
+            TypeKind::Intersection(Intersection { types }) => types
+                .iter()
+                .map(|t| match &self.arena[*t].kind {
+                    TypeKind::Function(_) => format!("({})", self.print_type_rec(t, printer)),
+                    _ => self.print_type_rec(t, printer),
+                })
+                .collect::<Vec<_>>()
+                .join(" & "),
+            TypeKind::Tuple(Tuple { types, labels }) => {
+                let elems: Vec<String> = self
+                    .print_types(types, printer)
+                    .into_iter()
+                    .zip(labels.iter())
+                    .map(|(t, label)| match label {
+                        // Rest elements are printed as `...label: T`
+                        Some(label) => match t.strip_prefix("...") {
+                            Some(t) => format!("...{label}: {t}"),
+                            None => format!("{label}: {t}"),
+                        },
+                        None => t,
+                    })
+                    .collect();
+                format!("[{}]", elems.join(", "))
             }
-            TypeKind::Array(Array { t }) => format!("{}[]", self.print_type(t)),
+            TypeKind::Array(Array { t }) => format!("{}[]", self.print_type_rec(t, printer)),
             TypeKind::TypeRef(TypeRef {
                 name,
                 scheme: _, // TODO
@@ -474,192 +719,41 @@ impl Checker {
                 if type_args.is_empty() {
                     name.to_string()
                 } else {
-                    format!("{}<{}>", name, self.print_types(type_args).join(", "))
+                    format!(
+                        "{}<{}>",
+                        name,
+                        self.print_types(type_args, printer).join(", ")
+                    )
                 }
             }
             TypeKind::Keyword(keyword) => keyword.to_string(),
             TypeKind::Primitive(primitive) => primitive.to_string(),
             TypeKind::Literal(lit) => lit.to_string(),
-            TypeKind::Object(object) => {
-                let mut fields = vec![];
-                for prop in &object.elems {
-                    match prop {
-                        TObjElem::Getter(TGetter {
-                            ret,
-                            name,
-                            throws: _,
-                        }) => {
-                            let ret_type = self.print_type(ret);
-                            fields.push(format!("get {name}(self) -> {ret_type}"));
-                        }
-                        TObjElem::Setter(TSetter {
-                            param,
-                            name,
-                            throws: _, // TODO
-                        }) => {
-                            let param = self.print_type(&param.t);
-                            fields.push(format!("set {name}(mut self, {param})"))
-                        }
-                        TObjElem::Constructor(Function {
-                            params,
-                            ret,
-                            type_params,
-                            throws: _, // TODO
-                        }) => {
-                            let mut result = "new fn".to_string();
-                            match type_params {
-                                Some(type_params) if !type_params.is_empty() => {
-                                    let type_params = type_params
-                                        .iter()
-                                        .map(|tp| match &tp.constraint {
-                                            Some(constraint) => format!(
-                                                "{}:{}",
-                                                tp.name.clone(),
-                                                self.print_type(constraint)
-                                            ),
-                                            None => tp.name.clone(),
-                                        })
-                                        .collect::<Vec<_>>();
-                                    result.push_str(&format!("<{}>", type_params.join(", ")))
-                                }
-                                _ => (),
-                            };
-                            result.push_str(&format!(
-                                "({}) -> {}",
-                                self.print_params(params).join(", "),
-                                self.print_type(ret)
-                            ));
-                            fields.push(result);
-                        }
-                        TObjElem::Call(Function {
-                            params,
-                            ret,
-                            type_params,
-                            throws: _, // TODO
-                        }) => {
-                            let mut result = "fn".to_string();
-                            match type_params {
-                                Some(type_params) if !type_params.is_empty() => {
-                                    let type_params = type_params
-                                        .iter()
-                                        .map(|tp| match &tp.constraint {
-                                            Some(constraint) => format!(
-                                                "{}:{}",
-                                                tp.name.clone(),
-                                                self.print_type(constraint)
-                                            ),
-                                            None => tp.name.clone(),
-                                        })
-                                        .collect::<Vec<_>>();
-                                    result.push_str(&format!("<{}>", type_params.join(", ")))
-                                }
-                                _ => (),
-                            };
-                            result.push_str(&format!(
-                                "({}) -> {}",
-                                self.print_params(params).join(", "),
-                                self.print_type(ret)
-                            ));
-                            fields.push(result);
-                        }
-                        TObjElem::Mapped(MappedType {
-                            key,
-                            value,
-                            target,
-                            source,
-                            optional: _, // TODO
-                            // TODO: handle `if`-clause
-                            check: _,
-                            extends: _,
-                        }) => {
-                            let key = self.print_type(key);
-                            let value = self.print_type(value);
-                            let source = self.print_type(source);
-
-                            let result = format!("[{key}]: {value} for {target} in {source}",);
-                            fields.push(result);
-                        }
-                        TObjElem::Method(TMethod {
-                            name,
-                            mutates,
-                            function:
-                                Function {
-                                    type_params,
-                                    params,
-                                    ret,
-                                    throws,
-                                },
-                        }) => {
-                            let name = match name {
-                                TPropKey::StringKey(s) => s,
-                                TPropKey::NumberKey(n) => n,
-                            };
-                            let type_params = match type_params {
-                                Some(type_params) if !type_params.is_empty() => {
-                                    let type_params = type_params
-                                        .iter()
-                                        .map(|tp| match &tp.constraint {
-                                            Some(constraint) => {
-                                                format!(
-                                                    "{}:{}",
-                                                    tp.name.clone(),
-                                                    self.print_type(constraint)
-                                                )
-                                            }
-                                            None => tp.name.clone(),
-                                        })
-                                        .collect::<Vec<_>>();
-                                    format!("<{}>", type_params.join(", "))
-                                }
-                                _ => "".to_string(),
-                            };
-
-                            let throws = match throws {
-                                Some(throws) => format!(" throws {}", self.print_type(throws)),
-                                None => "".to_string(),
-                            };
-
-                            let mut params = self.print_params(params);
-                            match mutates {
-                                true => params.insert(0, "mut self".to_string()),
-                                false => params.insert(0, "self".to_string()),
-                            }
-                            let params = params.join(", ");
-
-                            let ret = self.print_type(ret);
-                            let field = format!("{name}{type_params}({params}) -> {ret}{throws}",);
-                            fields.push(field);
-                        }
-                        TObjElem::Prop(TProp {
-                            name,
-                            optional,
-                            readonly,
-                            t,
-                        }) => {
-                            let name = match name {
-                                TPropKey::StringKey(s) => s,
-                                TPropKey::NumberKey(n) => n,
-                            };
-                            let t = self.print_type(t);
-                            let mut str = "".to_string();
-                            if *readonly {
-                                str += "readonly ";
-                            }
-
-                            str += name;
-                            if *optional {
-                                str += "?";
-                            }
-                            str += &format!(": {t}");
-
-                            fields.push(str);
-                        }
-                    }
+            TypeKind::TemplateLiteral(TemplateLitType { parts, types }) => {
+                let mut result = "`".to_string();
+                for (part, t) in parts.iter().zip(types.iter()) {
+                    result.push_str(part);
+                    result.push_str(&format!("${{{}}}", self.print_type_rec(t, printer)));
+                }
+                if let Some(last) = parts.last() {
+                    result.push_str(last);
                 }
-                format!("{{{}}}", fields.join(", "))
+                result.push('`');
+                result
+            }
+            TypeKind::Object(object) => {
+                let fields = printer.join(
+                    object
+                        .elems
+                        .iter()
+                        .map(|elem| self.print_obj_elem(elem, printer)),
+                    object.elems.len(),
+                    ", ",
+                );
+                format!("{{{fields}}}")
             }
             TypeKind::Rest(rest) => {
-                format!("...{}", self.print_type(&rest.arg))
+                format!("...{}", self.print_type_rec(&rest.arg, printer))
             }
             TypeKind::Function(func) => {
                 let type_params = match &func.type_params {
@@ -668,7 +762,11 @@ impl Checker {
                             .iter()
                             .map(|tp| match &tp.constraint {
                                 Some(constraint) => {
-                                    format!("{}:{}", tp.name.clone(), self.print_type(constraint))
+                                    format!(
+                                        "{}:{}",
+                                        tp.name.clone(),
+                                        self.print_type_rec(constraint, printer)
+                                    )
                                 }
                                 None => tp.name.clone(),
                             })
@@ -678,18 +776,22 @@ impl Checker {
                     _ => "".to_string(),
                 };
                 let throws = match func.throws {
-                    Some(throws) => format!(" throws {}", self.print_type(&throws)),
+                    Some(throws) => format!(" throws {}", self.print_type_rec(&throws, printer)),
                     None => "".to_string(),
                 };
                 format!(
                     "{type_params}({}) -> {}{throws}",
-                    self.print_params(&func.params).join(", "),
-                    self.print_type(&func.ret),
+                    self.print_params(&func.params, printer).join(", "),
+                    self.print_type_rec(&func.ret, printer),
                 )
             }
-            TypeKind::KeyOf(KeyOf { t }) => format!("keyof {}", self.print_type(t)),
+            TypeKind::KeyOf(KeyOf { t }) => format!("keyof {}", self.print_type_rec(t, printer)),
             TypeKind::IndexedAccess(IndexedAccess { obj, index }) => {
-                format!("{}[{}]", self.print_type(obj), self.print_type(index))
+                format!(
+                    "{}[{}]",
+                    self.print_type_rec(obj, printer),
+                    self.print_type_rec(index, printer)
+                )
             }
             TypeKind::Conditional(Conditional {
                 check,
@@ -699,14 +801,25 @@ impl Checker {
             }) => {
                 format!(
                     "{} extends {} ? {} : {}",
-                    self.print_type(check),
-                    self.print_type(extends),
-                    self.print_type(true_type),
-                    self.print_type(false_type),
+                    self.print_type_rec(check, printer),
+                    self.print_type_rec(extends, printer),
+                    self.print_type_rec(true_type, printer),
+                    self.print_type_rec(false_type, printer),
                 )
             }
             TypeKind::Infer(Infer { name }) => format!("infer {}", name),
             TypeKind::Wildcard => "_".to_string(),
+            TypeKind::UniqueSymbol(_) => "unique symbol".to_string(),
+            TypeKind::Predicate(Predicate { asserts, param, t }) => {
+                let asserts = match asserts {
+                    true => "asserts ",
+                    false => "",
+                };
+                match t {
+                    Some(t) => format!("{asserts}{param} is {}", self.print_type_rec(t, printer)),
+                    None => format!("{asserts}{param}"),
+                }
+            }
             TypeKind::Binary(BinaryT { op, left, right }) => {
                 let op = match op {
                     TBinaryOp::Add => "+",
@@ -717,35 +830,226 @@ impl Checker {
                 };
                 format!(
                     "{} {} {}",
-                    self.print_type(left),
+                    self.print_type_rec(left, printer),
                     op,
-                    self.print_type(right),
+                    self.print_type_rec(right, printer),
                 )
             }
         }
     }
 
-    fn print_types(&self, indexes: &[Index]) -> Vec<String> {
+    fn print_obj_elem(&self, elem: &TObjElem, printer: &Printer) -> String {
+        match elem {
+            TObjElem::Getter(TGetter {
+                ret,
+                name,
+                throws: _,
+            }) => {
+                let ret_type = self.print_type_rec(ret, printer);
+                format!("get {name}(self) -> {ret_type}")
+            }
+            TObjElem::Setter(TSetter {
+                param,
+                name,
+                throws: _, // TODO
+            }) => {
+                let param = self.print_type_rec(&param.t, printer);
+                format!("set {name}(mut self, {param})")
+            }
+            TObjElem::Constructor(Function {
+                params,
+                ret,
+                type_params,
+                throws: _, // TODO
+            }) => {
+                let mut result = "new fn".to_string();
+                match type_params {
+                    Some(type_params) if !type_params.is_empty() => {
+                        let type_params = type_params
+                            .iter()
+                            .map(|tp| match &tp.constraint {
+                                Some(constraint) => format!(
+                                    "{}:{}",
+                                    tp.name.clone(),
+                                    self.print_type_rec(constraint, printer)
+                                ),
+                                None => tp.name.clone(),
+                            })
+                            .collect::<Vec<_>>();
+                        result.push_str(&format!("<{}>", type_params.join(", ")))
+                    }
+                    _ => (),
+                };
+                result.push_str(&format!(
+                    "({}) -> {}",
+                    self.print_params(params, printer).join(", "),
+                    self.print_type_rec(ret, printer)
+                ));
+                result
+            }
+            TObjElem::Call(Function {
+                params,
+                ret,
+                type_params,
+                throws: _, // TODO
+            }) => {
+                let mut result = "fn".to_string();
+                match type_params {
+                    Some(type_params) if !type_params.is_empty() => {
+                        let type_params = type_params
+                            .iter()
+                            .map(|tp| match &tp.constraint {
+                                Some(constraint) => format!(
+                                    "{}:{}",
+                                    tp.name.clone(),
+                                    self.print_type_rec(constraint, printer)
+                                ),
+                                None => tp.name.clone(),
+                            })
+                            .collect::<Vec<_>>();
+                        result.push_str(&format!("<{}>", type_params.join(", ")))
+                    }
+                    _ => (),
+                };
+                result.push_str(&format!(
+                    "({}) -> {}",
+                    self.print_params(params, printer).join(", "),
+                    self.print_type_rec(ret, printer)
+                ));
+                result
+            }
+            TObjElem::Mapped(MappedType {
+                key,
+                value,
+                target,
+                source,
+                optional,
+                readonly,
+                // TODO: handle `if`-clause
+                check: _,
+                extends: _,
+            }) => {
+                let key = self.print_type_rec(key, printer);
+                let value = self.print_type_rec(value, printer);
+                let source = self.print_type_rec(source, printer);
+
+                let readonly = match readonly {
+                    Some(MappedModifier::Add) => "+readonly ",
+                    Some(MappedModifier::Remove) => "-readonly ",
+                    None => "",
+                };
+                let optional = match optional {
+                    Some(MappedModifier::Add) => "+?",
+                    Some(MappedModifier::Remove) => "-?",
+                    None => "",
+                };
+
+                let result =
+                    format!("{readonly}[{key}]{optional}: {value} for {target} in {source}",);
+                result
+            }
+            TObjElem::Method(TMethod {
+                name,
+                mutates,
+                is_abstract,
+                function:
+                    Function {
+                        type_params,
+                        params,
+                        ret,
+                        throws,
+                    },
+            }) => {
+                let name = name.to_string();
+                let type_params = match type_params {
+                    Some(type_params) if !type_params.is_empty() => {
+                        let type_params = type_params
+                            .iter()
+                            .map(|tp| match &tp.constraint {
+                                Some(constraint) => {
+                                    format!(
+                                        "{}:{}",
+                                        tp.name.clone(),
+                                        self.print_type_rec(constraint, printer)
+                                    )
+                                }
+                                None => tp.name.clone(),
+                            })
+                            .collect::<Vec<_>>();
+                        format!("<{}>", type_params.join(", "))
+                    }
+                    _ => "".to_string(),
+                };
+
+                let throws = match throws {
+                    Some(throws) => format!(" throws {}", self.print_type_rec(throws, printer)),
+                    None => "".to_string(),
+                };
+
+                let mut params = self.print_params(params, printer);
+                match mutates {
+                    true => params.insert(0, "mut self".to_string()),
+                    false => params.insert(0, "self".to_string()),
+                }
+                let params = params.join(", ");
+
+                let ret = self.print_type_rec(ret, printer);
+                let modifier = match is_abstract {
+                    true => "abstract ",
+                    false => "",
+                };
+                let field = format!("{modifier}{name}{type_params}({params}) -> {ret}{throws}",);
+                field
+            }
+            TObjElem::Prop(TProp {
+                name,
+                optional,
+                readonly,
+                t,
+            }) => {
+                let name = name.to_string();
+                let t = self.print_type_rec(t, printer);
+                let mut str = "".to_string();
+                if *readonly {
+                    str += "readonly ";
+                }
+
+                str += &name;
+                if *optional {
+                    str += "?";
+                }
+                str += &format!(": {t}");
+
+                str
+            }
+        }
+    }
+
+    fn print_types(&self, indexes: &[Index], printer: &Printer) -> Vec<String> {
         let mut result = vec![];
         for index in indexes {
-            result.push(self.print_type(index));
+            result.push(self.print_type_rec(index, printer));
         }
         result
     }
 
-    fn print_params(&self, params: &[FuncParam]) -> Vec<String> {
+    fn print_params(&self, params: &[FuncParam], printer: &Printer) -> Vec<String> {
         let mut strings = vec![];
         for param in params {
-            strings.push(self.print_param(param))
+            strings.push(self.print_param_rec(param, printer))
         }
         strings
     }
 
-    fn print_param(&self, param: &FuncParam) -> String {
+    pub fn print_param(&self, param: &FuncParam) -> String {
+        self.print_param_rec(param, &self.printer(&self.print_options))
+    }
+
+    fn print_param_rec(&self, param: &FuncParam, printer: &Printer) -> String {
         let name = Self::tpat_to_string(&param.pattern);
         match param.optional {
-            true => format!("{name}?: {}", self.print_type(&param.t)),
-            false => format!("{name}: {}", self.print_type(&param.t)),
+            true => format!("{name}?: {}", self.print_type_rec(&param.t, printer)),
+            false => format!("{name}: {}", self.print_type_rec(&param.t, printer)),
         }
     }
 
@@ -804,6 +1108,20 @@ impl Checker {
                 (None, Some(b_inst)) => self.equals(a, &b_inst),
                 (None, None) => v1.id == v2.id,
             },
+            (
+                TypeKind::TypeVar(TypeVar {
+                    instance: Some(a_inst),
+                    ..
+                }),
+                _,
+            ) => self.equals(a_inst, b),
+            (
+                _,
+                TypeKind::TypeVar(TypeVar {
+                    instance: Some(b_inst),
+                    ..
+                }),
+            ) => self.equals(a, b_inst),
             (TypeKind::TypeRef(c1), TypeKind::TypeRef(c2)) => {
                 c1.name == c2.name && self.types_equal(&c1.type_args, &c2.type_args)
             }
@@ -820,7 +1138,6 @@ impl Checker {
             (TypeKind::Primitive(prim1), TypeKind::Primitive(prim2)) => prim1 == prim2,
             (TypeKind::Literal(l1), TypeKind::Literal(l2)) => l1 == l2,
             (TypeKind::Function(f1), TypeKind::Function(f2)) => {
-                eprintln!("checking function types");
                 f1.params.len() == f2.params.len()
                     && f1
                         .params
@@ -857,7 +1174,12 @@ impl Checker {
                 // TODO: compare key types as well
                 self.equals(&map1.value, &map2.value)
             }
-            (TObjElem::Prop(p1), TObjElem::Prop(p2)) => self.equals(&p1.t, &p2.t),
+            (TObjElem::Prop(p1), TObjElem::Prop(p2)) => {
+                p1.name == p2.name
+                    && p1.optional == p2.optional
+                    && p1.readonly == p2.readonly
+                    && self.equals(&p1.t, &p2.t)
+            }
             _ => false,
         }
     }
@@ -904,12 +1226,21 @@ impl Checker {
     pub fn new_tuple_type(&mut self, types: &[Index]) -> Index {
         self.arena.insert(Type::from(TypeKind::Tuple(Tuple {
             types: types.to_owned(),
+            labels: vec![None; types.len()],
+        })))
+    }
+
+    pub fn new_labeled_tuple_type(&mut self, types: &[Index], labels: &[Option<String>]) -> Index {
+        self.arena.insert(Type::from(TypeKind::Tuple(Tuple {
+            types: types.to_owned(),
+            labels: labels.to_owned(),
         })))
     }
 
     pub fn new_object_type(&mut self, elems: &[TObjElem]) -> Index {
         self.arena.insert(Type::from(TypeKind::Object(Object {
             elems: elems.to_vec(),
+            class: None,
         })))
     }
 
@@ -952,6 +1283,14 @@ impl Checker {
             .insert(Type::from(TypeKind::Literal(lit.clone())))
     }
 
+    pub fn new_template_lit_type(&mut self, parts: &[String], types: &[Index]) -> Index {
+        self.arena
+            .insert(Type::from(TypeKind::TemplateLiteral(TemplateLitType {
+                parts: parts.to_owned(),
+                types: types.to_owned(),
+            })))
+    }
+
     pub fn new_keyof_type(&mut self, t: Index) -> Index {
         self.arena.insert(Type::from(TypeKind::KeyOf(KeyOf { t })))
     }
@@ -986,10 +1325,41 @@ impl Checker {
         })))
     }
 
+    pub fn new_predicate_type(&mut self, asserts: bool, param: &str, t: Option<Index>) -> Index {
+        self.arena.insert(Type::from(TypeKind::Predicate(Predicate {
+            asserts,
+            param: param.to_string(),
+            t,
+        })))
+    }
+
     pub fn new_wildcard_type(&mut self) -> Index {
         self.arena.insert(Type::from(TypeKind::Wildcard))
     }
 
+    pub fn new_unique_symbol_type(&mut self, name: &str) -> Index {
+        let id = self.new_id();
+        self.arena
+            .insert(Type::from(TypeKind::UniqueSymbol(UniqueSymbol {
+                id,
+                name: name.to_owned(),
+            })))
+    }
+
+    // Well-known symbols are the same symbol everywhere they're used.
+    pub fn well_known_symbol(&mut self, name: &str) -> UniqueSymbol {
+        let name = format!("Symbol.{name}");
+        let id = match self.well_known_symbols.get(&name) {
+            Some(id) => *id,
+            None => {
+                let id = self.new_id();
+                self.well_known_symbols.insert(name.to_owned(), id);
+                id
+            }
+        };
+        UniqueSymbol { id, name }
+    }
+
     pub fn from_type_kind(&mut self, kind: TypeKind) -> Index {
         self.arena.insert(Type::from(kind))
     }
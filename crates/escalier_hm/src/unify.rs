@@ -4,11 +4,11 @@ use itertools::Itertools;
 use std::collections::{BTreeSet, HashMap};
 use std::mem::transmute;
 
-use escalier_ast::{BindingIdent, Expr, Literal as Lit, Span};
+use escalier_ast::{BindingIdent, Expr, ExprKind, ExprOrSpread, Literal as Lit, Span};
 
 use crate::checker::Checker;
 use crate::context::*;
-use crate::diagnostic::Diagnostic;
+use crate::diagnostic::{codes, Diagnostic, Severity};
 use crate::infer::check_mutability;
 use crate::type_error::TypeError;
 use crate::types::*;
@@ -28,6 +28,25 @@ impl Checker {
     /// Raises:
     ///     InferenceError: Raised if the types cannot be unified.
     pub fn unify(&mut self, ctx: &Context, t1: Index, t2: Index) -> Result<(), TypeError> {
+        self.unify_rec(ctx, t1, t2)
+            .map_err(|error| self.report_unify_error(error))
+    }
+
+    /// Returns whether `t1` is a subtype of `t2`.  The type variables that
+    /// are bound while unifying them stay bound if they're unifiable and are
+    /// unbound otherwise, so a failed check doesn't affect either type.
+    pub fn try_unify(&mut self, ctx: &Context, t1: Index, t2: Index) -> bool {
+        let arena = self.arena.clone();
+        let is_ok = self.unify_rec(ctx, t1, t2).is_ok();
+        if !is_ok {
+            self.arena = arena;
+        }
+        is_ok
+    }
+
+    // Unifies the elements of tuples and the members of objects using
+    // `unify_rec` so that errors keep track of where they were found.
+    fn unify_rec(&mut self, ctx: &Context, t1: Index, t2: Index) -> Result<(), UnifyError> {
         let a = self.prune(t1);
         let b = self.prune(t2);
 
@@ -37,24 +56,22 @@ impl Checker {
         let b_t = self.arena[b].clone();
 
         match (&a_t.kind, &b_t.kind) {
-            (TypeKind::TypeVar(_), _) => self.bind(ctx, a, b),
-            (_, TypeKind::TypeVar(_)) => self.bind(ctx, b, a),
+            (TypeKind::TypeVar(_), _) => Ok(self.bind(ctx, a, b)?),
+            (_, TypeKind::TypeVar(_)) => Ok(self.bind(ctx, b, a)?),
 
             // Wildcards are always unifiable
             (TypeKind::Wildcard, _) => Ok(()),
             (_, TypeKind::Wildcard) => Ok(()),
 
+            // `never` is assignable to every type since values of type `never`
+            // can't exist, e.g. the result of a call that always throws.
+            (TypeKind::Keyword(Keyword::Never), _) => Ok(()),
+
             (TypeKind::Keyword(kw1), TypeKind::Keyword(kw2)) => {
                 if kw1 == kw2 {
                     Ok(())
                 } else {
-                    Err(TypeError {
-                        message: format!(
-                            "type mismatch: {} != {}",
-                            self.print_type(&a),
-                            self.print_type(&b),
-                        ),
-                    })
+                    Err(UnifyError::mismatch(a, b, Mismatch::NotEqual))
                 }
             }
 
@@ -63,10 +80,31 @@ impl Checker {
                 Ok(())
             }
 
+            (TypeKind::Literal(Lit::Undefined), TypeKind::Keyword(Keyword::Void)) => Ok(()),
+
+            // Type guards can be used anywhere a function returning `boolean`
+            // is expected and assertions anywhere a function returning `void`
+            // is expected, but not the other way around.
+            (TypeKind::Predicate(p1), TypeKind::Predicate(p2))
+                if p1.asserts == p2.asserts && p1.t.is_some() == p2.t.is_some() =>
+            {
+                match (p1.t, p2.t) {
+                    (Some(t1), Some(t2)) => self.unify_rec(ctx, t1, t2),
+                    _ => Ok(()),
+                }
+            }
+            (TypeKind::Predicate(Predicate { asserts, .. }), _) => {
+                let t = match asserts {
+                    true => self.new_keyword(Keyword::Void),
+                    false => self.new_primitive(Primitive::Boolean),
+                };
+                self.unify_rec(ctx, t, b)
+            }
+
             (TypeKind::Union(union), _) => {
                 // All types in the union must be subtypes of t2
                 for t in union.types.iter() {
-                    self.unify(ctx, *t, b)?;
+                    self.unify_rec(ctx, *t, b)?;
                 }
                 Ok(())
             }
@@ -74,18 +112,12 @@ impl Checker {
                 // If t1 is a subtype of any of the types in the union, then it is a
                 // subtype of the union.
                 for t2 in union.types.iter() {
-                    if self.unify(ctx, a, *t2).is_ok() {
+                    if self.unify_rec(ctx, a, *t2).is_ok() {
                         return Ok(());
                     }
                 }
 
-                Err(TypeError {
-                    message: format!(
-                        "type mismatch: unify({}, {}) failed",
-                        self.print_type(&a),
-                        self.print_type(&b),
-                    ),
-                })
+                Err(UnifyError::mismatch(a, b, Mismatch::Failed))
             }
             (TypeKind::Tuple(tuple1), TypeKind::Tuple(tuple2)) => {
                 'outer: {
@@ -104,7 +136,8 @@ impl Checker {
                                 tuple2.types.len(),
                                 tuple1.types.len()
                             ),
-                        });
+                        }
+                        .into());
                     }
                 }
 
@@ -114,17 +147,20 @@ impl Checker {
                         (TypeKind::Rest(_), TypeKind::Rest(_)) => {
                             return Err(TypeError {
                                 message: "Can't unify two rest elements".to_string(),
-                            })
+                            }
+                            .into())
                         }
                         (TypeKind::Rest(_), _) => {
                             let rest_q = self.new_tuple_type(&tuple2.types[i..]);
-                            self.unify(ctx, *p, rest_q)?;
+                            self.unify_rec(ctx, *p, rest_q)?;
                         }
                         (_, TypeKind::Rest(_)) => {
                             let rest_p = self.new_tuple_type(&tuple1.types[i..]);
-                            self.unify(ctx, rest_p, *q)?;
+                            self.unify_rec(ctx, rest_p, *q)?;
                         }
-                        (_, _) => self.unify(ctx, *p, *q)?,
+                        (_, _) => self
+                            .unify_rec(ctx, *p, *q)
+                            .map_err(|error| error.within(PathKey::Index(i)))?,
                     }
                 }
                 Ok(())
@@ -135,14 +171,14 @@ impl Checker {
                 let mut types = vec![];
                 for t in &tuple.types {
                     match &self.arena[*t].kind {
-                        TypeKind::Rest(Rest { arg }) => self.unify(ctx, *arg, t2)?,
+                        TypeKind::Rest(Rest { arg }) => self.unify_rec(ctx, *arg, t2)?,
                         _ => types.push(*t),
                     }
                 }
 
                 if !types.is_empty() {
                     let p = self.new_union_type(&types);
-                    self.unify(ctx, p, array.t)?;
+                    self.unify_rec(ctx, p, array.t)?;
                 }
 
                 Ok(())
@@ -154,35 +190,33 @@ impl Checker {
                     let p_or_undefined = self.new_union_type(&[p, undefined]);
 
                     match &self.arena[*q].kind {
-                        TypeKind::Rest(_) => self.unify(ctx, a, *q)?,
-                        _ => self.unify(ctx, p_or_undefined, *q)?,
+                        TypeKind::Rest(_) => self.unify_rec(ctx, a, *q)?,
+                        _ => self.unify_rec(ctx, p_or_undefined, *q)?,
                     }
                 }
                 Ok(())
             }
-            (TypeKind::Rest(rest), TypeKind::Array(_)) => self.unify(ctx, rest.arg, b),
-            (TypeKind::Rest(rest), TypeKind::Tuple(_)) => self.unify(ctx, rest.arg, b),
-            (TypeKind::Array(_), TypeKind::Rest(rest)) => self.unify(ctx, a, rest.arg),
-            (TypeKind::Tuple(_), TypeKind::Rest(rest)) => self.unify(ctx, a, rest.arg),
+            (TypeKind::Rest(rest), TypeKind::Array(_)) => self.unify_rec(ctx, rest.arg, b),
+            (TypeKind::Rest(rest), TypeKind::Tuple(_)) => self.unify_rec(ctx, rest.arg, b),
+            (TypeKind::Array(_), TypeKind::Rest(rest)) => self.unify_rec(ctx, a, rest.arg),
+            (TypeKind::Tuple(_), TypeKind::Rest(rest)) => self.unify_rec(ctx, a, rest.arg),
             (TypeKind::Array(array_a), TypeKind::Array(array_b)) => {
-                self.unify(ctx, array_a.t, array_b.t)
+                self.unify_rec(ctx, array_a.t, array_b.t)
             }
             (TypeKind::TypeRef(con_a), TypeKind::TypeRef(con_b)) => {
                 // TODO: support type constructors with optional and default type params
                 if con_a.name != con_b.name || con_a.type_args.len() != con_b.type_args.len() {
-                    return Err(TypeError {
-                        message: format!(
-                            "type mismatch: {} != {}",
-                            self.print_type(&a),
-                            self.print_type(&b),
-                        ),
-                    });
+                    return Err(UnifyError::mismatch(a, b, Mismatch::NotEqual));
                 }
                 for (p, q) in con_a.type_args.iter().zip(con_b.type_args.iter()) {
-                    self.unify(ctx, *p, *q)?;
+                    self.unify_rec(ctx, *p, *q)?;
                 }
                 Ok(())
             }
+            (TypeKind::IndexedAccess(access_a), TypeKind::IndexedAccess(access_b)) => {
+                self.unify_rec(ctx, access_a.obj, access_b.obj)?;
+                self.unify_rec(ctx, access_a.index, access_b.index)
+            }
             (TypeKind::Function(func_a), TypeKind::Function(func_b)) => {
                 // Is this the right place to instantiate the function types?
                 let func_a = self.instantiate_func(func_a, None)?;
@@ -211,7 +245,8 @@ impl Checker {
                         if rest_a.is_some() {
                             return Err(TypeError {
                                 message: "multiple rest params in function".to_string(),
-                            });
+                            }
+                            .into());
                         }
                         rest_a = Some((rest, param.t));
                     }
@@ -222,7 +257,8 @@ impl Checker {
                         if rest_b.is_some() {
                             return Err(TypeError {
                                 message: "multiple rest params in function".to_string(),
-                            });
+                            }
+                            .into());
                         }
                         rest_b = Some((rest, param.t));
                     }
@@ -241,7 +277,7 @@ impl Checker {
                             // NOTE: We reverse the order of the params here because func_a
                             // should be able to accept any params that func_b can accept,
                             // its params may be more lenient.
-                            self.unify(ctx, q.t, p.t)?;
+                            self.unify_rec(ctx, q.t, p.t)?;
                         }
 
                         let mut remaining_args_a = vec![];
@@ -263,7 +299,8 @@ impl Checker {
                                                 "rest param must be an array or tuple, got {}",
                                                 self.print_type(&p.t)
                                             ),
-                                        });
+                                        }
+                                        .into());
                                     }
                                 },
                                 _ => p.t,
@@ -277,20 +314,14 @@ impl Checker {
                         // NOTE: We reverse the order of the params here because func_a
                         // should be able to accept any params that func_b can accept,
                         // its params may be more lenient.
-                        self.unify(ctx, rest_b.1, remaining_args_a)?;
+                        self.unify_rec(ctx, rest_b.1, remaining_args_a)?;
 
-                        self.unify(ctx, func_a.ret, func_b.ret)?;
+                        self.unify_return_types(ctx, func_a.ret, func_b.ret)?;
 
                         return Ok(());
                     }
 
-                    return Err(TypeError {
-                        message: format!(
-                            "{} is not a subtype of {} since it requires more params",
-                            self.print_type(&a),
-                            self.print_type(&b),
-                        ),
-                    });
+                    return Err(UnifyError::mismatch(a, b, Mismatch::MoreParams));
                 }
 
                 for i in 0..min_params_a {
@@ -299,7 +330,7 @@ impl Checker {
                     // NOTE: We reverse the order of the params here because func_a
                     // should be able to accept any params that func_b can accept,
                     // its params may be more lenient.
-                    self.unify(ctx, q.t, p.t)?;
+                    self.unify_rec(ctx, q.t, p.t)?;
                 }
 
                 if let Some(rest_a) = rest_a {
@@ -307,24 +338,24 @@ impl Checker {
                         // NOTE: We reverse the order of the params here because func_a
                         // should be able to accept any params that func_b can accept,
                         // its params may be more lenient.
-                        self.unify(ctx, q.t, rest_a.1)?;
+                        self.unify_rec(ctx, q.t, rest_a.1)?;
                     }
 
                     if let Some(rest_b) = rest_b {
                         // NOTE: We reverse the order of the params here because func_a
                         // should be able to accept any params that func_b can accept,
                         // its params may be more lenient.
-                        self.unify(ctx, rest_b.1, rest_a.1)?;
+                        self.unify_rec(ctx, rest_b.1, rest_a.1)?;
                     }
                 }
 
-                self.unify(ctx, func_a.ret, func_b.ret)?;
+                self.unify_return_types(ctx, func_a.ret, func_b.ret)?;
 
                 let never = self.new_keyword(Keyword::Never);
                 let throws_a = func_a.throws.unwrap_or(never);
                 let throws_b = func_b.throws.unwrap_or(never);
 
-                self.unify(ctx, throws_a, throws_b)?;
+                self.unify_rec(ctx, throws_a, throws_b)?;
 
                 Ok(())
             }
@@ -338,31 +369,22 @@ impl Checker {
                     _ => false,
                 };
                 if !equal {
-                    return Err(TypeError {
-                        message: format!(
-                            "type mismatch: {} != {}",
-                            self.print_type(&a),
-                            self.print_type(&b),
-                        ),
-                    });
+                    return Err(UnifyError::mismatch(a, b, Mismatch::NotEqual));
                 }
                 Ok(())
             }
             (TypeKind::Literal(Lit::Number(_)), TypeKind::Primitive(Primitive::Number)) => Ok(()),
             (TypeKind::Literal(Lit::String(_)), TypeKind::Primitive(Primitive::String)) => Ok(()),
             (TypeKind::Literal(Lit::Boolean(_)), TypeKind::Primitive(Primitive::Boolean)) => Ok(()),
+            (TypeKind::TemplateLiteral(_), TypeKind::Primitive(Primitive::String)) => Ok(()),
+            (TypeKind::UniqueSymbol(_), TypeKind::Primitive(Primitive::Symbol)) => Ok(()),
+            (TypeKind::UniqueSymbol(sym1), TypeKind::UniqueSymbol(sym2)) if sym1 == sym2 => Ok(()),
             (TypeKind::Primitive(prim1), TypeKind::Primitive(prim2)) => match (prim1, prim2) {
                 (Primitive::Number, Primitive::Number) => Ok(()),
                 (Primitive::String, Primitive::String) => Ok(()),
                 (Primitive::Boolean, Primitive::Boolean) => Ok(()),
                 (Primitive::Symbol, Primitive::Symbol) => Ok(()),
-                _ => Err(TypeError {
-                    message: format!(
-                        "type mismatch: {} != {}",
-                        self.print_type(&a),
-                        self.print_type(&b),
-                    ),
-                }),
+                _ => Err(UnifyError::mismatch(a, b, Mismatch::NotEqual)),
             },
             (TypeKind::Object(object1), TypeKind::Object(object2)) => {
                 // object1 must have atleast as the same properties as object2
@@ -411,9 +433,9 @@ impl Checker {
                                 function.throws,
                             );
                             Some((
-                                name.to_string(),
+                                name.to_owned(),
                                 TProp {
-                                    name: TPropKey::StringKey(name.to_string()),
+                                    name: name.to_owned(),
                                     t: func_type,
                                     optional: false,
                                     readonly: false,
@@ -421,7 +443,7 @@ impl Checker {
                             ))
                         }
                         TObjElem::Getter(getter) => Some((
-                            getter.name.to_string(),
+                            getter.name.to_owned(),
                             TProp {
                                 name: getter.name.to_owned(),
                                 t: getter.ret,
@@ -432,12 +454,14 @@ impl Checker {
                         TObjElem::Setter(_) => None, // TODO
                         TObjElem::Prop(prop) => {
                             // TODO: handle getters/setters properly
-                            Some((prop.name.to_string(), prop.to_owned()))
+                            Some((prop.name.to_owned(), prop.to_owned()))
                         }
                     })
                     .collect();
 
-                let named_props_2: HashMap<_, _> = object2
+                // The props are kept in the order they're declared so that
+                // missing props can be reported in that order.
+                let ordered_props_2: Vec<(TPropKey, TProp)> = object2
                     .elems
                     .iter()
                     .filter_map(|elem| match elem {
@@ -452,9 +476,9 @@ impl Checker {
                                 function.throws,
                             );
                             Some((
-                                name.to_string(),
+                                name.to_owned(),
                                 TProp {
-                                    name: TPropKey::StringKey(name.to_string()),
+                                    name: name.to_owned(),
                                     t: func_type,
                                     optional: false,
                                     readonly: false,
@@ -462,7 +486,7 @@ impl Checker {
                             ))
                         }
                         TObjElem::Getter(getter) => Some((
-                            getter.name.to_string(),
+                            getter.name.to_owned(),
                             TProp {
                                 name: getter.name.to_owned(),
                                 t: getter.ret,
@@ -473,34 +497,35 @@ impl Checker {
                         TObjElem::Setter(_) => None, // TODO
                         TObjElem::Prop(prop) => {
                             // TODO: handle getters/setters properly
-                            Some((prop.name.to_string(), prop.to_owned()))
+                            Some((prop.name.to_owned(), prop.to_owned()))
                         }
                     })
                     .collect();
+                let named_props_2: HashMap<_, _> = ordered_props_2.iter().cloned().collect();
 
                 // object1 must have at least as the same named elements as object2
                 // TODO: handle the case where object1 has an indexer that covers
                 // some of the named elements of object2
-                for (name, prop_2) in &named_props_2 {
-                    match named_props_1.get(name) {
-                        Some(prop_1) => {
-                            let t1 = prop_1.get_type(self);
-                            let t2 = prop_2.get_type(self);
-                            self.unify(ctx, t1, t2)?;
-                        }
-                        None => {
-                            if prop_2.optional {
-                                continue;
-                            }
+                let missing_props = ordered_props_2
+                    .iter()
+                    .filter(|(name, prop_2)| !prop_2.optional && !named_props_1.contains_key(name))
+                    .map(|(name, _)| format!("'{name}'"))
+                    .unique()
+                    .collect_vec();
+                if !missing_props.is_empty() {
+                    return Err(UnifyError::mismatch(
+                        a,
+                        b,
+                        Mismatch::MissingProps(missing_props),
+                    ));
+                }
 
-                            return Err(TypeError {
-                                message: format!(
-                                    "'{}' is missing in {}",
-                                    name,
-                                    self.print_type(&a),
-                                ),
-                            });
-                        }
+                for (name, prop_2) in &named_props_2 {
+                    if let Some(prop_1) = named_props_1.get(name) {
+                        let t1 = prop_1.get_type(self);
+                        let t2 = prop_2.get_type(self);
+                        self.unify_rec(ctx, t1, t2)
+                            .map_err(|error| error.within(PathKey::Prop(name.to_owned())))?;
                     }
                 }
 
@@ -527,15 +552,17 @@ impl Checker {
                     1 => {
                         match mapped_1.len() {
                             0 => {
-                                for (_, prop_1) in named_props_1 {
+                                for (name, prop_1) in named_props_1 {
                                     let undefined = self.new_lit_type(&Lit::Undefined);
                                     let t1 = prop_1.get_type(self);
                                     let t2 = self.new_union_type(&[mapped_2[0].value, undefined]);
-                                    self.unify(ctx, t1, t2)?;
+                                    self.unify_rec(ctx, t1, t2).map_err(|error| {
+                                        error.within(PathKey::Prop(name.to_owned()))
+                                    })?;
                                 }
                             }
                             1 => {
-                                self.unify(ctx, mapped_1[0].value, mapped_2[0].value)?;
+                                self.unify_rec(ctx, mapped_1[0].value, mapped_2[0].value)?;
                                 // NOTE: the order is reverse here because object1
                                 // has to have at least the same keys as object2,
                                 // but it can have more.
@@ -553,7 +580,7 @@ impl Checker {
                                 let mapped_2_key =
                                     self.instantiate_type(&mapped_2[0].key, &mapping);
 
-                                self.unify(ctx, mapped_2_key, mapped_1_key)?;
+                                self.unify_rec(ctx, mapped_2_key, mapped_1_key)?;
                             }
                             _ => {
                                 return Err(TypeError {
@@ -561,14 +588,16 @@ impl Checker {
                                         "{} has multiple indexers",
                                         self.print_type(&a),
                                     ),
-                                })
+                                }
+                                .into())
                             }
                         }
                     }
                     _ => {
                         return Err(TypeError {
                             message: format!("{} has multiple indexers", self.print_type(&b),),
-                        })
+                        }
+                        .into())
                     }
                 }
 
@@ -596,7 +625,7 @@ impl Checker {
                 let obj_type = simplify_intersection(self, &obj_types);
 
                 match rest_types.len() {
-                    0 => self.unify(ctx, t1, obj_type),
+                    0 => self.unify_rec(ctx, t1, obj_type),
                     1 => {
                         let all_obj_elems = match &self.arena[obj_type].kind {
                             TypeKind::Object(obj) => obj.elems.to_owned(),
@@ -614,16 +643,17 @@ impl Checker {
                             });
 
                         let new_obj_type = self.new_object_type(&obj_elems);
-                        self.unify(ctx, new_obj_type, obj_type)?;
+                        self.unify_rec(ctx, new_obj_type, obj_type)?;
 
                         let new_rest_type = self.new_object_type(&rest_elems);
-                        self.unify(ctx, new_rest_type, rest_types[0])?;
+                        self.unify_rec(ctx, new_rest_type, rest_types[0])?;
 
                         Ok(())
                     }
                     _ => Err(TypeError {
                         message: "Inference is undecidable".to_string(),
-                    }),
+                    }
+                    .into()),
                 }
             }
             (TypeKind::Intersection(intersection), TypeKind::Object(object2)) => {
@@ -643,7 +673,7 @@ impl Checker {
                 let obj_type = simplify_intersection(self, &obj_types);
 
                 match rest_types.len() {
-                    0 => self.unify(ctx, t1, obj_type),
+                    0 => self.unify_rec(ctx, t1, obj_type),
                     1 => {
                         let all_obj_elems = match &self.arena[obj_type].kind {
                             TypeKind::Object(obj) => obj.elems.to_owned(),
@@ -661,16 +691,17 @@ impl Checker {
                             });
 
                         let new_obj_type = self.new_object_type(&obj_elems);
-                        self.unify(ctx, obj_type, new_obj_type)?;
+                        self.unify_rec(ctx, obj_type, new_obj_type)?;
 
                         let new_rest_type = self.new_object_type(&rest_elems);
-                        self.unify(ctx, rest_types[0], new_rest_type)?;
+                        self.unify_rec(ctx, rest_types[0], new_rest_type)?;
 
                         Ok(())
                     }
                     _ => Err(TypeError {
                         message: "Inference is undecidable".to_string(),
-                    }),
+                    }
+                    .into()),
                 }
             }
             _ => {
@@ -678,16 +709,17 @@ impl Checker {
                 let expanded_b = self.expand(ctx, b)?;
 
                 if expanded_a != a || expanded_b != b {
-                    return self.unify(ctx, expanded_a, expanded_b);
+                    return self
+                        .unify_rec(ctx, expanded_a, expanded_b)
+                        .map_err(|error| {
+                            error.unexpand(
+                                self.alias_expansion(a, expanded_a),
+                                self.alias_expansion(b, expanded_b),
+                            )
+                        });
                 }
 
-                Err(TypeError {
-                    message: format!(
-                        "type mismatch: unify({}, {}) failed",
-                        self.print_type(&a),
-                        self.print_type(&b),
-                    ),
-                })
+                Err(UnifyError::mismatch(a, b, Mismatch::Failed))
             }
         }
     }
@@ -713,12 +745,24 @@ impl Checker {
         }
     }
 
+    // Functions returning any type are subtypes of functions returning `void`
+    // since callers of the latter ignore the return value, e.g. the callback
+    // passed to `forEach` can return a value.
+    fn unify_return_types(&mut self, ctx: &Context, t1: Index, t2: Index) -> Result<(), TypeError> {
+        let t2 = self.prune(t2);
+        match &self.arena[t2].kind {
+            TypeKind::Keyword(Keyword::Void) => Ok(()),
+            _ => self.unify(ctx, t1, t2),
+        }
+    }
+
     // This function unifies and infers the return type of a function call.
     pub fn unify_call(
         &mut self,
         ctx: &mut Context,
-        args: &mut [Expr],
-        type_args: Option<&[Index]>,
+        args: &mut [ExprOrSpread],
+        span: Span,
+        type_args: Option<&CallTypeArgs>,
         newable: bool,
         t2: Index,
     ) -> Result<(Index, Option<Index>), TypeError> {
@@ -735,21 +779,30 @@ impl Checker {
                     .iter_mut()
                     .enumerate()
                     .map(|(i, arg)| {
-                        let t = self.infer_expression(arg, ctx)?;
+                        let (t, is_spread) = match arg {
+                            ExprOrSpread::Expr(arg) => (self.infer_expression(arg, ctx)?, false),
+                            ExprOrSpread::Spread(arg) => (self.infer_expression(arg, ctx)?, true),
+                        };
+                        let pattern = TPat::Ident(BindingIdent {
+                            name: format!("arg{i}"),
+                            mutable: false,
+                            // loc: DUMMY_LOC,
+                            span: Span { start: 0, end: 0 },
+                        });
                         let param = FuncParam {
-                            pattern: TPat::Ident(BindingIdent {
-                                name: format!("arg{i}"),
-                                mutable: false,
-                                // loc: DUMMY_LOC,
-                                span: Span { start: 0, end: 0 },
-                            }),
+                            pattern: match is_spread {
+                                true => TPat::Rest(RestPat {
+                                    arg: Box::new(pattern),
+                                }),
+                                false => pattern,
+                            },
                             // name: format!("arg{i}"),
                             t,
                             optional: false,
                         };
                         Ok(param)
                     })
-                    .collect::<Result<Vec<_>, _>>()?;
+                    .collect::<Result<Vec<_>, TypeError>>()?;
                 let call_type = self.new_func_type(&arg_types, ret_type, &None, None);
                 self.bind(ctx, b, call_type)?
             }
@@ -758,7 +811,7 @@ impl Checker {
                 let mut throws_types = vec![];
                 for t in types.iter() {
                     let (ret_type, throws_type) =
-                        self.unify_call(ctx, args, type_args, newable, *t)?;
+                        self.unify_call(ctx, args, span, type_args, newable, *t)?;
                     ret_types.push(ret_type);
                     if let Some(throws_type) = throws_type {
                         throws_types.push(throws_type);
@@ -776,29 +829,61 @@ impl Checker {
                 return Ok((ret, throws));
             }
             TypeKind::Intersection(Intersection { types }) => {
+                // The reasons each of the overloads failed to unify, these are
+                // combined into a single error if none of them match.
+                let mut failures: Vec<String> = vec![];
+
                 for t in types.iter() {
                     self.push_report();
 
                     // TODO: if there are multiple overloads that unify, pick the
                     // best one.
-                    let (ret_type, maybe_throws_type) =
-                        self.unify_call(ctx, args, type_args, newable, *t)?;
-
-                    if self.current_report.diagnostics.is_empty() {
-                        self.pop_report();
-                        return Ok((ret_type, maybe_throws_type));
-                    }
+                    let reasons: Vec<String> =
+                        match self.unify_call(ctx, args, span, type_args, newable, *t) {
+                            Ok((ret_type, maybe_throws_type)) => {
+                                if self.current_report.diagnostics.is_empty() {
+                                    self.pop_report();
+                                    return Ok((ret_type, maybe_throws_type));
+                                }
+                                self.current_report
+                                    .diagnostics
+                                    .iter()
+                                    .flat_map(|diagnostic| match diagnostic.reasons.is_empty() {
+                                        true => vec![diagnostic.message.to_owned()],
+                                        false => diagnostic
+                                            .reasons
+                                            .iter()
+                                            .map(|reason| reason.message.to_owned())
+                                            .collect(),
+                                    })
+                                    .collect()
+                            }
+                            Err(error) => vec![error.message],
+                        };
 
-                    // We just throw away reports that don't unify until we find
-                    // one that does.  We should probably be saving these so that
-                    // we can provide a more detailed report when no overloads
-                    // match.
+                    // Reports for overloads that don't unify are thrown away,
+                    // only the reasons are kept.
                     if let Some(report) = self.parent_reports.pop() {
                         self.current_report = report;
                     }
+
+                    // Members that aren't callable, e.g. the object type in
+                    // `(fn () -> void) & {meta: string}`, are skipped.
+                    let t = self.prune(*t);
+                    if let TypeKind::Function(_) = &self.arena[t].kind {
+                        failures.push(format!("{}: {}", self.print_type(&t), reasons.join(", ")));
+                    }
                 }
+
                 return Err(TypeError {
-                    message: "no valid overload for args".to_string(),
+                    message: format!(
+                        "no valid overload for args:\n{}",
+                        failures
+                            .iter()
+                            .map(|failure| format!("- {failure}"))
+                            .collect::<Vec<_>>()
+                            .join("\n")
+                    ),
                 });
             }
             TypeKind::Tuple(_) => {
@@ -830,13 +915,16 @@ impl Checker {
                 let t = self.instantiate_type(&scheme.t, &mapping);
                 // let t = self.expand_alias(ctx, &name, &type_args)?;
 
-                let type_args = if type_args.is_empty() {
-                    None
-                } else {
-                    Some(type_args.as_slice())
+                let type_args = CallTypeArgs {
+                    types: &type_args,
+                    callee: None,
+                };
+                let type_args = match type_args.types.is_empty() {
+                    true => None,
+                    false => Some(&type_args),
                 };
 
-                return self.unify_call(ctx, args, type_args, newable, t);
+                return self.unify_call(ctx, args, span, type_args, newable, t);
             }
             TypeKind::Literal(lit) => {
                 return Err(TypeError {
@@ -848,12 +936,17 @@ impl Checker {
                     message: format!("Primitive {primitive:#?} is not callable"),
                 });
             }
+            TypeKind::TemplateLiteral(_) => {
+                return Err(TypeError {
+                    message: "template literal is not callable".to_string(),
+                });
+            }
             TypeKind::Keyword(keyword) => {
                 return Err(TypeError {
                     message: format!("{keyword} is not callable"),
                 })
             }
-            TypeKind::Object(Object { elems }) => {
+            TypeKind::Object(Object { elems, class }) => {
                 let mut newables = vec![];
                 let mut callables = vec![];
 
@@ -872,6 +965,15 @@ impl Checker {
                         });
                     }
 
+                    if let Some(ClassInfo {
+                        is_abstract: true, ..
+                    }) = class
+                    {
+                        return Err(TypeError {
+                            message: "Cannot create an instance of an abstract class".to_string(),
+                        });
+                    }
+
                     // TODO: Cycle through all of the newables and try to unify
                     // using the best result.  One of criteria might be picking
                     // one that ignores the fewest number of arguments.
@@ -883,7 +985,7 @@ impl Checker {
                     };
 
                     maybe_throws_type =
-                        self.unify_func_call(ctx, args, type_args, ret_type, func)?;
+                        self.unify_func_call(ctx, args, span, type_args, ret_type, func)?;
                 } else {
                     if callables.is_empty() {
                         return Err(TypeError {
@@ -902,7 +1004,7 @@ impl Checker {
                     };
 
                     maybe_throws_type =
-                        self.unify_func_call(ctx, args, type_args, ret_type, func)?;
+                        self.unify_func_call(ctx, args, span, type_args, ret_type, func)?;
                 }
             }
             TypeKind::Rest(_) => {
@@ -913,7 +1015,8 @@ impl Checker {
             // TODO: extract this into a helper function so that it can
             // be reused when unifying callables/newables.
             TypeKind::Function(func) => {
-                maybe_throws_type = self.unify_func_call(ctx, args, type_args, ret_type, func)?;
+                maybe_throws_type =
+                    self.unify_func_call(ctx, args, span, type_args, ret_type, func)?;
             }
             TypeKind::KeyOf(KeyOf { t }) => {
                 return Err(TypeError {
@@ -923,7 +1026,7 @@ impl Checker {
             TypeKind::IndexedAccess(IndexedAccess { obj, index }) => {
                 let is_mut = true;
                 let t = self.get_prop_value(ctx, obj, index, is_mut)?;
-                self.unify_call(ctx, args, type_args, newable, t)?;
+                self.unify_call(ctx, args, span, type_args, newable, t)?;
             }
             TypeKind::Conditional(Conditional {
                 check,
@@ -932,8 +1035,8 @@ impl Checker {
                 false_type,
             }) => {
                 match self.unify(ctx, check, extends) {
-                    Ok(_) => self.unify_call(ctx, args, type_args, newable, true_type)?,
-                    Err(_) => self.unify_call(ctx, args, type_args, newable, false_type)?,
+                    Ok(_) => self.unify_call(ctx, args, span, type_args, newable, true_type)?,
+                    Err(_) => self.unify_call(ctx, args, span, type_args, newable, false_type)?,
                 };
             }
             TypeKind::Infer(Infer { name }) => {
@@ -946,6 +1049,16 @@ impl Checker {
                     message: "_ is not callable".to_string(),
                 });
             }
+            TypeKind::UniqueSymbol(_) => {
+                return Err(TypeError {
+                    message: "unique symbol is not callable".to_string(),
+                });
+            }
+            TypeKind::Predicate(_) => {
+                return Err(TypeError {
+                    message: format!("{} is not callable", self.print_type(&b)),
+                });
+            }
             TypeKind::Binary(BinaryT {
                 op: _,
                 left: _,
@@ -962,15 +1075,42 @@ impl Checker {
     pub fn unify_func_call(
         &mut self,
         ctx: &mut Context,
-        args: &mut [Expr],
-        type_args: Option<&[Index]>,
+        args: &mut [ExprOrSpread],
+        span: Span,
+        type_args: Option<&CallTypeArgs>,
         ret_type: Index,
         func: Function,
     ) -> Result<Option<Index>, TypeError> {
-        let func = if func.type_params.is_some() {
-            self.instantiate_func(&func, type_args)?
-        } else {
-            func
+        // Type params are instantiated with unconstrained type variables.
+        // Their constraints are checked once the args have been unified so
+        // that errors can name the type param whose constraint wasn't met.
+        let type_params = func.type_params.to_owned();
+        let (func, type_args) = match (&type_params, type_args) {
+            (Some(type_params), Some(type_args)) => {
+                // Errors use the callee's type when it doesn't have a name.
+                let name = match type_args.callee {
+                    Some(callee) => callee.to_owned(),
+                    None => {
+                        let t = self.new_func_type(
+                            &func.params,
+                            func.ret,
+                            &func.type_params,
+                            func.throws,
+                        );
+                        self.print_type(&t)
+                    }
+                };
+                let type_args = self.fill_type_args(type_params, type_args.types, &name)?;
+                (self.instantiate_func(&func, Some(&type_args))?, type_args)
+            }
+            (Some(type_params), None) => {
+                let type_args = type_params
+                    .iter()
+                    .map(|_| self.new_type_var(None))
+                    .collect_vec();
+                (self.instantiate_func(&func, Some(&type_args))?, type_args)
+            }
+            (None, _) => (func, vec![]),
         };
 
         let func_params = match func.params.get(0) {
@@ -994,32 +1134,96 @@ impl Checker {
 
         let required_params = params.iter().filter(|param| !param.optional).collect_vec();
 
-        if args.len() < required_params.len() {
-            return Err(TypeError {
-                message: format!(
-                    "too few arguments to function: expected {}, got {}",
-                    required_params.len(),
-                    args.len()
-                ),
-            });
+        // Spreading a tuple is the same as passing each of its elements as a
+        // separate arg.  Arrays can have any number of elements so everything
+        // after a spread array, e.g. `...rest` in `add(1, ...rest)`, can only
+        // be passed to the rest param and is kept in `spread_types`.
+        let mut arg_types: Vec<(&Expr, Index)> = vec![];
+        let mut spread_types: Vec<(&Expr, Index)> = vec![];
+        for arg in args.iter_mut() {
+            match arg {
+                ExprOrSpread::Expr(expr) => {
+                    // Callbacks, including the ones in object literals, get the
+                    // types of their params from the param they're passed to.
+                    if let (ExprKind::Function(_) | ExprKind::Object(_), true) =
+                        (&expr.kind, spread_types.is_empty())
+                    {
+                        ctx.expected_type = params.get(arg_types.len()).map(|param| param.t);
+                    }
+                    let t = self.infer_expression(expr, ctx)?;
+                    match spread_types.is_empty() {
+                        true => arg_types.push((expr, t)),
+                        false => spread_types.push((expr, t)),
+                    }
+                }
+                ExprOrSpread::Spread(expr) => {
+                    let t = self.infer_expression(expr, ctx)?;
+                    let t = self.expand_type(ctx, t)?;
+                    match &self.arena[t].kind {
+                        TypeKind::Tuple(Tuple { types, .. }) => match spread_types.is_empty() {
+                            true => arg_types.extend(types.iter().map(|t| (&*expr, *t))),
+                            false => spread_types.extend(types.iter().map(|t| (&*expr, *t))),
+                        },
+                        TypeKind::Array(Array { t }) => spread_types.push((expr, *t)),
+                        _ => match self.get_iterator_elem_type(ctx, t)? {
+                            Some(elem_t) => spread_types.push((expr, elem_t)),
+                            None => {
+                                return Err(TypeError {
+                                    message: format!("{} is not iterable", self.print_type(&t)),
+                                })
+                            }
+                        },
+                    }
+                }
+            }
         }
 
-        let arg_types = args
-            .iter_mut()
-            .map(|arg| {
-                // TODO: handle spreads
-                let t = self.infer_expression(arg, ctx)?;
-                Ok((arg, t))
-            })
-            .collect::<Result<Vec<_>, _>>()?;
+        let spread_error = TypeError {
+            message: "A spread argument must either be a tuple or be passed to a rest param"
+                .to_string(),
+        };
+        if !spread_types.is_empty() && (arg_types.len() < params.len() || rest_param.is_none()) {
+            return Err(spread_error);
+        }
 
-        let mut reasons: Vec<TypeError> = vec![];
-        for ((arg, p), param) in arg_types.iter().zip(params.iter()) {
+        // The number of args that the function accepts, `max` is `None` if
+        // it has a rest param.  Args after the elements of a tuple rest param
+        // are ignored.
+        let (min, max) = match rest_param {
+            Some(rest_param) => match &self.arena[rest_param.t].kind {
+                TypeKind::Tuple(tuple) => (required_params.len() + tuple.types.len(), None),
+                _ => (required_params.len(), None),
+            },
+            None => (required_params.len(), Some(params.len())),
+        };
+        let got = arg_types.len();
+        if spread_types.is_empty() && (got < min || matches!(max, Some(max) if got > max)) {
+            let expected = match max {
+                Some(max) if max == min => format!("{min}"),
+                Some(max) => format!("{min}-{max}"),
+                None => format!("at least {min}"),
+            };
+            let noun = match max {
+                Some(max) if max != min => "arguments",
+                _ if min == 1 => "argument",
+                _ => "arguments",
+            };
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::WRONG_ARG_COUNT,
+                severity: Severity::Error,
+                message: format!("Expected {expected} {noun}, but got {got}"),
+                reasons: vec![],
+                span: Some(span),
+            });
+        }
+
+        // Each arg that doesn't match its param gets its own diagnostic so
+        // that it can point at the arg.
+        let mut arg_errors: Vec<(usize, &Expr, Index, FuncParam, TypeError)> = vec![];
+        for (i, ((arg, p), param)) in arg_types.iter().zip(params.iter()).enumerate() {
             if param.optional {
-                if let Some(index) = arg.inferred_type {
-                    if let TypeKind::Literal(Lit::Undefined) = &self.arena[index].kind {
-                        continue;
-                    }
+                if let TypeKind::Literal(Lit::Undefined) = &self.arena[*p].kind {
+                    continue;
                 }
             }
 
@@ -1027,7 +1231,7 @@ impl Checker {
                 true => self.unify_mut(ctx, *p, param.t)?,
                 false => match self.unify(ctx, *p, param.t) {
                     Ok(_) => {}
-                    Err(error) => reasons.push(error),
+                    Err(error) => arg_errors.push((i, arg, *p, param.to_owned(), error)),
                 },
             };
         }
@@ -1038,32 +1242,40 @@ impl Checker {
             match kind {
                 TypeKind::Array(array) => {
                     if arg_types.len() >= params.len() {
-                        let remaining_arg_types = &arg_types[params.len()..];
-                        let t = array.t;
-                        for (_, p) in remaining_arg_types.iter() {
-                            match self.unify(ctx, *p, t) {
+                        // Errors name the rest param with the type of its
+                        // elements, e.g. `...rest: number`.
+                        let param = FuncParam {
+                            t: array.t,
+                            ..rest_param.to_owned()
+                        };
+                        for (i, (arg, p)) in arg_types
+                            .iter()
+                            .chain(&spread_types)
+                            .enumerate()
+                            .skip(params.len())
+                        {
+                            match self.unify(ctx, *p, array.t) {
                                 Ok(_) => {}
-                                Err(error) => reasons.push(error),
+                                Err(error) => arg_errors.push((i, arg, *p, param.clone(), error)),
                             }
                         }
                     }
                 }
+                // We don't know how many elements a spread array has so it
+                // can't be passed to a tuple.
+                TypeKind::Tuple(_) if !spread_types.is_empty() => return Err(spread_error),
                 TypeKind::Tuple(tuple) => {
-                    let remaining_arg_types = &arg_types[params.len()..];
-                    if remaining_arg_types.len() < tuple.types.len() {
-                        return Err(TypeError {
-                            message: format!(
-                                "too few arguments to function: expected {}, got {}",
-                                params.len() + tuple.types.len(),
-                                params.len() + remaining_arg_types.len()
-                            ),
-                        });
-                    }
-
-                    for ((_, p), t) in remaining_arg_types.iter().zip(tuple.types.iter()) {
+                    let remaining_arg_types = arg_types.iter().enumerate().skip(params.len());
+                    for ((i, (arg, p)), t) in remaining_arg_types.zip(tuple.types.iter()) {
                         match self.unify(ctx, *p, *t) {
                             Ok(_) => {}
-                            Err(error) => reasons.push(error),
+                            Err(error) => {
+                                let param = FuncParam {
+                                    t: *t,
+                                    ..rest_param.to_owned()
+                                };
+                                arg_errors.push((i, arg, *p, param, error));
+                            }
                         };
                     }
                 }
@@ -1078,11 +1290,63 @@ impl Checker {
             }
         }
 
+        let mut reasons: Vec<TypeError> = vec![];
+        if let Some(type_params) = &type_params {
+            // Constraints can reference other type params, e.g. `K: keyof T`.
+            let mapping: std::collections::HashMap<String, Index> = type_params
+                .iter()
+                .zip(type_args.iter())
+                .map(|(type_param, type_arg)| (type_param.name.to_owned(), *type_arg))
+                .collect();
+
+            for (type_param, type_arg) in type_params.iter().zip(type_args.iter()) {
+                let type_param = &TypeParam {
+                    constraint: type_param
+                        .constraint
+                        .map(|constraint| self.instantiate_type(&constraint, &mapping)),
+                    ..type_param.to_owned()
+                };
+                let t = self.prune(*type_arg);
+                match &mut self.arena[t].kind {
+                    // If the type param wasn't inferred from the args, we
+                    // constrain the type variable so that it's checked if it's
+                    // bound later.
+                    TypeKind::TypeVar(tv) if tv.constraint.is_none() => {
+                        tv.constraint = type_param.constraint;
+                    }
+                    TypeKind::TypeVar(_) => (),
+                    _ => {
+                        if let Err(error) = self.check_type_arg_constraint(ctx, type_param, t) {
+                            reasons.push(error);
+                        }
+                    }
+                }
+            }
+        }
+
+        for (i, arg, p, param, error) in arg_errors {
+            let message = format!(
+                "Argument {} of type {} is not assignable to param {}",
+                i + 1,
+                self.print_type(&p),
+                self.print_param(&param),
+            );
+            self.current_report.diagnostics.push(Diagnostic {
+                code: codes::INCORRECT_FUNCTION_ARGS,
+                severity: Severity::Error,
+                message,
+                reasons: vec![error],
+                span: Some(arg.span),
+            });
+        }
+
         if !reasons.is_empty() {
             self.current_report.diagnostics.push(Diagnostic {
-                code: 1000,
+                code: codes::INCORRECT_FUNCTION_ARGS,
+                severity: Severity::Error,
                 message: "Function arguments are incorrect".to_string(),
                 reasons,
+                span: Some(span),
             });
         }
 
@@ -1164,6 +1428,66 @@ impl Checker {
         Ok(())
     }
 
+    // Only aliases of structural types are reported by name, this matches
+    // what `print_type` does, e.g. `type Name = string` isn't used in place
+    // of `string`.
+    fn alias_expansion(&self, t: Index, expanded_t: Index) -> Option<(Index, Index)> {
+        match &self.arena[t].kind {
+            TypeKind::TypeRef(_) if is_aliasable(&self.arena[expanded_t].kind) => {
+                Some((t, expanded_t))
+            }
+            _ => None,
+        }
+    }
+
+    fn report_unify_error(&self, UnifyError { path, kind }: UnifyError) -> TypeError {
+        let message = match kind {
+            UnifyErrorKind::Mismatch { a, b, reason } => {
+                let a = self.print_type(&a);
+                let b = self.print_type(&b);
+                match reason {
+                    Mismatch::NotEqual => format!("type mismatch: {a} != {b}"),
+                    Mismatch::Failed => format!("type mismatch: unify({a}, {b}) failed"),
+                    Mismatch::MoreParams => {
+                        format!("{a} is not a subtype of {b} since it requires more params")
+                    }
+                    Mismatch::MissingProps(props) => {
+                        let verb = match props.len() {
+                            1 => "is",
+                            _ => "are",
+                        };
+                        format!(
+                            "{} {verb} missing in {a} but required in {b}",
+                            props.join(", ")
+                        )
+                    }
+                }
+            }
+            UnifyErrorKind::Other(error) => error.message,
+        };
+
+        if path.is_empty() {
+            return TypeError { message };
+        }
+
+        let mut path_str = String::new();
+        for key in &path {
+            match key {
+                // Symbol keys are printed as `[name]` and don't need a `.`
+                PathKey::Prop(name @ TPropKey::SymbolKey(_)) => {
+                    path_str.push_str(&name.to_string())
+                }
+                PathKey::Prop(name) if path_str.is_empty() => path_str.push_str(&name.to_string()),
+                PathKey::Prop(name) => path_str.push_str(&format!(".{name}")),
+                PathKey::Index(index) => path_str.push_str(&format!("[{index}]")),
+            }
+        }
+
+        TypeError {
+            message: format!("property '{path_str}': {message}"),
+        }
+    }
+
     fn expand(&mut self, ctx: &Context, a: Index) -> Result<Index, TypeError> {
         let a_t = self.arena[a].clone();
 
@@ -1189,6 +1513,89 @@ impl Checker {
     }
 }
 
+// Where in a type a mismatch was found, e.g. `a` and `[0]` in `a[0]`.
+enum PathKey {
+    Prop(TPropKey),
+    Index(usize),
+}
+
+enum Mismatch {
+    NotEqual,
+    Failed,
+    MoreParams,
+    MissingProps(Vec<String>),
+}
+
+enum UnifyErrorKind {
+    // `a` and `b` are only printed once the error is reported so that types
+    // which were expanded can be reported as the types they came from.
+    Mismatch {
+        a: Index,
+        b: Index,
+        reason: Mismatch,
+    },
+    Other(TypeError),
+}
+
+/// The type args that are passed explicitly to a call, e.g. `foo<number>(5)`.
+pub struct CallTypeArgs<'a> {
+    pub types: &'a [Index],
+    /// The name of the callee if it's an identifier or a member access.  It's
+    /// used in errors about the number of type args.
+    pub callee: Option<&'a str>,
+}
+
+struct UnifyError {
+    // The path to the mismatch starting from the outermost type.
+    path: Vec<PathKey>,
+    kind: UnifyErrorKind,
+}
+
+impl UnifyError {
+    fn mismatch(a: Index, b: Index, reason: Mismatch) -> Self {
+        UnifyError {
+            path: vec![],
+            kind: UnifyErrorKind::Mismatch { a, b, reason },
+        }
+    }
+
+    fn within(mut self, key: PathKey) -> Self {
+        self.path.insert(0, key);
+        self
+    }
+
+    // Replaces expanded type aliases in a mismatch between the expanded types
+    // with the aliases themselves, e.g. `Config` instead of `{name: string}`.
+    fn unexpand(mut self, a: Option<(Index, Index)>, b: Option<(Index, Index)>) -> Self {
+        if let (
+            true,
+            UnifyErrorKind::Mismatch {
+                a: err_a, b: err_b, ..
+            },
+        ) = (self.path.is_empty(), &mut self.kind)
+        {
+            match a {
+                Some((a, expanded_a)) if *err_a == expanded_a => *err_a = a,
+                _ => (),
+            }
+            match b {
+                Some((b, expanded_b)) if *err_b == expanded_b => *err_b = b,
+                _ => (),
+            }
+        }
+        self
+    }
+}
+
+impl From<TypeError> for UnifyError {
+    fn from(error: TypeError) -> Self {
+        UnifyError {
+            path: vec![],
+            kind: UnifyErrorKind::Other(error),
+        }
+    }
+}
+
 // TODO: handle optional properties correctly
 // Maybe we can have a function that will canonicalize objects by converting
 // `x: T | undefined` to `x?: T`
@@ -1202,7 +1609,10 @@ pub fn simplify_intersection(checker: &mut Checker, in_types: &[Index]) -> Index
         .collect();
 
     // The use of HashSet<Type> here is to avoid duplicate types
-    let mut props_map: DefaultHashMap<String, BTreeSet<Index>> = defaulthashmap!();
+    let mut props_map: DefaultHashMap<TPropKey, BTreeSet<Index>> = defaulthashmap!();
+    // A property is only optional if it's optional in all of the objects
+    // that have it.
+    let mut optional_map: HashMap<TPropKey, bool> = HashMap::new();
     for obj in obj_types {
         for elem in &obj.elems {
             match elem {
@@ -1214,11 +1624,9 @@ pub fn simplify_intersection(checker: &mut Checker, in_types: &[Index]) -> Index
                 TObjElem::Getter(_) => todo!(),
                 TObjElem::Setter(_) => todo!(),
                 TObjElem::Prop(prop) => {
-                    let key = match &prop.name {
-                        TPropKey::StringKey(key) => key.to_owned(),
-                        TPropKey::NumberKey(key) => key.to_owned(),
-                    };
-                    props_map[key].insert(prop.t);
+                    let optional = optional_map.entry(prop.name.to_owned()).or_insert(true);
+                    *optional = *optional && prop.optional;
+                    props_map[prop.name.to_owned()].insert(prop.t);
                 }
             }
         }
@@ -1236,11 +1644,8 @@ pub fn simplify_intersection(checker: &mut Checker, in_types: &[Index]) -> Index
                 // checker.from_type_kind(TypeKind::Intersection(types))
             };
             TObjElem::Prop(TProp {
-                name: TPropKey::StringKey(name.to_owned()),
-                // TODO: determine this field from all of the TProps with
-                // the same name.  This should only be optional if all of
-                // the TProps with the current name are optional.
-                optional: false,
+                name: name.to_owned(),
+                optional: optional_map[name],
                 readonly: false,
                 t,
             })
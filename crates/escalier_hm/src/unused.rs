@@ -0,0 +1,42 @@
+use escalier_ast::*;
+
+use crate::ast_utils::find_binding_idents;
+use crate::checker::{Checker, DeclaredBinding};
+use crate::diagnostic::{codes, Diagnostic, Severity};
+use crate::infer_pattern::Assump;
+
+impl Checker {
+    /// Records the bindings introduced by `pattern` so that we can warn about
+    /// them if they're never used.  Bindings starting with `_` are ignored.
+    pub fn declare_bindings(&mut self, pattern: &Pattern, bindings: &Assump) {
+        for ident in find_binding_idents(pattern) {
+            if ident.name.starts_with('_') {
+                continue;
+            }
+            if let Some(binding) = bindings.get(&ident.name) {
+                self.declared_bindings.push(DeclaredBinding {
+                    name: ident.name,
+                    span: ident.span,
+                    index: binding.index,
+                });
+            }
+        }
+    }
+
+    /// Reports a warning for each binding declared since `start` that was
+    /// never looked up.  This should be called once all of the statements in
+    /// the scope containing those declarations have been inferred.
+    pub fn report_unused_bindings(&mut self, start: usize) {
+        for binding in self.declared_bindings.split_off(start) {
+            if !self.used_bindings.contains(&binding.index) {
+                self.current_report.diagnostics.push(Diagnostic {
+                    code: codes::UNUSED_BINDING,
+                    severity: Severity::Warning,
+                    message: format!("{} is declared but never used", binding.name),
+                    reasons: vec![],
+                    span: Some(binding.span),
+                });
+            }
+        }
+    }
+}
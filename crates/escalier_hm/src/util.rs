@@ -34,27 +34,39 @@ impl Checker {
         // We clone here because we can't move out of a shared reference.
         // TODO: Consider using Rc<RefCell<Type>> to avoid unnecessary cloning.
         match self.arena.get(pruned_type2).unwrap().clone().kind {
-            TypeKind::TypeVar(_) => false,   // leaf node
-            TypeKind::Literal(_) => false,   // leaf node
-            TypeKind::Primitive(_) => false, // leaf node
-            TypeKind::Keyword(_) => false,   // leaf node
-            TypeKind::Infer(_) => false,     // leaf node
-            TypeKind::Wildcard => false,     // leaf node
-            TypeKind::Object(Object { elems }) => elems.iter().any(|elem| match elem {
+            TypeKind::TypeVar(_) => false,      // leaf node
+            TypeKind::Literal(_) => false,      // leaf node
+            TypeKind::Primitive(_) => false,    // leaf node
+            TypeKind::Keyword(_) => false,      // leaf node
+            TypeKind::Infer(_) => false,        // leaf node
+            TypeKind::Wildcard => false,        // leaf node
+            TypeKind::UniqueSymbol(_) => false, // leaf node
+            TypeKind::Object(Object { elems, .. }) => elems.iter().any(|elem| match elem {
                 TObjElem::Constructor(constructor) => {
                     // TODO: check constraints and default on type_params
                     let param_types: Vec<_> =
                         constructor.params.iter().map(|param| param.t).collect();
-                    self.occurs_in(v, &param_types) || self.occurs_in_type(v, constructor.ret)
+                    self.occurs_in(v, &param_types)
+                        || self.occurs_in_type(v, constructor.ret)
+                        || match constructor.throws {
+                            Some(throws) => self.occurs_in_type(v, throws),
+                            None => false,
+                        }
                 }
                 TObjElem::Call(call) => {
                     // TODO: check constraints and default on type_params
                     let param_types: Vec<_> = call.params.iter().map(|param| param.t).collect();
-                    self.occurs_in(v, &param_types) || self.occurs_in_type(v, call.ret)
+                    self.occurs_in(v, &param_types)
+                        || self.occurs_in_type(v, call.ret)
+                        || match call.throws {
+                            Some(throws) => self.occurs_in_type(v, throws),
+                            None => false,
+                        }
                 }
                 TObjElem::Method(TMethod {
                     name: _,
                     mutates: _,
+                    is_abstract: _,
                     function:
                         Function {
                             params,
@@ -107,15 +119,25 @@ impl Checker {
                 params,
                 ret,
                 type_params: _, // TODO
-                throws: _,      // TODO
+                throws,
             }) => {
                 // TODO: check constraints and default on type_params
                 let param_types: Vec<_> = params.iter().map(|param| param.t).collect();
-                self.occurs_in(v, &param_types) || self.occurs_in_type(v, ret)
+                // Skipping `throws` would allow `fn (x) { throw f }` to bind
+                // `f` to a function that throws itself.
+                self.occurs_in(v, &param_types)
+                    || self.occurs_in_type(v, ret)
+                    || match throws {
+                        Some(throws) => self.occurs_in_type(v, throws),
+                        None => false,
+                    }
             }
             TypeKind::Union(Union { types }) => self.occurs_in(v, &types),
             TypeKind::Intersection(Intersection { types }) => self.occurs_in(v, &types),
-            TypeKind::Tuple(Tuple { types }) => self.occurs_in(v, &types),
+            TypeKind::Tuple(Tuple { types, labels: _ }) => self.occurs_in(v, &types),
+            TypeKind::TemplateLiteral(TemplateLitType { parts: _, types }) => {
+                self.occurs_in(v, &types)
+            }
             TypeKind::Array(Array { t }) => self.occurs_in_type(v, t),
             TypeKind::TypeRef(TypeRef {
                 type_args: types, ..
@@ -138,6 +160,9 @@ impl Checker {
             TypeKind::Binary(BinaryT { op: _, left, right }) => {
                 self.occurs_in_type(v, left) || self.occurs_in_type(v, right)
             }
+            TypeKind::Predicate(Predicate { t, .. }) => {
+                t.map_or(false, |t| self.occurs_in_type(v, t))
+            }
         }
     }
 
@@ -213,6 +238,90 @@ impl Checker {
         self.expand_scheme(ctx, &scheme, type_args, name)
     }
 
+    /// Checks that `count` type args is enough to satisfy all of the required
+    /// `type_params` without exceeding the total number of `type_params`.
+    pub fn check_type_arg_count(
+        &self,
+        type_params: &[TypeParam],
+        count: usize,
+        name: &str,
+    ) -> Result<(), TypeError> {
+        let required = type_params
+            .iter()
+            .filter(|type_param| type_param.default.is_none())
+            .count();
+
+        if count < required || count > type_params.len() {
+            let expected = match required == type_params.len() {
+                true => format!("{required}"),
+                false => format!("{required}-{}", type_params.len()),
+            };
+            let noun = match expected == "1" {
+                true => "type arg",
+                false => "type args",
+            };
+            return Err(TypeError {
+                message: format!("{name} expects {expected} {noun}, but was passed {count}"),
+            });
+        }
+
+        Ok(())
+    }
+
+    /// Checks that `type_arg` satisfies the constraint on `type_param` if it
+    /// has one.
+    pub fn check_type_arg_constraint(
+        &mut self,
+        ctx: &Context,
+        type_param: &TypeParam,
+        type_arg: Index,
+    ) -> Result<(), TypeError> {
+        if let Some(constraint) = type_param.constraint {
+            if self.unify(ctx, type_arg, constraint).is_err() {
+                return Err(TypeError {
+                    message: format!(
+                        "type arg {} does not satisfy the constraint {} of type param {}",
+                        self.print_type(&type_arg),
+                        self.print_type(&constraint),
+                        type_param.name
+                    ),
+                });
+            }
+        }
+
+        Ok(())
+    }
+
+    /// Returns `type_args` with any missing type args filled in using the
+    /// defaults of the corresponding `type_params`.  Defaults can reference
+    /// earlier type params, e.g. `type Pair<A, B = A> = [A, B]`.
+    pub fn fill_type_args(
+        &mut self,
+        type_params: &[TypeParam],
+        type_args: &[Index],
+        name: &str,
+    ) -> Result<Vec<Index>, TypeError> {
+        self.check_type_arg_count(type_params, type_args.len(), name)?;
+
+        let mut mapping: HashMap<String, Index> = HashMap::new();
+        let mut filled_type_args: Vec<Index> = vec![];
+        for (i, type_param) in type_params.iter().enumerate() {
+            let type_arg = match (type_args.get(i), type_param.default) {
+                (Some(type_arg), _) => *type_arg,
+                (None, Some(default)) => self.instantiate_type(&default, &mapping),
+                (None, None) => {
+                    return Err(TypeError {
+                        message: format!("{name} is missing a type arg for {}", type_param.name),
+                    })
+                }
+            };
+            mapping.insert(type_param.name.to_owned(), type_arg);
+            filled_type_args.push(type_arg);
+        }
+
+        Ok(filled_type_args)
+    }
+
     pub fn expand_scheme(
         &mut self,
         ctx: &Context,
@@ -222,15 +331,7 @@ impl Checker {
     ) -> Result<Index, TypeError> {
         match &scheme.type_params {
             Some(type_params) => {
-                if type_params.len() != type_args.len() {
-                    return Err(TypeError {
-                        message: format!(
-                            "{name} expects {} type args, but was passed {}",
-                            type_params.len(),
-                            type_args.len()
-                        ),
-                    });
-                }
+                let type_args = &self.fill_type_args(type_params, type_args, name)?;
 
                 if let TypeKind::Conditional(Conditional { check, .. }) = self.arena[scheme.t].kind
                 {
@@ -301,9 +402,7 @@ impl Checker {
 
                 let mut mapping: HashMap<String, Index> = HashMap::new();
                 for (param, arg) in type_params.iter().zip(type_args.iter()) {
-                    if let Some(constraint) = param.constraint {
-                        self.unify(&sig_ctx, *arg, constraint)?;
-                    }
+                    self.check_type_arg_constraint(&sig_ctx, param, *arg)?;
                     mapping.insert(param.name.clone(), arg.to_owned());
                 }
 
@@ -323,7 +422,22 @@ impl Checker {
         }
     }
 
+    /// Uses up `amount` of the expansion budget and returns an error once
+    /// it's been exhausted.  This keeps pathological types, e.g. recursive
+    /// aliases or template literals with huge unions, from hanging the
+    /// checker or running it out of memory.
+    pub fn use_expansion_fuel(&mut self, amount: usize) -> Result<(), TypeError> {
+        self.expansion_fuel_used = self.expansion_fuel_used.saturating_add(amount);
+        match self.expansion_budget {
+            Some(budget) if self.expansion_fuel_used > budget => Err(TypeError {
+                message: String::from("type is too complex to expand"),
+            }),
+            _ => Ok(()),
+        }
+    }
+
     pub fn expand_type(&mut self, ctx: &Context, t: Index) -> Result<Index, TypeError> {
+        self.use_expansion_fuel(1)?;
         let t = self.prune(t);
 
         // It's okay to clone here because we aren't mutating the type
@@ -332,26 +446,131 @@ impl Checker {
             // TODO: Readonly<T> should remove mutating methods
             // TODO: IndexedAccess["key"] should remove the `self` param from methods
             TypeKind::IndexedAccess(IndexedAccess { obj, index }) => {
-                let is_mut = true;
-                self.get_computed_member(ctx, *obj, *index, is_mut)?
+                if self.is_type_param(ctx, *obj) || self.is_type_param(ctx, *index) {
+                    return Ok(t);
+                }
+                match self.get_tuple_indexed_access(ctx, *obj, *index)? {
+                    Some(t) => t,
+                    None => {
+                        let is_mut = true;
+                        self.get_computed_member(ctx, *obj, *index, is_mut)?
+                    }
+                }
             }
             TypeKind::Conditional(conditional) => self.expand_conditional(ctx, conditional)?,
             TypeKind::TypeRef(TypeRef {
                 name,
                 scheme,
                 type_args,
-            }) => match scheme {
-                Some(scheme) => self.expand_scheme(ctx, scheme, type_args, name)?,
-                None => self.expand_alias(ctx, name, type_args)?,
-            },
+            }) => {
+                if scheme.is_none() && name == "Match" && ctx.get_scheme(name).is_err() {
+                    return self.expand_regex_match(ctx, t, type_args);
+                }
+                if let Some(expanded) = self.get_expanded_type_ref(ctx, t) {
+                    return Ok(expanded);
+                }
+                let expanded = match scheme {
+                    Some(scheme) => self.expand_scheme(ctx, scheme, type_args, name)?,
+                    None => self.expand_alias(ctx, name, type_args)?,
+                };
+                let expanded = self.expand_type(ctx, expanded)?;
+                let expanded = self.add_named_groups(name, type_args, expanded);
+                self.cache_expanded_type_ref(ctx, t, expanded);
+                return Ok(expanded);
+            }
             TypeKind::Binary(binary) => self.expand_binary(ctx, binary)?,
-            TypeKind::Object(object) => return self.expand_object(ctx, object),
+            // Objects without mapped types are already expanded, returning a
+            // copy of them would cause `unify` to keep expanding them.
+            TypeKind::Object(object)
+                if object
+                    .elems
+                    .iter()
+                    .any(|elem| matches!(elem, TObjElem::Mapped(_))) =>
+            {
+                return self.expand_object(ctx, object)
+            }
+            TypeKind::TemplateLiteral(template) => {
+                return self.expand_template_literal(ctx, t, template)
+            }
             _ => return Ok(t), // Early return to avoid infinite loop
         };
 
         self.expand_type(ctx, t)
     }
 
+    // The results of matching a regex have a `groups` property with the named
+    // groups from its pattern, e.g. `/(?<year>\d{4})/.exec(s)` has `groups:
+    // {year: string}`.  The pattern is the first type arg of `RegExpExecArray`
+    // and `RegExpMatchArray`, it's only known for regex literals.  Patterns
+    // that are only known to be a `string` could have any groups so they get
+    // `groups: {[key: string]: string | undefined}`.
+    fn add_named_groups(&mut self, name: &str, type_args: &[Index], t: Index) -> Index {
+        if name != "RegExpExecArray" && name != "RegExpMatchArray" {
+            return t;
+        }
+        let pattern = match type_args.first() {
+            Some(type_arg) => self.prune(*type_arg),
+            None => return t,
+        };
+        let string = self.new_primitive(Primitive::String);
+        let groups: Vec<TObjElem> = match self.arena[pattern].kind.clone() {
+            TypeKind::Literal(Literal::String(pattern)) => find_named_groups(&pattern)
+                .into_iter()
+                .map(|name| {
+                    TObjElem::Prop(TProp {
+                        name: TPropKey::StringKey(name),
+                        optional: false,
+                        readonly: false,
+                        t: string,
+                    })
+                })
+                .collect(),
+            TypeKind::Primitive(Primitive::String) => {
+                let undefined = self.new_lit_type(&Literal::Undefined);
+                vec![TObjElem::Mapped(MappedType {
+                    key: self.new_type_ref("key", None, &[]),
+                    target: "key".to_string(),
+                    value: self.new_union_type(&[string, undefined]),
+                    source: string,
+                    optional: None,
+                    readonly: None,
+                    check: None,
+                    extends: None,
+                })]
+            }
+            _ => return t,
+        };
+        if groups.is_empty() {
+            return t;
+        }
+
+        let groups = TObjElem::Prop(TProp {
+            name: TPropKey::StringKey("groups".to_string()),
+            optional: false,
+            readonly: false,
+            t: self.new_object_type(&groups),
+        });
+
+        match &self.arena[t].kind {
+            TypeKind::Object(Object { elems, .. }) => {
+                let mut elems: Vec<TObjElem> = elems
+                    .iter()
+                    .filter(|elem| match elem {
+                        TObjElem::Prop(prop) => prop.name.to_string() != "groups",
+                        _ => true,
+                    })
+                    .cloned()
+                    .collect();
+                elems.push(groups);
+                self.new_object_type(&elems)
+            }
+            _ => {
+                let groups = self.new_object_type(&[groups]);
+                self.new_intersection_type(&[t, groups])
+            }
+        }
+    }
+
     // Expands `keyof` types into one of the followwing:
     // - string or number literals
     // - string, number, or symbol type
@@ -362,9 +581,10 @@ impl Checker {
         // We're not mutating `kind` so this should be safe.
         let obj_kind: &TypeKind = unsafe { transmute(&self.arena[obj].kind) };
         match obj_kind {
-            TypeKind::Object(Object { elems }) => {
+            TypeKind::Object(Object { elems, .. }) => {
                 let mut string_keys: Vec<Index> = Vec::new();
                 let mut number_keys: Vec<Index> = Vec::new();
+                let mut symbol_keys: Vec<Index> = Vec::new();
                 let mut maybe_string: Option<Index> = None;
                 let mut maybe_number: Option<Index> = None;
                 let mut maybe_symbol: Option<Index> = None;
@@ -389,7 +609,10 @@ impl Checker {
                                 _ => todo!(),
                             }
                         }
-                        TObjElem::Method(TMethod { name, .. }) => match name {
+                        TObjElem::Method(TMethod { name, .. })
+                        | TObjElem::Getter(TGetter { name, .. })
+                        | TObjElem::Setter(TSetter { name, .. })
+                        | TObjElem::Prop(TProp { name, .. }) => match name {
                             TPropKey::StringKey(name) => {
                                 string_keys
                                     .push(self.new_lit_type(&Literal::String(name.to_owned())));
@@ -398,35 +621,10 @@ impl Checker {
                                 number_keys
                                     .push(self.new_lit_type(&Literal::Number(name.to_owned())));
                             }
-                        },
-                        TObjElem::Getter(TGetter { name, .. }) => match name {
-                            TPropKey::StringKey(name) => {
-                                string_keys
-                                    .push(self.new_lit_type(&Literal::String(name.to_owned())));
-                            }
-                            TPropKey::NumberKey(name) => {
-                                number_keys
-                                    .push(self.new_lit_type(&Literal::Number(name.to_owned())));
-                            }
-                        },
-                        TObjElem::Setter(TSetter { name, .. }) => match name {
-                            TPropKey::StringKey(name) => {
-                                string_keys
-                                    .push(self.new_lit_type(&Literal::String(name.to_owned())));
-                            }
-                            TPropKey::NumberKey(name) => {
-                                number_keys
-                                    .push(self.new_lit_type(&Literal::Number(name.to_owned())));
-                            }
-                        },
-                        TObjElem::Prop(TProp { name, .. }) => match name {
-                            TPropKey::StringKey(name) => {
-                                string_keys
-                                    .push(self.new_lit_type(&Literal::String(name.to_owned())));
-                            }
-                            TPropKey::NumberKey(name) => {
-                                number_keys
-                                    .push(self.new_lit_type(&Literal::Number(name.to_owned())));
+                            TPropKey::SymbolKey(symbol) => {
+                                symbol_keys.push(
+                                    self.from_type_kind(TypeKind::UniqueSymbol(symbol.to_owned())),
+                                );
                             }
                         },
                     }
@@ -444,8 +642,9 @@ impl Checker {
                     None => all_keys.append(&mut string_keys),
                 }
 
-                if let Some(symbol) = maybe_symbol {
-                    all_keys.push(symbol);
+                match maybe_symbol {
+                    Some(symbol) => all_keys.push(symbol),
+                    None => all_keys.append(&mut symbol_keys),
                 }
 
                 Ok(self.new_union_type(&all_keys))
@@ -472,6 +671,7 @@ impl Checker {
             TypeKind::Intersection(Intersection { types }) => {
                 let mut string_keys = BTreeMap::new();
                 let mut number_keys = BTreeMap::new();
+                let mut symbol_keys = BTreeMap::new();
                 let mut maybe_string = None;
                 let mut maybe_number = None;
                 let mut maybe_symbol = None;
@@ -489,6 +689,9 @@ impl Checker {
                                     TypeKind::Literal(Literal::String(str)) => {
                                         string_keys.insert(str.to_string(), *t);
                                     }
+                                    TypeKind::UniqueSymbol(symbol) => {
+                                        symbol_keys.insert(symbol.id, *t);
+                                    }
                                     TypeKind::Primitive(Primitive::Number) => {
                                         maybe_number = Some(*t);
                                     }
@@ -508,6 +711,9 @@ impl Checker {
                         TypeKind::Literal(Literal::String(str)) => {
                             string_keys.insert(str.to_string(), keys);
                         }
+                        TypeKind::UniqueSymbol(symbol) => {
+                            symbol_keys.insert(symbol.id, keys);
+                        }
                         TypeKind::Primitive(Primitive::Number) => {
                             maybe_number = Some(keys);
                         }
@@ -537,8 +743,11 @@ impl Checker {
                     }
                 }
 
-                if let Some(symbol) = maybe_symbol {
-                    all_keys.push(symbol);
+                match maybe_symbol {
+                    Some(symbol) => all_keys.push(symbol),
+                    None => {
+                        all_keys.append(&mut symbol_keys.values().cloned().collect::<Vec<Index>>())
+                    }
                 }
 
                 Ok(self.new_union_type(&all_keys))
@@ -553,6 +762,7 @@ impl Checker {
                 }
                 Keyword::Object => Ok(self.new_keyword(Keyword::Object)),
                 Keyword::Unknown => Ok(self.new_keyword(Keyword::Never)),
+                Keyword::Void => Ok(self.new_keyword(Keyword::Never)),
             },
             TypeKind::Primitive(primitive) => {
                 let name = primitive.get_scheme_name();
@@ -661,6 +871,154 @@ impl Checker {
         Ok(t)
     }
 
+    // Expands template literal types into a union of all of the string literals
+    // they can produce, e.g. `${"a" | "b"}-${1 | 2}` expands to
+    // "a-1" | "a-2" | "b-1" | "b-2".  Template literal types that interpolate
+    // non-literal types, e.g. `${number}px`, can't be expanded and are
+    // returned as is.
+    pub fn expand_template_literal(
+        &mut self,
+        ctx: &Context,
+        t: Index,
+        template: &TemplateLitType,
+    ) -> Result<Index, TypeError> {
+        let mut strings: Vec<String> = vec![template.parts[0].to_owned()];
+
+        for (interp, part) in template.types.iter().zip(template.parts.iter().skip(1)) {
+            let interp = self.expand_type(ctx, *interp)?;
+            let members = match &self.arena[interp].kind {
+                TypeKind::Union(Union { types }) => types.to_owned(),
+                _ => vec![interp],
+            };
+
+            let mut values: Vec<String> = vec![];
+            for member in members {
+                let member = self.expand_type(ctx, member)?;
+                match &self.arena[member].kind {
+                    TypeKind::Literal(Literal::String(value)) => values.push(value.to_owned()),
+                    TypeKind::Literal(lit) => values.push(lit.to_string()),
+                    _ => return Ok(t),
+                }
+            }
+
+            // Check the size of the product before building it.
+            self.use_expansion_fuel(strings.len().saturating_mul(values.len()))?;
+            strings = strings
+                .iter()
+                .flat_map(|prefix| {
+                    values
+                        .iter()
+                        .map(move |value| format!("{prefix}{value}{part}"))
+                })
+                .collect();
+        }
+
+        let types: Vec<Index> = strings
+            .into_iter()
+            .map(|value| self.new_lit_type(&Literal::String(value)))
+            .collect();
+
+        Ok(self.new_union_type(&types))
+    }
+
+    // Expands `Match<S, R>` into the named groups captured by matching the
+    // regex `R` against the string literal `S`, e.g. `Match<"2024-01",
+    // /(?<y>\d+)-(?<m>\d+)/>` expands to `{y: "2024", m: "01"}`.  Groups that
+    // don't take part in the match are `undefined` and strings that don't
+    // match at all produce `never`.  `R` can also be a string literal with
+    // just the pattern.  Unions of strings are distributed over and `Match`
+    // is returned as is until both of its type args are known.
+    pub fn expand_regex_match(
+        &mut self,
+        ctx: &Context,
+        t: Index,
+        type_args: &[Index],
+    ) -> Result<Index, TypeError> {
+        let (string, regex) = match type_args {
+            [string, regex] => (*string, self.prune(*regex)),
+            _ => {
+                return Err(TypeError {
+                    message: format!(
+                        "Match expects 2 type args, but was passed {}",
+                        type_args.len()
+                    ),
+                })
+            }
+        };
+
+        // The pattern and flags are the type args of `RegExp`.
+        let regex_args = match &self.arena[regex].kind {
+            TypeKind::TypeRef(TypeRef {
+                name, type_args, ..
+            }) if name == "RegExp" => type_args.to_owned(),
+            _ => vec![regex],
+        };
+        let mut strings: Vec<String> = vec![];
+        for arg in regex_args {
+            let arg = self.prune(arg);
+            match &self.arena[arg].kind {
+                TypeKind::Literal(Literal::String(value)) => strings.push(value.to_owned()),
+                _ => return Ok(t),
+            }
+        }
+        let (pattern, flags) = match strings.as_slice() {
+            [pattern] => (pattern.to_owned(), String::new()),
+            [pattern, flags] => (pattern.to_owned(), flags.to_owned()),
+            _ => return Ok(t),
+        };
+
+        let re = to_rust_regex(&pattern, &flags).map_err(|error| TypeError {
+            message: format!("Invalid regex /{pattern}/{flags} in Match: {error}"),
+        })?;
+        let names = find_named_groups(&pattern);
+
+        let string = self.expand_type(ctx, string)?;
+        let members = match &self.arena[string].kind {
+            TypeKind::Union(Union { types }) => types.to_owned(),
+            _ => vec![string],
+        };
+
+        let mut results: Vec<Index> = vec![];
+        for member in members {
+            let member = self.expand_type(ctx, member)?;
+            let values: Vec<Index> = match &self.arena[member].kind.clone() {
+                TypeKind::Literal(Literal::String(value)) => match re.captures(value) {
+                    Some(captures) => names
+                        .iter()
+                        .map(|name| match captures.name(name) {
+                            Some(group) => {
+                                self.new_lit_type(&Literal::String(group.as_str().to_owned()))
+                            }
+                            None => self.new_lit_type(&Literal::Undefined),
+                        })
+                        .collect(),
+                    None => continue,
+                },
+                TypeKind::Primitive(Primitive::String) => names
+                    .iter()
+                    .map(|_| self.new_primitive(Primitive::String))
+                    .collect(),
+                _ => return Ok(t),
+            };
+
+            let elems: Vec<TObjElem> = names
+                .iter()
+                .zip(values)
+                .map(|(name, t)| {
+                    TObjElem::Prop(TProp {
+                        name: TPropKey::StringKey(name.to_owned()),
+                        optional: false,
+                        readonly: false,
+                        t,
+                    })
+                })
+                .collect();
+            results.push(self.new_object_type(&elems));
+        }
+
+        Ok(self.new_union_type(&results))
+    }
+
     pub fn expand_object(&mut self, ctx: &Context, object: &Object) -> Result<Index, TypeError> {
         let mut new_elems = vec![];
 
@@ -678,6 +1036,9 @@ impl Checker {
                                 let mut mapping: HashMap<String, Index> = HashMap::new();
                                 mapping.insert(mapped.target.to_owned(), *t);
                                 let key = self.instantiate_type(&mapped.key, &mapping);
+                                // The key may have been remapped, e.g.
+                                // `[P in keyof T as Exclude<P, "a">]`.
+                                let key = self.expand_type(ctx, key)?;
 
                                 let mut value = self.instantiate_type(&mapped.value, &mapping);
 
@@ -688,13 +1049,30 @@ impl Checker {
                                     TypeKind::Literal(Literal::Number(name)) => {
                                         TPropKey::NumberKey(name.to_owned())
                                     }
+                                    // Keys that are remapped to `never` are
+                                    // dropped.
+                                    TypeKind::Keyword(Keyword::Never) => continue,
                                     _ => {
                                         non_literal_keys.push(key);
                                         continue;
                                     }
                                 };
 
+                                // This is the name of the property in the
+                                // source object which may differ from `name`
+                                // if the key was remapped.
+                                let source_name = match &self.arena[*t].kind {
+                                    TypeKind::Literal(Literal::String(name)) => {
+                                        Some(TPropKey::StringKey(name.to_owned()))
+                                    }
+                                    TypeKind::Literal(Literal::Number(name)) => {
+                                        Some(TPropKey::NumberKey(name.to_owned()))
+                                    }
+                                    _ => None,
+                                };
+
                                 let mut optional = false;
+                                let mut readonly = false;
 
                                 // The mapped type's `value` is looks like T[P]
                                 // and `P` is the key type we need to copy the
@@ -704,19 +1082,24 @@ impl Checker {
                                 if let TypeKind::IndexedAccess(IndexedAccess { obj, index }) =
                                     &self.arena[mapped.value].kind
                                 {
-                                    if !self.equals(index, &mapped.key) {
+                                    let is_target = matches!(
+                                        &self.arena[*index].kind,
+                                        TypeKind::TypeRef(TypeRef { name, .. }) if *name == mapped.target
+                                    );
+                                    if !is_target {
                                         continue;
                                     }
 
                                     let obj = self.expand_type(ctx, *obj)?;
 
-                                    if let TypeKind::Object(Object { elems }) =
+                                    if let TypeKind::Object(Object { elems, .. }) =
                                         &self.arena[obj].kind
                                     {
                                         for elem in elems {
                                             if let TObjElem::Prop(prop) = elem {
-                                                if prop.name == name {
+                                                if Some(&prop.name) == source_name.as_ref() {
                                                     optional = prop.optional;
+                                                    readonly = prop.readonly;
                                                     // TODO: use mapped.optional to
                                                     // mimic TypeScript's behavior
                                                     // where optional fields are
@@ -746,10 +1129,17 @@ impl Checker {
                                     }
                                 }
 
+                                if let Some(mode) = &mapped.readonly {
+                                    match mode {
+                                        MappedModifier::Add => readonly = true,
+                                        MappedModifier::Remove => readonly = false,
+                                    }
+                                }
+
                                 new_elems.push(TObjElem::Prop(TProp {
                                     name,
                                     optional,
-                                    readonly: false,
+                                    readonly,
                                     t: self.expand_type(ctx, value)?,
                                 }));
                             }
@@ -762,6 +1152,7 @@ impl Checker {
                                     value: mapped.value,
                                     source: mapped.source,
                                     optional: mapped.optional.to_owned(),
+                                    readonly: mapped.readonly.to_owned(),
                                     check: mapped.check,
                                     extends: mapped.extends,
                                 }));
@@ -777,11 +1168,131 @@ impl Checker {
         }
 
         Ok(self.arena.insert(Type {
-            kind: TypeKind::Object(Object { elems: new_elems }),
+            kind: TypeKind::Object(Object {
+                elems: new_elems,
+                class: None,
+            }),
             provenance: None, // TODO
         }))
     }
 
+    fn is_type_param(&mut self, ctx: &Context, t: Index) -> bool {
+        let t = self.prune(t);
+        match &self.arena[t].kind {
+            TypeKind::TypeRef(TypeRef {
+                name, scheme: None, ..
+            }) => matches!(
+                ctx.schemes.get(name),
+                Some(Scheme {
+                    is_type_param: true,
+                    ..
+                })
+            ),
+            _ => false,
+        }
+    }
+
+    // Returns an error if `strict_null_checks` is enabled and `union` includes
+    // `null` or `undefined`, e.g. `p.x` where `p: {x: number} | null`.
+    pub fn check_nullable_member_access(
+        &self,
+        ctx: &Context,
+        obj_idx: Index,
+        union: &Union,
+    ) -> Result<(), TypeError> {
+        if ctx.strict_null_checks
+            && filter_nullables(&self.arena, &union.types).len() != union.types.len()
+        {
+            return Err(TypeError {
+                message: format!(
+                    "{} is possibly null or undefined, use ?. to access its properties",
+                    self.print_type(&obj_idx)
+                ),
+            });
+        }
+
+        Ok(())
+    }
+
+    // Indexing a tuple type with `number` gives the union of its elements and
+    // indexing it with "length" gives its length, e.g. `[5, "a"][number]` is
+    // `5 | "a"`.  Unlike indexing a tuple value with a number, neither of
+    // these can be out of bounds.  Returns `None` for all other indexes.
+    fn get_tuple_indexed_access(
+        &mut self,
+        ctx: &Context,
+        obj_idx: Index,
+        key_idx: Index,
+    ) -> Result<Option<Index>, TypeError> {
+        let obj_idx = self.prune(obj_idx);
+        let obj_idx = match &self.arena[obj_idx].kind {
+            TypeKind::TypeRef(_) => self.expand_type(ctx, obj_idx)?,
+            _ => obj_idx,
+        };
+        let types = match &self.arena[obj_idx].kind {
+            TypeKind::Tuple(tuple) => tuple.types.to_owned(),
+            _ => return Ok(None),
+        };
+        let has_rest = types
+            .iter()
+            .any(|t| matches!(self.arena[*t].kind, TypeKind::Rest(_)));
+
+        let key_idx = self.prune(key_idx);
+        match &self.arena[key_idx].kind {
+            TypeKind::Primitive(Primitive::Number) => {
+                let types = types
+                    .iter()
+                    .map(|t| match &self.arena[*t].kind {
+                        TypeKind::Rest(Rest { arg }) => match &self.arena[*arg].kind {
+                            TypeKind::Array(Array { t }) => *t,
+                            _ => *arg,
+                        },
+                        _ => *t,
+                    })
+                    .collect::<Vec<_>>();
+                Ok(Some(self.new_union_type(&types)))
+            }
+            TypeKind::Literal(Literal::String(key)) if key == "length" && !has_rest => {
+                let len = Literal::Number(types.len().to_string());
+                Ok(Some(self.new_lit_type(&len)))
+            }
+            _ => Ok(None),
+        }
+    }
+
+    /// Whether `name` refers to a private member of `obj_idx`.  Only the
+    /// instance types of classes can have private members.
+    pub fn is_private_member(
+        &mut self,
+        ctx: &Context,
+        obj_idx: Index,
+        name: &str,
+    ) -> Result<bool, TypeError> {
+        let obj_idx = self.expand_type(ctx, obj_idx)?;
+        let private_name = format!("#{name}");
+
+        if let TypeKind::Object(Object {
+            elems,
+            class: Some(_),
+        }) = &self.arena[obj_idx].kind
+        {
+            let names: Vec<String> = elems
+                .iter()
+                .filter_map(|elem| match elem {
+                    TObjElem::Method(TMethod { name, .. })
+                    | TObjElem::Getter(TGetter { name, .. })
+                    | TObjElem::Setter(TSetter { name, .. })
+                    | TObjElem::Prop(TProp { name, .. }) => Some(name.to_string()),
+                    _ => None,
+                })
+                .collect();
+            // Public members take precedence, see `get_prop_value`.
+            return Ok(!names.iter().any(|n| n == name) && names.contains(&private_name));
+        }
+
+        Ok(false)
+    }
+
     pub fn get_computed_member(
         &mut self,
         ctx: &Context,
@@ -789,6 +1300,15 @@ impl Checker {
         key_idx: Index,
         is_mut: bool,
     ) -> Result<Index, TypeError> {
+        // We can't look up the member until the type params have been
+        // instantiated, e.g. `o[k]` is `T[K]` in `fn <T, K: keyof T>(o: T, k: K)`.
+        if self.is_type_param(ctx, obj_idx) || self.is_type_param(ctx, key_idx) {
+            return Ok(self.new_indexed_access_type(obj_idx, key_idx));
+        }
+
+        let obj_idx = self.prune(obj_idx);
+        let key_idx = self.prune(key_idx);
+
         // NOTE: cloning is fine here because we aren't mutating `obj_type` or
         // `prop_type`.
         let obj_type = self.arena[obj_idx].clone();
@@ -854,6 +1374,8 @@ impl Checker {
             // declare let tuple: [number, number] | [string, string]
             // tuple[1]; // number | string
             TypeKind::Union(union) => {
+                self.check_nullable_member_access(ctx, obj_idx, union)?;
+
                 let mut result_types = vec![];
                 let mut undefined_count = 0;
                 for idx in &union.types {
@@ -950,6 +1472,7 @@ impl Checker {
                                 match &name {
                                     TPropKey::StringKey(_) if primitive == &Primitive::String => (),
                                     TPropKey::NumberKey(_) if primitive == &Primitive::Number => (),
+                                    TPropKey::SymbolKey(_) if primitive == &Primitive::Symbol => (),
                                     _ => continue,
                                 };
                                 values.push(*ret);
@@ -962,6 +1485,7 @@ impl Checker {
                                 match &name {
                                     TPropKey::StringKey(_) if primitive == &Primitive::String => (),
                                     TPropKey::NumberKey(_) if primitive == &Primitive::Number => (),
+                                    TPropKey::SymbolKey(_) if primitive == &Primitive::Symbol => (),
                                     _ => continue,
                                 };
                                 values.push(param.t);
@@ -970,6 +1494,7 @@ impl Checker {
                                 match &prop.name {
                                     TPropKey::StringKey(_) if primitive == &Primitive::String => (),
                                     TPropKey::NumberKey(_) if primitive == &Primitive::Number => (),
+                                    TPropKey::SymbolKey(_) if primitive == &Primitive::Symbol => (),
                                     _ => continue,
                                 };
 
@@ -1008,8 +1533,68 @@ impl Checker {
                         })
                     }
                 }
+                // Properties keyed by unique symbols, e.g. `[Symbol.iterator]`
+                // or `[sym]`, can only be accessed using that symbol.
+                TypeKind::UniqueSymbol(symbol) => {
+                    let key = TPropKey::SymbolKey(symbol.to_owned());
+                    let mut maybe_mapped: Option<&MappedType> = None;
+                    // Methods with the same name are overloads.
+                    let mut overloads: Vec<Index> = vec![];
+                    for elem in &object.elems {
+                        match elem {
+                            TObjElem::Mapped(mapped) => maybe_mapped = Some(mapped),
+                            TObjElem::Method(TMethod { name, function, .. }) if name == &key => {
+                                let Function {
+                                    params,
+                                    ret,
+                                    type_params,
+                                    throws,
+                                } = function;
+                                overloads.push(self.new_func_type(
+                                    params,
+                                    *ret,
+                                    type_params,
+                                    *throws,
+                                ));
+                            }
+                            TObjElem::Getter(getter) if getter.name == key && !ctx.is_lvalue => {
+                                return Ok(getter.ret);
+                            }
+                            TObjElem::Setter(setter) if setter.name == key && ctx.is_lvalue => {
+                                return Ok(setter.param.t);
+                            }
+                            TObjElem::Prop(prop) if prop.name == key => {
+                                return Ok(match prop.optional {
+                                    true => self.new_union_type(&[prop.t, undefined]),
+                                    false => prop.t,
+                                });
+                            }
+                            _ => (),
+                        }
+                    }
+
+                    match overloads.len() {
+                        0 => (),
+                        1 => return Ok(overloads[0]),
+                        _ => return Ok(self.new_intersection_type(&overloads)),
+                    }
+
+                    if let Some(mapped) = maybe_mapped {
+                        let mapped_key = get_mapped_key(self, mapped);
+                        if self.unify(ctx, key_idx, mapped_key).is_ok() {
+                            return Ok(self.new_union_type(&[mapped.value, undefined]));
+                        }
+                    }
+
+                    Err(TypeError {
+                        message: format!("Couldn't find property {key} in object"),
+                    })
+                }
                 TypeKind::Literal(Literal::String(name)) => {
                     let mut maybe_mapped: Option<&MappedType> = None;
+                    // Methods with the same name are overloads.
+                    let mut overloads: Vec<Index> = vec![];
+                    let (has_getter, has_setter) = find_accessors(object, name);
                     for elem in &object.elems {
                         match elem {
                             // Callable signatures have no name so we ignore them.
@@ -1029,11 +1614,13 @@ impl Checker {
                                 let key = match &method.name {
                                     TPropKey::StringKey(key) => key,
                                     TPropKey::NumberKey(key) => key,
+                                    TPropKey::SymbolKey(_) => continue,
                                 };
                                 if key == name {
                                     let TMethod {
                                         name: _,
                                         mutates, // TODO: check if the object is a mutable reference
+                                        is_abstract: _,
                                         function:
                                             Function {
                                                 params,
@@ -1069,16 +1656,18 @@ impl Checker {
 
                                     let func_t =
                                         self.new_func_type(params, *ret, type_params, *throws);
-                                    return Ok(func_t);
+                                    overloads.push(func_t);
                                 }
                             }
                             TObjElem::Getter(getter) => {
                                 let key = match &getter.name {
                                     TPropKey::StringKey(key) => key,
                                     TPropKey::NumberKey(key) => key,
+                                    TPropKey::SymbolKey(_) => continue,
                                 };
 
-                                if key == name {
+                                // When assigning, the setter determines the type.
+                                if key == name && !(ctx.is_lvalue && has_setter) {
                                     return Ok(getter.ret);
                                 }
                             }
@@ -1086,16 +1675,27 @@ impl Checker {
                                 let key = match &setter.name {
                                     TPropKey::StringKey(key) => key,
                                     TPropKey::NumberKey(key) => key,
+                                    TPropKey::SymbolKey(_) => continue,
                                 };
 
                                 if key == name {
-                                    return Ok(setter.param.t);
+                                    if ctx.is_lvalue {
+                                        return Ok(setter.param.t);
+                                    }
+                                    if !has_getter {
+                                        return Err(TypeError {
+                                            message: format!(
+                                                "Cannot read '{name}' because it only has a setter"
+                                            ),
+                                        });
+                                    }
                                 }
                             }
                             TObjElem::Prop(prop) => {
                                 let key = match &prop.name {
                                     TPropKey::StringKey(key) => key,
                                     TPropKey::NumberKey(key) => key,
+                                    TPropKey::SymbolKey(_) => continue,
                                 };
                                 if key == name {
                                     if let TypeKind::Function(Function { params, .. }) =
@@ -1123,6 +1723,38 @@ impl Checker {
                         }
                     }
 
+                    match overloads.len() {
+                        0 => (),
+                        1 => return Ok(overloads[0]),
+                        _ => return Ok(self.new_intersection_type(&overloads)),
+                    }
+
+                    // Private members are treated as absent unless they're
+                    // being accessed from within the body of their class.
+                    let private_name = format!("#{name}");
+                    let has_private_member = object.elems.iter().any(|elem| match elem {
+                        TObjElem::Method(TMethod { name, .. })
+                        | TObjElem::Getter(TGetter { name, .. })
+                        | TObjElem::Setter(TSetter { name, .. })
+                        | TObjElem::Prop(TProp { name, .. }) => name.to_string() == private_name,
+                        _ => false,
+                    });
+                    if has_private_member {
+                        let is_own_class = matches!(
+                            &object.class,
+                            Some(class) if ctx.class_ids.contains(&class.id)
+                        );
+                        if !is_own_class {
+                            return Err(TypeError {
+                                message: format!(
+                                    "'{name}' is private and can only be accessed within its class"
+                                ),
+                            });
+                        }
+                        let key_idx = self.new_lit_type(&Literal::String(private_name));
+                        return self.get_prop_value(ctx, obj_idx, key_idx, is_mut);
+                    }
+
                     if let Some(mapped) = maybe_mapped {
                         let mapped_key = get_mapped_key(self, mapped);
 
@@ -1141,6 +1773,15 @@ impl Checker {
                         })
                     }
                 }
+                // Accessing a property with a union of keys results in the
+                // union of the corresponding property types.
+                TypeKind::Union(union) => {
+                    let mut values: Vec<Index> = vec![];
+                    for key_idx in &union.types {
+                        values.push(self.get_prop_value(ctx, obj_idx, *key_idx, is_mut)?);
+                    }
+                    Ok(self.new_union_type(&values))
+                }
                 TypeKind::Literal(Literal::Number(name)) => {
                     let mut maybe_mapped: Option<&MappedType> = None;
                     for elem in &object.elems {
@@ -1184,6 +1825,189 @@ impl Checker {
             })
         }
     }
+
+    /// Checks that the property `name` on the object `obj_idx` can be used as
+    /// the target of an assignment.
+    pub fn check_prop_assignment(
+        &mut self,
+        ctx: &Context,
+        obj_idx: Index,
+        name: &str,
+    ) -> Result<(), TypeError> {
+        let obj_idx = self.expand_type(ctx, obj_idx)?;
+
+        if let TypeKind::Object(object) = &self.arena[obj_idx].kind {
+            for elem in &object.elems {
+                if let TObjElem::Prop(prop) = elem {
+                    if prop.readonly && prop.name.to_string() == name {
+                        return Err(TypeError {
+                            message: format!("Cannot assign to readonly property '{name}'"),
+                        });
+                    }
+                }
+            }
+
+            let (has_getter, has_setter) = find_accessors(object, name);
+            if has_getter && !has_setter {
+                return Err(TypeError {
+                    message: format!("Cannot assign to '{name}' because it only has a getter"),
+                });
+            }
+        }
+
+        Ok(())
+    }
+
+    /// Returns the type of the elements produced by the `[Symbol.iterator]`
+    /// method of `t` or `None` if `t` doesn't have one.
+    pub fn get_iterator_elem_type(
+        &mut self,
+        ctx: &Context,
+        t: Index,
+    ) -> Result<Option<Index>, TypeError> {
+        let t = self.expand_type(ctx, t)?;
+        let iterator = TPropKey::SymbolKey(self.well_known_symbol("iterator"));
+
+        // The iterator can also be a property whose value is a function, e.g.
+        // `[Symbol.iterator]: fn () -> Iterator<T>` in a type annotation.
+        let elem = match &self.arena[t].kind {
+            TypeKind::Object(object) => object
+                .elems
+                .iter()
+                .find(|elem| match elem {
+                    TObjElem::Method(method) => method.name == iterator,
+                    TObjElem::Prop(prop) => prop.name == iterator,
+                    _ => false,
+                })
+                .cloned(),
+            _ => None,
+        };
+        let func = match elem {
+            Some(TObjElem::Method(method)) => Some(method.function),
+            Some(TObjElem::Prop(prop)) => {
+                let prop_t = self.prune(prop.t);
+                match &self.arena[prop_t].kind {
+                    TypeKind::Function(func) => Some(func.to_owned()),
+                    _ => None,
+                }
+            }
+            _ => None,
+        };
+
+        let func = match func {
+            Some(func) => self.instantiate_func(&func, None)?,
+            None => return Ok(None),
+        };
+
+        let ret = self.prune(func.ret);
+        match &self.arena[ret].kind {
+            TypeKind::TypeRef(TypeRef {
+                name, type_args, ..
+            }) if matches!(name.as_str(), "Generator" | "Iterator" | "IterableIterator")
+                && !type_args.is_empty() =>
+            {
+                Ok(Some(type_args[0]))
+            }
+            _ => Err(TypeError {
+                message: format!(
+                    "[Symbol.iterator]() must return an iterator, found {}",
+                    self.print_type(&ret)
+                ),
+            }),
+        }
+    }
+}
+
+// Returns whether `object` has a getter and whether it has a setter for `name`.
+fn find_accessors(object: &Object, name: &str) -> (bool, bool) {
+    let mut has_getter = false;
+    let mut has_setter = false;
+    for elem in &object.elems {
+        match elem {
+            TObjElem::Getter(getter) if getter.name.to_string() == name => has_getter = true,
+            TObjElem::Setter(setter) if setter.name.to_string() == name => has_setter = true,
+            _ => (),
+        }
+    }
+    (has_getter, has_setter)
+}
+
+/// Returns the names of the named capture groups in a regex pattern, e.g.
+/// `year` and `month` for `(?<year>\d{4})-(?<month>\d{2})`.  Lookbehinds,
+/// escaped parens, and parens inside of character classes are skipped.
+pub fn find_named_groups(pattern: &str) -> Vec<String> {
+    let mut names = vec![];
+    let mut chars = pattern.chars().peekable();
+    let mut in_class = false;
+    while let Some(c) = chars.next() {
+        match c {
+            '\\' => {
+                chars.next();
+            }
+            '[' => in_class = true,
+            ']' => in_class = false,
+            '(' if !in_class => {
+                if chars.next_if_eq(&'?').is_none() || chars.next_if_eq(&'<').is_none() {
+                    continue;
+                }
+                if matches!(chars.peek(), Some('=') | Some('!')) {
+                    continue;
+                }
+                let name: String = chars.by_ref().take_while(|c| *c != '>').collect();
+                names.push(name);
+            }
+            _ => {}
+        }
+    }
+    names
+}
+
+// Converts a JavaScript regex to one that the `regex` crate can compile, the
+// named groups use `(?P<name>...)` and the flags are set inline.
+fn to_rust_regex(pattern: &str, flags: &str) -> Result<regex::Regex, regex::Error> {
+    let mut inline_flags: String = flags.chars().filter(|c| "ims".contains(*c)).collect();
+    if !inline_flags.is_empty() {
+        inline_flags = format!("(?{inline_flags})");
+    }
+
+    let mut result = inline_flags;
+    let mut chars = pattern.chars().peekable();
+    let mut in_class = false;
+    while let Some(c) = chars.next() {
+        match c {
+            '\\' => match chars.next() {
+                // `/` has to be escaped in regex literals but not here.
+                Some('/') => result.push('/'),
+                Some(c) => {
+                    result.push('\\');
+                    result.push(c);
+                }
+                None => result.push('\\'),
+            },
+            '[' => {
+                in_class = true;
+                result.push(c);
+            }
+            ']' => {
+                in_class = false;
+                result.push(c);
+            }
+            '(' if !in_class && chars.peek() == Some(&'?') => {
+                chars.next();
+                result.push_str("(?");
+                if chars.peek() == Some(&'<') {
+                    chars.next();
+                    match chars.peek() {
+                        Some('=') | Some('!') => result.push('<'),
+                        _ => result.push_str("P<"),
+                    }
+                }
+            }
+            _ => result.push(c),
+        }
+    }
+
+    regex::Regex::new(&result)
 }
 
 pub fn filter_nullables(arena: &Arena<Type>, types: &[Index]) -> Vec<Index> {
@@ -34,7 +34,7 @@ pub fn walk_index<V: Visitor>(visitor: &mut V, index: &Index) {
         TypeKind::Intersection(Intersection { types }) => {
             walk_indexes(visitor, types);
         }
-        TypeKind::Tuple(Tuple { types }) => {
+        TypeKind::Tuple(Tuple { types, labels: _ }) => {
             walk_indexes(visitor, types);
         }
         TypeKind::Array(Array { t }) => {
@@ -43,8 +43,11 @@ pub fn walk_index<V: Visitor>(visitor: &mut V, index: &Index) {
         TypeKind::Keyword(_) => (),
         TypeKind::Primitive(_) => (),
         TypeKind::Literal(_) => (),
+        TypeKind::TemplateLiteral(TemplateLitType { parts: _, types }) => {
+            walk_indexes(visitor, types);
+        }
         TypeKind::Function(function) => walk_function(visitor, function),
-        TypeKind::Object(Object { elems }) => {
+        TypeKind::Object(Object { elems, .. }) => {
             elems.iter().for_each(|elem| match elem {
                 TObjElem::Constructor(function) => walk_function(visitor, function),
                 TObjElem::Call(function) => walk_function(visitor, function),
@@ -104,10 +107,14 @@ pub fn walk_index<V: Visitor>(visitor: &mut V, index: &Index) {
         }
         TypeKind::Infer(_) => (),
         TypeKind::Wildcard => (),
+        TypeKind::UniqueSymbol(_) => (),
         TypeKind::Binary(BinaryT { op: _, left, right }) => {
             visitor.visit_index(left);
             visitor.visit_index(right);
         }
+        TypeKind::Predicate(Predicate { t, .. }) => {
+            t.map(|t| visitor.visit_index(&t));
+        }
     }
 }
 
@@ -35,6 +35,7 @@ fn test_env() -> (Checker, Context) {
         target: "P".to_string(),
         source: checker.new_primitive(Primitive::Number),
         optional: None,
+        readonly: None,
         check: None,
         extends: None,
     });
@@ -0,0 +1,260 @@
+use std::collections::{BTreeMap, HashSet};
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use escalier_hm::checker::Checker;
+use escalier_hm::context::{Binding, Context};
+
+use crate::parse::{get_dts_imports, load_dts};
+
+/// Finds the type definitions for the packages installed in a `node_modules`
+/// directory and loads them.  Each package is only loaded once.
+#[derive(Debug)]
+pub struct PackageRegistry {
+    node_modules: PathBuf,
+    loaded: HashSet<String>,
+}
+
+impl PackageRegistry {
+    pub fn new(node_modules: &Path) -> Self {
+        PackageRegistry {
+            node_modules: node_modules.to_owned(),
+            loaded: HashSet::new(),
+        }
+    }
+
+    /// Returns the path of the .d.ts file for the package `name`.  Packages
+    /// that don't include their own types fall back to the ones from
+    /// `@types`, e.g. `@types/lodash` or `@types/babel__core` for
+    /// `@babel/core`.
+    pub fn find_types(&self, name: &str) -> Option<PathBuf> {
+        let types_name = match name.strip_prefix('@') {
+            Some(scoped_name) => scoped_name.replace('/', "__"),
+            None => name.to_owned(),
+        };
+        let dirs = [
+            self.node_modules.join(name),
+            self.node_modules.join("@types").join(types_name),
+        ];
+        dirs.iter().find_map(|dir| find_types_in_dir(dir))
+    }
+
+    /// Adds the declarations from the package `name` to `ctx` and returns
+    /// the warnings for the declarations that were skipped.  The package's
+    /// values and types are in a namespace named after the package, see
+    /// `namespace_name`, except for declarations that augment the ones that
+    /// are already in `ctx`, e.g. interfaces from `declare global { ... }`.
+    /// Files that are referenced by `/// <reference path="..." />` directives
+    /// or imported using relative paths are loaded as part of the package.
+    pub fn load(
+        &mut self,
+        checker: &mut Checker,
+        ctx: &mut Context,
+        name: &str,
+    ) -> Result<Vec<String>, String> {
+        if self.loaded.contains(name) {
+            return Ok(vec![]);
+        }
+
+        let path = self
+            .find_types(name)
+            .ok_or_else(|| format!("couldn't find types for {name}"))?;
+        self.loaded.insert(name.to_owned());
+
+        let mut package = Package {
+            ctx: ctx.clone(),
+            files: HashSet::new(),
+            types: vec![],
+            warnings: vec![],
+        };
+        package.load_file(checker, &path)?;
+
+        let mut members = BTreeMap::new();
+        for (value_name, binding) in &package.ctx.values {
+            match ctx.values.get(value_name) {
+                Some(global) if global == binding => (),
+                Some(_) => {
+                    ctx.values.insert(value_name.to_owned(), binding.to_owned());
+                }
+                None => {
+                    members.insert(value_name.to_owned(), binding.to_owned());
+                }
+            }
+        }
+        let mut schemes = BTreeMap::new();
+        for (type_name, scheme) in &package.ctx.schemes {
+            match ctx.schemes.get(type_name) {
+                Some(global) if global.t == scheme.t => (),
+                Some(_) => {
+                    ctx.schemes.insert(type_name.to_owned(), scheme.to_owned());
+                }
+                None => {
+                    schemes.insert(type_name.to_owned(), scheme.to_owned());
+                }
+            }
+        }
+
+        let t = checker.new_namespace_type(&namespace_name(name), members, schemes, ctx);
+        let binding = Binding {
+            index: t,
+            is_mut: false,
+            is_var: false,
+            deprecated: None,
+        };
+        ctx.values.insert(namespace_name(name), binding);
+
+        // Packages referenced by `/// <reference types="..." />` directives
+        // are loaded into their own namespaces.
+        let mut warnings = package.warnings;
+        for types in package.types {
+            match self.load(checker, ctx, &types) {
+                Ok(mut types_warnings) => warnings.append(&mut types_warnings),
+                Err(message) => warnings.push(message),
+            }
+        }
+
+        Ok(warnings)
+    }
+}
+
+/// Returns the name of the namespace that the declarations from the package
+/// `name` are loaded into, e.g. `left_pad` for "left-pad" or `babel_core` for
+/// "@babel/core".
+pub fn namespace_name(name: &str) -> String {
+    name.trim_start_matches('@')
+        .chars()
+        .map(|c| match c.is_ascii_alphanumeric() {
+            true => c,
+            false => '_',
+        })
+        .collect()
+}
+
+// The state for loading the files that make up a package.
+struct Package {
+    ctx: Context,
+    files: HashSet<PathBuf>,
+    // The packages from `/// <reference types="..." />` directives.
+    types: Vec<String>,
+    warnings: Vec<String>,
+}
+
+impl Package {
+    // Loads the files that `path` references or imports before loading the
+    // file itself.  Each file is only loaded once, files can import each
+    // other.
+    fn load_file(&mut self, checker: &mut Checker, path: &Path) -> Result<(), String> {
+        let path = &fs::canonicalize(path)
+            .map_err(|error| format!("couldn't read {}: {error}", path.display()))?;
+        if !self.files.insert(path.to_owned()) {
+            return Ok(());
+        }
+
+        let src = fs::read_to_string(path)
+            .map_err(|error| format!("couldn't read {}: {error}", path.display()))?;
+        let dir = path.parent().unwrap_or(Path::new(""));
+
+        let mut specifiers = vec![];
+        for reference in get_references(&src) {
+            match reference {
+                Reference::Path(specifier) => specifiers.push(specifier),
+                Reference::Types(name) => self.types.push(name),
+            }
+        }
+        let imports =
+            get_dts_imports(&src).map_err(|_| format!("parsing {} failed", path.display()))?;
+        specifiers.extend(
+            imports
+                .into_iter()
+                .filter(|specifier| specifier.starts_with("./") || specifier.starts_with("../")),
+        );
+
+        for specifier in specifiers {
+            match resolve_dts(dir, &specifier) {
+                Some(dep_path) => self.load_file(checker, &dep_path)?,
+                None => self.warnings.push(format!(
+                    "couldn't find {specifier} referenced by {}",
+                    path.display()
+                )),
+            }
+        }
+
+        let mut warnings = load_dts(checker, &mut self.ctx, &src)
+            .map_err(|_| format!("parsing {} failed", path.display()))?;
+        self.warnings.append(&mut warnings);
+
+        Ok(())
+    }
+}
+
+#[derive(Debug, PartialEq)]
+enum Reference {
+    Path(String),
+    Types(String),
+}
+
+// Returns the `/// <reference ... />` directives at the top of a .d.ts file,
+// they're only allowed before the first statement.  Other kinds of directives,
+// e.g. `lib`, are ignored.
+fn get_references(src: &str) -> Vec<Reference> {
+    let mut references = vec![];
+    for line in src.lines().map(|line| line.trim()) {
+        if let Some(directive) = line.strip_prefix("///") {
+            let directive = directive.trim();
+            if !directive.starts_with("<reference ") {
+                continue;
+            }
+            if let Some(path) = get_attribute(directive, "path") {
+                references.push(Reference::Path(path));
+            } else if let Some(types) = get_attribute(directive, "types") {
+                references.push(Reference::Types(types));
+            }
+        } else if !line.is_empty() && !line.starts_with("//") {
+            break;
+        }
+    }
+    references
+}
+
+// Returns the value of the attribute `name` in a directive like
+// `<reference path="./foo.d.ts" />`.
+fn get_attribute(directive: &str, name: &str) -> Option<String> {
+    let (_, rest) = directive.split_once(&format!(" {name}="))?;
+    let quote = rest.chars().next().filter(|c| *c == '"' || *c == '\'')?;
+    let (value, _) = rest[1..].split_once(quote)?;
+    Some(value.to_owned())
+}
+
+// Resolves a path from a reference directive or a relative import to a .d.ts
+// file, imports can leave out the extension or use the .js one.
+fn resolve_dts(dir: &Path, specifier: &str) -> Option<PathBuf> {
+    let candidates = match specifier.strip_suffix(".d.ts") {
+        Some(_) => vec![dir.join(specifier)],
+        None => {
+            let stem = specifier.strip_suffix(".js").unwrap_or(specifier);
+            vec![
+                dir.join(format!("{stem}.d.ts")),
+                dir.join(stem).join("index.d.ts"),
+            ]
+        }
+    };
+
+    candidates.into_iter().find(|path| path.is_file())
+}
+
+// Uses the `types` or `typings` field from the package.json in `dir` if there
+// is one, otherwise index.d.ts.
+fn find_types_in_dir(dir: &Path) -> Option<PathBuf> {
+    let mut paths: Vec<PathBuf> = vec![];
+    if let Ok(src) = fs::read_to_string(dir.join("package.json")) {
+        if let Ok(package) = serde_json::from_str::<serde_json::Value>(&src) {
+            let types = package.get("types").or_else(|| package.get("typings"));
+            if let Some(types) = types.and_then(|types| types.as_str()) {
+                paths.push(dir.join(types));
+            }
+        }
+    }
+    paths.push(dir.join("index.d.ts"));
+
+    paths.into_iter().find(|path| path.is_file())
+}
@@ -1,6 +1,7 @@
 use escalier_hm::infer::generalize_func;
 use generational_arena::Index;
 use std::collections::HashMap;
+use std::mem;
 use std::sync::Arc;
 
 use swc_common::{comments::SingleThreadedComments, FileName, SourceMap};
@@ -46,9 +47,8 @@ pub fn infer_ts_type_ann(
             TsKeywordTypeKind::TsSymbolKeyword => {
                 Ok(checker.from_type_kind(TypeKind::Primitive(Primitive::Symbol)))
             }
-            // NOTE: `void` is treated the same as `undefined` ...for now.
             TsKeywordTypeKind::TsVoidKeyword => {
-                Ok(checker.from_type_kind(TypeKind::Literal(Lit::Undefined)))
+                Ok(checker.from_type_kind(TypeKind::Keyword(Keyword::Void)))
             }
             TsKeywordTypeKind::TsUndefinedKeyword => {
                 Ok(checker.from_type_kind(TypeKind::Literal(Lit::Undefined)))
@@ -182,6 +182,7 @@ pub fn infer_ts_type_ann(
 
             let t = checker.from_type_kind(TypeKind::Object(Object {
                 elems,
+                class: None,
                 // is_interface: false,
             }));
 
@@ -194,9 +195,27 @@ pub fn infer_ts_type_ann(
             // t.mutable = true;
             Ok(array_type)
         }
-        TsType::TsTupleType(_) => Err(String::from("can't parse tuple type yet")),
+        TsType::TsTupleType(TsTupleType { elem_types, .. }) => {
+            let mut types: Vec<Index> = vec![];
+            let mut labels: Vec<Option<String>> = vec![];
+            for elem in elem_types {
+                types.push(infer_ts_type_ann(checker, ctx, &elem.ty)?);
+                let label = match &elem.label {
+                    Some(Pat::Rest(rest)) => Some(rest.arg.as_ref()),
+                    label => label.as_ref(),
+                };
+                labels.push(match label {
+                    Some(Pat::Ident(BindingIdent { id, .. })) => Some(id.sym.to_string()),
+                    _ => None,
+                });
+            }
+            Ok(checker.new_labeled_tuple_type(&types, &labels))
+        }
         TsType::TsOptionalType(_) => Err(String::from("can't parse optional type yet")),
-        TsType::TsRestType(_) => Err(String::from("can't parse rest type yet")),
+        TsType::TsRestType(TsRestType { type_ann, .. }) => {
+            let t = infer_ts_type_ann(checker, ctx, type_ann)?;
+            Ok(checker.new_rest_type(t))
+        }
         TsType::TsUnionOrIntersectionType(union_or_intersection) => match union_or_intersection {
             TsUnionOrIntersectionType::TsUnionType(union) => {
                 let types = union
@@ -234,8 +253,14 @@ pub fn infer_ts_type_ann(
             }));
             Ok(t)
         }
-        TsType::TsInferType(_) => Err(String::from("can't parse infer type yet")),
-        TsType::TsParenthesizedType(_) => Err(String::from("can't parse parenthesized yet")),
+        // The checker replaces the type refs to `infer` types in the true
+        // branch of conditional types when they're expanded.
+        TsType::TsInferType(TsInferType { type_param, .. }) => {
+            Ok(checker.new_infer_type(&type_param.name.sym))
+        }
+        TsType::TsParenthesizedType(TsParenthesizedType { type_ann, .. }) => {
+            infer_ts_type_ann(checker, ctx, type_ann)
+        }
         TsType::TsTypeOperator(TsTypeOperator {
             op,
             type_ann,
@@ -260,7 +285,7 @@ pub fn infer_ts_type_ann(
                         Ok(checker.new_keyof_type(type_ann))
                     }
                 },
-                TsTypeOperatorOp::Unique => todo!(),
+                TsTypeOperatorOp::Unique => Err(String::from("can't parse unique type yet")),
                 TsTypeOperatorOp::ReadOnly => {
                     let type_ann = infer_ts_type_ann(checker, ctx, type_ann)?;
                     Ok(type_ann)
@@ -284,12 +309,17 @@ pub fn infer_ts_type_ann(
         TsType::TsMappedType(TsMappedType {
             span: _,
             type_param,
+            name_type,
             type_ann,
-            readonly: _,
+            readonly,
             optional,
             ..
         }) => {
-            let type_ann = infer_ts_type_ann(checker, ctx, type_ann.as_ref().unwrap())?;
+            // The value type defaults to `any` if it's left out.
+            let type_ann = match type_ann {
+                Some(type_ann) => infer_ts_type_ann(checker, ctx, type_ann)?,
+                None => checker.new_type_var(None),
+            };
             let constraint = match &type_param.constraint {
                 Some(constraint) => infer_ts_type_ann(checker, ctx, constraint)?,
                 None => {
@@ -308,16 +338,30 @@ pub fn infer_ts_type_ann(
                 None => None,
             };
 
-            // TODO: handle readonly modifier
+            let readonly = match readonly {
+                Some(mode) => match mode {
+                    TruePlusMinus::True => Some(types::MappedModifier::Add),
+                    TruePlusMinus::Plus => Some(types::MappedModifier::Add),
+                    TruePlusMinus::Minus => Some(types::MappedModifier::Remove),
+                },
+                None => None,
+            };
 
             let name = type_param.name.sym.to_string();
 
+            // `as` clauses are used to rename or filter out keys.
+            let key = match name_type {
+                Some(name_type) => infer_ts_type_ann(checker, ctx, name_type)?,
+                None => checker.new_type_ref(&name, None, &[]),
+            };
+
             let elems = vec![TObjElem::Mapped(MappedType {
-                key: checker.new_type_ref(&name, None, &[]),
+                key,
                 target: name,
                 source: constraint,
                 value: type_ann,
                 optional,
+                readonly,
                 check: None,
                 extends: None,
             })];
@@ -331,8 +375,34 @@ pub fn infer_ts_type_ann(
             TsLit::Str(str) => Ok(checker.new_lit_type(&Lit::String(str.value.to_string()))),
             TsLit::Bool(b) => Ok(checker.new_lit_type(&Lit::Boolean(b.value))),
             TsLit::BigInt(_) => Err(String::from("can't parse BigInt literal yet")),
-            TsLit::Tpl(_) => Err(String::from("can't parse Tpl literal yet")),
+            TsLit::Tpl(tpl) => {
+                let parts: Vec<String> = tpl
+                    .quasis
+                    .iter()
+                    .map(|quasi| match &quasi.cooked {
+                        Some(cooked) => cooked.to_string(),
+                        None => quasi.raw.to_string(),
+                    })
+                    .collect();
+                let mut types: Vec<Index> = vec![];
+                for t in &tpl.types {
+                    types.push(infer_ts_type_ann(checker, ctx, t)?);
+                }
+                Ok(checker.new_template_lit_type(&parts, &types))
+            }
         },
+        TsType::TsTypePredicate(TsTypePredicate {
+            asserts,
+            param_name: TsThisTypeOrIdent::Ident(ident),
+            type_ann,
+            ..
+        }) => {
+            let t = match type_ann {
+                Some(type_ann) => Some(infer_ts_type_ann(checker, ctx, &type_ann.type_ann)?),
+                None => None,
+            };
+            Ok(checker.new_predicate_type(*asserts, &ident.sym, t))
+        }
         TsType::TsTypePredicate(_) => Err(String::from("can't parse type predicate yet")),
         TsType::TsImportType(_) => Err(String::from("can't parse import type yet")),
     }
@@ -348,14 +418,18 @@ fn infer_fn_params(
         .enumerate()
         .filter_map(|(index, param)| match param {
             TsFnParam::Ident(ident) => {
-                let type_ann = ident.type_ann.clone().unwrap();
+                // Params without a type annotation are implicitly `any`.
+                let t = match &ident.type_ann {
+                    Some(type_ann) => infer_ts_type_ann(checker, ctx, &type_ann.type_ann).ok()?,
+                    None => checker.new_type_var(None),
+                };
                 let param = FuncParam {
                     pattern: TPat::Ident(identifier::BindingIdent {
                         span: Span { start: 0, end: 0 },
                         name: ident.id.sym.to_string(),
                         mutable: false,
                     }),
-                    t: infer_ts_type_ann(checker, ctx, &type_ann.type_ann).ok()?,
+                    t,
                     optional: ident.optional,
                 };
                 Some(param)
@@ -402,7 +476,7 @@ fn infer_method_sig(
     // is_static: bool,
 ) -> Result<TObjElem, String> {
     if sig.computed {
-        panic!("unexpected computed property in TypElement")
+        return Err(String::from("can't parse computed method names yet"));
     }
 
     let params = infer_fn_params(checker, ctx, &sig.params)?;
@@ -481,6 +555,7 @@ fn infer_method_sig(
     let elem = types::TObjElem::Method(types::TMethod {
         name: TPropKey::StringKey(name),
         mutates: false,
+        is_abstract: false,
         function: Function {
             params,
             ret,
@@ -620,6 +695,7 @@ fn infer_ts_type_element(
                         value: t,
                         source: key.t,
                         optional: None,
+                        readonly: None,
                         check: None,
                         extends: None,
                     }))
@@ -724,7 +800,16 @@ fn infer_interface_decl(
         })
         .collect();
 
-    let t = checker.from_type_kind(TypeKind::Object(Object { elems }));
+    let elems = match &*decl.id.sym {
+        "RegExp" => add_regexp_type_args(checker, elems, &["exec"], false),
+        "String" => add_regexp_type_args(checker, elems, &["match", "matchAll"], true),
+        _ => elems,
+    };
+
+    let t = checker.from_type_kind(TypeKind::Object(Object {
+        elems,
+        class: None,
+    }));
 
     let mut type_params = match &decl.type_params {
         Some(type_params) => Some(
@@ -760,7 +845,8 @@ fn infer_interface_decl(
     };
 
     // Add `TPattern` and `TFlags` type params to `RegExp`, `RegExpExecArray`,
-    // and `RegExpMatchArray`.
+    // and `RegExpMatchArray`.  They default to `string` so that references to
+    // these types without type args still work.
     let interface_name = decl.id.sym.to_string();
     if interface_name == "RegExp"
         || interface_name == "RegExpExecArray"
@@ -770,12 +856,12 @@ fn infer_interface_decl(
             TypeParam {
                 name: "TPattern".to_string(),
                 constraint: None,
-                default: None,
+                default: Some(checker.new_primitive(Primitive::String)),
             },
             TypeParam {
                 name: "TFlags".to_string(),
                 constraint: None,
-                default: None,
+                default: Some(checker.new_primitive(Primitive::String)),
             },
         ])
     }
@@ -789,6 +875,104 @@ fn infer_interface_decl(
     Ok(scheme)
 }
 
+// Passes the `TPattern` and `TFlags` type params through to the results of
+// `regexp.exec()`, `string.match(regexp)`, and `string.matchAll(regexp)` so
+// that the named groups in a regex literal's pattern are added to them.  When
+// `is_generic` is true the methods get their own `TPattern` and `TFlags` type
+// params, which are inferred from the `RegExp` that's passed to them.
+fn add_regexp_type_args(
+    checker: &mut Checker,
+    elems: Vec<TObjElem>,
+    method_names: &[&str],
+    is_generic: bool,
+) -> Vec<TObjElem> {
+    let type_args = [
+        checker.new_type_ref("TPattern", None, &[]),
+        checker.new_type_ref("TFlags", None, &[]),
+    ];
+
+    elems
+        .into_iter()
+        .map(|elem| match elem {
+            TObjElem::Method(mut method)
+                if method_names.contains(&method.name.to_string().as_str()) =>
+            {
+                let function = &mut method.function;
+                if is_generic {
+                    let mut has_regexp_param = false;
+                    for param in function.params.iter_mut() {
+                        let t = replace_regexp_refs(checker, param.t, &type_args);
+                        has_regexp_param = has_regexp_param || t != param.t;
+                        param.t = t;
+                    }
+                    if !has_regexp_param {
+                        return TObjElem::Method(method);
+                    }
+                    let mut type_params = vec![
+                        TypeParam {
+                            name: "TPattern".to_string(),
+                            constraint: None,
+                            default: None,
+                        },
+                        TypeParam {
+                            name: "TFlags".to_string(),
+                            constraint: None,
+                            default: None,
+                        },
+                    ];
+                    type_params.extend(function.type_params.take().unwrap_or_default());
+                    function.type_params = Some(type_params);
+                }
+                function.ret = replace_regexp_refs(checker, function.ret, &type_args);
+                TObjElem::Method(method)
+            }
+            elem => elem,
+        })
+        .collect()
+}
+
+// Adds `type_args` to references to `RegExp`, `RegExpExecArray`, and
+// `RegExpMatchArray` that don't have any, including inside of unions and the
+// type args of other types, e.g. `IterableIterator<RegExpMatchArray>`.
+fn replace_regexp_refs(checker: &mut Checker, t: Index, type_args: &[Index]) -> Index {
+    match checker.arena[t].kind.clone() {
+        TypeKind::TypeRef(TypeRef {
+            name,
+            scheme,
+            type_args: args,
+        }) if args.is_empty()
+            && (name == "RegExp" || name == "RegExpExecArray" || name == "RegExpMatchArray") =>
+        {
+            checker.new_type_ref(&name, scheme, type_args)
+        }
+        TypeKind::TypeRef(TypeRef {
+            name,
+            scheme,
+            type_args: args,
+        }) => {
+            let new_args: Vec<Index> = args
+                .iter()
+                .map(|t| replace_regexp_refs(checker, *t, type_args))
+                .collect();
+            match new_args == args {
+                true => t,
+                false => checker.new_type_ref(&name, scheme, &new_args),
+            }
+        }
+        TypeKind::Union(types::Union { types }) => {
+            let new_types: Vec<Index> = types
+                .iter()
+                .map(|t| replace_regexp_refs(checker, *t, type_args))
+                .collect();
+            match new_types == types {
+                true => t,
+                false => checker.new_union_type(&new_types),
+            }
+        }
+        _ => t,
+    }
+}
+
 fn get_key_name(key: &Expr) -> Result<String, String> {
     match key {
         Expr::Ident(Ident { sym, .. }) => Ok(sym.to_string()),
@@ -804,6 +988,11 @@ pub struct InterfaceCollector {
     pub comments: SingleThreadedComments,
     pub namespace: Vec<String>,
     pub interfaces: HashMap<String, Vec<TsInterfaceDecl>>,
+    // Overloaded functions have a signature for each declaration.
+    pub functions: HashMap<String, Vec<Function>>,
+    // Declarations that were skipped because they use TypeScript features
+    // that aren't supported yet.
+    pub warnings: Vec<String>,
 }
 
 impl Visit for InterfaceCollector {
@@ -814,9 +1003,7 @@ impl Visit for InterfaceCollector {
                 // eprintln!("inferring: {name} as scheme: {scheme}");
                 self.ctx.schemes.insert(name, scheme);
             }
-            Err(err) => {
-                eprintln!("couldn't infer {name}, {err:#?}")
-            }
+            Err(err) => self.warnings.push(format!("couldn't infer {name}, {err}")),
         }
     }
 
@@ -835,57 +1022,110 @@ impl Visit for InterfaceCollector {
         }
     }
 
+    // TODO: namespaces in .d.ts files aren't modeled yet so the declarations
+    // inside of modules and namespaces are added to the file's scope.
     fn visit_ts_module_decl(&mut self, decl: &TsModuleDecl) {
-        match &decl.id {
-            TsModuleName::Ident(id) => {
-                let name = id.sym.to_string();
-                eprintln!("module: {name}");
-                self.namespace.push(name);
-                decl.visit_children_with(self);
-                self.namespace.pop();
-            }
-            TsModuleName::Str(_) => todo!(),
+        let name = match &decl.id {
+            TsModuleName::Ident(id) => id.sym.to_string(),
+            TsModuleName::Str(str) => str.value.to_string(),
+        };
+        self.namespace.push(name);
+        decl.visit_children_with(self);
+        self.namespace.pop();
+    }
+
+    fn visit_fn_decl(&mut self, decl: &FnDecl) {
+        let name = decl.ident.sym.to_string();
+
+        let mut params: Vec<TsFnParam> = vec![];
+        for param in &decl.function.params {
+            let param = match &param.pat {
+                Pat::Ident(ident) => TsFnParam::Ident(ident.to_owned()),
+                Pat::Array(array) => TsFnParam::Array(array.to_owned()),
+                Pat::Rest(rest) => TsFnParam::Rest(rest.to_owned()),
+                Pat::Object(object) => TsFnParam::Object(object.to_owned()),
+                _ => {
+                    let msg = "params with default values aren't supported";
+                    self.warnings.push(format!("couldn't infer {name}, {msg}"));
+                    return;
+                }
+            };
+            params.push(param);
+        }
+
+        let ret = match &decl.function.return_type {
+            Some(type_ann) => &type_ann.type_ann,
+            None => {
+                let msg = "function is missing a return type";
+                self.warnings.push(format!("couldn't infer {name}, {msg}"));
+                return;
+            }
+        };
+
+        match infer_callable(
+            &mut self.checker,
+            &mut self.ctx,
+            &params,
+            ret,
+            &decl.function.type_params,
+        ) {
+            Ok(func) => self.functions.entry(name).or_default().push(func),
+            Err(err) => self.warnings.push(format!("couldn't infer {name}, {err}")),
         }
     }
 
     fn visit_var_decl(&mut self, decl: &VarDecl) {
-        if !decl.declare {
+        // Everything inside of a module or namespace is ambient even if it
+        // isn't marked with `declare`.
+        if !decl.declare && self.namespace.is_empty() {
             return;
         }
         for d in &decl.decls {
-            match &d.name {
-                Pat::Ident(bi) => {
-                    match &bi.type_ann {
-                        Some(type_ann) => {
-                            // TODO: capture errors and store them in self.errors
-                            let t = infer_ts_type_ann(
-                                &mut self.checker,
-                                &mut self.ctx,
-                                &type_ann.type_ann,
-                            )
-                            .unwrap();
-                            let name = bi.id.sym.to_string();
-                            let binding = Binding {
-                                index: t.to_owned(),
-                                is_mut: false,
-                            };
-                            self.ctx.values.insert(name, binding);
-                        }
-                        None => todo!(),
-                    }
+            let (name, type_ann) = match &d.name {
+                Pat::Ident(bi) => (bi.id.sym.to_string(), &bi.type_ann),
+                _ => {
+                    let msg = "destructuring isn't supported in declarations";
+                    self.warnings
+                        .push(format!("couldn't infer variable, {msg}"));
+                    continue;
+                }
+            };
+            let t = match type_ann {
+                Some(type_ann) => {
+                    infer_ts_type_ann(&mut self.checker, &mut self.ctx, &type_ann.type_ann)
+                }
+                None => Err(String::from("variable is missing a type annotation")),
+            };
+            match t {
+                Ok(t) => {
+                    let binding = Binding {
+                        index: t,
+                        is_mut: false,
+                        is_var: false,
+                        deprecated: None,
+                    };
+                    self.ctx.values.insert(name, binding);
                 }
-                Pat::Array(_) => todo!(),
-                Pat::Rest(_) => todo!(),
-                Pat::Object(_) => todo!(),
-                Pat::Assign(_) => todo!(),
-                Pat::Invalid(_) => todo!(),
-                Pat::Expr(_) => todo!(),
+                Err(err) => self.warnings.push(format!("couldn't infer {name}, {err}")),
             }
         }
     }
 }
 
+/// Parses a .d.ts file and returns a new checker and context containing its
+/// declarations.  Declarations that can't be inferred are skipped.
 pub fn parse_dts(d_ts_source: &str) -> Result<(Checker, Context), Error> {
+    let mut checker = Checker::default();
+    let mut ctx = Context::default();
+
+    for warning in load_dts(&mut checker, &mut ctx, d_ts_source)? {
+        eprintln!("{warning}");
+    }
+
+    Ok((checker, ctx))
+}
+
+fn parse_dts_module(d_ts_source: &str) -> Result<(Module, SingleThreadedComments), Error> {
     let cm = Arc::<SourceMap>::default();
     let fm = cm.new_source_file(FileName::Anon, d_ts_source.to_owned());
 
@@ -906,45 +1146,122 @@ pub fn parse_dts(d_ts_source: &str) -> Result<(Checker, Context), Error> {
         &mut errors,
     )?;
 
+    Ok((module, comments))
+}
+
+/// Returns the specifiers of the modules that a .d.ts file imports from or
+/// re-exports, e.g. "./utils" for `import { Foo } from "./utils"`.
+pub fn get_dts_imports(d_ts_source: &str) -> Result<Vec<String>, Error> {
+    let (module, _) = parse_dts_module(d_ts_source)?;
+
+    let imports = module
+        .body
+        .iter()
+        .filter_map(|item| match item {
+            ModuleItem::ModuleDecl(ModuleDecl::Import(ImportDecl { src, .. }))
+            | ModuleItem::ModuleDecl(ModuleDecl::ExportAll(ExportAll { src, .. })) => {
+                Some(src.value.to_string())
+            }
+            ModuleItem::ModuleDecl(ModuleDecl::ExportNamed(NamedExport {
+                src: Some(src), ..
+            })) => Some(src.value.to_string()),
+            _ => None,
+        })
+        .collect();
+
+    Ok(imports)
+}
+
+/// Adds the declarations from a .d.ts file to `ctx`, interfaces that are
+/// already in `ctx` are merged with the new declarations.  Declarations that
+/// use TypeScript features that aren't supported yet are skipped and a
+/// warning is returned for each of them.
+pub fn load_dts(
+    checker: &mut Checker,
+    ctx: &mut Context,
+    d_ts_source: &str,
+) -> Result<Vec<String>, Error> {
+    let (module, comments) = parse_dts_module(d_ts_source)?;
+
     let mut collector = InterfaceCollector {
-        checker: Checker::default(),
-        ctx: Context::default(),
+        checker: mem::take(checker),
+        ctx: mem::take(ctx),
         comments,
         namespace: vec![],
         interfaces: HashMap::new(),
+        functions: HashMap::new(),
+        warnings: vec![],
     };
 
     module.visit_with(&mut collector);
 
+    let has_arrays = collector.interfaces.contains_key("Array")
+        || collector.interfaces.contains_key("ReadonlyArray");
+
     for (name, decls) in collector.interfaces {
-        let schemes = decls
+        let mut schemes = decls
             .iter()
             .filter_map(|decl| {
                 match infer_interface_decl(&mut collector.checker, &mut collector.ctx, decl) {
                     Ok(scheme) => Some(scheme),
-                    Err(_) => {
-                        eprintln!("couldn't infer {name}");
+                    Err(err) => {
+                        let warning = format!("couldn't infer {name}, {err}");
+                        collector.warnings.push(warning);
                         None
                     }
                 }
             })
             .collect::<Vec<_>>();
+        if schemes.is_empty() {
+            continue;
+        }
+
+        // Interfaces can be augmented by other .d.ts files.
+        if let Some(scheme) = collector.ctx.schemes.get(&name) {
+            schemes.insert(0, scheme.to_owned());
+        }
 
         let scheme = new_merge_schemes(&schemes, &mut collector.checker);
 
         collector.ctx.schemes.insert(name.to_owned(), scheme);
     }
 
-    if let Some(array) = collector.ctx.schemes.get("Array") {
-        if let Some(readonly_array) = collector.ctx.schemes.get("ReadonlyArray") {
-            let array =
-                merge_readonly_and_mutable_schemes(readonly_array, array, &mut collector.checker);
-            collector.ctx.schemes.insert("Array".to_string(), array);
+    for (name, funcs) in collector.functions {
+        let types = funcs
+            .into_iter()
+            .map(|func| collector.checker.from_type_kind(TypeKind::Function(func)))
+            .collect::<Vec<_>>();
+        let t = match types.as_slice() {
+            [t] => *t,
+            _ => collector.checker.new_intersection_type(&types),
+        };
+        let binding = Binding {
+            index: t,
+            is_mut: false,
+            is_var: false,
+            deprecated: None,
+        };
+        collector.ctx.values.insert(name, binding);
+    }
+
+    if has_arrays {
+        if let Some(array) = collector.ctx.schemes.get("Array") {
+            if let Some(readonly_array) = collector.ctx.schemes.get("ReadonlyArray") {
+                let array = merge_readonly_and_mutable_schemes(
+                    readonly_array,
+                    array,
+                    &mut collector.checker,
+                );
+                collector.ctx.schemes.insert("Array".to_string(), array);
+            }
         }
     }
 
     // TODO: maintain a list of standard library methods that mutate and update
     // those methods here.
 
-    Ok((collector.checker, collector.ctx))
+    *checker = collector.checker;
+    *ctx = collector.ctx;
+
+    Ok(collector.warnings)
 }
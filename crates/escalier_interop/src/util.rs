@@ -25,7 +25,7 @@ pub fn new_merge_schemes(schemes: &[Scheme], checker: &mut Checker) -> Scheme {
         }
     }
 
-    let t = checker.from_type_kind(TypeKind::Object(TObject { elems }));
+    let t = checker.from_type_kind(TypeKind::Object(TObject { elems, class: None }));
 
     Scheme {
         t,
@@ -41,15 +41,15 @@ pub fn merge_readonly_and_mutable_schemes(
 ) -> Scheme {
     // BTreeMap is used here to ensure that the props are in alphabetical order
     // TODO: figure out how to handle overloads
-    let mut methods: BTreeMap<String, TMethod> = BTreeMap::new();
-    let mut mutating_methods: HashSet<String> = HashSet::new();
+    let mut methods: BTreeMap<TPropKey, TMethod> = BTreeMap::new();
+    let mut mutating_methods: HashSet<TPropKey> = HashSet::new();
 
     let mut mapped_types: Vec<MappedType> = vec![];
     let mut callables: Vec<Function> = vec![];
     let mut newables: Vec<Function> = vec![];
     let mut props: Vec<TProp> = vec![];
-    let mut getters: BTreeMap<String, TGetter> = BTreeMap::new();
-    let mut setters: BTreeMap<String, TSetter> = BTreeMap::new();
+    let mut getters: BTreeMap<TPropKey, TGetter> = BTreeMap::new();
+    let mut setters: BTreeMap<TPropKey, TSetter> = BTreeMap::new();
 
     // TODO: check that the type params are the same for both schemes
     let type_params = mutable_scheme.type_params.to_owned();
@@ -58,7 +58,7 @@ pub fn merge_readonly_and_mutable_schemes(
     // if there's a property in both scheme's that's marked as `readonly` then
     // we should mark in the merged scheme as `readonly`
 
-    if let TypeKind::Object(TObject { elems }) = &checker.arena[readonly_scheme.t].kind {
+    if let TypeKind::Object(TObject { elems, .. }) = &checker.arena[readonly_scheme.t].kind {
         for elem in elems {
             match elem {
                 TObjElem::Call(callable) => {
@@ -71,29 +71,17 @@ pub fn merge_readonly_and_mutable_schemes(
                     mapped_types.push(mapped_type.to_owned());
                 }
                 TObjElem::Method(method) => {
-                    let key = match &method.name {
-                        TPropKey::StringKey(key) => key,
-                        TPropKey::NumberKey(key) => key,
-                    };
-                    methods.insert(key.to_owned(), method.to_owned());
+                    methods.insert(method.name.to_owned(), method.to_owned());
                 }
                 // TODO: Check if there's already a getter for this, if so,
                 // raise an error
                 TObjElem::Getter(getter) => {
-                    let key = match &getter.name {
-                        TPropKey::StringKey(key) => key,
-                        TPropKey::NumberKey(key) => key,
-                    };
-                    getters.insert(key.to_owned(), getter.to_owned());
+                    getters.insert(getter.name.to_owned(), getter.to_owned());
                 }
                 // TODO: Check if there's already a setter for this, if so,
                 // raise an error
                 TObjElem::Setter(setter) => {
-                    let key = match &setter.name {
-                        TPropKey::StringKey(key) => key,
-                        TPropKey::NumberKey(key) => key,
-                    };
-                    setters.insert(key.to_owned(), setter.to_owned());
+                    setters.insert(setter.name.to_owned(), setter.to_owned());
                 }
                 // TODO: TS doesn't support merging interfaces with properties
                 // that have different types
@@ -104,13 +92,10 @@ pub fn merge_readonly_and_mutable_schemes(
         }
     }
 
-    if let TypeKind::Object(TObject { elems }) = &checker.arena[mutable_scheme.t].kind {
+    if let TypeKind::Object(TObject { elems, .. }) = &checker.arena[mutable_scheme.t].kind {
         for elem in elems {
             if let TObjElem::Method(method) = elem {
-                let key = match &method.name {
-                    TPropKey::StringKey(key) => key,
-                    TPropKey::NumberKey(key) => key,
-                };
+                let key = &method.name;
 
                 if !methods.contains_key(key) {
                     mutating_methods.insert(key.to_owned());
@@ -165,6 +150,7 @@ pub fn merge_readonly_and_mutable_schemes(
         // TODO: add a check to make sure since we shouldn't be trying to
         // merge things that aren't interfaces.
         // is_interface: true,
+        class: None,
     }));
 
     Scheme {
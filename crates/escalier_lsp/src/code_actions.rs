@@ -0,0 +1,131 @@
+use escalier_ast::*;
+use escalier_hm::annotate::{AnnotateOptions, TextEdit};
+use escalier_hm::checker::Checker;
+use escalier_hm::context::Context;
+
+pub struct CodeAction {
+    pub title: String,
+    pub edits: Vec<TextEdit>,
+}
+
+/// Returns actions that add the inferred type as an annotation to the
+/// top-level declaration at `offset`.  There are separate actions for the
+/// inferred type and the widened type, e.g. `5` and `number`, unless they're
+/// the same.  `script` must have already been checked by `checker`.
+pub fn get_annotation_actions(
+    src: &str,
+    script: &Script,
+    checker: &mut Checker,
+    ctx: &Context,
+    offset: usize,
+) -> Vec<CodeAction> {
+    let decl = script.stmts.iter().find_map(|stmt| match &stmt.kind {
+        StmtKind::Decl(Decl {
+            kind: DeclKind::VarDecl(decl),
+            span,
+            ..
+        }) if span.start <= offset && offset <= span.end => Some(decl),
+        _ => None,
+    });
+    let decl = match decl {
+        Some(decl) => decl,
+        None => return vec![],
+    };
+
+    let mut actions: Vec<CodeAction> = vec![];
+    for widen_literals in [false, true] {
+        let options = AnnotateOptions { widen_literals };
+        let edits = checker.get_decl_annotation_edits(src, decl, ctx, &options);
+        if edits.is_empty() || actions.iter().any(|action| action.edits == edits) {
+            continue;
+        }
+        let title = match (widen_literals, edits.as_slice()) {
+            (false, [edit]) => format!("Add type annotation `{}`", &edit.text[2..]),
+            (true, [edit]) => format!("Add widened type annotation `{}`", &edit.text[2..]),
+            _ => String::from("Add type annotations"),
+        };
+        actions.push(CodeAction { title, edits });
+    }
+
+    actions
+}
+
+#[cfg(test)]
+mod tests {
+    use escalier_hm::annotate::apply_edits;
+    use escalier_parser::parse;
+
+    use super::*;
+
+    // Checks `src` and returns each action at the `|` in it followed by the
+    // result of applying it.
+    fn annotation_actions(src: &str) -> String {
+        let offset = src.find('|').unwrap();
+        let src = src.replacen('|', "", 1);
+
+        let mut checker = Checker::default();
+        let mut ctx = Context::default();
+        let mut script = parse(&src).unwrap();
+        checker.infer_script(&mut script, &mut ctx).unwrap();
+
+        let actions = get_annotation_actions(&src, &script, &mut checker, &ctx, offset);
+
+        let mut output = String::new();
+        for action in actions {
+            output.push_str(&format!(
+                "{}:{}",
+                action.title,
+                apply_edits(&src, &action.edits)
+            ));
+        }
+        output
+    }
+
+    #[test]
+    fn literal_bindings() {
+        let src = r#"
+let x = |5
+let y = x
+"#;
+        insta::assert_snapshot!(annotation_actions(src), @r###"
+        Add type annotation `5`:
+        let x: 5 = 5
+        let y = x
+        Add widened type annotation `number`:
+        let x: number = 5
+        let y = x
+        "###);
+    }
+
+    #[test]
+    fn non_literal_bindings() {
+        let src = r#"
+let p = {x: 5, y: 10}
+let |q = [1, 2]
+"#;
+        insta::assert_snapshot!(annotation_actions(src), @r###"
+        Add type annotation `[1, 2]`:
+        let p = {x: 5, y: 10}
+        let q: [1, 2] = [1, 2]
+        "###);
+    }
+
+    #[test]
+    fn functions() {
+        let src = r#"
+let add = fn (a: number, b) => |a + b
+"#;
+        insta::assert_snapshot!(annotation_actions(src), @r###"
+        Add type annotations:
+        let add = fn (a: number, b: number) -> number => a + b
+        "###);
+    }
+
+    #[test]
+    fn annotated_bindings() {
+        let src = r#"
+let x: number = |5
+"#;
+        insta::assert_snapshot!(annotation_actions(src), @"");
+    }
+}
@@ -0,0 +1,989 @@
+use escalier_ast::expr::Prop;
+use escalier_ast::*;
+
+// The properties of a binding's value that we know the location of.
+#[derive(Clone, Debug)]
+enum Members {
+    Unknown,
+    // The value is an object literal, a class, or a namespace.
+    Props(Vec<Ident>),
+    // The value is created by calling `new` with the class bound to this
+    // identifier.
+    Instance(Ident),
+    // The binding is an alias for the namespace bound to this identifier.
+    Alias(Ident),
+}
+
+#[derive(Clone, Debug)]
+struct Binding {
+    name: String,
+    span: Span,
+    // The span of the innermost block, function, etc. containing the binding.
+    scope: Span,
+    members: Members,
+}
+
+#[derive(Clone)]
+struct MemberRef {
+    object: Ident,
+    property: Ident,
+}
+
+#[derive(Clone)]
+struct DefinitionVisitor {
+    scopes: Vec<Span>,
+    bindings: Vec<Binding>,
+    idents: Vec<Ident>,
+    members: Vec<MemberRef>,
+    // Identifiers that are both a property name and a binding or reference,
+    // e.g. `x` in `{x}` or `let {x} = point`.
+    shorthands: Vec<Span>,
+}
+
+impl DefinitionVisitor {
+    fn index(script: &Script) -> Self {
+        let mut visitor = DefinitionVisitor {
+            scopes: vec![Span {
+                start: 0,
+                end: usize::MAX,
+            }],
+            bindings: vec![],
+            idents: vec![],
+            members: vec![],
+            shorthands: vec![],
+        };
+        visitor.visit_program(script);
+        visitor
+    }
+
+    fn with_scope(&mut self, scope: Span, f: impl FnOnce(&mut Self)) {
+        self.scopes.push(scope);
+        f(self);
+        self.scopes.pop();
+    }
+
+    fn add_binding(&mut self, ident: &BindingIdent) {
+        self.bindings.push(Binding {
+            name: ident.name.to_owned(),
+            span: ident.span,
+            scope: *self.scopes.last().unwrap(),
+            members: Members::Unknown,
+        });
+    }
+
+    fn set_members(&mut self, span: Span, members: Members) {
+        if let Some(binding) = self
+            .bindings
+            .iter_mut()
+            .find(|binding| binding.span == span)
+        {
+            binding.members = members;
+        }
+    }
+
+    fn visit_block(&mut self, block: &Block) {
+        self.with_scope(block.span, |visitor| walk_block(visitor, block));
+    }
+
+    fn visit_block_or_expr(&mut self, block_or_expr: &BlockOrExpr) {
+        match block_or_expr {
+            BlockOrExpr::Block(block) => self.visit_block(block),
+            BlockOrExpr::Expr(expr) => self.visit_expr(expr),
+        }
+    }
+
+    fn visit_params(&mut self, params: &[FuncParam]) {
+        for param in params {
+            self.visit_pattern(&param.pattern);
+            if let Some(type_ann) = &param.type_ann {
+                self.visit_type_ann(type_ann);
+            }
+        }
+    }
+
+    // Resolves `name` as seen from `span`.  Bindings in inner scopes shadow
+    // ones in outer scopes.  Within a scope the closest binding before `span`
+    // wins, otherwise the first one after it is used since functions can
+    // reference bindings that are declared later.
+    fn resolve(&self, name: &str, span: Span) -> Option<&Binding> {
+        let candidates = self
+            .bindings
+            .iter()
+            .filter(|binding| binding.name == name && contains(binding.scope, span))
+            .collect::<Vec<_>>();
+        let scope = candidates
+            .iter()
+            .map(|binding| binding.scope)
+            .min_by_key(|scope| scope.end - scope.start)?;
+        let candidates = candidates
+            .into_iter()
+            .filter(|binding| binding.scope == scope)
+            .collect::<Vec<_>>();
+
+        candidates
+            .iter()
+            .rev()
+            .find(|binding| binding.span.start <= span.start)
+            .or(candidates.first())
+            .copied()
+    }
+
+    fn resolve_member(&self, member: &MemberRef) -> Option<Span> {
+        let binding = self.resolve(&member.object.name, member.object.span)?;
+        let props = match &binding.members {
+            Members::Props(props) => props,
+            Members::Instance(ident) | Members::Alias(ident) => {
+                match &self.resolve(&ident.name, ident.span)?.members {
+                    Members::Props(props) => props,
+                    _ => return None,
+                }
+            }
+            Members::Unknown => return None,
+        };
+
+        props
+            .iter()
+            .find(|prop| prop.name == member.property.name)
+            .map(|prop| prop.span)
+    }
+
+    fn definition_at(&self, offset: usize) -> Option<Span> {
+        if let Some(member) = self
+            .members
+            .iter()
+            .find(|member| contains_offset(member.property.span, offset))
+        {
+            return self.resolve_member(member);
+        }
+
+        let mut declarations = self.bindings.iter().flat_map(|binding| {
+            let props = match &binding.members {
+                Members::Props(props) => props.iter().map(|prop| prop.span).collect(),
+                _ => vec![],
+            };
+            std::iter::once(binding.span).chain(props)
+        });
+        if let Some(span) = declarations.find(|span| contains_offset(*span, offset)) {
+            return Some(span);
+        }
+
+        let ident = self
+            .idents
+            .iter()
+            .find(|ident| contains_offset(ident.span, offset))?;
+        self.resolve(&ident.name, ident.span)
+            .map(|binding| binding.span)
+    }
+
+    // Returns the spans of all of the identifiers that refer to `definition`.
+    fn references_to(&self, definition: Span) -> Vec<Span> {
+        let idents = self.idents.iter().filter_map(|ident| {
+            let binding = self.resolve(&ident.name, ident.span)?;
+            (binding.span == definition).then_some(ident.span)
+        });
+        let members = self.members.iter().filter_map(|member| {
+            let prop = self.resolve_member(member)?;
+            (prop == definition).then_some(member.property.span)
+        });
+
+        let mut references = idents.chain(members).collect::<Vec<_>>();
+        references.sort();
+        references.dedup();
+        references
+    }
+}
+
+impl Visitor for DefinitionVisitor {
+    fn visit_expr(&mut self, expr: &Expr) {
+        match &expr.kind {
+            ExprKind::Ident(ident) => self.idents.push(ident.to_owned()),
+            ExprKind::Object(Object { properties }) => {
+                for prop in properties {
+                    if let PropOrSpread::Prop(Prop::Shorthand(ident)) = prop {
+                        self.idents.push(ident.to_owned());
+                        self.shorthands.push(ident.span);
+                    }
+                }
+                walk_expr(self, expr);
+            }
+            ExprKind::Member(Member {
+                object,
+                property: MemberProp::Ident(property),
+                ..
+            }) => {
+                if let ExprKind::Ident(object) = &object.kind {
+                    self.members.push(MemberRef {
+                        object: object.to_owned(),
+                        property: property.to_owned(),
+                    });
+                }
+                walk_expr(self, expr);
+            }
+            ExprKind::Function(_) => {
+                self.with_scope(expr.span, |visitor| walk_expr(visitor, expr));
+            }
+            ExprKind::Class(Class {
+                super_class, body, ..
+            }) => {
+                if let Some(super_class) = super_class {
+                    self.idents.push(super_class.to_owned());
+                }
+                walk_expr(self, expr);
+
+                // `walk_expr` doesn't visit class members yet.
+                for member in body {
+                    match member {
+                        ClassMember::Method(Method { span, function, .. }) => {
+                            self.with_scope(*span, |visitor| {
+                                visitor.visit_params(&function.params);
+                                visitor.visit_block_or_expr(&function.body);
+                            });
+                        }
+                        ClassMember::Getter(Getter {
+                            span, params, body, ..
+                        })
+                        | ClassMember::Setter(Setter {
+                            span, params, body, ..
+                        }) => {
+                            self.with_scope(*span, |visitor| {
+                                visitor.visit_params(params);
+                                visitor.visit_block(body);
+                            });
+                        }
+                        ClassMember::Field(Field { init, .. }) => {
+                            if let Some(init) = init {
+                                self.visit_expr(init);
+                            }
+                        }
+                    }
+                }
+            }
+            ExprKind::IfElse(IfElse {
+                cond,
+                consequent,
+                alternate,
+            }) => {
+                self.visit_expr(cond);
+                self.visit_block(consequent);
+                if let Some(alternate) = alternate {
+                    self.visit_block_or_expr(alternate);
+                }
+            }
+            ExprKind::Match(Match { expr, arms }) => {
+                self.visit_expr(expr);
+                for arm in arms {
+                    self.with_scope(arm.span, |visitor| {
+                        visitor.visit_pattern(&arm.pattern);
+                        if let Some(guard) = &arm.guard {
+                            visitor.visit_expr(guard);
+                        }
+                        visitor.visit_block_or_expr(&arm.body);
+                    });
+                }
+            }
+            ExprKind::Try(Try {
+                body,
+                catch,
+                finally,
+            }) => {
+                self.visit_block(body);
+                if let Some(catch) = catch {
+                    let start = match &catch.param {
+                        Some(param) => param.span.start,
+                        None => catch.body.span.start,
+                    };
+                    let scope = Span {
+                        start,
+                        end: catch.body.span.end,
+                    };
+                    self.with_scope(scope, |visitor| {
+                        if let Some(param) = &catch.param {
+                            visitor.visit_pattern(param);
+                        }
+                        walk_block(visitor, &catch.body);
+                    });
+                }
+                if let Some(finally) = finally {
+                    self.visit_block(finally);
+                }
+            }
+            ExprKind::Do(Do { body }) => self.visit_block(body),
+            _ => walk_expr(self, expr),
+        }
+    }
+
+    fn visit_pattern(&mut self, pattern: &Pattern) {
+        match &pattern.kind {
+            PatternKind::Ident(ident) => self.add_binding(ident),
+            PatternKind::Is(IsPat { ident, .. }) => self.add_binding(ident),
+            // All of the alternatives bind the same names.
+            PatternKind::Or(OrPat { patterns }) => {
+                if let Some(pattern) = patterns.first() {
+                    self.visit_pattern(pattern);
+                }
+            }
+            PatternKind::Object(ObjectPat { props, .. }) => {
+                for prop in props {
+                    if let ObjectPatProp::Shorthand(ShorthandPatProp { ident, init, .. }) = prop {
+                        self.add_binding(ident);
+                        self.shorthands.push(ident.span);
+                        if let Some(init) = init {
+                            self.visit_expr(init);
+                        }
+                    }
+                }
+                walk_pattern(self, pattern);
+            }
+            _ => walk_pattern(self, pattern),
+        }
+    }
+
+    fn visit_decl(&mut self, decl: &Decl) {
+        match &decl.kind {
+            // The declarations in a namespace are only in scope inside of it,
+            // outside of it they're accessed as members, e.g. `Foo.bar`.
+            DeclKind::NamespaceDecl(NamespaceDecl { name, decls, .. }) => {
+                self.add_binding(&BindingIdent {
+                    name: name.name.to_owned(),
+                    span: name.span,
+                    mutable: false,
+                });
+                self.set_members(name.span, Members::Props(get_namespace_members(decls)));
+                self.with_scope(decl.span, |visitor| {
+                    for decl in decls {
+                        visitor.visit_decl(decl);
+                    }
+                });
+                return;
+            }
+            DeclKind::NamespaceAliasDecl(NamespaceAliasDecl { name, target }) => {
+                self.visit_expr(target);
+                self.add_binding(&BindingIdent {
+                    name: name.name.to_owned(),
+                    span: name.span,
+                    mutable: false,
+                });
+                if let ExprKind::Ident(target) = &target.kind {
+                    self.set_members(name.span, Members::Alias(target.to_owned()));
+                }
+                return;
+            }
+            _ => walk_decl(self, decl),
+        }
+
+        if let DeclKind::VarDecl(VarDecl {
+            pattern,
+            expr: Some(expr),
+            ..
+        }) = &decl.kind
+        {
+            if let PatternKind::Ident(ident) = &pattern.kind {
+                self.set_members(ident.span, get_members(expr));
+            }
+        }
+    }
+
+    fn visit_stmt(&mut self, stmt: &Stmt) {
+        match &stmt.kind {
+            StmtKind::For(ForStmt {
+                left, right, body, ..
+            }) => {
+                self.visit_expr(right);
+                let scope = Span {
+                    start: left.span.start,
+                    end: body.span.end,
+                };
+                self.with_scope(scope, |visitor| {
+                    visitor.visit_pattern(left);
+                    walk_block(visitor, body);
+                });
+            }
+            _ => walk_stmt(self, stmt),
+        }
+    }
+}
+
+fn get_members(expr: &Expr) -> Members {
+    match &expr.kind {
+        ExprKind::Object(Object { properties }) => {
+            let props = properties
+                .iter()
+                .filter_map(|prop| match prop {
+                    PropOrSpread::Prop(Prop::Shorthand(ident)) => Some(ident.to_owned()),
+                    PropOrSpread::Prop(Prop::Property {
+                        key: ObjectKey::Ident(ident),
+                        ..
+                    }) => Some(ident.to_owned()),
+                    _ => None,
+                })
+                .collect();
+            Members::Props(props)
+        }
+        ExprKind::Class(Class { body, .. }) => {
+            let props = body
+                .iter()
+                .filter_map(|member| match member {
+                    ClassMember::Method(Method {
+                        name: PropName::Ident(name),
+                        ..
+                    })
+                    | ClassMember::Getter(Getter {
+                        name: PropName::Ident(name),
+                        ..
+                    })
+                    | ClassMember::Setter(Setter {
+                        name: PropName::Ident(name),
+                        ..
+                    })
+                    | ClassMember::Field(Field { name, .. }) => Some(name.to_owned()),
+                    _ => None,
+                })
+                .collect();
+            Members::Props(props)
+        }
+        ExprKind::New(New { callee, .. }) => match &callee.kind {
+            ExprKind::Ident(class) => Members::Instance(class.to_owned()),
+            _ => Members::Unknown,
+        },
+        _ => Members::Unknown,
+    }
+}
+
+// Returns the names of the values declared in a namespace.
+fn get_namespace_members(decls: &[Decl]) -> Vec<Ident> {
+    decls
+        .iter()
+        .flat_map(|decl| match &decl.kind {
+            DeclKind::VarDecl(VarDecl { pattern, .. }) => match &pattern.kind {
+                PatternKind::Ident(BindingIdent { name, span, .. }) => vec![Ident {
+                    name: name.to_owned(),
+                    span: *span,
+                }],
+                _ => vec![],
+            },
+            DeclKind::NamespaceDecl(NamespaceDecl { name, .. })
+            | DeclKind::NamespaceAliasDecl(NamespaceAliasDecl { name, .. }) => {
+                vec![name.to_owned()]
+            }
+            DeclKind::TypeDecl(_) | DeclKind::GlobalDecl(_) => vec![],
+        })
+        .collect()
+}
+
+fn contains(outer: Span, inner: Span) -> bool {
+    outer.start <= inner.start && inner.end <= outer.end
+}
+
+fn contains_offset(span: Span, offset: usize) -> bool {
+    span.start <= offset && offset < span.end
+}
+
+/// Returns the span of the declaration of the identifier at `offset`.  For
+/// member accesses like `obj.prop` this is the declaration of `prop` in the
+/// object literal or class that `obj` was initialized with, or in the
+/// namespace `obj`.
+///
+/// Only declarations in `script` are found since each document is checked on
+/// its own.
+pub fn get_definition(script: &Script, offset: usize) -> Option<Span> {
+    DefinitionVisitor::index(script).definition_at(offset)
+}
+
+/// Returns the spans of all of the identifiers that refer to the same
+/// declaration as the identifier at `offset`, sorted by position.  The
+/// declaration itself is only included if `include_declaration` is true.
+pub fn get_references(script: &Script, offset: usize, include_declaration: bool) -> Vec<Span> {
+    let visitor = DefinitionVisitor::index(script);
+    let definition = match visitor.definition_at(offset) {
+        Some(definition) => definition,
+        None => return vec![],
+    };
+
+    let mut references = visitor.references_to(definition);
+    if include_declaration && !references.contains(&definition) {
+        references.push(definition);
+        references.sort();
+    }
+    references
+}
+
+// These need to be kept in sync with the keywords in `lex_ident_or_keyword`.
+const KEYWORDS: &[&str] = &[
+    "import",
+    "export",
+    "from",
+    "as",
+    "fn",
+    "get",
+    "set",
+    "pub",
+    "private",
+    "static",
+    "async",
+    "await",
+    "gen",
+    "yield",
+    "declare",
+    "let",
+    "var",
+    "mut",
+    "match",
+    "is",
+    "try",
+    "catch",
+    "finally",
+    "throw",
+    "do",
+    "for",
+    "in",
+    "instanceof",
+    "class",
+    "abstract",
+    "extends",
+    "implements",
+    "infer",
+    "return",
+    "throws",
+    "if",
+    "else",
+    "true",
+    "false",
+    "null",
+    "undefined",
+    "number",
+    "string",
+    "boolean",
+    "symbol",
+    "unknown",
+    "never",
+    "void",
+    "type",
+    "interface",
+    "typeof",
+    "keyof",
+    "new",
+    "_",
+];
+
+// Whether `name` can be used as the name of a binding.
+fn is_valid_binding_name(name: &str) -> bool {
+    let mut chars = name.chars();
+    matches!(chars.next(), Some('a'..='z' | 'A'..='Z' | '_'))
+        && chars.all(|c| matches!(c, 'a'..='z' | 'A'..='Z' | '_' | '0'..='9'))
+        && !KEYWORDS.contains(&name)
+}
+
+/// Returns the edits needed to rename the declaration of the identifier at
+/// `offset` and all of its references to `new_name`.  Bindings with the same
+/// name in other scopes are left alone.  An error is returned if `new_name`
+/// isn't a valid identifier or if renaming would change what any identifier
+/// refers to, e.g. because there's already a binding named `new_name` in the
+/// same scope.
+pub fn get_rename_edits(
+    script: &Script,
+    offset: usize,
+    new_name: &str,
+) -> Result<Vec<(Span, String)>, String> {
+    if !is_valid_binding_name(new_name) {
+        return Err(format!("{new_name} isn't a valid identifier"));
+    }
+
+    let visitor = DefinitionVisitor::index(script);
+    let definition = visitor
+        .definition_at(offset)
+        .ok_or("There's nothing to rename here")?;
+    let references = visitor.references_to(definition);
+
+    match visitor
+        .bindings
+        .iter()
+        .find(|binding| binding.span == definition)
+    {
+        Some(binding) => {
+            let old_name = &binding.name;
+            if visitor.bindings.iter().any(|other| {
+                other.scope == binding.scope && &other.name == new_name && other.span != definition
+            }) {
+                return Err(format!("{new_name} is already declared in this scope"));
+            }
+
+            // Check that every identifier still resolves to the same binding
+            // after renaming, otherwise one of the bindings would capture
+            // references to the other.
+            let mut renamed = visitor.clone();
+            for other in renamed.bindings.iter_mut() {
+                if other.span == definition {
+                    other.name = new_name.to_owned();
+                }
+            }
+            for ident in renamed.idents.iter_mut() {
+                if references.contains(&ident.span) {
+                    ident.name = new_name.to_owned();
+                }
+            }
+            for (before, after) in visitor.idents.iter().zip(&renamed.idents) {
+                let before = visitor.resolve(&before.name, before.span);
+                let after = renamed.resolve(&after.name, after.span);
+                if before.map(|binding| binding.span) != after.map(|binding| binding.span) {
+                    return Err(format!(
+                        "Renaming {old_name} to {new_name} would change what {} refers to",
+                        before.map_or(new_name, |binding| &binding.name)
+                    ));
+                }
+            }
+
+            // Shorthands keep the old name as the property name.
+            Ok(std::iter::once(definition)
+                .chain(references)
+                .map(|span| match visitor.shorthands.contains(&span) {
+                    true => (span, format!("{old_name}: {new_name}")),
+                    false => (span, new_name.to_owned()),
+                })
+                .collect())
+        }
+        None => {
+            let props = visitor
+                .bindings
+                .iter()
+                .find_map(|binding| match &binding.members {
+                    Members::Props(props) if props.iter().any(|prop| prop.span == definition) => {
+                        Some(props)
+                    }
+                    _ => None,
+                })
+                .ok_or("There's nothing to rename here")?;
+            if props.iter().any(|prop| prop.name == new_name) {
+                return Err(format!("{new_name} is already a property of this object"));
+            }
+            let old_name = &props
+                .iter()
+                .find(|prop| prop.span == definition)
+                .unwrap()
+                .name;
+
+            // Shorthands keep the old name as the value.
+            Ok(std::iter::once(definition)
+                .chain(references.into_iter().filter(|span| *span != definition))
+                .map(|span| match visitor.shorthands.contains(&span) {
+                    true => (span, format!("{new_name}: {old_name}")),
+                    false => (span, new_name.to_owned()),
+                })
+                .collect())
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use escalier_parser::parse;
+
+    use super::*;
+
+    // Returns the source of the definition of the nth occurrence of `name`.
+    fn definition_of(src: &str, name: &str, nth: usize) -> Option<String> {
+        let script = parse(src).unwrap();
+        let (offset, _) = src.match_indices(name).nth(nth).unwrap();
+        let span = get_definition(&script, offset)?;
+        let line = src[..span.start].matches('\n').count();
+        Some(format!("{} @ line {line}", &src[span.start..span.end]))
+    }
+
+    #[test]
+    fn top_level_bindings() {
+        let src = "let a = 5\nlet b = a + 1\n";
+
+        assert_eq!(definition_of(src, "a", 1), Some("a @ line 0".to_string()));
+        assert_eq!(definition_of(src, "b", 0), Some("b @ line 1".to_string()));
+    }
+
+    #[test]
+    fn inner_bindings_shadow_outer_bindings() {
+        let src = r#"
+let x = 5
+let f = fn (x) {
+    let y = x
+    return y
+}
+let z = x
+"#;
+
+        assert_eq!(definition_of(src, "x", 2), Some("x @ line 2".to_string()));
+        assert_eq!(definition_of(src, "x", 3), Some("x @ line 1".to_string()));
+        assert_eq!(definition_of(src, "y", 1), Some("y @ line 3".to_string()));
+    }
+
+    #[test]
+    fn functions_can_reference_later_bindings() {
+        let src = r#"
+let isEven = fn (n) => if (n == 0) { true } else { isOdd(n - 1) }
+let isOdd = fn (n) => if (n == 0) { false } else { isEven(n - 1) }
+"#;
+
+        assert_eq!(
+            definition_of(src, "isOdd", 0),
+            Some("isOdd @ line 2".to_string())
+        );
+        assert_eq!(
+            definition_of(src, "isEven", 1),
+            Some("isEven @ line 1".to_string())
+        );
+    }
+
+    #[test]
+    fn match_arm_bindings() {
+        let src = r#"
+let n = 5
+let result = match (n) {
+    0 => 0,
+    n => n + 1
+}
+"#;
+
+        assert_eq!(definition_of(src, "n", 1), Some("n @ line 1".to_string()));
+        assert_eq!(definition_of(src, "n", 3), Some("n @ line 4".to_string()));
+    }
+
+    #[test]
+    fn object_literal_members() {
+        let src = r#"
+let x = 5
+let point = {
+    x,
+    y: 10,
+}
+let sum = point.x + point.y
+"#;
+
+        assert_eq!(definition_of(src, "x", 2), Some("x @ line 3".to_string()));
+        assert_eq!(definition_of(src, "y", 1), Some("y @ line 4".to_string()));
+        assert_eq!(
+            definition_of(src, "point", 1),
+            Some("point @ line 2".to_string())
+        );
+    }
+
+    #[test]
+    fn class_members() {
+        let src = r#"
+let Counter = class {
+    count: number
+    fn increment(mut self) {
+        self.count = self.count + 1
+    }
+}
+let counter = new Counter()
+counter.increment()
+let count = counter.count
+"#;
+
+        assert_eq!(
+            definition_of(src, "increment", 1),
+            Some("increment @ line 3".to_string())
+        );
+        assert_eq!(
+            definition_of(src, "count", 7),
+            Some("count @ line 2".to_string())
+        );
+    }
+
+    #[test]
+    fn namespace_members() {
+        let src = r#"
+namespace Geometry {
+    let PI = 3.14
+    let area = fn (r) => PI * r * r
+    namespace Units {
+        let scale = 10
+    }
+}
+namespace Geo = Geometry
+let a = Geometry.area(Geometry.PI)
+let u = Geo.Units
+"#;
+
+        assert_eq!(definition_of(src, "PI", 1), Some("PI @ line 2".to_string()));
+        assert_eq!(
+            definition_of(src, "area", 1),
+            Some("area @ line 3".to_string())
+        );
+        assert_eq!(definition_of(src, "PI", 2), Some("PI @ line 2".to_string()));
+        assert_eq!(
+            definition_of(src, "Geometry", 1),
+            Some("Geometry @ line 1".to_string())
+        );
+        assert_eq!(
+            definition_of(src, "Units", 1),
+            Some("Units @ line 4".to_string())
+        );
+    }
+
+    #[test]
+    fn namespace_members_are_not_in_scope_outside_of_it() {
+        let src = r#"
+namespace Foo {
+    let x = 5
+}
+let y = x
+"#;
+
+        assert_eq!(definition_of(src, "x", 1), None);
+    }
+
+    #[test]
+    fn unknown_members() {
+        let src = "let f = fn (obj) => obj.x\n";
+
+        assert_eq!(definition_of(src, "x", 0), None);
+    }
+    // Returns the lines and source of each reference to the nth occurrence of
+    // `name`.
+    fn references_to(src: &str, name: &str, nth: usize, include_declaration: bool) -> Vec<String> {
+        let script = parse(src).unwrap();
+        let (offset, _) = src.match_indices(name).nth(nth).unwrap();
+        get_references(&script, offset, include_declaration)
+            .into_iter()
+            .map(|span| {
+                let line = src[..span.start].matches('\n').count();
+                format!("{} @ line {line}", &src[span.start..span.end])
+            })
+            .collect()
+    }
+
+    #[test]
+    fn references_to_bindings() {
+        let src = r#"
+let x = 5
+let f = fn (x) => x + 1
+let y = x * 2
+let z = f(x)
+"#;
+
+        assert_eq!(
+            references_to(src, "x", 0, true),
+            vec!["x @ line 1", "x @ line 3", "x @ line 4"]
+        );
+        assert_eq!(
+            references_to(src, "x", 3, false),
+            vec!["x @ line 3", "x @ line 4"]
+        );
+        assert_eq!(
+            references_to(src, "x", 2, true),
+            vec!["x @ line 2", "x @ line 2"]
+        );
+    }
+
+    #[test]
+    fn references_to_members() {
+        let src = r#"
+let point = {x: 5, y: 10}
+let sum = point.x + point.y
+let double = point.x * 2
+"#;
+
+        assert_eq!(
+            references_to(src, "x", 0, false),
+            vec!["x @ line 2", "x @ line 3"]
+        );
+        assert_eq!(
+            references_to(src, "y", 1, true),
+            vec!["y @ line 1", "y @ line 2"]
+        );
+    }
+    // Renames the nth occurrence of `name` and returns the updated source.
+    fn rename(src: &str, name: &str, nth: usize, new_name: &str) -> Result<String, String> {
+        let script = parse(src).unwrap();
+        let (offset, _) = src.match_indices(name).nth(nth).unwrap();
+        let mut edits = get_rename_edits(&script, offset, new_name)?;
+        edits.sort_by_key(|(span, _)| std::cmp::Reverse(span.start));
+
+        let mut result = src.to_string();
+        for (span, text) in edits {
+            result.replace_range(span.start..span.end, &text);
+        }
+        Ok(result)
+    }
+
+    #[test]
+    fn rename_bindings() {
+        let src = r#"
+let x = 5
+let f = fn (x) => x + 1
+let y = f(x) + x
+"#;
+
+        insta::assert_snapshot!(rename(src, "x", 0, "count").unwrap(), @r###"
+        let count = 5
+        let f = fn (x) => x + 1
+        let y = f(count) + count
+        "###);
+        insta::assert_snapshot!(rename(src, "x", 2, "n").unwrap(), @r###"
+        let x = 5
+        let f = fn (n) => n + 1
+        let y = f(x) + x
+        "###);
+    }
+
+    #[test]
+    fn rename_shorthands() {
+        let src = r#"
+let x = 5
+let point = {x, y: 10}
+let {y} = point
+let sum = point.x + y
+"#;
+
+        insta::assert_snapshot!(rename(src, "x", 0, "a").unwrap(), @r###"
+        let a = 5
+        let point = {x: a, y: 10}
+        let {y} = point
+        let sum = point.x + y
+        "###);
+        insta::assert_snapshot!(rename(src, "x", 2, "b").unwrap(), @r###"
+        let x = 5
+        let point = {b: x, y: 10}
+        let {y} = point
+        let sum = point.b + y
+        "###);
+        insta::assert_snapshot!(rename(src, "y", 1, "c").unwrap(), @r###"
+        let x = 5
+        let point = {x, y: 10}
+        let {y: c} = point
+        let sum = point.x + c
+        "###);
+    }
+
+    #[test]
+    fn rename_errors() {
+        let src = r#"
+let x = 5
+let y = 10
+let f = fn (z) => x + z
+let point = {x, y}
+"#;
+
+        assert_eq!(
+            rename(src, "x", 0, "let"),
+            Err("let isn't a valid identifier".to_string())
+        );
+        assert_eq!(
+            rename(src, "x", 0, "a b"),
+            Err("a b isn't a valid identifier".to_string())
+        );
+        assert_eq!(
+            rename(src, "x", 0, "y"),
+            Err("y is already declared in this scope".to_string())
+        );
+        assert_eq!(
+            rename(src, "z", 0, "x"),
+            Err("Renaming z to x would change what x refers to".to_string())
+        );
+        assert_eq!(
+            rename(src, "x", 2, "y"),
+            Err("y is already a property of this object".to_string())
+        );
+        assert_eq!(
+            rename(src, "5", 0, "a"),
+            Err("There's nothing to rename here".to_string())
+        );
+    }
+}
@@ -0,0 +1,373 @@
+use lsp_types::{DocumentSymbol, Range, SymbolKind, SymbolTag};
+
+use escalier_ast::expr::Prop;
+use escalier_ast::*;
+
+use crate::util::get_position;
+
+fn get_range(src: &str, span: Span) -> Range {
+    Range {
+        start: get_position(src, span.start),
+        end: get_position(src, span.end),
+    }
+}
+
+#[allow(deprecated)] // `DocumentSymbol::deprecated` has been replaced by `tags`
+fn new_symbol(
+    src: &str,
+    name: &str,
+    kind: SymbolKind,
+    span: Span,
+    name_span: Span,
+    children: Vec<DocumentSymbol>,
+) -> DocumentSymbol {
+    DocumentSymbol {
+        name: name.to_owned(),
+        detail: None,
+        kind,
+        tags: None,
+        deprecated: None,
+        range: get_range(src, span),
+        selection_range: get_range(src, name_span),
+        children: match children.is_empty() {
+            true => None,
+            false => Some(children),
+        },
+    }
+}
+
+// Returns the identifiers bound by `pattern` in the order they appear.
+fn get_binding_idents(pattern: &Pattern) -> Vec<&BindingIdent> {
+    match &pattern.kind {
+        PatternKind::Ident(ident) => vec![ident],
+        PatternKind::Rest(RestPat { arg }) => get_binding_idents(arg),
+        PatternKind::Object(ObjectPat { props, .. }) => props
+            .iter()
+            .flat_map(|prop| match prop {
+                ObjectPatProp::KeyValue(KeyValuePatProp { value, .. }) => get_binding_idents(value),
+                ObjectPatProp::Shorthand(ShorthandPatProp { ident, .. }) => vec![ident],
+                ObjectPatProp::Rest(RestPat { arg }) => get_binding_idents(arg),
+            })
+            .collect(),
+        PatternKind::Tuple(TuplePat { elems, .. }) => elems
+            .iter()
+            .flatten()
+            .flat_map(|elem| get_binding_idents(&elem.pattern))
+            .collect(),
+        PatternKind::Is(IsPat { ident, .. }) => vec![ident],
+        PatternKind::Lit(_) | PatternKind::Range(_) | PatternKind::Or(_) => vec![],
+        PatternKind::Wildcard => vec![],
+    }
+}
+
+fn get_object_symbols(src: &str, properties: &[PropOrSpread]) -> Vec<DocumentSymbol> {
+    properties
+        .iter()
+        .filter_map(|prop| match prop {
+            PropOrSpread::Prop(Prop::Shorthand(ident)) => Some(new_symbol(
+                src,
+                &ident.name,
+                SymbolKind::PROPERTY,
+                ident.span,
+                ident.span,
+                vec![],
+            )),
+            PropOrSpread::Prop(Prop::Property {
+                key: ObjectKey::Ident(ident),
+                value,
+            }) => {
+                let span = Span {
+                    start: ident.span.start,
+                    end: value.span.end,
+                };
+                let (kind, children) = get_value_symbols(src, value, SymbolKind::PROPERTY);
+                Some(new_symbol(
+                    src,
+                    &ident.name,
+                    kind,
+                    span,
+                    ident.span,
+                    children,
+                ))
+            }
+            _ => None,
+        })
+        .collect()
+}
+
+fn get_class_symbols(src: &str, body: &[ClassMember]) -> Vec<DocumentSymbol> {
+    body.iter()
+        .filter_map(|member| {
+            let (name, kind, span) = match member {
+                // The spans of fields include whitespace after them so we use
+                // the end of the type annotation or initializer instead.
+                ClassMember::Field(Field {
+                    name,
+                    type_ann,
+                    init,
+                    ..
+                }) => {
+                    let end = match (init, type_ann) {
+                        (Some(init), _) => init.span.end,
+                        (None, Some(type_ann)) => type_ann.span.end,
+                        (None, None) => name.span.end,
+                    };
+                    let span = Span {
+                        start: name.span.start,
+                        end,
+                    };
+                    (name, SymbolKind::FIELD, span)
+                }
+                ClassMember::Method(Method {
+                    name: PropName::Ident(name),
+                    span,
+                    ..
+                }) => match name.name.as_str() {
+                    "constructor" => (name, SymbolKind::CONSTRUCTOR, *span),
+                    _ => (name, SymbolKind::METHOD, *span),
+                },
+                ClassMember::Getter(Getter {
+                    name: PropName::Ident(name),
+                    span,
+                    ..
+                })
+                | ClassMember::Setter(Setter {
+                    name: PropName::Ident(name),
+                    span,
+                    ..
+                }) => (name, SymbolKind::PROPERTY, *span),
+                _ => return None,
+            };
+            Some(new_symbol(src, &name.name, kind, span, name.span, vec![]))
+        })
+        .collect()
+}
+
+// Returns the kind of symbol for a binding or property initialized with
+// `value` along with the symbols nested inside of it.
+fn get_value_symbols(
+    src: &str,
+    value: &Expr,
+    default_kind: SymbolKind,
+) -> (SymbolKind, Vec<DocumentSymbol>) {
+    match &value.kind {
+        ExprKind::Function(_) => match default_kind {
+            SymbolKind::PROPERTY => (SymbolKind::METHOD, vec![]),
+            _ => (SymbolKind::FUNCTION, vec![]),
+        },
+        ExprKind::Class(Class { body, .. }) => (SymbolKind::CLASS, get_class_symbols(src, body)),
+        ExprKind::Object(Object { properties }) => {
+            (default_kind, get_object_symbols(src, properties))
+        }
+        _ => (default_kind, vec![]),
+    }
+}
+
+fn get_decl_symbols(src: &str, decl: &Decl) -> Vec<DocumentSymbol> {
+    let mut symbols = match &decl.kind {
+        DeclKind::VarDecl(VarDecl {
+            pattern,
+            expr,
+            is_var,
+            ..
+        }) => {
+            let default_kind = match is_var {
+                true => SymbolKind::VARIABLE,
+                false => SymbolKind::CONSTANT,
+            };
+            match (&pattern.kind, expr) {
+                (PatternKind::Ident(ident), Some(expr)) => {
+                    let (kind, children) = get_value_symbols(src, expr, default_kind);
+                    vec![new_symbol(
+                        src,
+                        &ident.name,
+                        kind,
+                        decl.span,
+                        ident.span,
+                        children,
+                    )]
+                }
+                _ => get_binding_idents(pattern)
+                    .into_iter()
+                    .map(|ident| {
+                        new_symbol(
+                            src,
+                            &ident.name,
+                            default_kind,
+                            decl.span,
+                            ident.span,
+                            vec![],
+                        )
+                    })
+                    .collect(),
+            }
+        }
+        // TODO: include the span of the name in TypeDecl
+        DeclKind::TypeDecl(TypeDecl {
+            name, is_interface, ..
+        }) => {
+            let kind = match is_interface {
+                true => SymbolKind::INTERFACE,
+                false => SymbolKind::TYPE_PARAMETER,
+            };
+            vec![new_symbol(src, name, kind, decl.span, decl.span, vec![])]
+        }
+        DeclKind::GlobalDecl(GlobalDecl { decls }) => {
+            let children = decls
+                .iter()
+                .flat_map(|decl| get_decl_symbols(src, decl))
+                .collect();
+            vec![new_symbol(
+                src,
+                "global",
+                SymbolKind::NAMESPACE,
+                decl.span,
+                decl.span,
+                children,
+            )]
+        }
+        DeclKind::NamespaceDecl(NamespaceDecl { name, decls, .. }) => {
+            let children = decls
+                .iter()
+                .flat_map(|decl| get_decl_symbols(src, decl))
+                .collect();
+            vec![new_symbol(
+                src,
+                &name.name,
+                SymbolKind::NAMESPACE,
+                decl.span,
+                name.span,
+                children,
+            )]
+        }
+        DeclKind::NamespaceAliasDecl(NamespaceAliasDecl { name, .. }) => {
+            vec![new_symbol(
+                src,
+                &name.name,
+                SymbolKind::NAMESPACE,
+                decl.span,
+                name.span,
+                vec![],
+            )]
+        }
+    };
+
+    if decl
+        .annotations
+        .iter()
+        .any(|annotation| annotation.name == "deprecated")
+    {
+        for symbol in symbols.iter_mut() {
+            symbol.tags = Some(vec![SymbolTag::DEPRECATED]);
+        }
+    }
+
+    symbols
+}
+
+/// Returns an outline of the top-level declarations in `script`, classes and
+/// object literals include their members as children.  This only looks at
+/// the AST so it works even if the script has type errors.
+pub fn get_document_symbols(src: &str, script: &Script) -> Vec<DocumentSymbol> {
+    script
+        .stmts
+        .iter()
+        .flat_map(|stmt| match &stmt.kind {
+            StmtKind::Decl(decl) => get_decl_symbols(src, decl),
+            _ => vec![],
+        })
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use escalier_parser::parse;
+
+    use super::*;
+
+    // Prints each symbol on its own line, indented by its depth.
+    fn print_symbols(symbols: &[DocumentSymbol], depth: usize, output: &mut String) {
+        for symbol in symbols {
+            let Range { start, end } = symbol.range;
+            output.push_str(&format!(
+                "{}{} {:?} {}:{}-{}:{}{}\n",
+                "  ".repeat(depth),
+                symbol.name,
+                symbol.kind,
+                start.line,
+                start.character,
+                end.line,
+                end.character,
+                match &symbol.tags {
+                    Some(_) => " (deprecated)",
+                    None => "",
+                }
+            ));
+            if let Some(children) = &symbol.children {
+                print_symbols(children, depth + 1, output);
+            }
+        }
+    }
+
+    fn outline(src: &str) -> String {
+        let script = parse(src).unwrap();
+        let mut output = String::new();
+        print_symbols(&get_document_symbols(src, &script), 0, &mut output);
+        output
+    }
+
+    #[test]
+    fn top_level_declarations() {
+        let src = r#"let add = fn (a: number, b: number) => a + b
+var count = 0
+let [x, {y}] = [1, {y: 2}]
+type Point = {x: number, y: number}
+interface Shape {area: number}
+@deprecated("use add")
+let sum = add
+"#;
+
+        insta::assert_snapshot!(outline(src), @r###"
+        add FUNCTION 0:0-0:44
+        count VARIABLE 1:0-1:13
+        x CONSTANT 2:0-2:26
+        y CONSTANT 2:0-2:26
+        Point TYPE_PARAMETER 3:0-3:35
+        Shape INTERFACE 4:0-4:30
+        sum CONSTANT 6:0-6:13 (deprecated)
+        "###);
+    }
+
+    #[test]
+    fn classes_and_objects() {
+        let src = r#"let Point = class {
+    x: number
+    fn constructor(mut self, x: number) {
+        self.x = x
+    }
+    fn length(self) {
+        return self.x
+    }
+}
+let config = {
+    name: "escalier",
+    format: fn () => "json",
+    nested: {debug: true},
+}
+let result = add(1, 2)
+"#;
+
+        insta::assert_snapshot!(outline(src), @r###"
+        Point CLASS 0:0-8:1
+          x FIELD 1:4-1:13
+          constructor CONSTRUCTOR 2:4-4:5
+          length METHOD 5:4-7:5
+        config CONSTANT 9:0-13:1
+          name PROPERTY 10:4-10:20
+          format METHOD 11:4-11:27
+          nested PROPERTY 12:4-12:25
+            debug PROPERTY 12:13-12:24
+        result CONSTANT 14:0-14:22
+        "###);
+    }
+}
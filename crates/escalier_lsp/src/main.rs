@@ -1,13 +1,15 @@
-use std::collections::HashMap;
 use std::error::Error;
 use std::fs;
-use swc_common::source_map::SourceFile;
 
 use lsp_server::Connection;
 use lsp_types::*;
 
+mod code_actions;
+mod definition;
+mod document_symbols;
 mod semantic_tokens;
 mod server;
+mod signature_help;
 mod util;
 
 use server::LanguageServer;
@@ -25,6 +27,16 @@ fn main() -> Result<(), Box<dyn Error + Sync + Send>> {
     // Run the server and wait for the two threads to end (typically by trigger LSP Exit event).
     let server_capabilities = serde_json::to_value(ServerCapabilities {
         hover_provider: Some(HoverProviderCapability::Simple(true)),
+        definition_provider: Some(OneOf::Left(true)),
+        references_provider: Some(OneOf::Left(true)),
+        rename_provider: Some(OneOf::Left(true)),
+        document_symbol_provider: Some(OneOf::Left(true)),
+        code_action_provider: Some(CodeActionProviderCapability::Simple(true)),
+        signature_help_provider: Some(SignatureHelpOptions {
+            trigger_characters: Some(vec!["(".to_string(), ",".to_string()]),
+            retrigger_characters: None,
+            work_done_progress_options: WorkDoneProgressOptions::default(),
+        }),
         text_document_sync: Some(TextDocumentSyncCapability::Kind(TextDocumentSyncKind::FULL)),
         semantic_tokens_provider: Some(SemanticTokensServerCapabilities::SemanticTokensOptions(
             SemanticTokensOptions {
@@ -57,11 +69,17 @@ fn main() -> Result<(), Box<dyn Error + Sync + Send>> {
     .unwrap();
 
     let initialization_params = connection.initialize(server_capabilities)?;
-    let _params: InitializeParams = serde_json::from_value(initialization_params).unwrap();
+    let params: InitializeParams = serde_json::from_value(initialization_params).unwrap();
 
     let lib = fs::read_to_string(LIB_ES5_D_TS).unwrap();
-    let file_cache: HashMap<Url, SourceFile> = HashMap::new();
-    let mut server = LanguageServer { lib, file_cache };
+    let mut server = LanguageServer::new(lib);
+
+    // e.g. `{"hoverMaxWidth": 80}`, `null` prints hovers in full.
+    if let Some(options) = &params.initialization_options {
+        if let Some(max_width) = options.get("hoverMaxWidth") {
+            server.hover_options.max_width = max_width.as_u64().map(|width| width as usize);
+        }
+    }
 
     server.main_loop(&connection)?;
 
@@ -7,7 +7,7 @@ use escalier_ast::*;
 
 use crate::util::*;
 
-pub fn get_semantic_tokens(file: &SourceFile, program: &mut Script) -> Vec<SemanticToken> {
+pub fn get_semantic_tokens(file: &SourceFile, program: &Script) -> Vec<SemanticToken> {
     let mut visitor = SemanticTokenVisitor {
         file,
         raw_tokens: vec![],
@@ -94,6 +94,7 @@ impl<'a> Visitor for SemanticTokenVisitor<'a> {
             ExprKind::Num(_) => Some(11),
             ExprKind::Bool(_) => None,
             ExprKind::Str(_) => Some(10),
+            ExprKind::Regex(_) => Some(12),
             ExprKind::Null(_) => None,
             ExprKind::Undefined(_) => None,
             ExprKind::Binary(_) => None,
@@ -184,16 +185,21 @@ impl<'a> Visitor for SemanticTokenVisitor<'a> {
             TypeAnnKind::Number => Some(0),
             TypeAnnKind::StrLit(_) => Some(10),
             TypeAnnKind::String => Some(0),
+            TypeAnnKind::TemplateLiteral(_) => None,
+            TypeAnnKind::Regex(_) => Some(12),
             TypeAnnKind::Symbol => None,
+            TypeAnnKind::UniqueSymbol => None,
             TypeAnnKind::Null => None,
             TypeAnnKind::Undefined => None,
             TypeAnnKind::Unknown => Some(0),
             TypeAnnKind::Never => Some(0),
+            TypeAnnKind::Void => Some(0),
             TypeAnnKind::Rest(_) => None,
             TypeAnnKind::TypeOf(_) => None,
             TypeAnnKind::Match(_) => None,
             TypeAnnKind::Wildcard => None,
             TypeAnnKind::Binary(_) => None,
+            TypeAnnKind::Predicate(_) => None,
         };
 
         let TypeAnn { span, .. } = type_ann;
@@ -8,26 +8,86 @@ use lsp_server::{
     Connection, ErrorCode, ExtractError, Message, Notification, Request, RequestId, Response,
     ResponseError,
 };
-use lsp_types::notification::{DidChangeTextDocument, DidOpenTextDocument};
-use lsp_types::request::{HoverRequest, SemanticTokensFullRequest};
+use lsp_types::notification::{
+    DidChangeTextDocument, DidOpenTextDocument, Notification as _, PublishDiagnostics,
+};
+use lsp_types::request::{
+    CodeActionRequest, DocumentSymbolRequest, GotoDefinition, HoverRequest, References, Rename,
+    SemanticTokensFullRequest, SignatureHelpRequest,
+};
 use lsp_types::*;
 
 use escalier_ast::{
-    walk_expr, walk_pattern, walk_stmt, walk_type_ann, Expr, Pattern, Script, Stmt, TypeAnn,
+    walk_expr, walk_pattern, walk_stmt, walk_type_ann, Expr, Pattern, Script, Span, Stmt, TypeAnn,
     Visitor,
 };
+use escalier_hm::checker::Checker;
+use escalier_hm::context::Context;
+use escalier_hm::diagnostic::Severity;
+use escalier_hm::type_error::TypeError;
+use escalier_hm::types::PrintOptions;
 use escalier_interop::parse::parse_dts;
-use escalier_parser::parse;
+use escalier_parser::{parse, ParseError};
 
+use crate::code_actions::get_annotation_actions;
+use crate::definition::{get_definition, get_references, get_rename_edits};
+use crate::document_symbols::get_document_symbols;
 use crate::semantic_tokens::get_semantic_tokens;
+use crate::signature_help::get_signature_help;
 use crate::util;
 
+// Documents with types that take more steps than this to expand report an
+// error instead of hanging the server.
+const EXPANSION_BUDGET: usize = 1_000_000;
+
+// Unions and object types in hovers that are wider than this are cut short
+// unless the client sets `hoverMaxWidth` in its initialization options.
+pub const DEFAULT_HOVER_MAX_WIDTH: usize = 120;
+
 pub struct LanguageServer {
     pub lib: String,
     pub file_cache: HashMap<Url, SourceFile>,
+    // Documents are parsed when they're opened or changed so that requests
+    // don't have to parse them again.
+    pub ast_cache: HashMap<Url, ParsedDocument>,
+    // Documents are type checked the first time a request needs the results,
+    // these are reused until the document changes.
+    pub check_cache: HashMap<Url, CheckedDocument>,
+    // How types are printed in hovers, the full type is also shown when it's
+    // cut short.
+    pub hover_options: PrintOptions,
+}
+
+pub struct ParsedDocument {
+    pub version: i32,
+    pub script: Result<Script, ParseError>,
+}
+
+pub struct CheckedDocument {
+    // The version of the document that was checked.
+    pub version: i32,
+    pub checker: Checker,
+    pub ctx: Context,
+    // A copy of the parsed script with the inferred types filled in.
+    pub script: Script,
+    // Set if inference stopped early, the types in `script` may be incomplete.
+    pub error: Option<TypeError>,
 }
 
 impl LanguageServer {
+    pub fn new(lib: String) -> Self {
+        LanguageServer {
+            lib,
+            file_cache: HashMap::new(),
+            ast_cache: HashMap::new(),
+            check_cache: HashMap::new(),
+            hover_options: PrintOptions {
+                max_width: Some(DEFAULT_HOVER_MAX_WIDTH),
+                ..PrintOptions::default()
+            },
+        }
+    }
+
     pub fn main_loop(
         &mut self,
         connection: &Connection,
@@ -41,7 +101,11 @@ impl LanguageServer {
                     eprintln!("got response: {resp:?}");
                 }
                 Message::Notification(note) => {
+                    let uri = get_text_document_uri(&note);
                     self.handle_notification(note)?;
+                    if let Some(uri) = uri {
+                        self.publish_diagnostics(connection, &uri)?;
+                    }
                 }
             }
         }
@@ -49,7 +113,7 @@ impl LanguageServer {
     }
 
     pub fn handle_request(
-        &self,
+        &mut self,
         connection: &Connection,
         req: Request,
     ) -> Result<(), Box<dyn Error + Sync + Send>> {
@@ -64,55 +128,48 @@ impl LanguageServer {
 
                 eprintln!("Handling HoverRequest");
 
-                // NOTE: This is slow so we'll want to do this once once
-                // on startup and re-use the results.
-                eprintln!("parsing .d.ts");
-                let (mut checker, mut ctx) = match parse_dts(&self.lib) {
-                    Ok(value) => {
-                        eprintln!("success");
-                        value
-                    }
-                    Err(_) => {
-                        eprintln!("error");
-                        panic!("parsing .d.ts file failed");
-                    }
-                };
-
-                let start = SystemTime::now()
-                    .duration_since(UNIX_EPOCH)
-                    .expect("Time went backwards");
-
-                let file = self
-                    .file_cache
-                    .get(&params.text_document_position_params.text_document.uri)
-                    .unwrap();
-
-                // Update ParseError to implement Error + Sync + Send
-                eprintln!("parsing input");
-                let mut program = parse(&file.src).unwrap();
-
-                // Update TypeError to implement Error + Sync + Send
-                eprintln!("inferring types");
-                checker.infer_script(&mut program, &mut ctx).unwrap();
-
-                eprintln!("program = {program:#?}");
-
-                let end = SystemTime::now()
-                    .duration_since(UNIX_EPOCH)
-                    .expect("Time went backwards");
-                let elapsed = end - start;
-                eprintln!("request took {}ms", elapsed.as_millis());
+                let uri = &params.text_document_position_params.text_document.uri;
+                self.check_document(uri);
 
                 // TODO: create a From impl to convert from one Position to another.
                 let cursor_loc = params.text_document_position_params.position;
 
-                let message = match get_type_at_location(file, &program, &cursor_loc) {
-                    Some(t) => checker.print_type(&t),
-                    None => String::from("no type info"),
+                let t = match (self.file_cache.get(uri), self.check_cache.get(uri)) {
+                    (Some(file), Some(checked)) => {
+                        get_type_at_location(file, &checked.script, &cursor_loc)
+                            .map(|t| (t, checked))
+                    }
+                    _ => None,
+                };
+                let contents = match t {
+                    Some((t, checked)) => {
+                        let short = checked
+                            .checker
+                            .print_type_with_options(&t, &self.hover_options);
+                        let full = checked.checker.print_type_with_options(
+                            &t,
+                            &PrintOptions {
+                                max_width: None,
+                                ..self.hover_options.clone()
+                            },
+                        );
+                        // The full type is shown below the shortened one so
+                        // that it's still available when it's needed.
+                        match short == full {
+                            true => HoverContents::Scalar(MarkedString::String(full)),
+                            false => HoverContents::Array(vec![
+                                MarkedString::String(short),
+                                MarkedString::String(full),
+                            ]),
+                        }
+                    }
+                    None => {
+                        HoverContents::Scalar(MarkedString::String(String::from("no type info")))
+                    }
                 };
 
                 let result = Some(Hover {
-                    contents: HoverContents::Scalar(MarkedString::String(message)),
+                    contents,
                     range: None,
                 });
                 let resp = Response {
@@ -122,6 +179,76 @@ impl LanguageServer {
                 };
                 connection.sender.send(Message::Response(resp))?;
             }
+            "textDocument/definition" => {
+                let (id, params) = cast_req::<GotoDefinition>(req)?;
+                let result = self.handle_definition(&params.text_document_position_params);
+                let resp = Response {
+                    id,
+                    result: Some(serde_json::to_value(result).unwrap()),
+                    error: None,
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
+            "textDocument/references" => {
+                let (id, params) = cast_req::<References>(req)?;
+                let result = self.handle_references(&params);
+                let resp = Response {
+                    id,
+                    result: Some(serde_json::to_value(result).unwrap()),
+                    error: None,
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
+            "textDocument/rename" => {
+                let (id, params) = cast_req::<Rename>(req)?;
+                let resp = match self.handle_rename(&params) {
+                    Ok(result) => Response {
+                        id,
+                        result: Some(serde_json::to_value(result).unwrap()),
+                        error: None,
+                    },
+                    Err(message) => Response {
+                        id,
+                        result: None,
+                        error: Some(ResponseError {
+                            code: ErrorCode::InvalidParams as i32,
+                            message,
+                            data: None,
+                        }),
+                    },
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
+            "textDocument/signatureHelp" => {
+                let (id, params) = cast_req::<SignatureHelpRequest>(req)?;
+                let result = self.handle_signature_help(&params.text_document_position_params);
+                let resp = Response {
+                    id,
+                    result: Some(serde_json::to_value(result).unwrap()),
+                    error: None,
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
+            "textDocument/codeAction" => {
+                let (id, params) = cast_req::<CodeActionRequest>(req)?;
+                let result = self.handle_code_action(&params);
+                let resp = Response {
+                    id,
+                    result: Some(serde_json::to_value(result).unwrap()),
+                    error: None,
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
+            "textDocument/documentSymbol" => {
+                let (id, params) = cast_req::<DocumentSymbolRequest>(req)?;
+                let result = self.handle_document_symbols(&params);
+                let resp = Response {
+                    id,
+                    result: Some(serde_json::to_value(result).unwrap()),
+                    error: None,
+                };
+                connection.sender.send(Message::Response(resp))?;
+            }
             "textDocument/semanticTokens/full" => {
                 let (id, params) = cast_req::<SemanticTokensFullRequest>(req)?;
                 let resp = self.handle_semantic_tokens(id, params);
@@ -146,33 +273,21 @@ impl LanguageServer {
                 let params = cast_note::<DidOpenTextDocument>(note)?;
                 let TextDocumentItem {
                     uri,
-                    version: _,
+                    version,
                     text,
                     language_id: _,
                 } = params.text_document;
 
-                let file = SourceFile::new(FileName::Anon, false, FileName::Anon, text, BytePos(1));
-
-                self.file_cache.insert(uri, file);
+                self.update_document(uri, version, text);
             }
             "textDocument/didChange" => {
                 let params = cast_note::<DidChangeTextDocument>(note)?;
-                let VersionedTextDocumentIdentifier { uri, version: _ } = params.text_document;
+                let VersionedTextDocumentIdentifier { uri, version } = params.text_document;
 
                 for change in params.content_changes {
                     match change.range {
                         Some(_) => todo!(),
-                        None => {
-                            let file = SourceFile::new(
-                                FileName::Anon,
-                                false,
-                                FileName::Anon,
-                                change.text.to_owned(),
-                                BytePos(1),
-                            );
-
-                            self.file_cache.insert(uri.to_owned(), file);
-                        }
+                        None => self.update_document(uri.to_owned(), version, change.text),
                     }
                 }
             }
@@ -184,6 +299,321 @@ impl LanguageServer {
         Ok(())
     }
 
+    // Replaces the contents of the document at `uri` and parses it.  The
+    // results of checking the previous version are no longer valid.
+    fn update_document(&mut self, uri: Url, version: i32, text: String) {
+        let script = parse(&text);
+        let file = SourceFile::new(FileName::Anon, false, FileName::Anon, text, BytePos(1));
+
+        self.file_cache.insert(uri.to_owned(), file);
+        self.ast_cache
+            .insert(uri.to_owned(), ParsedDocument { version, script });
+        self.check_cache.remove(&uri);
+    }
+
+    // Type checks the document at `uri` unless the current version has
+    // already been checked, the results are stored in `check_cache`.  Nothing
+    // is stored if the document couldn't be parsed.
+    fn check_document(&mut self, uri: &Url) {
+        let parsed = match self.ast_cache.get(uri) {
+            Some(parsed) => parsed,
+            None => return,
+        };
+        if let Some(checked) = self.check_cache.get(uri) {
+            if checked.version == parsed.version {
+                return;
+            }
+        }
+        let mut script = match &parsed.script {
+            Ok(script) => script.clone(),
+            Err(_) => return,
+        };
+
+        // NOTE: This is slow so we'll want to do this once
+        // on startup and re-use the results.
+        let (mut checker, mut ctx) = match parse_dts(&self.lib) {
+            Ok(value) => value,
+            Err(_) => {
+                eprintln!("parsing .d.ts file failed");
+                return;
+            }
+        };
+        checker.expansion_budget = Some(EXPANSION_BUDGET);
+
+        let start = SystemTime::now()
+            .duration_since(UNIX_EPOCH)
+            .expect("Time went backwards");
+
+        let error = checker.infer_script(&mut script, &mut ctx).err();
+        if error.is_none() {
+            if let Some(file) = self.file_cache.get(uri) {
+                checker.apply_suppressions(&file.src, &script.comments);
+            }
+        }
+
+        let end = SystemTime::now()
+            .duration_since(UNIX_EPOCH)
+            .expect("Time went backwards");
+        let elapsed = end - start;
+        eprintln!("checking took {}ms", elapsed.as_millis());
+
+        let checked = CheckedDocument {
+            version: parsed.version,
+            checker,
+            ctx,
+            script,
+            error,
+        };
+        self.check_cache.insert(uri.to_owned(), checked);
+    }
+
+    fn publish_diagnostics(
+        &mut self,
+        connection: &Connection,
+        uri: &Url,
+    ) -> Result<(), Box<dyn Error + Sync + Send>> {
+        if !self.file_cache.contains_key(uri) {
+            return Ok(());
+        }
+
+        let params = PublishDiagnosticsParams {
+            uri: uri.to_owned(),
+            diagnostics: self.get_diagnostics(uri),
+            version: None,
+        };
+        let note = Notification {
+            method: PublishDiagnostics::METHOD.to_string(),
+            params: serde_json::to_value(params)?,
+        };
+        connection.sender.send(Message::Notification(note))?;
+
+        Ok(())
+    }
+
+    fn get_diagnostics(&mut self, uri: &Url) -> Vec<Diagnostic> {
+        // TODO: include spans in ParseError and TypeError
+        if let Some(ParsedDocument {
+            script: Err(error), ..
+        }) = self.ast_cache.get(uri)
+        {
+            return vec![Diagnostic {
+                severity: Some(DiagnosticSeverity::ERROR),
+                message: error.message.to_owned(),
+                ..Default::default()
+            }];
+        }
+
+        self.check_document(uri);
+
+        let (file, checked) = match (self.file_cache.get(uri), self.check_cache.get(uri)) {
+            (Some(file), Some(checked)) => (file, checked),
+            _ => return vec![],
+        };
+        let src = &file.src;
+
+        if let Some(error) = &checked.error {
+            return vec![Diagnostic {
+                severity: Some(DiagnosticSeverity::ERROR),
+                message: error.message.to_owned(),
+                ..Default::default()
+            }];
+        }
+
+        checked
+            .checker
+            .current_report
+            .diagnostics
+            .iter()
+            .map(|diagnostic| {
+                let range = match diagnostic.span {
+                    Some(span) => Range {
+                        start: util::get_position(src, span.start),
+                        end: util::get_position(src, span.end),
+                    },
+                    None => Range::default(),
+                };
+                let severity = match diagnostic.severity {
+                    Severity::Error => DiagnosticSeverity::ERROR,
+                    Severity::Warning => DiagnosticSeverity::WARNING,
+                    Severity::Info => DiagnosticSeverity::INFORMATION,
+                };
+                let mut message = diagnostic.message.to_owned();
+                for reason in &diagnostic.reasons {
+                    message.push_str(&format!("\n{}", reason.message));
+                }
+                Diagnostic {
+                    range,
+                    severity: Some(severity),
+                    code: Some(NumberOrString::String(diagnostic.code_name())),
+                    source: Some("escalier".to_string()),
+                    message,
+                    ..Default::default()
+                }
+            })
+            .collect()
+    }
+
+    // Returns the source and parsed script of the document at `uri` along
+    // with the offset of `position` in it.
+    fn get_parsed_document(
+        &self,
+        uri: &Url,
+        position: &Position,
+    ) -> Option<(&SourceFile, &Script, usize)> {
+        let file = self.file_cache.get(uri)?;
+        let script = match self.ast_cache.get(uri)? {
+            ParsedDocument {
+                script: Ok(script), ..
+            } => script,
+            _ => return None,
+        };
+        let offset = util::get_offset(&file.src, position)?;
+
+        Some((file, script, offset))
+    }
+
+    fn handle_definition(
+        &self,
+        params: &TextDocumentPositionParams,
+    ) -> Option<GotoDefinitionResponse> {
+        let uri = &params.text_document.uri;
+        let (file, script, offset) = self.get_parsed_document(uri, &params.position)?;
+        let span = get_definition(script, offset)?;
+
+        Some(GotoDefinitionResponse::Scalar(get_location(
+            uri, &file.src, span,
+        )))
+    }
+
+    fn handle_references(&self, params: &ReferenceParams) -> Option<Vec<Location>> {
+        let TextDocumentPositionParams {
+            text_document,
+            position,
+        } = &params.text_document_position;
+        let uri = &text_document.uri;
+        let (file, script, offset) = self.get_parsed_document(uri, position)?;
+
+        let references = get_references(script, offset, params.context.include_declaration);
+
+        Some(
+            references
+                .into_iter()
+                .map(|span| get_location(uri, &file.src, span))
+                .collect(),
+        )
+    }
+
+    fn handle_rename(&self, params: &RenameParams) -> Result<Option<WorkspaceEdit>, String> {
+        let TextDocumentPositionParams {
+            text_document,
+            position,
+        } = &params.text_document_position;
+        let uri = &text_document.uri;
+        let (file, script, offset) = match self.get_parsed_document(uri, position) {
+            Some(document) => document,
+            None => return Ok(None),
+        };
+
+        let edits = get_rename_edits(script, offset, &params.new_name)?
+            .into_iter()
+            .map(|(span, new_text)| TextEdit {
+                range: get_location(uri, &file.src, span).range,
+                new_text,
+            })
+            .collect();
+
+        Ok(Some(WorkspaceEdit {
+            changes: Some(HashMap::from([(uri.to_owned(), edits)])),
+            ..Default::default()
+        }))
+    }
+
+    fn handle_signature_help(
+        &mut self,
+        params: &TextDocumentPositionParams,
+    ) -> Option<SignatureHelp> {
+        let uri = &params.text_document.uri;
+        self.check_document(uri);
+
+        let file = self.file_cache.get(uri)?;
+        let checked = self.check_cache.get_mut(uri)?;
+        let offset = util::get_offset(&file.src, &params.position)?;
+
+        get_signature_help(
+            &file.src,
+            &checked.script,
+            &mut checked.checker,
+            &checked.ctx,
+            offset,
+        )
+    }
+
+    fn handle_code_action(&mut self, params: &CodeActionParams) -> Option<CodeActionResponse> {
+        let uri = &params.text_document.uri;
+        self.check_document(uri);
+
+        let file = self.file_cache.get(uri)?;
+        let checked = self.check_cache.get_mut(uri)?;
+        let offset = util::get_offset(&file.src, &params.range.start)?;
+
+        let actions = get_annotation_actions(
+            &file.src,
+            &checked.script,
+            &mut checked.checker,
+            &checked.ctx,
+            offset,
+        );
+
+        Some(
+            actions
+                .into_iter()
+                .map(|action| {
+                    let edits = action
+                        .edits
+                        .into_iter()
+                        .map(|edit| {
+                            let position = util::get_position(&file.src, edit.offset);
+                            TextEdit {
+                                range: Range {
+                                    start: position,
+                                    end: position,
+                                },
+                                new_text: edit.text,
+                            }
+                        })
+                        .collect();
+                    CodeActionOrCommand::CodeAction(CodeAction {
+                        title: action.title,
+                        kind: Some(CodeActionKind::QUICKFIX),
+                        edit: Some(WorkspaceEdit {
+                            changes: Some(HashMap::from([(uri.to_owned(), edits)])),
+                            ..Default::default()
+                        }),
+                        ..Default::default()
+                    })
+                })
+                .collect(),
+        )
+    }
+
+    fn handle_document_symbols(
+        &self,
+        params: &DocumentSymbolParams,
+    ) -> Option<DocumentSymbolResponse> {
+        let uri = &params.text_document.uri;
+        let file = self.file_cache.get(uri)?;
+        let script = match self.ast_cache.get(uri)? {
+            ParsedDocument {
+                script: Ok(script), ..
+            } => script,
+            _ => return None,
+        };
+
+        Some(DocumentSymbolResponse::Nested(get_document_symbols(
+            &file.src, script,
+        )))
+    }
+
     fn handle_semantic_tokens(&self, id: RequestId, params: SemanticTokensParams) -> Response {
         // TODO: if it isn't in the cache yet, we should load it from disk
         // TODO: if we can't load it from disk then we should report an error
@@ -202,9 +632,11 @@ impl LanguageServer {
             }
         };
 
-        let mut prog = match parse(&file.src) {
-            Ok(prog) => prog,
-            Err(_) => {
+        let prog = match self.ast_cache.get(&params.text_document.uri) {
+            Some(ParsedDocument {
+                script: Ok(prog), ..
+            }) => prog,
+            _ => {
                 return Response {
                     id,
                     result: None,
@@ -218,7 +650,7 @@ impl LanguageServer {
         };
 
         let result = Some(SemanticTokensPartialResult {
-            data: get_semantic_tokens(file, &mut prog),
+            data: get_semantic_tokens(file, prog),
         });
 
         let value = match serde_json::to_value(result) {
@@ -313,6 +745,22 @@ fn get_type_at_location(
     visitor.t
 }
 
+fn get_location(uri: &Url, src: &str, span: Span) -> Location {
+    Location {
+        uri: uri.to_owned(),
+        range: Range {
+            start: util::get_position(src, span.start),
+            end: util::get_position(src, span.end),
+        },
+    }
+}
+
+// Returns the URI of the document a `textDocument/*` notification refers to.
+fn get_text_document_uri(note: &Notification) -> Option<Url> {
+    let uri = note.params.get("textDocument")?.get("uri")?;
+    serde_json::from_value(uri.to_owned()).ok()
+}
+
 fn cast_req<R>(req: Request) -> Result<(RequestId, R::Params), ExtractError<Request>>
 where
     R: lsp_types::request::Request,
@@ -340,12 +788,7 @@ mod tests {
 
     #[test]
     fn test_handle_notification_did_open() {
-        let file_cache = HashMap::new();
-
-        let mut server = LanguageServer {
-            file_cache,
-            lib: String::from(""),
-        };
+        let mut server = LanguageServer::new(String::from(""));
 
         let uri = Url::from_str("file://path/to/file.esc").unwrap();
         let params = DidOpenTextDocumentParams {
@@ -367,25 +810,16 @@ mod tests {
         assert!(server.file_cache.contains_key(&uri));
         let file = server.file_cache.get(&uri).unwrap();
         assert_eq!(file.src.to_string(), "let a = 5");
+        let parsed = server.ast_cache.get(&uri).unwrap();
+        assert_eq!(parsed.version, 123);
+        assert!(parsed.script.is_ok());
     }
 
     #[test]
     fn test_handle_notification_did_change() {
         let uri = Url::from_str("file://path/to/file.esc").unwrap();
-        let mut file_cache = HashMap::new();
-        let file = SourceFile::new(
-            FileName::Anon,
-            false,
-            FileName::Anon,
-            String::from("let a = 5"),
-            BytePos(1),
-        );
-        file_cache.insert(uri.to_owned(), file);
-
-        let mut server = LanguageServer {
-            file_cache,
-            lib: String::from(""),
-        };
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 123, String::from("let a = 5"));
 
         let params = DidChangeTextDocumentParams {
             text_document: VersionedTextDocumentIdentifier {
@@ -409,44 +843,102 @@ mod tests {
         assert!(server.file_cache.contains_key(&uri));
         let file = server.file_cache.get(&uri).unwrap();
         assert_eq!(file.src.to_string(), "let a = 10;");
+        assert_eq!(server.ast_cache.get(&uri).unwrap().version, 456);
     }
 
     #[test]
-    fn test_handle_hover_request() {
+    fn test_publish_deprecation_warnings() {
+        let mut server = LanguageServer::new(String::from(""));
+
         let uri = Url::from_str("file://path/to/file.esc").unwrap();
-        let mut file_cache = HashMap::new();
-        let file = SourceFile::new(
-            FileName::Anon,
-            false,
-            FileName::Anon,
-            String::from("let a = 5"),
-            BytePos(1),
-        );
-        file_cache.insert(uri.to_owned(), file);
+        let params = DidOpenTextDocumentParams {
+            text_document: TextDocumentItem {
+                uri: uri.to_owned(),
+                language_id: String::from("escalier"),
+                version: 123,
+                text: String::from("@deprecated(\"use b\")\nlet a = 5\nlet b = a"),
+            },
+        };
 
-        let server = LanguageServer {
-            file_cache,
-            lib: String::from(""),
+        let note = Notification {
+            method: String::from("textDocument/didOpen"),
+            params: to_value(params).unwrap(),
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.handle_notification(note).unwrap();
+        server.publish_diagnostics(&connection, &uri).unwrap();
+
+        let params = match writer_receiver.recv().unwrap() {
+            Message::Notification(note) => cast_note::<PublishDiagnostics>(note).unwrap(),
+            msg => panic!("expected notification, got {msg:?}"),
         };
 
+        assert_eq!(params.uri, uri);
+        assert_eq!(params.diagnostics.len(), 1);
+        let diagnostic = &params.diagnostics[0];
+        assert_eq!(diagnostic.severity, Some(DiagnosticSeverity::WARNING));
+        assert_eq!(diagnostic.message, "a is deprecated: use b");
+        assert_eq!(
+            diagnostic.range,
+            Range {
+                start: Position {
+                    line: 2,
+                    character: 8
+                },
+                end: Position {
+                    line: 2,
+                    character: 9
+                },
+            }
+        );
+    }
+
+    fn hover_request(id: i32, uri: &Url, line: u32, character: u32) -> Request {
         let params = HoverParams {
             text_document_position_params: TextDocumentPositionParams {
-                text_document: TextDocumentIdentifier { uri },
-                position: Position {
-                    line: 1, // deal with 1-indexing vs 0-indexing
-                    character: 4,
+                text_document: TextDocumentIdentifier {
+                    uri: uri.to_owned(),
                 },
+                position: Position { line, character },
             },
             work_done_progress_params: WorkDoneProgressParams {
                 work_done_token: None,
             },
         };
 
-        let req = Request {
-            id: RequestId::from(3),
+        Request {
+            id: RequestId::from(id),
             method: String::from("textDocument/hover"),
             params: to_value(params).unwrap(),
-        };
+        }
+    }
+
+    fn hover_contents(msg: Message) -> Value {
+        match msg {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => result["contents"].to_owned(),
+            msg => panic!("expected response, got {msg:?}"),
+        }
+    }
+
+    #[test]
+    fn test_handle_hover_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 1, String::from("let a = 5"));
+
+        // deal with 1-indexing vs 0-indexing
+        let req = hover_request(3, &uri, 1, 4);
 
         let (writer_sender, writer_receiver) = unbounded();
         let (_, reader_receiver) = unbounded();
@@ -481,4 +973,400 @@ mod tests {
         )
         "###);
     }
+    #[test]
+    fn test_check_results_are_reused_until_the_document_changes() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 1, String::from("let a = 5\nlet b = a"));
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.publish_diagnostics(&connection, &uri).unwrap();
+        writer_receiver.recv().unwrap();
+        assert_eq!(server.check_cache.get(&uri).unwrap().version, 1);
+        let arena_len = server.check_cache.get(&uri).unwrap().checker.arena.len();
+
+        // Requests for the same version use the cached results instead of
+        // checking the document again.
+        server
+            .handle_request(&connection, hover_request(1, &uri, 1, 4))
+            .unwrap();
+        server
+            .handle_request(&connection, hover_request(2, &uri, 2, 4))
+            .unwrap();
+        assert_eq!(hover_contents(writer_receiver.recv().unwrap()), "5");
+        assert_eq!(hover_contents(writer_receiver.recv().unwrap()), "5");
+        assert_eq!(
+            server.check_cache.get(&uri).unwrap().checker.arena.len(),
+            arena_len
+        );
+
+        let params = DidChangeTextDocumentParams {
+            text_document: VersionedTextDocumentIdentifier {
+                uri: uri.to_owned(),
+                version: 2,
+            },
+            content_changes: vec![TextDocumentContentChangeEvent {
+                range: None,
+                range_length: None, // deprecated
+                text: String::from("let a = \"hello\"\nlet b = a"),
+            }],
+        };
+        let note = Notification {
+            method: String::from("textDocument/didChange"),
+            params: to_value(params).unwrap(),
+        };
+        server.handle_notification(note).unwrap();
+
+        assert_eq!(server.ast_cache.get(&uri).unwrap().version, 2);
+        assert!(!server.check_cache.contains_key(&uri));
+
+        server
+            .handle_request(&connection, hover_request(3, &uri, 2, 4))
+            .unwrap();
+        assert_eq!(hover_contents(writer_receiver.recv().unwrap()), "\"hello\"");
+        assert_eq!(server.check_cache.get(&uri).unwrap().version, 2);
+    }
+    #[test]
+    fn test_handle_definition_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(
+            uri.to_owned(),
+            1,
+            String::from("let point = {x: 5, y: 10}\nlet x = point.x"),
+        );
+
+        let params = GotoDefinitionParams {
+            text_document_position_params: TextDocumentPositionParams {
+                text_document: TextDocumentIdentifier {
+                    uri: uri.to_owned(),
+                },
+                position: Position {
+                    line: 1,
+                    character: 14,
+                },
+            },
+            work_done_progress_params: WorkDoneProgressParams {
+                work_done_token: None,
+            },
+            partial_result_params: PartialResultParams {
+                partial_result_token: None,
+            },
+        };
+        let req = Request {
+            id: RequestId::from(4),
+            method: String::from("textDocument/definition"),
+            params: to_value(params).unwrap(),
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.handle_request(&connection, req).unwrap();
+
+        let result = match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => from_value::<GotoDefinitionResponse>(result).unwrap(),
+            msg => panic!("expected response, got {msg:?}"),
+        };
+
+        assert_eq!(
+            result,
+            GotoDefinitionResponse::Scalar(Location {
+                uri,
+                range: Range {
+                    start: Position {
+                        line: 0,
+                        character: 13
+                    },
+                    end: Position {
+                        line: 0,
+                        character: 14
+                    },
+                },
+            })
+        );
+    }
+    #[test]
+    fn test_handle_references_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 1, String::from("let a = 5\nlet b = a + a"));
+
+        let params = ReferenceParams {
+            text_document_position: TextDocumentPositionParams {
+                text_document: TextDocumentIdentifier {
+                    uri: uri.to_owned(),
+                },
+                position: Position {
+                    line: 0,
+                    character: 4,
+                },
+            },
+            work_done_progress_params: WorkDoneProgressParams {
+                work_done_token: None,
+            },
+            partial_result_params: PartialResultParams {
+                partial_result_token: None,
+            },
+            context: ReferenceContext {
+                include_declaration: false,
+            },
+        };
+        let req = Request {
+            id: RequestId::from(5),
+            method: String::from("textDocument/references"),
+            params: to_value(params).unwrap(),
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.handle_request(&connection, req).unwrap();
+
+        let result = match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => from_value::<Vec<Location>>(result).unwrap(),
+            msg => panic!("expected response, got {msg:?}"),
+        };
+
+        let characters = result
+            .iter()
+            .map(|location| (location.range.start.line, location.range.start.character))
+            .collect::<Vec<_>>();
+        assert_eq!(characters, vec![(1, 8), (1, 12)]);
+    }
+    #[test]
+    fn test_handle_rename_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 1, String::from("let a = 5\nlet b = a + 1"));
+
+        let rename_request = |id: i32, new_name: &str| {
+            let params = RenameParams {
+                text_document_position: TextDocumentPositionParams {
+                    text_document: TextDocumentIdentifier {
+                        uri: uri.to_owned(),
+                    },
+                    position: Position {
+                        line: 1,
+                        character: 8,
+                    },
+                },
+                new_name: new_name.to_string(),
+                work_done_progress_params: WorkDoneProgressParams {
+                    work_done_token: None,
+                },
+            };
+            Request {
+                id: RequestId::from(id),
+                method: String::from("textDocument/rename"),
+                params: to_value(params).unwrap(),
+            }
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server
+            .handle_request(&connection, rename_request(6, "count"))
+            .unwrap();
+        let edit = match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => from_value::<WorkspaceEdit>(result).unwrap(),
+            msg => panic!("expected response, got {msg:?}"),
+        };
+        let edits = edit.changes.unwrap().remove(&uri).unwrap();
+        let edits = edits
+            .iter()
+            .map(|edit| {
+                (
+                    edit.range.start.line,
+                    edit.range.start.character,
+                    edit.new_text.as_str(),
+                )
+            })
+            .collect::<Vec<_>>();
+        assert_eq!(edits, vec![(0, 4, "count"), (1, 8, "count")]);
+
+        server
+            .handle_request(&connection, rename_request(7, "b"))
+            .unwrap();
+        match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                error: Some(error), ..
+            }) => assert_eq!(error.message, "b is already declared in this scope"),
+            msg => panic!("expected error response, got {msg:?}"),
+        };
+    }
+    #[test]
+    fn test_handle_signature_help_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(
+            uri.to_owned(),
+            1,
+            String::from("let add = fn (a: number, b: number) => a + b\nlet sum = add(1, 2)"),
+        );
+
+        let params = SignatureHelpParams {
+            context: None,
+            text_document_position_params: TextDocumentPositionParams {
+                text_document: TextDocumentIdentifier {
+                    uri: uri.to_owned(),
+                },
+                position: Position {
+                    line: 1,
+                    character: 17,
+                },
+            },
+            work_done_progress_params: WorkDoneProgressParams {
+                work_done_token: None,
+            },
+        };
+        let req = Request {
+            id: RequestId::from(8),
+            method: String::from("textDocument/signatureHelp"),
+            params: to_value(params).unwrap(),
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.handle_request(&connection, req).unwrap();
+
+        let result = match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => from_value::<SignatureHelp>(result).unwrap(),
+            msg => panic!("expected response, got {msg:?}"),
+        };
+
+        assert_eq!(result.signatures.len(), 1);
+        assert_eq!(
+            result.signatures[0].label,
+            "(a: number, b: number) -> number"
+        );
+        assert_eq!(result.active_signature, Some(0));
+        assert_eq!(result.active_parameter, Some(1));
+    }
+    #[test]
+    fn test_handle_code_action_request() {
+        let uri = Url::from_str("file://path/to/file.esc").unwrap();
+        let mut server = LanguageServer::new(String::from(""));
+        server.update_document(uri.to_owned(), 1, String::from("let a = 5\nlet b = a"));
+
+        let position = Position {
+            line: 0,
+            character: 4,
+        };
+        let params = CodeActionParams {
+            text_document: TextDocumentIdentifier {
+                uri: uri.to_owned(),
+            },
+            range: Range {
+                start: position,
+                end: position,
+            },
+            context: CodeActionContext::default(),
+            work_done_progress_params: WorkDoneProgressParams {
+                work_done_token: None,
+            },
+            partial_result_params: PartialResultParams {
+                partial_result_token: None,
+            },
+        };
+        let req = Request {
+            id: RequestId::from(9),
+            method: String::from("textDocument/codeAction"),
+            params: to_value(params).unwrap(),
+        };
+
+        let (writer_sender, writer_receiver) = unbounded();
+        let (_, reader_receiver) = unbounded();
+
+        let connection = Connection {
+            sender: writer_sender,
+            receiver: reader_receiver,
+        };
+
+        server.handle_request(&connection, req).unwrap();
+
+        let result = match writer_receiver.recv().unwrap() {
+            Message::Response(Response {
+                result: Some(result),
+                ..
+            }) => from_value::<CodeActionResponse>(result).unwrap(),
+            msg => panic!("expected response, got {msg:?}"),
+        };
+
+        let actions = result
+            .into_iter()
+            .map(|action| match action {
+                CodeActionOrCommand::CodeAction(action) => {
+                    let mut changes = action.edit.unwrap().changes.unwrap();
+                    let edits = changes.remove(&uri).unwrap();
+                    (
+                        action.title,
+                        edits[0].range.start,
+                        edits[0].new_text.clone(),
+                    )
+                }
+                CodeActionOrCommand::Command(command) => panic!("unexpected command {command:?}"),
+            })
+            .collect::<Vec<_>>();
+        let position = Position {
+            line: 0,
+            character: 5,
+        };
+        assert_eq!(
+            actions,
+            vec![
+                (
+                    String::from("Add type annotation `5`"),
+                    position,
+                    String::from(": 5")
+                ),
+                (
+                    String::from("Add widened type annotation `number`"),
+                    position,
+                    String::from(": number")
+                ),
+            ]
+        );
+    }
 }
@@ -0,0 +1,298 @@
+use generational_arena::Index;
+use lsp_types::{ParameterInformation, ParameterLabel, SignatureHelp, SignatureInformation};
+
+use escalier_ast::{walk_expr, Call, Expr, ExprKind, New, Script, Span, Visitor};
+use escalier_hm::checker::Checker;
+use escalier_hm::context::Context;
+use escalier_hm::types::{self, Function, Intersection, TObjElem, TPat, TypeKind};
+
+// The innermost call or `new` expression whose argument list contains the
+// cursor.
+struct CallFinder {
+    offset: usize,
+    callee: Option<(Span, Option<Index>, bool)>,
+}
+
+impl Visitor for CallFinder {
+    fn visit_expr(&mut self, expr: &Expr) {
+        let (callee, is_new) = match &expr.kind {
+            ExprKind::Call(Call { callee, .. }) => (Some(callee), false),
+            ExprKind::New(New { callee, .. }) => (Some(callee), true),
+            _ => (None, false),
+        };
+        if let Some(callee) = callee {
+            // The argument list starts after the callee and ends with the
+            // closing paren at the end of the call.
+            if callee.span.end < self.offset && self.offset < expr.span.end {
+                self.callee = Some((callee.span, callee.inferred_type, is_new));
+            }
+        }
+        // TODO: visit class members once `walk_expr` does.
+        walk_expr(self, expr);
+    }
+}
+
+// Returns the index of the argument containing `offset` by counting the
+// commas between the start of the argument list and `offset` that aren't
+// nested inside of another expression or a string.
+fn get_arg_index(src: &str, start: usize, offset: usize) -> u32 {
+    let mut depth = 0;
+    let mut index = 0;
+    let mut quote: Option<char> = None;
+    let mut chars = src[start..offset].chars();
+
+    while let Some(c) = chars.next() {
+        if let Some(q) = quote {
+            match c {
+                '\\' => {
+                    chars.next();
+                }
+                _ if c == q => quote = None,
+                _ => {}
+            }
+            continue;
+        }
+        match c {
+            '"' | '\'' | '`' => quote = Some(c),
+            '(' | '[' | '{' => depth += 1,
+            ')' | ']' | '}' => depth -= 1,
+            // The opening paren of the argument list puts us at depth 1.
+            ',' if depth == 1 => index += 1,
+            _ => {}
+        }
+    }
+
+    index
+}
+
+// Returns the signatures that can be used to call a value of type `t`, or to
+// construct one if `is_new` is true.  Overloaded functions have a signature
+// for each overload.
+fn get_signatures(checker: &mut Checker, ctx: &Context, t: Index, is_new: bool) -> Vec<Function> {
+    let t = match checker.expand_type(ctx, t) {
+        Ok(t) => t,
+        Err(_) => return vec![],
+    };
+
+    match checker.arena[t].kind.clone() {
+        TypeKind::Function(func) if !is_new => vec![func],
+        TypeKind::Intersection(Intersection { types }) => {
+            let mut signatures = vec![];
+            for t in types {
+                signatures.extend(get_signatures(checker, ctx, t, is_new));
+            }
+            signatures
+        }
+        TypeKind::Object(types::Object { elems, .. }) => elems
+            .into_iter()
+            .filter_map(|elem| match elem {
+                TObjElem::Call(func) if !is_new => Some(func),
+                TObjElem::Constructor(func) if is_new => Some(func),
+                _ => None,
+            })
+            .collect(),
+        _ => vec![],
+    }
+}
+
+fn get_signature_info(checker: &Checker, func: &Function) -> SignatureInformation {
+    let mut label = match &func.type_params {
+        Some(type_params) if !type_params.is_empty() => {
+            let names: Vec<_> = type_params.iter().map(|tp| tp.name.as_str()).collect();
+            format!("<{}>(", names.join(", "))
+        }
+        _ => String::from("("),
+    };
+
+    let mut parameters = vec![];
+    for (i, param) in func.params.iter().filter(|p| !p.is_self()).enumerate() {
+        if i > 0 {
+            label.push_str(", ");
+        }
+        // Offsets are in UTF-16 code units like the rest of the protocol.
+        let start = label.encode_utf16().count() as u32;
+        label.push_str(&checker.print_param(param));
+        let end = label.encode_utf16().count() as u32;
+        parameters.push(ParameterInformation {
+            label: ParameterLabel::LabelOffsets([start, end]),
+            documentation: None,
+        });
+    }
+    label.push_str(&format!(") -> {}", checker.print_type(&func.ret)));
+
+    SignatureInformation {
+        label,
+        documentation: None,
+        parameters: Some(parameters),
+        active_parameter: None,
+    }
+}
+
+/// Returns the signatures of the function being called at `offset` in
+/// `script`, which must have already been checked by `checker`.  The active
+/// parameter is the argument containing `offset`, rest params stay active for
+/// all of the arguments after them.
+pub fn get_signature_help(
+    src: &str,
+    script: &Script,
+    checker: &mut Checker,
+    ctx: &Context,
+    offset: usize,
+) -> Option<SignatureHelp> {
+    let mut finder = CallFinder {
+        offset,
+        callee: None,
+    };
+    finder.visit_program(script);
+
+    let (callee_span, t, is_new) = finder.callee?;
+    let arg_index = get_arg_index(src, callee_span.end, offset);
+    let funcs = get_signatures(checker, ctx, t?, is_new);
+    if funcs.is_empty() {
+        return None;
+    }
+
+    let mut signatures = vec![];
+    let mut active_signature = None;
+    for (i, func) in funcs.iter().enumerate() {
+        let params: Vec<_> = func.params.iter().filter(|p| !p.is_self()).collect();
+        let is_variadic =
+            matches!(params.last(), Some(param) if matches!(param.pattern, TPat::Rest(_)));
+
+        let active_parameter = match is_variadic {
+            true => arg_index.min(params.len() as u32 - 1),
+            false => arg_index,
+        };
+        // Prefer the first overload that has enough params for the args
+        // written so far.
+        if active_signature.is_none() && (is_variadic || (arg_index as usize) < params.len()) {
+            active_signature = Some(i as u32);
+        }
+
+        let mut info = get_signature_info(checker, func);
+        info.active_parameter = Some(active_parameter);
+        signatures.push(info);
+    }
+
+    Some(SignatureHelp {
+        signatures,
+        active_signature: Some(active_signature.unwrap_or(0)),
+        active_parameter: Some(arg_index),
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use escalier_parser::parse;
+
+    use super::*;
+
+    // Checks `src` and returns the signature help at the `|` in it, each
+    // signature is printed on its own line with its active param in brackets.
+    fn signature_help(src: &str) -> String {
+        let offset = src.find('|').unwrap();
+        let src = src.replacen('|', "", 1);
+
+        let mut checker = Checker::default();
+        let mut ctx = Context::default();
+        let mut script = parse(&src).unwrap();
+        checker.infer_script(&mut script, &mut ctx).unwrap();
+
+        let help = match get_signature_help(&src, &script, &mut checker, &ctx, offset) {
+            Some(help) => help,
+            None => return String::from("none"),
+        };
+
+        let mut output = String::new();
+        for (i, signature) in help.signatures.iter().enumerate() {
+            let active = signature.active_parameter.unwrap() as usize;
+            let params = signature.parameters.as_ref().unwrap();
+            let label = match params.get(active).map(|param| &param.label) {
+                Some(ParameterLabel::LabelOffsets([start, end])) => {
+                    let label: Vec<u16> = signature.label.encode_utf16().collect();
+                    format!(
+                        "{}[{}]{}",
+                        String::from_utf16_lossy(&label[..*start as usize]),
+                        String::from_utf16_lossy(&label[*start as usize..*end as usize]),
+                        String::from_utf16_lossy(&label[*end as usize..]),
+                    )
+                }
+                _ => signature.label.to_owned(),
+            };
+            let marker = match help.active_signature == Some(i as u32) {
+                true => "* ",
+                false => "  ",
+            };
+            output.push_str(&format!("{marker}{label}\n"));
+        }
+        output
+    }
+
+    #[test]
+    fn active_parameter() {
+        let src = r#"
+        let add = fn (a: number, b: number) => a + b
+        let sum = add(1, |2)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* (a: number, [b: number]) -> number");
+
+        let src = r#"
+        let add = fn (a: number, b: number) => a + b
+        let sum = add(add(|1, 2), 3)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* ([a: number], b: number) -> number");
+
+        let src = r#"
+        let add = fn (a: number, b: number) => a + b
+        let sum = add(add(1, 2), |3)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* (a: number, [b: number]) -> number");
+
+        let src = r#"
+        let f = fn (s: string, n: number) => n
+        let x = f("a, b", |1)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* (s: string, [n: number]) -> number");
+
+        let src = r#"
+        let add = fn (a: number, b: number) => a + b
+        let sum = |add(1, 2)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"none");
+    }
+
+    #[test]
+    fn rest_params_and_overloads() {
+        let src = r#"
+        let max = fn (first: number, ...rest: number[]) => first
+        let x = max(1, 2, |3)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* (first: number, [...rest: number[]]) -> number");
+
+        let src = r#"
+        declare fn parse(s: string) -> number
+        declare fn parse(s: string, radix: number) -> number
+        let x = parse("10", |16)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @r###"
+          (s: string) -> number
+        * (s: string, [radix: number]) -> number
+        "###);
+    }
+
+    #[test]
+    fn constructors() {
+        let src = r#"
+        let Point = class {
+            x: number
+            y: number
+            fn constructor(mut self, x: number, y: number) {
+                self.x = x
+                self.y = y
+            }
+        }
+        let p = new Point(1, |2)
+        "#;
+        insta::assert_snapshot!(signature_help(src), @"* (x: number, [y: number]) -> Self");
+    }
+}
@@ -26,3 +26,35 @@ pub fn get_byte_pos(file: &SourceFile, pos: &Position) -> Option<BytePos> {
 
     Some(byte_pos)
 }
+
+// Unlike `get_location`, this returns a 0-indexed position using UTF-16 code
+// units for the character offset as required by the language server protocol.
+pub fn get_position(src: &str, offset: usize) -> Position {
+    let before = &src[..offset];
+    let line_start = before.rfind('\n').map_or(0, |index| index + 1);
+
+    Position {
+        line: before.matches('\n').count() as u32,
+        character: before[line_start..].encode_utf16().count() as u32,
+    }
+}
+
+// The inverse of `get_position`, returns `None` if `pos` is past the last line
+// of `src`.
+pub fn get_offset(src: &str, pos: &Position) -> Option<usize> {
+    let line_start = match pos.line {
+        0 => 0,
+        line => src.match_indices('\n').nth(line as usize - 1)?.0 + 1,
+    };
+    let line = src[line_start..].split('\n').next().unwrap_or_default();
+
+    let mut character = 0;
+    for (index, c) in line.char_indices() {
+        if character >= pos.character {
+            return Some(line_start + index);
+        }
+        character += c.len_utf16() as u32;
+    }
+
+    Some(line_start + line.len())
+}
@@ -6,9 +6,56 @@ use crate::token::*;
 
 impl<'a> Parser<'a> {
     pub fn parse_class(&mut self) -> Result<Expr, ParseError> {
+        let (token, is_abstract) = self.parse_class_keyword()?;
+        self.parse_class_rest(token, is_abstract, false)
+    }
+
+    // Parses `class Foo { ... }` after `declare`, which must already have
+    // been consumed.  Methods in declared classes don't have bodies.
+    pub fn parse_declare_class(&mut self) -> Result<(Ident, Expr), ParseError> {
+        let (token, is_abstract) = self.parse_class_keyword()?;
+
+        let ident = match self.next().unwrap_or(EOF.clone()) {
+            Token {
+                kind: TokenKind::Identifier(name),
+                span,
+            } => Ident { name, span },
+            _ => {
+                return Err(ParseError {
+                    message: "expected identifier after 'class'".to_string(),
+                })
+            }
+        };
+
+        let class = self.parse_class_rest(token, is_abstract, true)?;
+
+        Ok((ident, class))
+    }
+
+    // Consumes `class` or `abstract class` and returns the first token along
+    // with whether the class is abstract.
+    fn parse_class_keyword(&mut self) -> Result<(Token, bool), ParseError> {
         let token = self.next().unwrap_or(EOF.clone());
-        assert_eq!(token.kind, TokenKind::Class);
+        let is_abstract = if token.kind == TokenKind::Abstract {
+            if self.next().unwrap_or(EOF.clone()).kind != TokenKind::Class {
+                return Err(ParseError {
+                    message: "expected 'class' after 'abstract'".to_string(),
+                });
+            }
+            true
+        } else {
+            assert_eq!(token.kind, TokenKind::Class);
+            false
+        };
+        Ok((token, is_abstract))
+    }
 
+    fn parse_class_rest(
+        &mut self,
+        token: Token,
+        is_abstract: bool,
+        is_declare: bool,
+    ) -> Result<Expr, ParseError> {
         let type_params = self.maybe_parse_type_params()?;
 
         let super_class = if self.peek().unwrap_or(&EOF).kind == TokenKind::Extends {
@@ -26,6 +73,18 @@ impl<'a> Parser<'a> {
             None
         };
 
+        let mut implements = vec![];
+        if self.peek().unwrap_or(&EOF).kind == TokenKind::Implements {
+            self.next(); // consumes 'implements'
+            loop {
+                implements.push(self.parse_type_ann()?);
+                if self.peek().unwrap_or(&EOF).kind != TokenKind::Comma {
+                    break;
+                }
+                self.next(); // consumes ','
+            }
+        }
+
         assert_eq!(
             self.next().unwrap_or(EOF.clone()).kind,
             TokenKind::LeftBrace
@@ -34,7 +93,7 @@ impl<'a> Parser<'a> {
         let mut body = vec![];
 
         while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
-            let member = self.parse_class_member()?;
+            let member = self.parse_class_member(is_declare)?;
             body.push(member);
         }
 
@@ -53,6 +112,9 @@ impl<'a> Parser<'a> {
             type_params,
             super_class,
             super_type_args: None, // TODO
+            implements,
+            is_abstract,
+            is_declare,
             body,
         });
 
@@ -63,9 +125,9 @@ impl<'a> Parser<'a> {
         })
     }
 
-    fn parse_class_member(&mut self) -> Result<ClassMember, ParseError> {
-        let is_public = if self.peek().unwrap_or(&EOF).kind == TokenKind::Pub {
-            self.next(); // consumes 'pub'
+    fn parse_class_member(&mut self, is_declare: bool) -> Result<ClassMember, ParseError> {
+        let is_private = if self.peek().unwrap_or(&EOF).kind == TokenKind::Private {
+            self.next(); // consumes 'private'
             true
         } else {
             false
@@ -78,23 +140,42 @@ impl<'a> Parser<'a> {
             false
         };
 
+        let is_abstract = if self.peek().unwrap_or(&EOF).kind == TokenKind::Abstract {
+            self.next(); // consumes 'abstract'
+            true
+        } else {
+            false
+        };
+
+        if is_abstract && is_static {
+            return Err(ParseError {
+                message: "static methods can't be abstract".to_string(),
+            });
+        }
+
         let token = self.peek().unwrap_or(&EOF);
         match token.kind {
-            TokenKind::Identifier(_) => self.parse_field(is_public, is_static),
-            TokenKind::Fn => self.parse_method(is_public, is_static),
-            TokenKind::Gen => self.parse_method(is_public, is_static),
-            TokenKind::Async => self.parse_method(is_public, is_static),
+            TokenKind::Fn | TokenKind::Gen | TokenKind::Async => {
+                self.parse_method(is_private, is_static, is_abstract, is_declare)
+            }
+            _ if is_abstract => Err(ParseError {
+                message: "only methods can be abstract".to_string(),
+            }),
+            TokenKind::Identifier(_) => self.parse_field(is_private, is_static, is_declare),
+            TokenKind::Get | TokenKind::Set if is_declare => Err(ParseError {
+                message: "declared classes can't have getters or setters".to_string(),
+            }),
             TokenKind::Get => match is_static {
                 true => Err(ParseError {
                     message: "static getters are not allowed".to_string(),
                 }),
-                false => self.parse_getter(is_public),
+                false => self.parse_getter(is_private),
             },
             TokenKind::Set => match is_static {
                 true => Err(ParseError {
                     message: "static setters are not allowed".to_string(),
                 }),
-                false => self.parse_setter(is_public),
+                false => self.parse_setter(is_private),
             },
             _ => Err(ParseError {
                 message: format!("unexpected token {:?}", token),
@@ -102,8 +183,13 @@ impl<'a> Parser<'a> {
         }
     }
 
-    fn parse_field(&mut self, is_public: bool, is_static: bool) -> Result<ClassMember, ParseError> {
-        // TODO: how do we include `pub` and `static` in the span?
+    fn parse_field(
+        &mut self,
+        is_private: bool,
+        is_static: bool,
+        is_declare: bool,
+    ) -> Result<ClassMember, ParseError> {
+        // TODO: how do we include `private` and `static` in the span?
         let token = self.next().unwrap_or(EOF.clone());
         let start = token.span.start;
 
@@ -127,12 +213,17 @@ impl<'a> Parser<'a> {
                 ClassMember::Field(Field {
                     span,
                     name,
-                    is_public,
+                    is_private,
                     is_static,
                     init: None,
                     type_ann: Some(type_ann),
                 })
             }
+            TokenKind::Assign if is_declare => {
+                return Err(ParseError {
+                    message: "fields in declared classes can't be initialized".to_string(),
+                })
+            }
             TokenKind::Assign => {
                 self.next(); // consumes '='
                 let init = self.parse_expr()?;
@@ -143,7 +234,7 @@ impl<'a> Parser<'a> {
                 ClassMember::Field(Field {
                     span,
                     name,
-                    is_public,
+                    is_private,
                     is_static,
                     init: Some(Box::new(init)),
                     type_ann: None,
@@ -155,7 +246,7 @@ impl<'a> Parser<'a> {
         Ok(field)
     }
 
-    fn parse_getter(&mut self, is_public: bool) -> Result<ClassMember, ParseError> {
+    fn parse_getter(&mut self, is_private: bool) -> Result<ClassMember, ParseError> {
         let token = self.next().unwrap_or(EOF.clone());
         assert_eq!(token.kind, TokenKind::Get);
         let start = token.span.start;
@@ -171,7 +262,7 @@ impl<'a> Parser<'a> {
         let getter = ClassMember::Getter(Getter {
             span,
             name,
-            is_public,
+            is_private,
             type_ann: None,
             params,
             body,
@@ -180,7 +271,7 @@ impl<'a> Parser<'a> {
         Ok(getter)
     }
 
-    fn parse_setter(&mut self, is_public: bool) -> Result<ClassMember, ParseError> {
+    fn parse_setter(&mut self, is_private: bool) -> Result<ClassMember, ParseError> {
         let token = self.next().unwrap_or(EOF.clone());
         assert_eq!(token.kind, TokenKind::Set);
         let start = token.span.start;
@@ -196,7 +287,7 @@ impl<'a> Parser<'a> {
         let setter = ClassMember::Setter(Setter {
             span,
             name,
-            is_public,
+            is_private,
             type_ann: None,
             params,
             body,
@@ -207,10 +298,12 @@ impl<'a> Parser<'a> {
 
     fn parse_method(
         &mut self,
-        is_public: bool,
+        is_private: bool,
         is_static: bool,
+        is_abstract: bool,
+        is_declare: bool,
     ) -> Result<ClassMember, ParseError> {
-        // TODO: how do we include `pub` and `static` in the span?
+        // TODO: how do we include `private` and `static` in the span?
         let start = self.peek().unwrap_or(&EOF).span.start;
 
         let is_async = if self.peek().unwrap_or(&EOF).kind == TokenKind::Async {
@@ -237,7 +330,7 @@ impl<'a> Parser<'a> {
         };
         let type_ann = if self.peek().unwrap_or(&EOF).kind == TokenKind::SingleArrow {
             self.next(); // consumes '->'
-            Some(self.parse_type_ann()?)
+            Some(self.parse_return_type()?)
         } else {
             None
         };
@@ -250,16 +343,30 @@ impl<'a> Parser<'a> {
             _ => None,
         };
 
-        let body = self.parse_block()?;
+        // Abstract methods and methods in declared classes don't have a body.
+        let body = match is_abstract || is_declare {
+            true => {
+                let cursor = self.scanner.cursor();
+                Block {
+                    span: Span {
+                        start: cursor,
+                        end: cursor,
+                    },
+                    stmts: vec![],
+                }
+            }
+            false => self.parse_block()?,
+        };
         let end = self.scanner.cursor();
         let span = Span { start, end };
 
         let method = ClassMember::Method(Method {
             span,
             name,
-            is_public,
+            is_private,
             is_mutating,
             is_static,
+            is_abstract,
             function: Function {
                 is_async,
                 is_gen,
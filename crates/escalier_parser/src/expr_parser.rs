@@ -42,6 +42,10 @@ fn get_infix_op_info(op: &Token) -> Option<OpInfo> {
             PRECEDENCE_TABLE.get(&Operator::GreaterThanOrEqual).cloned()
         }
 
+        // relational
+        TokenKind::In => PRECEDENCE_TABLE.get(&Operator::In).cloned(),
+        TokenKind::InstanceOf => PRECEDENCE_TABLE.get(&Operator::Instanceof).cloned(),
+
         // logic
         TokenKind::And => PRECEDENCE_TABLE.get(&Operator::LogicalAnd).cloned(),
         TokenKind::Or => PRECEDENCE_TABLE.get(&Operator::LogicalOr).cloned(),
@@ -81,8 +85,7 @@ impl<'a> Parser<'a> {
         let mut stmts = Vec::new();
         while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
             // TODO: attach comments to AST nodes
-            if let TokenKind::Comment(_) = &self.peek().unwrap_or(&EOF).kind {
-                self.next(); // consumes the comment
+            if self.skip_comment() {
                 continue;
             }
 
@@ -101,6 +104,32 @@ impl<'a> Parser<'a> {
         Ok(Block { span, stmts })
     }
 
+    // Returns true if the `{` at the current position starts a block whose
+    // value is its last expression, e.g. `{let x = 1; x + 1}`, instead of an
+    // object literal.  Objects are empty or start with a spread, a computed
+    // key, or a key followed by `:`, `,`, or `}` which means that `{x}` is a
+    // shorthand object.
+    fn is_block_expr(&mut self) -> bool {
+        let backup = self.clone();
+        self.next(); // consumes '{'
+        while self.skip_comment() {}
+
+        let first = self
+            .next_with_mode(IdentMode::PropName)
+            .unwrap_or(EOF.clone());
+        let is_block = match first.kind {
+            TokenKind::RightBrace | TokenKind::DotDotDot | TokenKind::LeftBracket => false,
+            TokenKind::Identifier(_) | TokenKind::StrLit(_) | TokenKind::NumLit(_) => !matches!(
+                self.peek().unwrap_or(&EOF).kind,
+                TokenKind::Colon | TokenKind::Comma | TokenKind::RightBrace
+            ),
+            _ => true,
+        };
+
+        self.restore(backup);
+        is_block
+    }
+
     fn parse_atom(&mut self) -> Result<Expr, ParseError> {
         let token = self.peek().unwrap_or(&EOF).clone();
 
@@ -183,24 +212,31 @@ impl<'a> Parser<'a> {
                     inferred_type: None,
                 }
             }
+            // A `/` where an expression is expected starts a regex literal,
+            // `/=` is lexed as a single token so it could be one as well.
+            TokenKind::Divide | TokenKind::DivideAssign => {
+                self.next(); // consume '/' or '/='
+                let prefix = match token.kind {
+                    TokenKind::DivideAssign => "=",
+                    _ => "",
+                };
+                let regex = self.lex_regex(prefix)?;
+
+                Expr {
+                    kind: ExprKind::Regex(regex),
+                    span: Span {
+                        start: token.span.start,
+                        end: self.scanner.cursor(),
+                    },
+                    inferred_type: None,
+                }
+            }
             TokenKind::LeftParen => self.parse_inside_parens(|p| p.parse_expr())?,
             TokenKind::LeftBracket => {
                 self.next(); // consumes '['
                 let start = token;
                 let elements = self.parse_many(
-                    |p| {
-                        match p.peek().unwrap_or(&EOF).kind {
-                            TokenKind::DotDotDot => {
-                                p.next().unwrap_or(EOF.clone()); // consumes `...`
-                                let expr = p.parse_expr()?;
-                                Ok(ExprOrSpread::Spread(expr))
-                            }
-                            _ => {
-                                let expr = p.parse_expr()?;
-                                Ok(ExprOrSpread::Expr(expr))
-                            }
-                        }
-                    },
+                    |p| p.parse_expr_or_spread(),
                     TokenKind::Comma,
                     TokenKind::RightBracket,
                 )?;
@@ -215,6 +251,16 @@ impl<'a> Parser<'a> {
                     inferred_type: None,
                 }
             }
+            TokenKind::LeftBrace if self.is_block_expr() => {
+                let body = self.parse_block()?;
+                let span = body.span;
+
+                Expr {
+                    kind: ExprKind::Do(Do { body }),
+                    span,
+                    inferred_type: None,
+                }
+            }
             TokenKind::LeftBrace => {
                 self.next(); // consumes '{'
                 let start = token;
@@ -298,7 +344,22 @@ impl<'a> Parser<'a> {
 
                 let arms = self.parse_many(
                     |p| {
-                        let pattern = p.parse_pattern()?;
+                        let mut pattern = p.parse_pattern()?;
+
+                        if let TokenKind::Pipe = p.peek().unwrap_or(&EOF).kind {
+                            let mut patterns = vec![pattern];
+                            while let TokenKind::Pipe = p.peek().unwrap_or(&EOF).kind {
+                                p.next(); // consumes '|'
+                                patterns.push(p.parse_pattern()?);
+                            }
+                            let span =
+                                merge_spans(&patterns[0].span, &patterns[patterns.len() - 1].span);
+                            pattern = Pattern {
+                                kind: PatternKind::Or(OrPat { patterns }),
+                                span,
+                                inferred_type: None,
+                            };
+                        }
 
                         let guard = if let TokenKind::If = p.peek().unwrap_or(&EOF).kind {
                             p.next(); // consumes 'if'
@@ -448,7 +509,7 @@ impl<'a> Parser<'a> {
                     }
                 }
             }
-            TokenKind::Class => self.parse_class()?,
+            TokenKind::Class | TokenKind::Abstract => self.parse_class()?,
             _ => todo!(),
         };
 
@@ -609,7 +670,7 @@ impl<'a> Parser<'a> {
         let type_ann = match self.peek().unwrap_or(&EOF).kind {
             TokenKind::SingleArrow => {
                 self.next();
-                Some(self.parse_type_ann()?)
+                Some(self.parse_return_type()?)
             }
             _ => None,
         };
@@ -673,13 +734,19 @@ impl<'a> Parser<'a> {
         } else {
             None
         };
+        let default = if self.peek().unwrap_or(&EOF).kind == TokenKind::Assign {
+            self.next().unwrap_or(EOF.clone());
+            Some(self.parse_type_ann()?)
+        } else {
+            None
+        };
         let end = self.scanner.cursor();
 
         Ok(TypeParam {
             span: Span { start, end },
             name,
             bound,
-            default: None,
+            default,
         })
     }
 
@@ -771,6 +838,8 @@ impl<'a> Parser<'a> {
             TokenKind::GreaterThanOrEqual => BinaryOp::GreaterThanOrEqual,
             TokenKind::And => BinaryOp::And,
             TokenKind::Or => BinaryOp::Or,
+            TokenKind::In => BinaryOp::In,
+            TokenKind::InstanceOf => BinaryOp::InstanceOf,
             _ => panic!("unexpected token: {:?}", token),
         };
 
@@ -818,6 +887,7 @@ impl<'a> Parser<'a> {
                             expr: Box::new(rhs),
                         }),
                         opt_chain,
+                        is_private: false,
                     }),
                     span,
                     inferred_type: None,
@@ -825,7 +895,11 @@ impl<'a> Parser<'a> {
             }
             TokenKind::LeftParen => {
                 let args = self.parse_inside_parens(|p| {
-                    p.parse_many(|p| p.parse_expr(), TokenKind::Comma, TokenKind::RightParen)
+                    p.parse_many(
+                        |p| p.parse_expr_or_spread(),
+                        TokenKind::Comma,
+                        TokenKind::RightParen,
+                    )
                 })?;
 
                 let end = self.scanner.cursor();
@@ -875,7 +949,11 @@ impl<'a> Parser<'a> {
                 );
 
                 let args = self.parse_inside_parens(|p| {
-                    p.parse_many(|p| p.parse_expr(), TokenKind::Comma, TokenKind::RightParen)
+                    p.parse_many(
+                        |p| p.parse_expr_or_spread(),
+                        TokenKind::Comma,
+                        TokenKind::RightParen,
+                    )
                 })?;
 
                 let end = self.scanner.cursor();
@@ -899,6 +977,9 @@ impl<'a> Parser<'a> {
             }
             TokenKind::Dot => {
                 self.next(); // consumes '.'
+
+                // Keywords can be used as property names, e.g. `str.match(re)`.
+                self.peek_with_mode(IdentMode::PropName);
                 let rhs = self.parse_expr_with_precedence(precedence)?;
                 match &rhs.kind {
                     ExprKind::Ident(ident) => {
@@ -908,6 +989,7 @@ impl<'a> Parser<'a> {
                                 object: Box::new(lhs),
                                 property: MemberProp::Ident(ident.to_owned()),
                                 opt_chain: false,
+                                is_private: false,
                             }),
                             span,
                             inferred_type: None,
@@ -923,7 +1005,11 @@ impl<'a> Parser<'a> {
             TokenKind::QuestionDot => {
                 self.next(); // consumes '?.'
 
-                let result = match self.peek().unwrap_or(&EOF).kind {
+                let result = match self
+                    .peek_with_mode(IdentMode::PropName)
+                    .unwrap_or(&EOF)
+                    .kind
+                {
                     TokenKind::LeftParen | TokenKind::LeftBracket => {
                         self.parse_postfix(lhs, next_op_info, true)?
                     }
@@ -937,6 +1023,7 @@ impl<'a> Parser<'a> {
                                         object: Box::new(lhs),
                                         property: MemberProp::Ident(ident.to_owned()),
                                         opt_chain: true,
+                                        is_private: false,
                                     }),
                                     span,
                                     inferred_type: None,
@@ -1009,7 +1096,7 @@ impl<'a> Parser<'a> {
         result
     }
 
-    fn parse_many<T>(
+    pub fn parse_many<T>(
         &mut self,
         mut callback: impl FnMut(&mut Self) -> Result<T, ParseError>,
         separator: TokenKind,
@@ -1040,6 +1127,22 @@ impl<'a> Parser<'a> {
     pub fn parse_expr(&mut self) -> Result<Expr, ParseError> {
         self.parse_expr_with_precedence(0)
     }
+
+    // Parses an element of a tuple or an arg in a call, both of which can be
+    // spread, e.g. `[...a, b]` or `f(...a, b)`.
+    fn parse_expr_or_spread(&mut self) -> Result<ExprOrSpread, ParseError> {
+        match self.peek().unwrap_or(&EOF).kind {
+            TokenKind::DotDotDot => {
+                self.next().unwrap_or(EOF.clone()); // consumes `...`
+                let expr = self.parse_expr()?;
+                Ok(ExprOrSpread::Spread(expr))
+            }
+            _ => {
+                let expr = self.parse_expr()?;
+                Ok(ExprOrSpread::Expr(expr))
+            }
+        }
+    }
 }
 
 #[cfg(test)]
@@ -1062,6 +1165,19 @@ mod tests {
         insta::assert_debug_snapshot!(parse(r#""hello""#));
     }
 
+    #[test]
+    fn parse_regex_literals() {
+        insta::assert_debug_snapshot!(parse(r#"/(?<year>\d{4})[/\]]/gu"#));
+        insta::assert_debug_snapshot!(parse("str.split(/=/)"));
+        insta::assert_debug_snapshot!(parse("a / b / c"));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_regex_literals_unterminated() {
+        parse("/abc");
+    }
+
     #[test]
     fn parse_tuple_literals() {
         insta::assert_debug_snapshot!(parse("[]"));
@@ -1100,7 +1216,7 @@ mod tests {
     #[test]
     #[should_panic]
     fn parse_object_literals_missing_colon() {
-        parse("{ a 1 }");
+        parse("{ a: 1, b 2 }");
     }
 
     #[test]
@@ -1144,6 +1260,8 @@ mod tests {
     fn parse_comparisons_and_logic() {
         insta::assert_debug_snapshot!(parse("a > b && c >= d || e < f && g <= h"));
         insta::assert_debug_snapshot!(parse("x != y && z == w"));
+        insta::assert_debug_snapshot!(parse(r#""kind" in obj && x"#));
+        insta::assert_debug_snapshot!(parse("p instanceof Point || q"));
     }
 
     #[test]
@@ -1227,6 +1345,7 @@ mod tests {
         insta::assert_debug_snapshot!(parse("add(5, 10)"));
         insta::assert_debug_snapshot!(parse("add(5)(10)"));
         insta::assert_debug_snapshot!(parse("add(obj.x, obj.y)"));
+        insta::assert_debug_snapshot!(parse("add(1, ...rest)"));
     }
 
     #[test]
@@ -1240,6 +1359,7 @@ mod tests {
         insta::assert_debug_snapshot!(parse("a.b.c"));
         insta::assert_debug_snapshot!(parse("a.b+c.d"));
         insta::assert_debug_snapshot!(parse("a[b][c]"));
+        insta::assert_debug_snapshot!(parse("str.match(re)?.if"));
     }
 
     #[test]
@@ -1299,6 +1419,18 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn parse_or_patterns() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            match (x) {
+                1 | 2 | 3 => "small",
+                _ => "big",
+            }
+            "#
+        ));
+    }
+
     #[test]
     fn parse_try_catch() {
         insta::assert_debug_snapshot!(parse(
@@ -1353,6 +1485,19 @@ mod tests {
         ))
     }
 
+    #[test]
+    fn parse_block_exprs() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            {
+                let x = 5
+                x + 1
+            }
+            "#
+        ));
+        insta::assert_debug_snapshot!(parse("{ f(x) }"));
+    }
+
     #[test]
     fn parse_assignment() {
         insta::assert_debug_snapshot!(parse("x = y"));
@@ -1446,6 +1591,7 @@ mod tests {
     fn parse_func_calls_with_type_args() {
         insta::assert_debug_snapshot!(parse("id<number>(5)"));
         insta::assert_debug_snapshot!(parse(r#"fst<number, string>(5, "hello")"#));
+        insta::assert_debug_snapshot!(parse(r#"foo.bar<number>(5)"#));
     }
 
     #[test]
@@ -1511,6 +1657,75 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn parse_class_with_private_members() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            class {
+                private count: number
+                private static fn clamp(count) {
+                    return count
+                }
+            }
+        "#
+        ));
+    }
+
+    #[test]
+    fn parse_abstract_class() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            abstract class {
+                abstract fn area(self) -> number
+                fn describe(self) {
+                    return `area = ${self.area()}`
+                }
+            }
+        "#
+        ));
+    }
+
+    #[test]
+    fn parse_abstract_static_method_error() {
+        let mut parser = Parser::new(
+            r#"
+            abstract class {
+                static abstract fn make() -> number
+            }
+        "#,
+        );
+        let result = parser.parse_expr();
+        assert_eq!(
+            result,
+            Err(ParseError {
+                message: "static methods can't be abstract".to_string()
+            })
+        );
+    }
+
+    #[test]
+    fn parse_abstract_without_class_error() {
+        let mut parser = Parser::new("abstract fn () => 5");
+        let result = parser.parse_expr();
+        assert_eq!(
+            result,
+            Err(ParseError {
+                message: "expected 'class' after 'abstract'".to_string()
+            })
+        );
+    }
+
+    #[test]
+    fn parse_class_with_implements() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            class extends Foo implements Bar, Baz<number> {
+                x: number
+            }
+        "#
+        ));
+    }
+
     #[test]
     fn parse_generic_class() {
         insta::assert_debug_snapshot!(parse(
@@ -9,14 +9,7 @@ impl<'a> Parser<'a> {
         let start = self.scanner.cursor();
 
         assert_eq!(self.next().unwrap_or(EOF.clone()).kind, TokenKind::LessThan);
-        let name_token = self.lex_ident_or_keyword(IdentMode::Default);
-        let name = match name_token.kind {
-            TokenKind::Identifier(name) => JSXElementName::Ident(Ident {
-                name,
-                span: name_token.span,
-            }),
-            _ => panic!("Expected identifier or keyword"),
-        };
+        let name = self.parse_jsx_element_name();
 
         let mut attrs = vec![];
         let mut self_closing = false;
@@ -60,18 +53,18 @@ impl<'a> Parser<'a> {
 
             assert_eq!(self.scanner.pop(), Some('<'));
             assert_eq!(self.scanner.pop(), Some('/'));
-            let end_name = self.lex_ident_or_keyword(IdentMode::Default);
+            let end_name = self.parse_jsx_element_name();
             assert_eq!(self.scanner.pop(), Some('>'));
 
             let end = self.scanner.cursor();
 
             Some(JSXClosingElement {
-                name: match end_name.kind {
-                    TokenKind::Identifier(name) => JSXElementName::Ident(Ident {
+                name: match end_name {
+                    JSXElementName::Ident(Ident { name, .. }) => JSXElementName::Ident(Ident {
                         name,
                         span: Span { start, end },
                     }),
-                    _ => panic!("Expected identifier or keyword"),
+                    end_name => end_name,
                 },
             })
         };
@@ -86,6 +79,36 @@ impl<'a> Parser<'a> {
         })
     }
 
+    // Names can be identifiers or members of other components, e.g.
+    // `Foo.Bar.Baz`.
+    fn parse_jsx_element_name(&mut self) -> JSXElementName {
+        let mut obj = JSXObject::Ident(self.parse_jsx_ident());
+        while self.scanner.peek(0) == Some('.') {
+            self.scanner.pop();
+            let member = JSXMemberExpr {
+                obj,
+                prop: self.parse_jsx_ident(),
+            };
+            obj = JSXObject::JSXMemberExpr(Box::new(member));
+        }
+
+        match obj {
+            JSXObject::Ident(ident) => JSXElementName::Ident(ident),
+            JSXObject::JSXMemberExpr(member) => JSXElementName::JSXMemberExpr(*member),
+        }
+    }
+
+    fn parse_jsx_ident(&mut self) -> Ident {
+        let token = self.lex_ident_or_keyword(IdentMode::Default);
+        match token.kind {
+            TokenKind::Identifier(name) => Ident {
+                name,
+                span: token.span,
+            },
+            _ => panic!("Expected identifier or keyword"),
+        }
+    }
+
     pub fn parse_jsx_fragment(&mut self) -> Result<JSXFragment, ParseError> {
         let start = self.scanner.cursor();
 
@@ -309,6 +332,15 @@ mod tests {
         insta::assert_debug_snapshot!(jsx_elem);
     }
 
+    #[test]
+    fn parse_jsx_member_expr_names() {
+        let mut parser = Parser::new(r#"<Foo.Bar.Baz qux><Foo.Qux /></Foo.Bar.Baz>"#);
+
+        let jsx_elem = parser.parse_jsx_element().unwrap();
+
+        insta::assert_debug_snapshot!(jsx_elem);
+    }
+
     #[test]
     fn parse_jsx_props_dot_children() {
         let mut parser = Parser::new(r#"<div>{a+b}</div>"#);
@@ -54,6 +54,7 @@ impl<'a> Parser<'a> {
                         type_ann,
                     }),
                     span,
+                    annotations: vec![],
                 }
             }
             TokenKind::Type => {
@@ -79,10 +80,23 @@ impl<'a> Parser<'a> {
                         name,
                         type_ann,
                         type_params,
+                        is_interface: false,
                     }),
                     span,
+                    annotations: vec![],
                 }
             }
+            TokenKind::Interface => self.parse_interface_decl()?,
+            TokenKind::Identifier(name) if name == "namespace" => self.parse_namespace_decl()?,
+            // Declarations are parsed the same way as they are in scripts.
+            TokenKind::Declare => match self.parse_stmt()?.kind {
+                StmtKind::Decl(decl) => decl,
+                _ => {
+                    return Err(ParseError {
+                        message: "expected declaration after 'declare'".to_string(),
+                    })
+                }
+            },
             _ => {
                 return Err(ParseError {
                     message: "expected module item".to_string(),
@@ -94,13 +108,29 @@ impl<'a> Parser<'a> {
     }
 
     fn parse_module_item(&mut self) -> Result<ModuleItem, ParseError> {
+        let annotations = self.parse_annotations()?;
         let token = self.peek().unwrap_or(&EOF).clone();
 
         let item = match &token.kind {
+            TokenKind::Export if self.is_re_export() => {
+                if !annotations.is_empty() {
+                    return Err(ParseError {
+                        message: "annotations can't be applied to re-exports".to_string(),
+                    });
+                }
+
+                self.parse_re_export()?
+            }
             TokenKind::Export => {
                 self.next(); // consumes 'export'
 
-                let decl = self.parse_decl()?;
+                let mut decl = self.parse_decl()?;
+                if let DeclKind::GlobalDecl(_) = decl.kind {
+                    return Err(ParseError {
+                        message: "'declare global' can't be exported".to_string(),
+                    });
+                }
+                decl.annotations = annotations;
                 let span = merge_spans(&token.span, &decl.span);
 
                 ModuleItem {
@@ -109,6 +139,12 @@ impl<'a> Parser<'a> {
                 }
             }
             TokenKind::Import => {
+                if !annotations.is_empty() {
+                    return Err(ParseError {
+                        message: "annotations can't be applied to imports".to_string(),
+                    });
+                }
+
                 self.next(); // consumes 'import'
 
                 assert_eq!(
@@ -171,7 +207,8 @@ impl<'a> Parser<'a> {
                 }
             }
             _ => {
-                let decl = self.parse_decl()?;
+                let mut decl = self.parse_decl()?;
+                decl.annotations = annotations;
                 let span = decl.span;
 
                 ModuleItem {
@@ -184,17 +221,102 @@ impl<'a> Parser<'a> {
         Ok(item)
     }
 
+    fn is_re_export(&mut self) -> bool {
+        let mut lookahead = self.clone();
+        lookahead.next(); // consumes 'export'
+        lookahead.peek().unwrap_or(&EOF).kind == TokenKind::LeftBrace
+    }
+
+    // Parses `export {foo, bar as baz} from "./util"`.
+    fn parse_re_export(&mut self) -> Result<ModuleItem, ParseError> {
+        let token = self.next().unwrap_or(EOF.clone()); // consumes 'export'
+        self.next(); // consumes '{'
+
+        let mut specifiers: Vec<ExportSpecifier> = vec![];
+        while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
+            let exported = match self.next().unwrap_or(EOF.clone()).kind {
+                TokenKind::Identifier(name) => name,
+                _ => {
+                    return Err(ParseError {
+                        message: "expected identifier".to_string(),
+                    })
+                }
+            };
+
+            match self.peek().unwrap_or(&EOF).kind {
+                TokenKind::As => {
+                    self.next(); // consumes 'as'
+
+                    let local = Some(exported);
+
+                    match self.next().unwrap_or(EOF.clone()).kind {
+                        TokenKind::Identifier(exported) => {
+                            specifiers.push(ExportSpecifier { exported, local });
+                        }
+                        _ => {
+                            return Err(ParseError {
+                                message: "expected identifier after 'as'".to_string(),
+                            })
+                        }
+                    };
+                }
+                _ => {
+                    specifiers.push(ExportSpecifier {
+                        exported,
+                        local: None,
+                    });
+                }
+            };
+
+            match self.peek().unwrap_or(&EOF).kind {
+                TokenKind::RightBrace => break,
+                TokenKind::Comma => {
+                    self.next(); // consumes ','
+                }
+                _ => {
+                    return Err(ParseError {
+                        message: "expected ',' or '}'".to_string(),
+                    })
+                }
+            }
+        }
+
+        self.next(); // consumes '}'
+
+        if self.next().unwrap_or(EOF.clone()).kind != TokenKind::From {
+            return Err(ParseError {
+                message: "expected 'from' after re-exported names".to_string(),
+            });
+        }
+
+        let source = self.next().unwrap_or(EOF.clone());
+        match source.kind {
+            TokenKind::StrLit(value) => Ok(ModuleItem {
+                kind: ModuleItemKind::ReExport(ReExport {
+                    specifiers,
+                    source: value,
+                }),
+                span: merge_spans(&token.span, &source.span),
+            }),
+            _ => Err(ParseError {
+                message: "expected string literal".to_string(),
+            }),
+        }
+    }
+
     pub fn parse_module(&mut self) -> Result<Module, ParseError> {
         let mut items = Vec::new();
         while self.peek().unwrap_or(&EOF).kind != TokenKind::Eof {
             // TODO: attach comments to AST nodes
-            if let TokenKind::Comment(_) = &self.peek().unwrap_or(&EOF).kind {
-                self.next(); // consumes the comment
+            if self.skip_comment() {
                 continue;
             }
             items.push(self.parse_module_item()?);
         }
-        Ok(Module { items })
+        Ok(Module {
+            items,
+            comments: std::mem::take(&mut self.comments),
+        })
     }
 }
 
@@ -238,4 +360,40 @@ mod tests {
     fn parse_imports() {
         insta::assert_debug_snapshot!(parse(r#"import {a, b as c} from "foo""#));
     }
+
+    #[test]
+    fn parse_re_exports() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            export {a, b as c} from "./util"
+            export namespace Short = Very.Long.Namespace
+            "#
+        ));
+    }
+
+    #[test]
+    fn parse_declares() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            declare let foo: number
+            export declare fn bar(x: number) -> string
+            export declare namespace Baz {
+                type T = number
+                let qux: T
+            }
+            "#
+        ));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_declare_with_expression_should_fail() {
+        parse(r#"declare foo + 1"#);
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_re_export_without_source_should_fail() {
+        parse(r#"export {a, b}"#);
+    }
 }
@@ -12,6 +12,11 @@ pub struct Parser<'a> {
     pub scanner: Scanner<'a>,
     pub brace_counts: Vec<usize>,
     pub peeked: Option<Token>,
+    // When true, interpolations in template strings are parsed as type
+    // annotations instead of expressions.
+    pub in_type_ann: bool,
+    // Comments between statements, these aren't attached to AST nodes.
+    pub comments: Vec<Comment>,
 }
 
 impl<'a> Iterator for Parser<'a> {
@@ -40,6 +45,8 @@ impl<'a> Parser<'a> {
             scanner: Scanner::new(input),
             brace_counts: vec![0], // we need separate brace counts for each mode
             peeked: None,
+            in_type_ann: false,
+            comments: vec![],
         }
     }
 
@@ -47,6 +54,24 @@ impl<'a> Parser<'a> {
         self.scanner = backup.scanner;
         self.brace_counts = backup.brace_counts;
         self.peeked = backup.peeked;
+        self.in_type_ann = backup.in_type_ann;
+    }
+
+    // Consumes the next token if it's a comment.  Returns true if a comment
+    // was consumed.
+    pub fn skip_comment(&mut self) -> bool {
+        let text = match &self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Comment(text) => text.to_owned(),
+            _ => return false,
+        };
+        let span = self.next().unwrap_or(EOF.clone()).span; // consumes the comment
+
+        // Comments can be seen more than once when backtracking.
+        if !self.comments.iter().any(|comment| comment.span == span) {
+            self.comments.push(Comment { text, span });
+        }
+
+        true
     }
 
     pub fn peek(&mut self) -> Option<&Token> {
@@ -212,6 +237,10 @@ impl<'a> Parser<'a> {
                             self.scanner.pop();
                             self.scanner.pop();
                             TokenKind::DotDotDot
+                        } else if self.scanner.peek(2) == Some('=') {
+                            self.scanner.pop();
+                            self.scanner.pop();
+                            TokenKind::DotDotEquals
                         } else {
                             self.scanner.pop();
                             TokenKind::DotDot
@@ -221,6 +250,7 @@ impl<'a> Parser<'a> {
                     }
                 }
                 ';' => TokenKind::Semicolon,
+                '@' => TokenKind::At,
                 ':' => TokenKind::Colon,
                 '?' => match self.scanner.peek(1) {
                     Some('.') => {
@@ -309,6 +339,7 @@ impl<'a> Parser<'a> {
                 "async" => TokenKind::Async,
                 "gen" => TokenKind::Gen,
                 "private" => TokenKind::Private,
+                "abstract" => TokenKind::Abstract,
                 // 'mut' is special because it can be used to modify bindings
                 // introduced by patterns
                 "mut" => TokenKind::Mut,
@@ -352,8 +383,11 @@ impl<'a> Parser<'a> {
             "do" => TokenKind::Do,
             "for" => TokenKind::For,
             "in" => TokenKind::In,
+            "instanceof" => TokenKind::InstanceOf,
             "class" => TokenKind::Class,
+            "abstract" => TokenKind::Abstract,
             "extends" => TokenKind::Extends,
+            "implements" => TokenKind::Implements,
             "infer" => TokenKind::Infer,
             "return" => TokenKind::Return,
             "throws" => TokenKind::Throws,
@@ -369,7 +403,9 @@ impl<'a> Parser<'a> {
             "symbol" => TokenKind::Symbol,
             "unknown" => TokenKind::Unknown,
             "never" => TokenKind::Never,
+            "void" => TokenKind::Void,
             "type" => TokenKind::Type,
+            "interface" => TokenKind::Interface,
             "typeof" => TokenKind::TypeOf,
             "keyof" => TokenKind::KeyOf,
             "new" => TokenKind::New,
@@ -398,6 +434,10 @@ impl<'a> Parser<'a> {
                     number.push(character);
                     self.scanner.pop();
                 }
+                // `0..9` is a range, not the number `0.` followed by `.9`
+                '.' if self.scanner.peek(1) == Some('.') => {
+                    break;
+                }
                 '.' => {
                     if decimal {
                         panic!("Unexpected character: '{}'", character);
@@ -472,10 +512,63 @@ impl<'a> Parser<'a> {
         }
     }
 
+    // Lexes the rest of a regex literal after the opening `/`.  `prefix` is
+    // the part of the pattern that was lexed along with the `/`, e.g. the `=`
+    // in `/=/`.  The pattern is kept as is, escapes are handled by the regex
+    // engine.
+    pub fn lex_regex(&mut self, prefix: &str) -> Result<Regex, ParseError> {
+        let unterminated = || ParseError {
+            message: "Unterminated regex literal".to_string(),
+        };
+
+        let mut pattern = String::from(prefix);
+        // `/` doesn't end the pattern inside of a character class, e.g. `[/]`.
+        let mut in_class = false;
+        loop {
+            match self.scanner.pop() {
+                Some('/') if !in_class => break,
+                Some('\\') => {
+                    pattern.push('\\');
+                    match self.scanner.pop() {
+                        Some('\n') | None => return Err(unterminated()),
+                        Some(c) => pattern.push(c),
+                    }
+                }
+                Some('\n') | None => return Err(unterminated()),
+                Some(c) => {
+                    match c {
+                        '[' => in_class = true,
+                        ']' => in_class = false,
+                        _ => {}
+                    }
+                    pattern.push(c);
+                }
+            }
+        }
+
+        let mut flags = String::new();
+        while let Some(c) = self.scanner.peek(0) {
+            if !c.is_ascii_alphabetic() {
+                break;
+            }
+            flags.push(c);
+            self.scanner.pop();
+        }
+
+        Ok(Regex {
+            pattern,
+            flags: match flags.is_empty() {
+                true => None,
+                false => Some(flags),
+            },
+        })
+    }
+
     pub fn lex_template_string(&mut self, start: usize) -> Result<Token, ParseError> {
         let mut string = String::new();
         let mut parts: Vec<Token> = vec![];
         let mut exprs: Vec<Expr> = vec![];
+        let mut types: Vec<TypeAnn> = vec![];
         let mut string_start = start;
         self.scanner.pop();
         while !self.scanner.is_done() {
@@ -522,7 +615,11 @@ impl<'a> Parser<'a> {
                         self.scanner.pop(); // consumes '{'
 
                         self.brace_counts.push(0);
-                        exprs.push(self.parse_expr()?);
+                        if self.in_type_ann {
+                            types.push(self.parse_type_ann()?);
+                        } else {
+                            exprs.push(self.parse_expr()?);
+                        }
                         self.brace_counts.pop();
 
                         self.scanner.pop(); // consumes '}'
@@ -548,8 +645,13 @@ impl<'a> Parser<'a> {
             },
         });
 
+        let kind = match self.in_type_ann {
+            true => TokenKind::StrTemplateLitType { parts, types },
+            false => TokenKind::StrTemplateLit { parts, exprs },
+        };
+
         Ok(Token {
-            kind: TokenKind::StrTemplateLit { parts, exprs },
+            kind,
             span: Span {
                 start,
                 end: self.scanner.cursor(),
@@ -716,6 +818,32 @@ mod tests {
         assert_eq!(tokens[2].kind, crate::token::TokenKind::DotDotDot);
     }
 
+    #[test]
+    fn lex_ranges() {
+        let parser = Parser::new("0..9 0..=9");
+
+        let tokens = parser.collect::<Vec<_>>();
+
+        assert_eq!(
+            tokens[0].kind,
+            crate::token::TokenKind::NumLit("0".to_string())
+        );
+        assert_eq!(tokens[1].kind, crate::token::TokenKind::DotDot);
+        assert_eq!(
+            tokens[2].kind,
+            crate::token::TokenKind::NumLit("9".to_string())
+        );
+        assert_eq!(
+            tokens[3].kind,
+            crate::token::TokenKind::NumLit("0".to_string())
+        );
+        assert_eq!(tokens[4].kind, crate::token::TokenKind::DotDotEquals);
+        assert_eq!(
+            tokens[5].kind,
+            crate::token::TokenKind::NumLit("9".to_string())
+        );
+    }
+
     #[test]
     fn lex_dots_reverse() {
         let parser = Parser::new("... .. .");
@@ -5,6 +5,18 @@ use crate::parser::{IdentMode, Parser};
 use crate::token::*;
 
 impl<'a> Parser<'a> {
+    // Parses the default value after a property or element in an object or
+    // tuple pattern, e.g. the `5` in `{x = 5}`.
+    fn parse_pattern_init(&mut self) -> Result<Option<Box<Expr>>, ParseError> {
+        match self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Assign => {
+                self.next(); // consumes '='
+                Ok(Some(Box::new(self.parse_expr()?)))
+            }
+            _ => Ok(None),
+        }
+    }
+
     pub fn parse_pattern(&mut self) -> Result<Pattern, ParseError> {
         let mut span = self.peek().unwrap_or(&EOF).span;
         let kind = match self.next().unwrap_or(EOF.clone()).kind {
@@ -66,6 +78,19 @@ impl<'a> Parser<'a> {
             TokenKind::NumLit(value) => PatternKind::Lit(LitPat {
                 lit: Literal::Number(value),
             }),
+            // Negative numbers are literals in patterns, there's no unary minus.
+            TokenKind::Minus => {
+                let next = self.next().unwrap_or(EOF.clone());
+                match next.kind {
+                    TokenKind::NumLit(value) => {
+                        span = merge_spans(&span, &next.span);
+                        PatternKind::Lit(LitPat {
+                            lit: Literal::Number(format!("-{value}")),
+                        })
+                    }
+                    _ => panic!("expected number after '-'"),
+                }
+            }
             TokenKind::BoolLit(value) => PatternKind::Lit(LitPat {
                 lit: Literal::Boolean(value),
             }),
@@ -89,10 +114,9 @@ impl<'a> Parser<'a> {
                             has_rest = true;
                         }
                         _ => {
-                            elems.push(Some(TuplePatElem {
-                                pattern: self.parse_pattern()?,
-                                init: None,
-                            }));
+                            let pattern = self.parse_pattern()?;
+                            let init = self.parse_pattern_init()?;
+                            elems.push(Some(TuplePatElem { pattern, init }));
                         }
                     }
 
@@ -132,27 +156,37 @@ impl<'a> Parser<'a> {
                                 self.next();
 
                                 let pattern = self.parse_pattern()?;
+                                let init = self.parse_pattern_init()?;
 
+                                let end_span = match &init {
+                                    Some(init) => init.span,
+                                    None => pattern.span,
+                                };
                                 // TODO: handle `var` and `mut` modifiers
                                 props.push(ObjectPatProp::KeyValue(KeyValuePatProp {
-                                    span: merge_spans(&first_span, &pattern.span),
+                                    span: merge_spans(&first_span, &end_span),
                                     key: Ident {
                                         name: name.clone(),
                                         span: first_span,
                                     },
                                     value: Box::new(pattern),
-                                    init: None,
+                                    init,
                                 }));
                             } else {
+                                let init = self.parse_pattern_init()?;
+
                                 // TODO: handle `var` and `mut` modifiers
                                 props.push(ObjectPatProp::Shorthand(ShorthandPatProp {
-                                    span: first_span,
+                                    span: match &init {
+                                        Some(init) => merge_spans(&first_span, &init.span),
+                                        None => first_span,
+                                    },
                                     ident: BindingIdent {
                                         name: name.clone(),
                                         span: first_span,
                                         mutable: false,
                                     },
-                                    init: None,
+                                    init,
                                 }))
                             }
 
@@ -223,6 +257,34 @@ impl<'a> Parser<'a> {
             }
         };
 
+        let range_op = self.peek().unwrap_or(&EOF).kind.clone();
+        if let (
+            PatternKind::Lit(LitPat {
+                lit: start @ (Literal::Number(_) | Literal::String(_)),
+            }),
+            TokenKind::DotDot | TokenKind::DotDotEquals,
+        ) = (&kind, &range_op)
+        {
+            self.next(); // consumes '..' or '..='
+            let end = self.parse_pattern()?;
+            span = merge_spans(&span, &end.span);
+            let end = match end.kind {
+                PatternKind::Lit(LitPat {
+                    lit: end @ (Literal::Number(_) | Literal::String(_)),
+                }) => end,
+                _ => panic!("expected number or string at the end of range pattern"),
+            };
+            return Ok(Pattern {
+                span,
+                kind: PatternKind::Range(RangePat {
+                    start: start.to_owned(),
+                    end,
+                    inclusive: range_op == TokenKind::DotDotEquals,
+                }),
+                inferred_type: None,
+            });
+        }
+
         Ok(Pattern {
             span,
             kind,
@@ -251,6 +313,30 @@ mod tests {
         insta::assert_debug_snapshot!(parse(r#""hello""#));
     }
 
+    #[test]
+    fn parse_negative_number_pattern() {
+        insta::assert_debug_snapshot!(parse("-1"));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_negative_non_number_pattern() {
+        parse("-x");
+    }
+
+    #[test]
+    fn parse_range_patterns() {
+        insta::assert_debug_snapshot!(parse("0..=9"));
+        insta::assert_debug_snapshot!(parse("-10..0"));
+        insta::assert_debug_snapshot!(parse(r#""a"..="z""#));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_range_pattern_with_non_literal_end() {
+        parse("0..x");
+    }
+
     #[test]
     fn parse_tuple_patterns() {
         insta::assert_debug_snapshot!(parse("[a, b, mut c]"));
@@ -9,12 +9,14 @@ impl<'a> Parser<'a> {
         let mut stmts = Vec::new();
         while self.peek().unwrap_or(&EOF).kind != TokenKind::Eof {
             // TODO: attach comments to AST nodes
-            if let TokenKind::Comment(_) = &self.peek().unwrap_or(&EOF).kind {
-                self.next(); // consumes the comment
+            if self.skip_comment() {
                 continue;
             }
             stmts.push(self.parse_stmt()?);
         }
-        Ok(Script { stmts })
+        Ok(Script {
+            stmts,
+            comments: std::mem::take(&mut self.comments),
+        })
     }
 }
@@ -5,7 +5,47 @@ use crate::parser::*;
 use crate::token::*;
 
 impl<'a> Parser<'a> {
+    pub fn parse_annotations(&mut self) -> Result<Vec<Annotation>, ParseError> {
+        let mut annotations = vec![];
+
+        while self.peek().unwrap_or(&EOF).kind == TokenKind::At {
+            let start = self.next().unwrap_or(EOF.clone()); // consumes '@'
+            let token = self.next().unwrap_or(EOF.clone());
+            let name = match token.kind {
+                TokenKind::Identifier(name) => name,
+                _ => {
+                    return Err(ParseError {
+                        message: "expected identifier after '@'".to_string(),
+                    })
+                }
+            };
+
+            let (args, end) = match self.peek().unwrap_or(&EOF).kind {
+                TokenKind::LeftParen => {
+                    let args = self.parse_inside_parens(|p| {
+                        p.parse_many(|p| p.parse_expr(), TokenKind::Comma, TokenKind::RightParen)
+                    })?;
+                    (args, self.scanner.cursor())
+                }
+                _ => (vec![], token.span.end),
+            };
+
+            annotations.push(Annotation {
+                name,
+                args,
+                span: Span {
+                    start: start.span.start,
+                    end,
+                },
+            });
+        }
+
+        Ok(annotations)
+    }
+
     pub fn parse_stmt(&mut self) -> Result<Stmt, ParseError> {
+        let annotations = self.parse_annotations()?;
+
         let mut token = self.peek().unwrap_or(&EOF).clone();
         let start = token.span.start;
 
@@ -19,6 +59,22 @@ impl<'a> Parser<'a> {
             _ => false,
         };
 
+        if !annotations.is_empty()
+            && !matches!(
+                token.kind,
+                TokenKind::Let | TokenKind::Var | TokenKind::Type | TokenKind::Interface
+            )
+            && !(is_declare
+                && matches!(
+                    token.kind,
+                    TokenKind::Fn | TokenKind::Class | TokenKind::Abstract
+                ))
+        {
+            return Err(ParseError {
+                message: "annotations can only be applied to declarations".to_string(),
+            });
+        }
+
         let stmt = match &token.kind {
             TokenKind::Let | TokenKind::Var => {
                 let token = self.next().unwrap_or(EOF.clone()); // consumes 'let' or 'var'
@@ -63,6 +119,7 @@ impl<'a> Parser<'a> {
                         type_ann,
                     }),
                     span,
+                    annotations,
                 };
 
                 // TODO: check invariants in semantic analysis pass
@@ -72,9 +129,153 @@ impl<'a> Parser<'a> {
                     inferred_type: None,
                 }
             }
+            TokenKind::Fn if is_declare => {
+                let (ident, func) = self.parse_declare_fn_sig()?;
+                let mut end = func.span.end;
+                let mut sigs = vec![func];
+
+                // Consecutive `declare fn` statements with the same name are
+                // overloads.  They're merged into a single declaration whose
+                // type is the intersection of all of the signatures.
+                loop {
+                    let backup = self.clone();
+                    if self.next().unwrap_or(EOF.clone()).kind == TokenKind::Declare
+                        && self.peek().unwrap_or(&EOF).kind == TokenKind::Fn
+                    {
+                        let (next_ident, func) = self.parse_declare_fn_sig()?;
+                        if next_ident.name == ident.name {
+                            end = func.span.end;
+                            sigs.push(func);
+                            continue;
+                        }
+                    }
+                    self.restore(backup);
+                    break;
+                }
+
+                let mut type_anns = sigs
+                    .into_iter()
+                    .map(|func| TypeAnn {
+                        span: func.span,
+                        kind: TypeAnnKind::Function(func),
+                        inferred_type: None,
+                    })
+                    .collect::<Vec<_>>();
+
+                let type_ann = match type_anns.len() {
+                    1 => type_anns.remove(0),
+                    _ => TypeAnn {
+                        span: merge_spans(&type_anns[0].span, &type_anns[type_anns.len() - 1].span),
+                        kind: TypeAnnKind::Intersection(type_anns),
+                        inferred_type: None,
+                    },
+                };
+
+                let span = Span { start, end };
+
+                let decl = Decl {
+                    kind: DeclKind::VarDecl(VarDecl {
+                        is_declare,
+                        is_var: false,
+                        pattern: Pattern {
+                            kind: PatternKind::Ident(BindingIdent {
+                                name: ident.name,
+                                span: ident.span,
+                                mutable: false,
+                            }),
+                            span: ident.span,
+                            inferred_type: None,
+                        },
+                        expr: None,
+                        type_ann: Some(type_ann),
+                    }),
+                    span,
+                    annotations,
+                };
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
+            TokenKind::Class | TokenKind::Abstract if is_declare => {
+                let (ident, class) = self.parse_declare_class()?;
+                let span = Span {
+                    start,
+                    end: class.span.end,
+                };
+
+                // Declared classes are bound like `let Foo = class { ... }`
+                // but they're never emitted.
+                let decl = Decl {
+                    kind: DeclKind::VarDecl(VarDecl {
+                        is_declare,
+                        is_var: false,
+                        pattern: Pattern {
+                            kind: PatternKind::Ident(BindingIdent {
+                                name: ident.name,
+                                span: ident.span,
+                                mutable: false,
+                            }),
+                            span: ident.span,
+                            inferred_type: None,
+                        },
+                        expr: Some(class),
+                        type_ann: None,
+                    }),
+                    span,
+                    annotations,
+                };
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
+            TokenKind::Identifier(name) if is_declare && name == "namespace" => {
+                let decl = self.parse_declare_namespace(start)?;
+                let span = decl.span;
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
+            TokenKind::Identifier(name)
+                if !is_declare && name == "namespace" && self.is_namespace_decl() =>
+            {
+                let decl = self.parse_namespace_decl()?;
+                let span = decl.span;
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
+            TokenKind::Identifier(name) if is_declare && name == "global" => {
+                let decl = self.parse_global_decl(start)?;
+                let span = decl.span;
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
             TokenKind::For => {
                 self.next(); // consumes 'for'
 
+                let is_await = if self.peek().unwrap_or(&EOF).kind == TokenKind::Await {
+                    self.next(); // consumes 'await'
+                    true
+                } else {
+                    false
+                };
+
                 assert_eq!(
                     self.next().unwrap_or(EOF.clone()).kind,
                     TokenKind::LeftParen
@@ -96,6 +297,7 @@ impl<'a> Parser<'a> {
                         left: Box::new(left),
                         right: Box::new(right),
                         body,
+                        is_await,
                     }),
                     span,
                     inferred_type: None,
@@ -145,8 +347,10 @@ impl<'a> Parser<'a> {
                         name,
                         type_ann,
                         type_params,
+                        is_interface: false,
                     }),
                     span,
+                    annotations,
                 };
 
                 Stmt {
@@ -155,6 +359,17 @@ impl<'a> Parser<'a> {
                     inferred_type: None,
                 }
             }
+            TokenKind::Interface => {
+                let mut decl = self.parse_interface_decl()?;
+                decl.annotations = annotations;
+                let span = decl.span;
+
+                Stmt {
+                    kind: StmtKind::Decl(decl),
+                    span,
+                    inferred_type: None,
+                }
+            }
             _ => {
                 let expr = self.parse_expr()?;
                 let span = expr.get_span();
@@ -168,6 +383,324 @@ impl<'a> Parser<'a> {
 
         Ok(stmt)
     }
+
+    // Parses `interface Foo<T> { ... }`.  The body is parsed as an object type.
+    pub fn parse_interface_decl(&mut self) -> Result<Decl, ParseError> {
+        let token = self.next().unwrap_or(EOF.clone()); // consumes 'interface'
+
+        let name = match self.next().unwrap_or(EOF.clone()).kind {
+            TokenKind::Identifier(name) => name,
+            _ => {
+                return Err(ParseError {
+                    message: "expected identifier".to_string(),
+                })
+            }
+        };
+
+        let type_params = self.maybe_parse_type_params()?;
+
+        if self.peek().unwrap_or(&EOF).kind != TokenKind::LeftBrace {
+            return Err(ParseError {
+                message: "expected '{' after interface name".to_string(),
+            });
+        }
+        let type_ann = self.parse_type_ann()?;
+        let span = merge_spans(&token.span, &type_ann.span);
+
+        Ok(Decl {
+            kind: DeclKind::TypeDecl(TypeDecl {
+                name,
+                type_ann,
+                type_params,
+                is_interface: true,
+            }),
+            span,
+            annotations: vec![],
+        })
+    }
+
+    // Parses `global { ... }` after `declare`, which must already have been
+    // consumed.  Only declarations are allowed inside the braces and none of
+    // the variables can be initialized since they're ambient.
+    pub fn parse_global_decl(&mut self, start: usize) -> Result<Decl, ParseError> {
+        self.next(); // consumes 'global'
+
+        if self.next().unwrap_or(EOF.clone()).kind != TokenKind::LeftBrace {
+            return Err(ParseError {
+                message: "expected '{' after 'declare global'".to_string(),
+            });
+        }
+
+        let mut decls = vec![];
+        while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
+            if self.skip_comment() {
+                continue;
+            }
+
+            let mut decl = match self.parse_stmt()?.kind {
+                StmtKind::Decl(decl) => decl,
+                _ => {
+                    return Err(ParseError {
+                        message: "only declarations are allowed in 'declare global'".to_string(),
+                    })
+                }
+            };
+            match &mut decl.kind {
+                DeclKind::VarDecl(VarDecl { expr: Some(_), .. }) => {
+                    return Err(ParseError {
+                        message: "variables in 'declare global' can't be initialized".to_string(),
+                    })
+                }
+                DeclKind::VarDecl(var_decl) => var_decl.is_declare = true,
+                DeclKind::TypeDecl(_)
+                | DeclKind::NamespaceDecl(NamespaceDecl {
+                    is_declare: true, ..
+                }) => {}
+                DeclKind::GlobalDecl(_) => {
+                    return Err(ParseError {
+                        message: "'declare global' can't be nested".to_string(),
+                    })
+                }
+                DeclKind::NamespaceDecl(_) | DeclKind::NamespaceAliasDecl(_) => {
+                    return Err(ParseError {
+                        message: "only declared namespaces are allowed in 'declare global'"
+                            .to_string(),
+                    })
+                }
+            }
+            decls.push(decl);
+        }
+
+        let close = self.next().unwrap_or(EOF.clone()); // consumes '}'
+
+        Ok(Decl {
+            kind: DeclKind::GlobalDecl(GlobalDecl { decls }),
+            span: Span {
+                start,
+                end: close.span.end,
+            },
+            annotations: vec![],
+        })
+    }
+
+    // `namespace` isn't a keyword so it's only the start of a namespace if
+    // it's followed by the name of the namespace, e.g. `namespace.x` is a
+    // member access.
+    fn is_namespace_decl(&mut self) -> bool {
+        let mut lookahead = self.clone();
+        lookahead.next(); // consumes 'namespace'
+        matches!(
+            lookahead.peek().unwrap_or(&EOF).kind,
+            TokenKind::Identifier(_)
+        )
+    }
+
+    // Parses `namespace Foo { ... }`.  Only declarations are allowed inside
+    // the braces, including other namespaces.  Also parses namespace aliases,
+    // e.g. `namespace Short = Very.Long.Namespace`.
+    pub fn parse_namespace_decl(&mut self) -> Result<Decl, ParseError> {
+        let token = self.next().unwrap_or(EOF.clone()); // consumes 'namespace'
+
+        let name = match self.next().unwrap_or(EOF.clone()) {
+            Token {
+                kind: TokenKind::Identifier(name),
+                span,
+            } => Ident { name, span },
+            _ => {
+                return Err(ParseError {
+                    message: "expected identifier after 'namespace'".to_string(),
+                })
+            }
+        };
+
+        if self.peek().unwrap_or(&EOF).kind == TokenKind::Assign {
+            self.next(); // consumes '='
+
+            let target = self.parse_expr()?;
+            let mut expr = &target;
+            while let ExprKind::Member(Member {
+                object,
+                property: MemberProp::Ident(_),
+                opt_chain: false,
+                ..
+            }) = &expr.kind
+            {
+                expr = object;
+            }
+            if !matches!(expr.kind, ExprKind::Ident(_)) {
+                return Err(ParseError {
+                    message: "namespace aliases must refer to a namespace by name".to_string(),
+                });
+            }
+
+            let span = merge_spans(&token.span, &target.get_span());
+            return Ok(Decl {
+                kind: DeclKind::NamespaceAliasDecl(NamespaceAliasDecl { name, target }),
+                span,
+                annotations: vec![],
+            });
+        }
+
+        if self.next().unwrap_or(EOF.clone()).kind != TokenKind::LeftBrace {
+            return Err(ParseError {
+                message: "expected '{' after namespace name".to_string(),
+            });
+        }
+
+        let mut decls = vec![];
+        while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
+            if self.skip_comment() {
+                continue;
+            }
+
+            match self.parse_stmt()?.kind {
+                StmtKind::Decl(Decl {
+                    kind: DeclKind::GlobalDecl(_),
+                    ..
+                }) => {
+                    return Err(ParseError {
+                        message: "'declare global' can't be used inside of a namespace".to_string(),
+                    })
+                }
+                StmtKind::Decl(decl) => decls.push(decl),
+                _ => {
+                    return Err(ParseError {
+                        message: "only declarations are allowed in namespaces".to_string(),
+                    })
+                }
+            }
+        }
+
+        let close = self.next().unwrap_or(EOF.clone()); // consumes '}'
+
+        Ok(Decl {
+            kind: DeclKind::NamespaceDecl(NamespaceDecl {
+                name,
+                decls,
+                is_declare: false,
+            }),
+            span: merge_spans(&token.span, &close.span),
+            annotations: vec![],
+        })
+    }
+
+    // Parses `namespace Foo { ... }` after `declare`, which must already have
+    // been consumed.  Everything inside of the namespace is ambient so none of
+    // the variables can be initialized, nested namespaces are declared as well.
+    fn parse_declare_namespace(&mut self, start: usize) -> Result<Decl, ParseError> {
+        self.next(); // consumes 'namespace'
+
+        let name = match self.next().unwrap_or(EOF.clone()) {
+            Token {
+                kind: TokenKind::Identifier(name),
+                span,
+            } => Ident { name, span },
+            _ => {
+                return Err(ParseError {
+                    message: "expected identifier after 'namespace'".to_string(),
+                })
+            }
+        };
+
+        if self.next().unwrap_or(EOF.clone()).kind != TokenKind::LeftBrace {
+            return Err(ParseError {
+                message: "expected '{' after namespace name".to_string(),
+            });
+        }
+
+        let mut decls = vec![];
+        while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBrace {
+            if self.skip_comment() {
+                continue;
+            }
+
+            let token = self.peek().unwrap_or(&EOF).clone();
+            if matches!(&token.kind, TokenKind::Identifier(name) if name == "namespace")
+                && self.is_namespace_decl()
+            {
+                decls.push(self.parse_declare_namespace(token.span.start)?);
+                continue;
+            }
+
+            let mut decl = match self.parse_stmt()?.kind {
+                StmtKind::Decl(decl) => decl,
+                _ => {
+                    return Err(ParseError {
+                        message: "only declarations are allowed in namespaces".to_string(),
+                    })
+                }
+            };
+            match &mut decl.kind {
+                // Declared classes are the only variables with an initializer.
+                DeclKind::VarDecl(VarDecl {
+                    expr: Some(_),
+                    is_declare: false,
+                    ..
+                }) => {
+                    return Err(ParseError {
+                        message: "variables in declared namespaces can't be initialized"
+                            .to_string(),
+                    })
+                }
+                DeclKind::VarDecl(var_decl) => var_decl.is_declare = true,
+                DeclKind::TypeDecl(_) => {}
+                DeclKind::NamespaceDecl(NamespaceDecl {
+                    is_declare: true, ..
+                }) => {}
+                DeclKind::GlobalDecl(_) => {
+                    return Err(ParseError {
+                        message: "'declare global' can't be used inside of a namespace".to_string(),
+                    })
+                }
+                DeclKind::NamespaceAliasDecl(_) => {
+                    return Err(ParseError {
+                        message: "namespace aliases can't be used in declared namespaces"
+                            .to_string(),
+                    })
+                }
+                // Nested namespaces are parsed as declared namespaces above.
+                DeclKind::NamespaceDecl(_) => unreachable!(),
+            }
+            decls.push(decl);
+        }
+
+        let close = self.next().unwrap_or(EOF.clone()); // consumes '}'
+
+        Ok(Decl {
+            kind: DeclKind::NamespaceDecl(NamespaceDecl {
+                name,
+                decls,
+                is_declare: true,
+            }),
+            span: Span {
+                start,
+                end: close.span.end,
+            },
+            annotations: vec![],
+        })
+    }
+
+    // Parses the signature in `declare fn foo(a: number) -> string`.  The
+    // `declare` keyword must already have been consumed.
+    fn parse_declare_fn_sig(&mut self) -> Result<(Ident, FunctionType), ParseError> {
+        let token = self.next().unwrap_or(EOF.clone()); // consumes 'fn'
+
+        let ident = match self.next().unwrap_or(EOF.clone()) {
+            Token {
+                kind: TokenKind::Identifier(name),
+                span,
+            } => Ident { name, span },
+            _ => {
+                return Err(ParseError {
+                    message: "expected identifier".to_string(),
+                })
+            }
+        };
+
+        let func = self.parse_func_type(token.span)?;
+
+        Ok((ident, func))
+    }
 }
 
 // TODO: remove this function
@@ -213,6 +746,27 @@ mod tests {
         assert_eq!(stmts.len(), 4);
     }
 
+    #[test]
+    fn comments_between_statements() {
+        let input = "// first\nlet f = fn () {\n  // second\n  return 5\n}\n";
+        let mut parser = Parser::new(input);
+        let script = parser.parse_script().unwrap();
+
+        assert_eq!(
+            script.comments,
+            vec![
+                Comment {
+                    text: " first".to_string(),
+                    span: Span { start: 0, end: 8 },
+                },
+                Comment {
+                    text: " second".to_string(),
+                    span: Span { start: 27, end: 36 },
+                },
+            ]
+        );
+    }
+
     #[test]
     fn parse_let() {
         insta::assert_debug_snapshot!(parse(r#"let y = m*x + b"#));
@@ -229,6 +783,142 @@ mod tests {
         insta::assert_debug_snapshot!(parse(r#"declare let bar: fn () -> number"#));
     }
 
+    #[test]
+    fn parse_declare_fn() {
+        insta::assert_debug_snapshot!(parse(r#"declare fn parse(s: string) -> number"#));
+    }
+
+    #[test]
+    fn parse_declare_fn_overloads() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            declare fn parse(s: string) -> number
+            declare fn parse(s: string, radix: number) -> number
+            declare fn print(value: number) -> string
+            "#
+        ));
+    }
+
+    #[test]
+    fn parse_declare_class() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            declare class Point {
+                x: number
+                fn constructor(mut self, x: number)
+                fn getX(self) -> number
+                static fn origin() -> Point
+            }
+            "#
+        ));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_declare_class_with_field_initializer_should_fail() {
+        parse(
+            r#"
+            declare class Point {
+                x = 5
+            }
+            "#,
+        );
+    }
+
+    #[test]
+    fn parse_declare_namespace() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            declare namespace Math {
+                let PI: number
+                declare fn abs(x: number) -> number
+                type Angle = number
+                interface Point { x: number, y: number }
+                namespace Units {
+                    let scale: number
+                }
+            }
+            "#
+        ));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_declare_namespace_with_initializer_should_fail() {
+        parse(
+            r#"
+            declare namespace Math {
+                let PI: number = 3.14
+            }
+            "#,
+        );
+    }
+
+    #[test]
+    fn parse_namespace() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            namespace Foo {
+                let x = 5
+                type T = number
+                namespace Bar {
+                    let y = x
+                }
+            }
+            let z = namespace.x
+            "#
+        ));
+    }
+
+    #[test]
+    fn parse_namespace_alias() {
+        insta::assert_debug_snapshot!(parse(r#"namespace Short = Very.Long.Namespace"#));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_namespace_alias_with_call_should_fail() {
+        parse(r#"namespace Short = getNamespace().Long"#);
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_namespace_with_expression_should_fail() {
+        parse(
+            r#"
+            namespace Foo {
+                let x = 5
+                x + 1
+            }
+            "#,
+        );
+    }
+
+    #[test]
+    fn parse_declare_global() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            declare global {
+                let foo: number
+                declare fn bar(x: number) -> string
+                interface Window { foo: number }
+            }
+            "#
+        ));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_declare_global_with_initializer_should_fail() {
+        parse(
+            r#"
+            declare global {
+                let foo: number = 5
+            }
+            "#,
+        );
+    }
+
     #[test]
     fn parse_let_with_destructuring() {
         insta::assert_debug_snapshot!(parse(r#"let {x, y} = point"#));
@@ -266,6 +956,12 @@ mod tests {
         insta::assert_debug_snapshot!(parse("let [head, ...tail] = polygon"));
     }
 
+    #[test]
+    fn parse_let_destructuring_with_defaults() {
+        insta::assert_debug_snapshot!(parse(r#"let {a: x = 1, b: {c = "two"}, ...rest} = obj"#));
+        insta::assert_debug_snapshot!(parse("let [a, b = 2] = tuple"));
+    }
+
     #[test]
     fn parse_let_fn_with_fn_type() {
         insta::assert_debug_snapshot!(parse(
@@ -306,6 +1002,17 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn parse_interface() {
+        insta::assert_debug_snapshot!(parse("interface Point<T> {x: T, y: T}"));
+    }
+
+    #[test]
+    fn parse_type_alias_with_default_type_params() {
+        insta::assert_debug_snapshot!(parse(r#"type Box<T = number> = {value: T}"#));
+        insta::assert_debug_snapshot!(parse(r#"type Pair<A: string, B = A> = [A, B]"#));
+    }
+
     #[test]
     fn parse_var_decls() {
         insta::assert_debug_snapshot!(parse(r#"let mut p = {x: 5, y: 10}"#));
@@ -326,6 +1033,37 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn parse_for_await_loop() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            for await (msg in messages()) {
+                console.log(msg)
+            }"#
+        ));
+    }
+
+    #[test]
+    fn parse_annotations() {
+        insta::assert_debug_snapshot!(parse(
+            r#"
+            @deprecated("use bar instead")
+            let foo = fn () => 5
+            "#
+        ));
+    }
+
+    #[test]
+    #[should_panic]
+    fn parse_annotations_on_expr_should_fail() {
+        parse(
+            r#"
+            @deprecated("use bar instead")
+            foo()
+            "#,
+        );
+    }
+
     #[test]
     fn parse_comments() {
         insta::assert_debug_snapshot!(parse(
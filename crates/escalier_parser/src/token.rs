@@ -13,6 +13,10 @@ pub enum TokenKind {
         parts: Vec<Token>, // This should only contain StrLit tokens
         exprs: Vec<Expr>,
     },
+    StrTemplateLitType {
+        parts: Vec<Token>, // This should only contain StrLit tokens
+        types: Vec<TypeAnn>,
+    },
     Null,
     Undefined,
 
@@ -23,6 +27,7 @@ pub enum TokenKind {
     Symbol,
     Unknown,
     Never,
+    Void,
 
     // Keywords
     Import,
@@ -56,9 +61,13 @@ pub enum TokenKind {
     Do,
     For,
     In,
+    InstanceOf,
     Class,
+    Abstract,
     Extends,
+    Implements,
     Type,
+    Interface,
     TypeOf,
     KeyOf,
     Infer,
@@ -108,10 +117,12 @@ pub enum TokenKind {
     Question,
     QuestionDot, // used for optional chaining
     Dot,
-    DotDot,    // used for ranges
-    DotDotDot, // used for rest/spread
+    DotDot,       // used for ranges
+    DotDotEquals, // used for inclusive ranges
+    DotDotDot,    // used for rest/spread
     Pipe,
     Ampersand,
+    At, // used for annotations
 
     Eof,
 }
@@ -23,6 +23,14 @@ fn get_infix_op_info(op: &Token) -> Option<OpInfo> {
     }
 }
 
+fn get_prefix_op_info(op: &Token) -> Option<OpInfo> {
+    match &op.kind {
+        // binds tighter than `&` and `|` but looser than `[]`
+        TokenKind::KeyOf => Some(OpInfo::new_prefix(12)),
+        _ => None,
+    }
+}
+
 fn get_postfix_op_info(op: &Token) -> Option<OpInfo> {
     match &op.kind {
         TokenKind::LeftBracket => Some(OpInfo::new_postfix(12)),
@@ -58,6 +66,34 @@ impl<'a> Parser<'a> {
                 self.next();
                 TypeAnnKind::String
             }
+            TokenKind::StrTemplateLitType { parts, types } => {
+                self.next(); // consumes template literal type
+                TypeAnnKind::TemplateLiteral(TemplateLitTypeAnn {
+                    parts: parts
+                        .iter()
+                        .map(|token| match &token.kind {
+                            TokenKind::StrLit(value) => Str {
+                                span: token.span,
+                                value: value.to_owned(),
+                            },
+                            _ => panic!("Expected string literal, got {:?}", token),
+                        })
+                        .collect(),
+                    types,
+                })
+            }
+            // A `/` where a type is expected starts a regex type, the same as
+            // with expressions.
+            TokenKind::Divide | TokenKind::DivideAssign => {
+                let token = self.next().unwrap_or(EOF.clone()); // consumes '/' or '/='
+                let prefix = match token.kind {
+                    TokenKind::DivideAssign => "=",
+                    _ => "",
+                };
+                let regex = self.lex_regex(prefix)?;
+                span.end = self.scanner.cursor();
+                TypeAnnKind::Regex(regex)
+            }
             TokenKind::Symbol => {
                 self.next();
                 TypeAnnKind::Symbol
@@ -78,6 +114,10 @@ impl<'a> Parser<'a> {
                 self.next();
                 TypeAnnKind::Never
             }
+            TokenKind::Void => {
+                self.next();
+                TypeAnnKind::Void
+            }
             TokenKind::Underscore => {
                 self.next(); // consumes '_'
                 TypeAnnKind::Wildcard
@@ -97,7 +137,29 @@ impl<'a> Parser<'a> {
                         .unwrap_or(EOF.clone())
                         .kind
                     {
+                        TokenKind::Identifier(name)
+                            if name == "readonly"
+                                && self.peek().unwrap_or(&EOF).kind == TokenKind::LeftBracket =>
+                        {
+                            self.next(); // consumes '['
+                            props.push(self.parse_mapped_type(Some(MappedModifier::Add))?);
+                        }
                         TokenKind::Identifier(name) => {
+                            // `readonly` is only a modifier when it's followed
+                            // by the name of the property.
+                            let (name, readonly) = match self
+                                .peek_with_mode(IdentMode::PropName)
+                                .unwrap_or(&EOF)
+                                .kind
+                                .clone()
+                            {
+                                TokenKind::Identifier(prop_name) if name == "readonly" => {
+                                    self.next_with_mode(IdentMode::PropName); // consumes name
+                                    (prop_name, true)
+                                }
+                                _ => (name, false),
+                            };
+
                             let optional =
                                 if self.peek().unwrap_or(&EOF).kind == TokenKind::Question {
                                     self.next().unwrap_or(EOF.clone());
@@ -135,6 +197,7 @@ impl<'a> Parser<'a> {
 
                                     ObjectProp::Prop(type_ann::Prop {
                                         name,
+                                        computed: false,
                                         modifier: Some(PropModifier::Getter),
                                         optional,
                                         readonly: false, // TODO
@@ -169,6 +232,7 @@ impl<'a> Parser<'a> {
 
                                     ObjectProp::Prop(type_ann::Prop {
                                         name,
+                                        computed: false,
                                         modifier: Some(PropModifier::Setter),
                                         optional,
                                         readonly: false, // TODO
@@ -183,9 +247,10 @@ impl<'a> Parser<'a> {
                                     let type_ann = self.parse_type_ann()?;
                                     ObjectProp::Prop(type_ann::Prop {
                                         name,
+                                        computed: false,
                                         modifier: None,
                                         optional,
-                                        readonly: false, // TODO
+                                        readonly,
                                         type_ann: Box::new(type_ann),
                                         // TODO(#642): compute correct spans for type annotations
                                         span: Span { start: 0, end: 0 },
@@ -196,67 +261,22 @@ impl<'a> Parser<'a> {
                             props.push(prop);
                         }
                         TokenKind::LeftBracket => {
-                            let key = self.parse_type_ann()?;
-                            assert_eq!(
-                                self.next().unwrap_or_else(|| EOF.clone()).kind,
-                                TokenKind::RightBracket
-                            );
-
-                            let mut optional: Option<MappedModifier> = None;
-                            if self.peek().unwrap_or(&EOF).kind == TokenKind::Plus {
-                                self.next(); // consume '+'
-                                assert_eq!(
-                                    self.next().unwrap_or(EOF.clone()).kind,
-                                    TokenKind::Question
-                                );
-                                optional = Some(MappedModifier::Add);
-                            } else if self.peek().unwrap_or(&EOF).kind == TokenKind::Minus {
-                                self.next(); // consume '-'
-                                assert_eq!(
-                                    self.next().unwrap_or(EOF.clone()).kind,
-                                    TokenKind::Question
-                                );
-                                optional = Some(MappedModifier::Remove);
-                            }
-
-                            assert_eq!(
-                                self.next().unwrap_or_else(|| EOF.clone()).kind,
-                                TokenKind::Colon
-                            );
-                            let value = self.parse_type_ann()?;
-
+                            props.push(self.parse_mapped_type(None)?);
+                        }
+                        kind @ (TokenKind::Plus | TokenKind::Minus) => {
+                            let readonly = match kind {
+                                TokenKind::Plus => MappedModifier::Add,
+                                _ => MappedModifier::Remove,
+                            };
                             assert_eq!(
-                                self.next().unwrap_or_else(|| EOF.clone()).kind,
-                                TokenKind::For
+                                self.next().unwrap_or(EOF.clone()).kind,
+                                TokenKind::Identifier("readonly".to_string())
                             );
-
-                            let target_token = self.next().unwrap_or_else(|| EOF.clone());
-                            let target = match target_token.kind {
-                                TokenKind::Identifier(name) => name,
-                                _ => {
-                                    return Err(ParseError {
-                                        message: "target must be an identifier".to_string(),
-                                    })
-                                }
-                            };
-
                             assert_eq!(
-                                self.next().unwrap_or_else(|| EOF.clone()).kind,
-                                TokenKind::In
+                                self.next().unwrap_or(EOF.clone()).kind,
+                                TokenKind::LeftBracket
                             );
-
-                            let source = self.parse_type_ann()?; // should expand to a union of valid key types
-
-                            props.push(ObjectProp::Mapped(Mapped {
-                                key: Box::new(key),
-                                value: Box::new(value),
-                                target,
-                                source: Box::new(source),
-                                optional,
-                                // TODO: handle 'if' clause
-                                check: None,
-                                extends: None,
-                            }))
+                            props.push(self.parse_mapped_type(Some(readonly))?);
                         }
                         TokenKind::Fn => {
                             match self.peek().unwrap_or(&EOF).kind.clone() {
@@ -271,7 +291,7 @@ impl<'a> Parser<'a> {
                                         self.next().unwrap_or(EOF.clone()).kind,
                                         TokenKind::SingleArrow
                                     );
-                                    let ret = self.parse_type_ann()?;
+                                    let ret = self.parse_return_type()?;
                                     let throws = match self.peek().unwrap_or(&EOF).kind {
                                         TokenKind::Throws => {
                                             self.next(); // consume `throws`
@@ -304,7 +324,7 @@ impl<'a> Parser<'a> {
                                         self.next().unwrap_or(EOF.clone()).kind,
                                         TokenKind::SingleArrow
                                     );
-                                    let ret = self.parse_type_ann()?;
+                                    let ret = self.parse_return_type()?;
                                     let throws = match self.peek().unwrap_or(&EOF).kind {
                                         TokenKind::Throws => {
                                             self.next(); // consume `throws`
@@ -459,23 +479,29 @@ impl<'a> Parser<'a> {
             }
             TokenKind::LeftBracket => {
                 self.next(); // consumes '['
-                let mut elems: Vec<TypeAnn> = vec![];
+                let mut elems: Vec<TupleElemTypeAnn> = vec![];
 
                 while self.peek().unwrap_or(&EOF).kind != TokenKind::RightBracket {
                     if self.peek().unwrap_or(&EOF).kind == TokenKind::DotDotDot {
                         let token = self.next().ok_or(ParseError {
                             message: "expected '...'".to_string(),
                         })?;
+                        let label = self.maybe_parse_tuple_label();
                         let type_ann = self.parse_type_ann()?;
                         let span = merge_spans(&token.span, &type_ann.span);
 
-                        elems.push(TypeAnn {
-                            kind: TypeAnnKind::Rest(Box::new(type_ann)),
-                            span,
-                            inferred_type: None,
+                        elems.push(TupleElemTypeAnn {
+                            label,
+                            type_ann: TypeAnn {
+                                kind: TypeAnnKind::Rest(Box::new(type_ann)),
+                                span,
+                                inferred_type: None,
+                            },
                         });
                     } else {
-                        elems.push(self.parse_type_ann()?);
+                        let label = self.maybe_parse_tuple_label();
+                        let type_ann = self.parse_type_ann()?;
+                        elems.push(TupleElemTypeAnn { label, type_ann });
                     }
 
                     if self.peek().unwrap_or(&EOF).kind == TokenKind::Comma {
@@ -497,10 +523,33 @@ impl<'a> Parser<'a> {
                 let atom = self.parse_inside_parens(|p| p.parse_type_ann())?;
                 return Ok(atom);
             }
-            TokenKind::Identifier(ident) => {
+            TokenKind::Identifier(mut ident) => {
                 self.next(); // consumes identifier
 
-                if self.peek().unwrap_or(&EOF).kind == TokenKind::LessThan {
+                // Qualified names refer to types in namespaces, e.g. `Foo.Bar`
+                while self.peek().unwrap_or(&EOF).kind == TokenKind::Dot {
+                    self.next(); // consumes '.'
+                    let token = self
+                        .next_with_mode(IdentMode::PropName)
+                        .unwrap_or(EOF.clone());
+                    match token.kind {
+                        TokenKind::Identifier(name) => {
+                            span = merge_spans(&span, &token.span);
+                            ident.push('.');
+                            ident.push_str(&name);
+                        }
+                        _ => {
+                            return Err(ParseError {
+                                message: "expected identifier".to_string(),
+                            })
+                        }
+                    }
+                }
+
+                if ident == "unique" && self.peek().unwrap_or(&EOF).kind == TokenKind::Symbol {
+                    span = merge_spans(&span, &self.next().unwrap_or(EOF.clone()).span);
+                    TypeAnnKind::UniqueSymbol
+                } else if self.peek().unwrap_or(&EOF).kind == TokenKind::LessThan {
                     self.next().unwrap_or(EOF.clone());
                     let mut params: Vec<TypeAnn> = vec![];
 
@@ -528,40 +577,15 @@ impl<'a> Parser<'a> {
             TokenKind::Fn => {
                 self.next(); // consumes 'fn'
 
-                let type_params = self.maybe_parse_type_params()?;
-                let params = self.parse_type_ann_func_params()?;
-                assert_eq!(
-                    self.next().unwrap_or(EOF.clone()).kind,
-                    TokenKind::SingleArrow
-                );
-                let return_type = self.parse_type_ann()?;
-
-                let throws = match self.peek().unwrap_or(&EOF).kind {
-                    TokenKind::Throws => {
-                        self.next(); // consume `throws`
-                        let type_ann = self.parse_type_ann()?;
-                        Some(Box::new(type_ann))
-                    }
-                    _ => None,
-                };
-
-                let end_span = match &throws {
-                    Some(throws) => throws.span,
-                    None => return_type.span,
-                };
-
-                TypeAnnKind::Function(FunctionType {
-                    span: merge_spans(&span, &end_span),
-                    type_params,
-                    params,
-                    ret: Box::new(return_type),
-                    throws,
-                })
+                TypeAnnKind::Function(self.parse_func_type(span)?)
             }
             TokenKind::KeyOf => {
-                self.next(); // consumes 'keyof'
+                let token = self.next().unwrap_or(EOF.clone()); // consumes 'keyof'
+                let op_info = get_prefix_op_info(&token).unwrap();
 
-                let type_ann = self.parse_type_ann()?;
+                let precedence = op_info.normalized_prec() - 1;
+                let type_ann = self.parse_type_ann_with_precedence(precedence)?;
+                span = merge_spans(&span, &type_ann.span);
 
                 TypeAnnKind::KeyOf(Box::new(type_ann))
             }
@@ -657,6 +681,109 @@ impl<'a> Parser<'a> {
         Ok(atom)
     }
 
+    // Parses everything after the `fn` keyword in a function type, e.g.
+    // `<T>(a: T) -> T throws E`.  `span` is the span of the `fn` keyword.
+    pub fn parse_func_type(&mut self, span: Span) -> Result<FunctionType, ParseError> {
+        let type_params = self.maybe_parse_type_params()?;
+        let params = self.parse_type_ann_func_params()?;
+        assert_eq!(
+            self.next().unwrap_or(EOF.clone()).kind,
+            TokenKind::SingleArrow
+        );
+        let return_type = self.parse_return_type()?;
+
+        let throws = match self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Throws => {
+                self.next(); // consume `throws`
+                let type_ann = self.parse_type_ann()?;
+                Some(Box::new(type_ann))
+            }
+            _ => None,
+        };
+
+        let end_span = match &throws {
+            Some(throws) => throws.span,
+            None => return_type.span,
+        };
+
+        Ok(FunctionType {
+            span: merge_spans(&span, &end_span),
+            type_params,
+            params,
+            ret: Box::new(return_type),
+            throws,
+        })
+    }
+
+    // Parses the return type of a function which can be a type predicate,
+    // e.g. `x is string`, or an assertion signature, e.g. `asserts x is string`
+    // or `asserts x`, in addition to a regular type annotation.
+    pub fn parse_return_type(&mut self) -> Result<TypeAnn, ParseError> {
+        let start = match &self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Identifier(_) => self.peek().unwrap_or(&EOF).span,
+            _ => return self.parse_type_ann(),
+        };
+        let backup = self.clone();
+
+        // `asserts` isn't a keyword so that it can still be used as a name.
+        let asserts = match &self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Identifier(name) if name == "asserts" => {
+                self.next(); // consumes 'asserts'
+                true
+            }
+            _ => false,
+        };
+
+        if let TokenKind::Identifier(name) = self.peek().unwrap_or(&EOF).kind.clone() {
+            let token = self.next().unwrap_or(EOF.clone()); // consumes identifier
+            let type_ann = match self.peek().unwrap_or(&EOF).kind {
+                TokenKind::Is => {
+                    self.next(); // consumes 'is'
+                    Some(self.parse_type_ann()?)
+                }
+                _ => None,
+            };
+
+            if asserts || type_ann.is_some() {
+                let end = type_ann
+                    .as_ref()
+                    .map_or(token.span, |type_ann| type_ann.span);
+                return Ok(TypeAnn {
+                    kind: TypeAnnKind::Predicate(PredicateTypeAnn {
+                        asserts,
+                        param: Ident {
+                            name,
+                            span: token.span,
+                        },
+                        type_ann: type_ann.map(Box::new),
+                    }),
+                    span: merge_spans(&start, &end),
+                    inferred_type: None,
+                });
+            }
+        }
+
+        self.restore(backup);
+        self.parse_type_ann()
+    }
+
+    // Parses the label of a labeled tuple element, e.g. `x` in `[x: number]`.
+    fn maybe_parse_tuple_label(&mut self) -> Option<Ident> {
+        if let TokenKind::Identifier(name) = self.peek().unwrap_or(&EOF).kind.clone() {
+            let backup = self.clone();
+            let token = self.next().unwrap_or(EOF.clone()); // consumes identifier
+            if self.peek().unwrap_or(&EOF).kind == TokenKind::Colon {
+                self.next(); // consumes ':'
+                return Some(Ident {
+                    name,
+                    span: token.span,
+                });
+            }
+            self.restore(backup);
+        }
+        None
+    }
+
     pub fn parse_type_ann_func_params(&mut self) -> Result<Vec<TypeAnnFuncParam>, ParseError> {
         assert_eq!(
             self.next().unwrap_or(EOF.clone()).kind,
@@ -794,11 +921,9 @@ impl<'a> Parser<'a> {
                     }
                     _ => {
                         let index_type = self.parse_type_ann()?;
-                        let merged_span = merge_spans(&lhs.span, &index_type.span);
-                        assert_eq!(
-                            self.next().unwrap_or(EOF.clone()).kind,
-                            TokenKind::RightBracket
-                        );
+                        let next = self.next().unwrap_or(EOF.clone());
+                        assert_eq!(next.kind, TokenKind::RightBracket);
+                        let merged_span = merge_spans(&lhs.span, &next.span);
                         TypeAnn {
                             kind: TypeAnnKind::IndexedAccess(Box::new(lhs), Box::new(index_type)),
                             span: merged_span,
@@ -929,6 +1054,146 @@ impl<'a> Parser<'a> {
         Ok(result)
     }
 
+    // Parses the rest of a mapped type after the opening '['.  Both
+    // `[K]: V for P in S` and TypeScript's `[P in S as K]: V` are supported.
+    fn parse_mapped_type(
+        &mut self,
+        readonly: Option<MappedModifier>,
+    ) -> Result<ObjectProp, ParseError> {
+        let mut key = self.parse_type_ann()?;
+
+        // Properties can be keyed by well-known symbols, e.g.
+        // `[Symbol.iterator]: T`, or by variables that are unique symbols,
+        // e.g. `[sym]: T`.
+        let mut computed_key = match &key.kind {
+            TypeAnnKind::TypeRef(name, None) => Some(name.to_owned()),
+            _ => None,
+        };
+        if let Some(computed_key) = &mut computed_key {
+            while self.peek().unwrap_or(&EOF).kind == TokenKind::Dot {
+                self.next(); // consumes '.'
+                match self
+                    .next_with_mode(IdentMode::PropName)
+                    .unwrap_or(EOF.clone())
+                    .kind
+                {
+                    TokenKind::Identifier(name) => {
+                        computed_key.push('.');
+                        computed_key.push_str(&name);
+                    }
+                    _ => {
+                        return Err(ParseError {
+                            message: "expected identifier".to_string(),
+                        })
+                    }
+                }
+            }
+        }
+
+        let ts_mapped = match self.peek().unwrap_or(&EOF).kind {
+            TokenKind::In => {
+                self.next(); // consumes 'in'
+
+                let target = match &key.kind {
+                    TypeAnnKind::TypeRef(name, None) => name.to_owned(),
+                    _ => {
+                        return Err(ParseError {
+                            message: "target must be an identifier".to_string(),
+                        })
+                    }
+                };
+                let source = self.parse_type_ann()?;
+
+                // Key remapping, e.g. `[P in keyof T as Exclude<P, "kind">]`
+                if self.peek().unwrap_or(&EOF).kind == TokenKind::As {
+                    self.next(); // consumes 'as'
+                    key = self.parse_type_ann()?;
+                }
+
+                Some((target, source))
+            }
+            _ => None,
+        };
+
+        assert_eq!(
+            self.next().unwrap_or_else(|| EOF.clone()).kind,
+            TokenKind::RightBracket
+        );
+
+        let mut optional: Option<MappedModifier> = None;
+        if self.peek().unwrap_or(&EOF).kind == TokenKind::Plus {
+            self.next(); // consume '+'
+            assert_eq!(self.next().unwrap_or(EOF.clone()).kind, TokenKind::Question);
+            optional = Some(MappedModifier::Add);
+        } else if self.peek().unwrap_or(&EOF).kind == TokenKind::Minus {
+            self.next(); // consume '-'
+            assert_eq!(self.next().unwrap_or(EOF.clone()).kind, TokenKind::Question);
+            optional = Some(MappedModifier::Remove);
+        } else if self.peek().unwrap_or(&EOF).kind == TokenKind::Question {
+            self.next(); // consume '?'
+            optional = Some(MappedModifier::Add);
+        }
+
+        assert_eq!(
+            self.next().unwrap_or_else(|| EOF.clone()).kind,
+            TokenKind::Colon
+        );
+        let value = self.parse_type_ann()?;
+
+        let (target, source) = match (ts_mapped, computed_key) {
+            (Some(ts_mapped), _) => ts_mapped,
+            (None, Some(name)) if self.peek().unwrap_or(&EOF).kind != TokenKind::For => {
+                return Ok(ObjectProp::Prop(type_ann::Prop {
+                    name,
+                    computed: true,
+                    modifier: None,
+                    optional: optional == Some(MappedModifier::Add),
+                    readonly: readonly == Some(MappedModifier::Add),
+                    type_ann: Box::new(value),
+                    // TODO(#642): compute correct spans for type annotations
+                    span: Span { start: 0, end: 0 },
+                }));
+            }
+            (None, _) => {
+                assert_eq!(
+                    self.next().unwrap_or_else(|| EOF.clone()).kind,
+                    TokenKind::For
+                );
+
+                let target_token = self.next().unwrap_or_else(|| EOF.clone());
+                let target = match target_token.kind {
+                    TokenKind::Identifier(name) => name,
+                    _ => {
+                        return Err(ParseError {
+                            message: "target must be an identifier".to_string(),
+                        })
+                    }
+                };
+
+                assert_eq!(
+                    self.next().unwrap_or_else(|| EOF.clone()).kind,
+                    TokenKind::In
+                );
+
+                let source = self.parse_type_ann()?; // should expand to a union of valid key types
+
+                (target, source)
+            }
+        };
+
+        Ok(ObjectProp::Mapped(Mapped {
+            key: Box::new(key),
+            value: Box::new(value),
+            target,
+            source: Box::new(source),
+            optional,
+            readonly,
+            // TODO: handle 'if' clause
+            check: None,
+            extends: None,
+        }))
+    }
+
     fn parse_conditional_type(&mut self) -> Result<TypeAnn, ParseError> {
         // TODO(#642): compute correct spans for type annotations
         let span = self.peek().unwrap_or(&EOF).span;
@@ -990,7 +1255,50 @@ impl<'a> Parser<'a> {
     }
 
     pub fn parse_type_ann(&mut self) -> Result<TypeAnn, ParseError> {
-        self.parse_type_ann_with_precedence(0)
+        // Template strings that appear inside of type annotations are lexed
+        // as template literal types.
+        let in_type_ann = self.in_type_ann;
+        self.in_type_ann = true;
+        let result = self.parse_type_ann_or_conditional();
+        self.in_type_ann = in_type_ann;
+        result
+    }
+
+    fn parse_type_ann_or_conditional(&mut self) -> Result<TypeAnn, ParseError> {
+        let check = self.parse_type_ann_with_precedence(0)?;
+
+        match self.peek().unwrap_or(&EOF).kind {
+            TokenKind::Extends => self.parse_ts_conditional_type(check),
+            _ => Ok(check),
+        }
+    }
+
+    // Parses TypeScript style conditional types, e.g. `T extends U ? X : Y`.
+    // These are equivalent to `if (T: U) { X } else { Y }`.
+    fn parse_ts_conditional_type(&mut self, check: TypeAnn) -> Result<TypeAnn, ParseError> {
+        self.next(); // consumes 'extends'
+
+        // Conditional types aren't allowed in the `extends` clause without
+        // parens, otherwise `?` would be ambiguous.
+        let extends = self.parse_type_ann_with_precedence(0)?;
+        assert_eq!(self.next().unwrap_or(EOF.clone()).kind, TokenKind::Question);
+        let true_type = self.parse_type_ann()?;
+        assert_eq!(self.next().unwrap_or(EOF.clone()).kind, TokenKind::Colon);
+        let false_type = self.parse_type_ann()?;
+
+        let span = merge_spans(&check.span, &false_type.span);
+        let kind = TypeAnnKind::Condition(ConditionType {
+            check: Box::new(check),
+            extends: Box::new(extends),
+            true_type: Box::new(true_type),
+            false_type: Box::new(false_type),
+        });
+
+        Ok(TypeAnn {
+            kind,
+            span,
+            inferred_type: None,
+        })
     }
 }
 
@@ -1066,6 +1374,11 @@ mod tests {
         Ok(())
     }
 
+    #[test]
+    fn parse_readonly_object_properties() {
+        insta::assert_debug_snapshot!(parse("{readonly a: number, readonly?: string}"));
+    }
+
     #[test]
     #[should_panic]
     fn parse_object_type_missing_comma() {
@@ -1112,6 +1425,12 @@ mod tests {
         insta::assert_debug_snapshot!(parse("T"));
     }
 
+    #[test]
+    fn parse_qualified_type_refs() {
+        insta::assert_debug_snapshot!(parse("Foo.Bar"));
+        insta::assert_debug_snapshot!(parse("Foo.Bar.Baz<T>"));
+    }
+
     #[test]
     fn parse_fn_type_ann() {
         insta::assert_debug_snapshot!(parse("fn (a: number, b: number) -> number"));
@@ -1145,6 +1464,7 @@ mod tests {
     fn parse_indexed_access() {
         insta::assert_debug_snapshot!(parse("T[K]"));
         insta::assert_debug_snapshot!(parse(r#"T["foo"]"#));
+        insta::assert_debug_snapshot!(parse(r#"T["foo"][number]"#));
     }
 
     #[test]
@@ -1157,6 +1477,28 @@ mod tests {
         insta::assert_debug_snapshot!(parse("{[P]-?: T[P] for P in keyof T}"));
     }
 
+    #[test]
+    fn parse_symbol_keyed_props() {
+        insta::assert_debug_snapshot!(parse("{[Symbol.iterator]: fn () -> Iterator<T>}"));
+        insta::assert_debug_snapshot!(parse("{readonly [sym]?: number, [P]: T for P in K}"));
+        insta::assert_debug_snapshot!(parse("unique symbol"));
+    }
+
+    #[test]
+    fn parse_mapped_type_modifiers() {
+        insta::assert_debug_snapshot!(parse("{readonly [P]: T[P] for P in keyof T}"));
+        insta::assert_debug_snapshot!(parse("{-readonly [P]-?: T[P] for P in keyof T}"));
+        insta::assert_debug_snapshot!(parse("{+readonly [P]?: T[P] for P in keyof T}"));
+    }
+
+    #[test]
+    fn parse_ts_mapped_type() {
+        insta::assert_debug_snapshot!(parse("{[P in keyof T]+?: T[P]}"));
+        insta::assert_debug_snapshot!(parse(
+            "{readonly [P in keyof T as P extends K ? never : P]: T[P]}"
+        ));
+    }
+
     #[test]
     fn parse_conditional_type() {
         insta::assert_debug_snapshot!(parse("if (T: U) { never } else { T }"));
@@ -1165,6 +1507,23 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn parse_ts_conditional_type() {
+        insta::assert_debug_snapshot!(parse("T extends U ? never : T"));
+        insta::assert_debug_snapshot!(parse(
+            r#"T extends string ? "string" : T extends number ? "number" : "other""#
+        ));
+        insta::assert_debug_snapshot!(parse("T extends Array<infer U> ? U : T"));
+    }
+
+    #[test]
+    fn parse_keyof_precedence() {
+        insta::assert_debug_snapshot!(parse("keyof A | B"));
+        insta::assert_debug_snapshot!(parse("keyof A & B"));
+        insta::assert_debug_snapshot!(parse("keyof A[]"));
+        insta::assert_debug_snapshot!(parse("keyof typeof x"));
+    }
+
     #[test]
     fn parse_wildcard_type() {
         insta::assert_debug_snapshot!(parse("Array<_>"));
@@ -1205,4 +1564,41 @@ mod tests {
         insta::assert_debug_snapshot!(parse(r#"A * B + C"#));
         insta::assert_debug_snapshot!(parse(r#"A * (B + C)"#));
     }
+
+    #[test]
+    fn parse_template_literal_type() {
+        insta::assert_debug_snapshot!(parse(r#"`margin-${Vert}-${Horiz}`"#));
+        insta::assert_debug_snapshot!(parse(r#"`id-${number | "a"}`"#));
+        insta::assert_debug_snapshot!(parse(r#"Foo<`${T}px`>"#));
+    }
+
+    #[test]
+    fn parse_regex_types() {
+        insta::assert_debug_snapshot!(parse(r#"/(?<y>\d+)-(?<m>\d+)/"#));
+        insta::assert_debug_snapshot!(parse(r#"Match<"2024-01", /[>]+/i>"#));
+    }
+
+    #[test]
+    fn parse_callable_with_props() {
+        insta::assert_debug_snapshot!(parse(r#"(fn () -> void) & {meta: string}"#));
+    }
+
+    #[test]
+    fn parse_labeled_tuple() {
+        insta::assert_debug_snapshot!(parse("[x: number, y: number]"));
+        insta::assert_debug_snapshot!(parse("[first: string, ...rest: number[]]"));
+    }
+
+    #[test]
+    fn parse_type_predicate() {
+        insta::assert_debug_snapshot!(parse("fn (x: unknown) -> x is string"));
+        insta::assert_debug_snapshot!(parse("{fn isKey(self, key: string) -> key is Keys}"));
+    }
+
+    #[test]
+    fn parse_assertion_signatures() {
+        insta::assert_debug_snapshot!(parse("fn (x: unknown) -> asserts x is string"));
+        insta::assert_debug_snapshot!(parse("fn (cond: boolean) -> asserts cond"));
+        insta::assert_debug_snapshot!(parse("fn () -> asserts"));
+    }
 }